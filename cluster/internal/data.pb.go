@@ -13,6 +13,11 @@ It has these top-level messages:
 	WriteShardResponse
 	MapShardRequest
 	MapShardResponse
+	QueryInfo
+	ShowQueriesRequest
+	ShowQueriesResponse
+	KillQueryRequest
+	KillQueryResponse
 */
 package internal
 
@@ -52,6 +57,7 @@ func (m *WriteShardRequest) GetPoints() [][]byte {
 type WriteShardResponse struct {
 	Code             *int32  `protobuf:"varint,1,req,name=Code" json:"Code,omitempty"`
 	Message          *string `protobuf:"bytes,2,opt,name=Message" json:"Message,omitempty"`
+	Dropped          *int64  `protobuf:"varint,3,opt,name=Dropped" json:"Dropped,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
@@ -73,10 +79,18 @@ func (m *WriteShardResponse) GetMessage() string {
 	return ""
 }
 
+func (m *WriteShardResponse) GetDropped() int64 {
+	if m != nil && m.Dropped != nil {
+		return *m.Dropped
+	}
+	return 0
+}
+
 type MapShardRequest struct {
 	ShardID          *uint64 `protobuf:"varint,1,req,name=ShardID" json:"ShardID,omitempty"`
 	Query            *string `protobuf:"bytes,2,req,name=Query" json:"Query,omitempty"`
 	ChunkSize        *int32  `protobuf:"varint,3,req,name=ChunkSize" json:"ChunkSize,omitempty"`
+	MaxPointN        *int64  `protobuf:"varint,4,opt,name=MaxPointN" json:"MaxPointN,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
@@ -105,12 +119,20 @@ func (m *MapShardRequest) GetChunkSize() int32 {
 	return 0
 }
 
+func (m *MapShardRequest) GetMaxPointN() int64 {
+	if m != nil && m.MaxPointN != nil {
+		return *m.MaxPointN
+	}
+	return 0
+}
+
 type MapShardResponse struct {
 	Code             *int32   `protobuf:"varint,1,req,name=Code" json:"Code,omitempty"`
 	Message          *string  `protobuf:"bytes,2,opt,name=Message" json:"Message,omitempty"`
 	Data             []byte   `protobuf:"bytes,3,opt,name=Data" json:"Data,omitempty"`
 	TagSets          []string `protobuf:"bytes,4,rep,name=TagSets" json:"TagSets,omitempty"`
 	Fields           []string `protobuf:"bytes,5,rep,name=Fields" json:"Fields,omitempty"`
+	PointN           *int64   `protobuf:"varint,6,opt,name=PointN" json:"PointN,omitempty"`
 	XXX_unrecognized []byte   `json:"-"`
 }
 
@@ -152,3 +174,162 @@ func (m *MapShardResponse) GetFields() []string {
 	}
 	return nil
 }
+
+func (m *MapShardResponse) GetPointN() int64 {
+	if m != nil && m.PointN != nil {
+		return *m.PointN
+	}
+	return 0
+}
+
+type QueryInfo struct {
+	ID               *uint64 `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
+	Query            *string `protobuf:"bytes,2,req,name=Query" json:"Query,omitempty"`
+	Database         *string `protobuf:"bytes,3,opt,name=Database" json:"Database,omitempty"`
+	User             *string `protobuf:"bytes,4,opt,name=User" json:"User,omitempty"`
+	Start            *int64  `protobuf:"varint,5,req,name=Start" json:"Start,omitempty"`
+	Status           *string `protobuf:"bytes,6,req,name=Status" json:"Status,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *QueryInfo) Reset()         { *m = QueryInfo{} }
+func (m *QueryInfo) String() string { return proto.CompactTextString(m) }
+func (*QueryInfo) ProtoMessage()    {}
+
+func (m *QueryInfo) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+func (m *QueryInfo) GetQuery() string {
+	if m != nil && m.Query != nil {
+		return *m.Query
+	}
+	return ""
+}
+
+func (m *QueryInfo) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *QueryInfo) GetUser() string {
+	if m != nil && m.User != nil {
+		return *m.User
+	}
+	return ""
+}
+
+func (m *QueryInfo) GetStart() int64 {
+	if m != nil && m.Start != nil {
+		return *m.Start
+	}
+	return 0
+}
+
+func (m *QueryInfo) GetStatus() string {
+	if m != nil && m.Status != nil {
+		return *m.Status
+	}
+	return ""
+}
+
+type ShowQueriesRequest struct {
+	NodeID           *uint64 `protobuf:"varint,1,req,name=NodeID" json:"NodeID,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *ShowQueriesRequest) Reset()         { *m = ShowQueriesRequest{} }
+func (m *ShowQueriesRequest) String() string { return proto.CompactTextString(m) }
+func (*ShowQueriesRequest) ProtoMessage()    {}
+
+func (m *ShowQueriesRequest) GetNodeID() uint64 {
+	if m != nil && m.NodeID != nil {
+		return *m.NodeID
+	}
+	return 0
+}
+
+type ShowQueriesResponse struct {
+	Code             *int32       `protobuf:"varint,1,req,name=Code" json:"Code,omitempty"`
+	Message          *string      `protobuf:"bytes,2,opt,name=Message" json:"Message,omitempty"`
+	Queries          []*QueryInfo `protobuf:"bytes,3,rep,name=Queries" json:"Queries,omitempty"`
+	XXX_unrecognized []byte       `json:"-"`
+}
+
+func (m *ShowQueriesResponse) Reset()         { *m = ShowQueriesResponse{} }
+func (m *ShowQueriesResponse) String() string { return proto.CompactTextString(m) }
+func (*ShowQueriesResponse) ProtoMessage()    {}
+
+func (m *ShowQueriesResponse) GetCode() int32 {
+	if m != nil && m.Code != nil {
+		return *m.Code
+	}
+	return 0
+}
+
+func (m *ShowQueriesResponse) GetMessage() string {
+	if m != nil && m.Message != nil {
+		return *m.Message
+	}
+	return ""
+}
+
+func (m *ShowQueriesResponse) GetQueries() []*QueryInfo {
+	if m != nil {
+		return m.Queries
+	}
+	return nil
+}
+
+type KillQueryRequest struct {
+	NodeID           *uint64 `protobuf:"varint,1,req,name=NodeID" json:"NodeID,omitempty"`
+	QueryID          *uint64 `protobuf:"varint,2,req,name=QueryID" json:"QueryID,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *KillQueryRequest) Reset()         { *m = KillQueryRequest{} }
+func (m *KillQueryRequest) String() string { return proto.CompactTextString(m) }
+func (*KillQueryRequest) ProtoMessage()    {}
+
+func (m *KillQueryRequest) GetNodeID() uint64 {
+	if m != nil && m.NodeID != nil {
+		return *m.NodeID
+	}
+	return 0
+}
+
+func (m *KillQueryRequest) GetQueryID() uint64 {
+	if m != nil && m.QueryID != nil {
+		return *m.QueryID
+	}
+	return 0
+}
+
+type KillQueryResponse struct {
+	Code             *int32  `protobuf:"varint,1,req,name=Code" json:"Code,omitempty"`
+	Message          *string `protobuf:"bytes,2,opt,name=Message" json:"Message,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *KillQueryResponse) Reset()         { *m = KillQueryResponse{} }
+func (m *KillQueryResponse) String() string { return proto.CompactTextString(m) }
+func (*KillQueryResponse) ProtoMessage()    {}
+
+func (m *KillQueryResponse) GetCode() int32 {
+	if m != nil && m.Code != nil {
+		return *m.Code
+	}
+	return 0
+}
+
+func (m *KillQueryResponse) GetMessage() string {
+	if m != nil && m.Message != nil {
+		return *m.Message
+	}
+	return ""
+}
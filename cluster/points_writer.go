@@ -274,16 +274,28 @@ func (w *PointsWriter) WritePoints(p *WritePointsRequest) error {
 		w.statMap.Add(statSubWriteDrop, 1)
 	}
 
+	// Points dropped from each shard are added up in a single partial write error.
+	var partial *tsdb.PartialWriteError
 	for range shardMappings.Points {
 		select {
 		case <-w.closing:
 			return ErrWriteFailed
 		case err := <-ch:
-			if err != nil {
+			if e, ok := err.(tsdb.PartialWriteError); ok {
+				if partial == nil {
+					partial = &e
+				} else {
+					partial.Dropped += e.Dropped
+				}
+				continue
+			} else if err != nil {
 				return err
 			}
 		}
 	}
+	if partial != nil {
+		return *partial
+	}
 	return nil
 }
 
@@ -349,7 +361,7 @@ func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, retentionPo
 
 	var wrote int
 	timeout := time.After(w.WriteTimeout)
-	var writeError error
+	var writeError, partialError error
 	for range shard.Owners {
 		select {
 		case <-w.closing:
@@ -359,6 +371,14 @@ func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, retentionPo
 			// return timeout error to caller
 			return ErrTimeout
 		case result := <-ch:
+			// A partial write succeeded for the points which weren't dropped.
+			if _, ok := result.Err.(tsdb.PartialWriteError); ok {
+				if partialError == nil {
+					partialError = result.Err
+				}
+				result.Err = nil
+			}
+
 			// If the write returned an error, continue to the next response
 			if result.Err != nil {
 				w.statMap.Add(statWriteErr, 1)
@@ -376,7 +396,7 @@ func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, retentionPo
 			// We wrote the required consistency level
 			if wrote >= required {
 				w.statMap.Add(statWriteOK, 1)
-				return nil
+				return partialError
 			}
 		}
 	}
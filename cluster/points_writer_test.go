@@ -10,6 +10,7 @@ import (
 	"github.com/influxdb/influxdb/cluster"
 	"github.com/influxdb/influxdb/meta"
 	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/tsdb"
 )
 
 // Ensures the points writer maps a single point to a single shard.
@@ -228,6 +229,16 @@ func TestPointsWriter_WritePoints(t *testing.T) {
 			expErr:          nil,
 		},
 
+		// Points dropped by the shards are reported once consistency is met
+		{
+			name:            "write one, points dropped",
+			database:        "mydb",
+			retentionPolicy: "myrp",
+			consistency:     cluster.ConsistencyLevelOne,
+			err:             []error{tsdb.PartialWriteError{Reason: "limit exceeded", Dropped: 1}, tsdb.PartialWriteError{Reason: "limit exceeded", Dropped: 1}, tsdb.PartialWriteError{Reason: "limit exceeded", Dropped: 1}},
+			expErr:          tsdb.PartialWriteError{Reason: "limit exceeded", Dropped: 2},
+		},
+
 		// Write to non-existent database
 		{
 			name:            "write to non-existent database",
@@ -0,0 +1,138 @@
+package cluster
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/tsdb"
+)
+
+// QueryManager lists and kills the queries running on the other nodes in the
+// cluster.
+type QueryManager struct {
+	MetaStore interface {
+		NodeID() uint64
+		Node(id uint64) (ni *meta.NodeInfo, err error)
+		Nodes() ([]meta.NodeInfo, error)
+	}
+
+	timeout time.Duration
+}
+
+// NewQueryManager returns a new instance of QueryManager.
+func NewQueryManager(timeout time.Duration) *QueryManager {
+	return &QueryManager{timeout: timeout}
+}
+
+// Queries returns the queries running on the other nodes. Queries of nodes
+// which can't be reached are left out and reported in the returned error.
+func (m *QueryManager) Queries() ([]tsdb.QueryInfo, error) {
+	nodes, err := m.MetaStore.Nodes()
+	if err != nil {
+		return nil, err
+	}
+
+	var queries []tsdb.QueryInfo
+	var errs []string
+	for _, ni := range nodes {
+		if ni.ID == m.MetaStore.NodeID() {
+			continue
+		}
+
+		a, err := m.nodeQueries(ni.ID)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("node %d: %s", ni.ID, err))
+			continue
+		}
+		for i := range a {
+			a[i].NodeID = ni.ID
+		}
+		queries = append(queries, a...)
+	}
+
+	if len(errs) > 0 {
+		return queries, fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+	return queries, nil
+}
+
+// nodeQueries returns the queries running on a node.
+func (m *QueryManager) nodeQueries(nodeID uint64) ([]tsdb.QueryInfo, error) {
+	var req ShowQueriesRequest
+	req.SetNodeID(nodeID)
+
+	buf, err := req.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err = m.call(nodeID, showQueriesRequestMessage, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp ShowQueriesResponse
+	if err := resp.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	} else if resp.Code() != 0 {
+		return nil, fmt.Errorf("error code %d: %s", resp.Code(), resp.Message())
+	}
+	return resp.Queries(), nil
+}
+
+// KillQuery kills a query running on a node.
+func (m *QueryManager) KillQuery(nodeID, queryID uint64) error {
+	var req KillQueryRequest
+	req.SetNodeID(nodeID)
+	req.SetQueryID(queryID)
+
+	buf, err := req.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	buf, err = m.call(nodeID, killQueryRequestMessage, buf)
+	if err != nil {
+		return err
+	}
+
+	var resp KillQueryResponse
+	if err := resp.UnmarshalBinary(buf); err != nil {
+		return err
+	} else if resp.Code() != 0 {
+		return fmt.Errorf("error code %d: %s", resp.Code(), resp.Message())
+	}
+	return nil
+}
+
+// call sends a request to a node and returns the response.
+func (m *QueryManager) call(nodeID uint64, typ byte, buf []byte) ([]byte, error) {
+	ni, err := m.MetaStore.Node(nodeID)
+	if err != nil {
+		return nil, err
+	} else if ni == nil {
+		return nil, fmt.Errorf("node not found: %d", nodeID)
+	}
+
+	conn, err := net.DialTimeout("tcp", ni.Host, m.timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(m.timeout))
+
+	// Write the cluster multiplexing header byte
+	if _, err := conn.Write([]byte{MuxHeader}); err != nil {
+		return nil, err
+	}
+
+	if err := WriteTLV(conn, typ, buf); err != nil {
+		return nil, err
+	}
+
+	_, buf, err = ReadTLV(conn)
+	return buf, err
+}
@@ -0,0 +1,101 @@
+package cluster_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/tsdb"
+)
+
+// Ensure the query manager can list and kill the queries of remote nodes.
+func TestQueryManager_Queries(t *testing.T) {
+	ts := newTestWriteService(nil)
+
+	start := time.Unix(0, 1000)
+	var killed []uint64
+	s := cluster.NewService(cluster.Config{})
+	s.Listener = ts.muxln
+	s.TSDBStore = ts
+	s.MetaStore = &queryMetaStore{nodeID: 2}
+	s.QueryExecutor = &queryExecutor{
+		queries: []tsdb.QueryInfo{{ID: 3, NodeID: 2, Query: "SELECT * FROM cpu", Database: "db0", User: "alice", Start: start, Status: tsdb.QueryStatusRunning}},
+		killFn: func(id uint64) error {
+			if id != 3 {
+				return tsdb.ErrQueryNotFound
+			}
+			killed = append(killed, id)
+			return nil
+		},
+	}
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	defer ts.Close()
+
+	m := cluster.NewQueryManager(time.Minute)
+	m.MetaStore = &queryMetaStore{nodeID: 1, host: ts.ln.Addr().String()}
+
+	// Queries of the local node aren't requested.
+	queries, err := m.Queries()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(queries) != 1 {
+		t.Fatalf("unexpected queries: %v", queries)
+	} else if q := queries[0]; q.ID != 3 || q.NodeID != 2 || q.Query != "SELECT * FROM cpu" || q.Database != "db0" || q.User != "alice" || !q.Start.Equal(start) || q.Status != tsdb.QueryStatusRunning {
+		t.Fatalf("unexpected query: %#v", q)
+	}
+
+	if err := m.KillQuery(2, 3); err != nil {
+		t.Fatal(err)
+	} else if len(killed) != 1 {
+		t.Fatalf("unexpected kills: %v", killed)
+	}
+
+	// Errors of the remote node are returned.
+	if err := m.KillQuery(2, 4); err == nil || err.Error() != "error code 1: query not found" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Requests are rejected by nodes they're not addressed to.
+	if err := m.KillQuery(5, 3); err == nil || err.Error() != "error code 1: request for node 5 received by node 2" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// queryMetaStore is a meta store of a cluster with nodes 1 and 2.
+type queryMetaStore struct {
+	nodeID uint64
+	host   string
+}
+
+func (m *queryMetaStore) NodeID() uint64 { return m.nodeID }
+
+func (m *queryMetaStore) Node(nodeID uint64) (*meta.NodeInfo, error) {
+	return &meta.NodeInfo{ID: nodeID, Host: m.host}, nil
+}
+
+func (m *queryMetaStore) Nodes() ([]meta.NodeInfo, error) {
+	return []meta.NodeInfo{{ID: 1, Host: m.host}, {ID: 2, Host: m.host}}, nil
+}
+
+func (m *queryMetaStore) ShardOwner(shardID uint64) (string, string, *meta.ShardGroupInfo) {
+	return "", "", nil
+}
+
+type queryExecutor struct {
+	queries []tsdb.QueryInfo
+	killFn  func(id uint64) error
+}
+
+func (e *queryExecutor) Queries() []tsdb.QueryInfo { return e.queries }
+
+func (e *queryExecutor) KillQuery(id uint64) error {
+	if e.killFn == nil {
+		return errors.New("not implemented")
+	}
+	return e.killFn(id)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"github.com/influxdb/influxdb/cluster/internal"
 	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/tsdb"
 )
 
 //go:generate protoc --gogo_out=. internal/data.proto
@@ -25,6 +26,12 @@ func (m *MapShardRequest) Query() string { return m.pb.GetQuery() }
 // ChunkSize returns Shard map request's chunk size
 func (m *MapShardRequest) ChunkSize() int32 { return m.pb.GetChunkSize() }
 
+// MaxPointN returns the number of points the mapper may read and whether the
+// number is limited.
+func (m *MapShardRequest) MaxPointN() (int64, bool) {
+	return m.pb.GetMaxPointN(), m.pb.MaxPointN != nil
+}
+
 // SetShardID sets the map request's shard id
 func (m *MapShardRequest) SetShardID(id uint64) { m.pb.ShardID = &id }
 
@@ -34,6 +41,9 @@ func (m *MapShardRequest) SetQuery(query string) { m.pb.Query = &query }
 // SetChunkSize sets the Shard map request's chunk size
 func (m *MapShardRequest) SetChunkSize(chunkSize int32) { m.pb.ChunkSize = &chunkSize }
 
+// SetMaxPointN limits the number of points the mapper may read.
+func (m *MapShardRequest) SetMaxPointN(n int64) { m.pb.MaxPointN = &n }
+
 // MarshalBinary encodes the object to a binary format.
 func (m *MapShardRequest) MarshalBinary() ([]byte, error) {
 	return proto.Marshal(&m.pb)
@@ -75,6 +85,9 @@ func (r *MapShardResponse) Fields() []string { return r.pb.GetFields() }
 // Data returns the Shard map response's Data
 func (r *MapShardResponse) Data() []byte { return r.pb.GetData() }
 
+// PointN returns the number of points read by the mapper for the response's Data
+func (r *MapShardResponse) PointN() int64 { return r.pb.GetPointN() }
+
 // SetCode sets the Shard map response's code
 func (r *MapShardResponse) SetCode(code int) { r.pb.Code = proto.Int32(int32(code)) }
 
@@ -90,6 +103,9 @@ func (r *MapShardResponse) SetFields(fields []string) { r.pb.Fields = fields }
 // SetData sets the Shard map response's Data
 func (r *MapShardResponse) SetData(data []byte) { r.pb.Data = data }
 
+// SetPointN sets the number of points read by the mapper for the response's Data
+func (r *MapShardResponse) SetPointN(n int64) { r.pb.PointN = &n }
+
 // MarshalBinary encodes the object to a binary format.
 func (r *MapShardResponse) MarshalBinary() ([]byte, error) {
 	return proto.Marshal(&r.pb)
@@ -127,6 +143,10 @@ type WriteShardRequest struct {
 	pb internal.WriteShardRequest
 }
 
+// writeShardPartialCode is the code of a WriteShardResponse to a write which
+// dropped some of its points. The message holds the reason and Dropped the number of points.
+const writeShardPartialCode = 2
+
 // WriteShardResponse represents the response returned from a remote WriteShardRequest call
 type WriteShardResponse struct {
 	pb internal.WriteShardResponse
@@ -199,6 +219,12 @@ func (w *WriteShardResponse) Code() int { return int(w.pb.GetCode()) }
 // Message returns the Message
 func (w *WriteShardResponse) Message() string { return w.pb.GetMessage() }
 
+// SetDropped sets the number of points dropped by a partial write
+func (w *WriteShardResponse) SetDropped(n int) { w.pb.Dropped = proto.Int64(int64(n)) }
+
+// Dropped returns the number of points dropped by a partial write
+func (w *WriteShardResponse) Dropped() int { return int(w.pb.GetDropped()) }
+
 // MarshalBinary encodes the object to a binary format.
 func (w *WriteShardResponse) MarshalBinary() ([]byte, error) {
 	return proto.Marshal(&w.pb)
@@ -211,3 +237,149 @@ func (w *WriteShardResponse) UnmarshalBinary(buf []byte) error {
 	}
 	return nil
 }
+
+// ShowQueriesRequest represents the request to list the queries running on a remote node.
+type ShowQueriesRequest struct {
+	pb internal.ShowQueriesRequest
+}
+
+// NodeID returns the ID of the node the request is sent to.
+func (r *ShowQueriesRequest) NodeID() uint64 { return r.pb.GetNodeID() }
+
+// SetNodeID sets the ID of the node the request is sent to.
+func (r *ShowQueriesRequest) SetNodeID(id uint64) { r.pb.NodeID = &id }
+
+// MarshalBinary encodes the object to a binary format.
+func (r *ShowQueriesRequest) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&r.pb)
+}
+
+// UnmarshalBinary populates ShowQueriesRequest from a binary format.
+func (r *ShowQueriesRequest) UnmarshalBinary(buf []byte) error {
+	if err := proto.Unmarshal(buf, &r.pb); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ShowQueriesResponse represents the response returned from a remote ShowQueriesRequest call.
+type ShowQueriesResponse struct {
+	pb internal.ShowQueriesResponse
+}
+
+// Code returns the response's code.
+func (r *ShowQueriesResponse) Code() int { return int(r.pb.GetCode()) }
+
+// Message returns the response's message.
+func (r *ShowQueriesResponse) Message() string { return r.pb.GetMessage() }
+
+// SetCode sets the response's code.
+func (r *ShowQueriesResponse) SetCode(code int) { r.pb.Code = proto.Int32(int32(code)) }
+
+// SetMessage sets the response's message.
+func (r *ShowQueriesResponse) SetMessage(message string) { r.pb.Message = &message }
+
+// Queries returns the queries running on the remote node.
+func (r *ShowQueriesResponse) Queries() []tsdb.QueryInfo {
+	a := make([]tsdb.QueryInfo, len(r.pb.GetQueries()))
+	for i, q := range r.pb.GetQueries() {
+		a[i] = tsdb.QueryInfo{
+			ID:       q.GetID(),
+			Query:    q.GetQuery(),
+			Database: q.GetDatabase(),
+			User:     q.GetUser(),
+			Start:    time.Unix(0, q.GetStart()),
+			Status:   q.GetStatus(),
+		}
+	}
+	return a
+}
+
+// SetQueries sets the queries running on the remote node.
+func (r *ShowQueriesResponse) SetQueries(a []tsdb.QueryInfo) {
+	r.pb.Queries = make([]*internal.QueryInfo, len(a))
+	for i := range a {
+		q := &a[i]
+		r.pb.Queries[i] = &internal.QueryInfo{
+			ID:       proto.Uint64(q.ID),
+			Query:    proto.String(q.Query),
+			Database: proto.String(q.Database),
+			User:     proto.String(q.User),
+			Start:    proto.Int64(q.Start.UnixNano()),
+			Status:   proto.String(q.Status),
+		}
+	}
+}
+
+// MarshalBinary encodes the object to a binary format.
+func (r *ShowQueriesResponse) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&r.pb)
+}
+
+// UnmarshalBinary populates ShowQueriesResponse from a binary format.
+func (r *ShowQueriesResponse) UnmarshalBinary(buf []byte) error {
+	if err := proto.Unmarshal(buf, &r.pb); err != nil {
+		return err
+	}
+	return nil
+}
+
+// KillQueryRequest represents the request to kill a query running on a remote node.
+type KillQueryRequest struct {
+	pb internal.KillQueryRequest
+}
+
+// NodeID returns the ID of the node the request is sent to.
+func (r *KillQueryRequest) NodeID() uint64 { return r.pb.GetNodeID() }
+
+// QueryID returns the ID of the query to kill.
+func (r *KillQueryRequest) QueryID() uint64 { return r.pb.GetQueryID() }
+
+// SetNodeID sets the ID of the node the request is sent to.
+func (r *KillQueryRequest) SetNodeID(id uint64) { r.pb.NodeID = &id }
+
+// SetQueryID sets the ID of the query to kill.
+func (r *KillQueryRequest) SetQueryID(id uint64) { r.pb.QueryID = &id }
+
+// MarshalBinary encodes the object to a binary format.
+func (r *KillQueryRequest) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&r.pb)
+}
+
+// UnmarshalBinary populates KillQueryRequest from a binary format.
+func (r *KillQueryRequest) UnmarshalBinary(buf []byte) error {
+	if err := proto.Unmarshal(buf, &r.pb); err != nil {
+		return err
+	}
+	return nil
+}
+
+// KillQueryResponse represents the response returned from a remote KillQueryRequest call.
+type KillQueryResponse struct {
+	pb internal.KillQueryResponse
+}
+
+// Code returns the response's code.
+func (r *KillQueryResponse) Code() int { return int(r.pb.GetCode()) }
+
+// Message returns the response's message.
+func (r *KillQueryResponse) Message() string { return r.pb.GetMessage() }
+
+// SetCode sets the response's code.
+func (r *KillQueryResponse) SetCode(code int) { r.pb.Code = proto.Int32(int32(code)) }
+
+// SetMessage sets the response's message.
+func (r *KillQueryResponse) SetMessage(message string) { r.pb.Message = &message }
+
+// MarshalBinary encodes the object to a binary format.
+func (r *KillQueryResponse) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&r.pb)
+}
+
+// UnmarshalBinary populates KillQueryResponse from a binary format.
+func (r *KillQueryResponse) UnmarshalBinary(buf []byte) error {
+	if err := proto.Unmarshal(buf, &r.pb); err != nil {
+		return err
+	}
+	return nil
+}
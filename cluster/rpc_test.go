@@ -108,3 +108,39 @@ func TestWriteShardResponseBinary(t *testing.T) {
 	}
 
 }
+
+func TestMapShardRequestBinary(t *testing.T) {
+	var req MapShardRequest
+	req.SetShardID(1)
+	req.SetQuery("SELECT value FROM cpu")
+	req.SetChunkSize(10)
+	req.SetMaxPointN(0)
+
+	b, err := req.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MapShardRequest.MarshalBinary() failed: %v", err)
+	}
+
+	var got MapShardRequest
+	if err := got.UnmarshalBinary(b); err != nil {
+		t.Fatalf("MapShardRequest.UnmarshalBinary() failed: %v", err)
+	}
+
+	// A limit of zero points is still a limit.
+	if n, ok := got.MaxPointN(); n != 0 || !ok {
+		t.Errorf("MaxPointN mismatch: got %v, %v", n, ok)
+	}
+
+	// Requests without a limit aren't limited.
+	req = MapShardRequest{}
+	req.SetShardID(1)
+	req.SetQuery("SELECT value FROM cpu")
+	req.SetChunkSize(10)
+	if b, err = req.MarshalBinary(); err != nil {
+		t.Fatal(err)
+	} else if err := got.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	} else if _, ok := got.MaxPointN(); ok {
+		t.Errorf("unexpected point limit")
+	}
+}
@@ -32,6 +32,8 @@ const (
 	writeShardFail      = "writeShardFail"
 	mapShardReq         = "mapShardReq"
 	mapShardResp        = "mapShardResp"
+	showQueriesReq      = "showQueriesReq"
+	killQueryReq        = "killQueryReq"
 )
 
 // Service processes data received over raw TCP connections.
@@ -44,6 +46,7 @@ type Service struct {
 	Listener net.Listener
 
 	MetaStore interface {
+		NodeID() uint64
 		ShardOwner(shardID uint64) (string, string, *meta.ShardGroupInfo)
 	}
 
@@ -53,6 +56,11 @@ type Service struct {
 		CreateMapper(shardID uint64, stmt influxql.Statement, chunkSize int) (tsdb.Mapper, error)
 	}
 
+	QueryExecutor interface {
+		Queries() []tsdb.QueryInfo
+		KillQuery(id uint64) error
+	}
+
 	Logger  *log.Logger
 	statMap *expvar.Map
 }
@@ -173,6 +181,20 @@ func (s *Service) handleConn(conn net.Conn) {
 					s.Logger.Printf("process map shard error writing response: %s", err.Error())
 				}
 			}
+		case showQueriesRequestMessage:
+			s.statMap.Add(showQueriesReq, 1)
+			queries, err := s.processShowQueriesRequest(buf)
+			if err != nil {
+				s.Logger.Printf("process show queries error: %s", err)
+			}
+			s.writeShowQueriesResponse(conn, queries, err)
+		case killQueryRequestMessage:
+			s.statMap.Add(killQueryReq, 1)
+			err := s.processKillQueryRequest(buf)
+			if err != nil {
+				s.Logger.Printf("process kill query error: %s", err)
+			}
+			s.writeKillQueryResponse(conn, err)
 		default:
 			s.Logger.Printf("cluster service message type not found: %d", typ)
 		}
@@ -214,7 +236,10 @@ func (s *Service) processWriteShardRequest(buf []byte) error {
 		return s.TSDBStore.WriteToShard(req.ShardID(), req.Points())
 	}
 
-	if err != nil {
+	// Partial writes are returned as is so the sender can tell them apart.
+	if _, ok := err.(tsdb.PartialWriteError); ok {
+		return err
+	} else if err != nil {
 		s.statMap.Add(writeShardFail, 1)
 		return fmt.Errorf("write shard %d: %s", req.ShardID(), err)
 	}
@@ -225,7 +250,11 @@ func (s *Service) processWriteShardRequest(buf []byte) error {
 func (s *Service) writeShardResponse(w io.Writer, e error) {
 	// Build response.
 	var resp WriteShardResponse
-	if e != nil {
+	if pe, ok := e.(tsdb.PartialWriteError); ok {
+		resp.SetCode(writeShardPartialCode)
+		resp.SetMessage(pe.Reason)
+		resp.SetDropped(pe.Dropped)
+	} else if e != nil {
 		resp.SetCode(1)
 		resp.SetMessage(e.Error())
 	} else {
@@ -268,6 +297,16 @@ func (s *Service) processMapShardRequest(w io.Writer, buf []byte) error {
 		return writeMapShardResponseMessage(w, NewMapShardResponse(0, ""))
 	}
 
+	// Enforce the points remaining in the query's budget. The points read for
+	// each chunk are sent back so the query can count them in its budget.
+	var limiter *tsdb.PointLimiter
+	if n, ok := req.MaxPointN(); ok {
+		if m, ok := m.(tsdb.PointLimitedMapper); ok {
+			limiter = tsdb.NewPointLimiter(int(n))
+			m.SetPointLimiter(limiter)
+		}
+	}
+
 	if err := m.Open(); err != nil {
 		return fmt.Errorf("mapper open: %s", err)
 	}
@@ -283,10 +322,18 @@ func (s *Service) processMapShardRequest(w io.Writer, buf []byte) error {
 			metaSent = true
 		}
 
+		var pointN int
+		if limiter != nil {
+			pointN = limiter.N()
+		}
+
 		chunk, err := m.NextChunk()
 		if err != nil {
 			return fmt.Errorf("next chunk: %s", err)
 		}
+		if limiter != nil {
+			resp.SetPointN(int64(limiter.N() - pointN))
+		}
 
 		// NOTE: Even if the chunk is nil, we still need to send one
 		// empty response to let the other side know we're out of data.
@@ -313,6 +360,86 @@ func (s *Service) processMapShardRequest(w io.Writer, buf []byte) error {
 	}
 }
 
+// checkNodeID returns an error if a request for a node was received by another
+// node, which can happen if the address of a node has changed.
+func (s *Service) checkNodeID(id uint64) error {
+	if nodeID := s.MetaStore.NodeID(); id != nodeID {
+		return fmt.Errorf("request for node %d received by node %d", id, nodeID)
+	}
+	return nil
+}
+
+func (s *Service) processShowQueriesRequest(buf []byte) ([]tsdb.QueryInfo, error) {
+	var req ShowQueriesRequest
+	if err := req.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkNodeID(req.NodeID()); err != nil {
+		return nil, err
+	}
+	return s.QueryExecutor.Queries(), nil
+}
+
+func (s *Service) writeShowQueriesResponse(w io.Writer, queries []tsdb.QueryInfo, e error) {
+	// Build response.
+	var resp ShowQueriesResponse
+	if e != nil {
+		resp.SetCode(1)
+		resp.SetMessage(e.Error())
+	} else {
+		resp.SetCode(0)
+		resp.SetQueries(queries)
+	}
+
+	// Marshal response to binary.
+	buf, err := resp.MarshalBinary()
+	if err != nil {
+		s.Logger.Printf("error marshalling show queries response: %s", err)
+		return
+	}
+
+	// Write to connection.
+	if err := WriteTLV(w, showQueriesResponseMessage, buf); err != nil {
+		s.Logger.Printf("show queries response error: %s", err)
+	}
+}
+
+func (s *Service) processKillQueryRequest(buf []byte) error {
+	var req KillQueryRequest
+	if err := req.UnmarshalBinary(buf); err != nil {
+		return err
+	}
+
+	if err := s.checkNodeID(req.NodeID()); err != nil {
+		return err
+	}
+	return s.QueryExecutor.KillQuery(req.QueryID())
+}
+
+func (s *Service) writeKillQueryResponse(w io.Writer, e error) {
+	// Build response.
+	var resp KillQueryResponse
+	if e != nil {
+		resp.SetCode(1)
+		resp.SetMessage(e.Error())
+	} else {
+		resp.SetCode(0)
+	}
+
+	// Marshal response to binary.
+	buf, err := resp.MarshalBinary()
+	if err != nil {
+		s.Logger.Printf("error marshalling kill query response: %s", err)
+		return
+	}
+
+	// Write to connection.
+	if err := WriteTLV(w, killQueryResponseMessage, buf); err != nil {
+		s.Logger.Printf("kill query response error: %s", err)
+	}
+}
+
 func writeMapShardResponseMessage(w io.Writer, msg *MapShardResponse) error {
 	buf, err := msg.MarshalBinary()
 	if err != nil {
@@ -90,6 +90,7 @@ type RemoteMapper struct {
 
 	conn             net.Conn
 	bufferedResponse *MapShardResponse
+	limiter          *tsdb.PointLimiter
 
 	unmarshallers []tsdb.UnmarshalFunc // Mapping-specific unmarshal functions.
 }
@@ -117,6 +118,9 @@ func (r *RemoteMapper) Open() (err error) {
 	request.SetShardID(r.shardID)
 	request.SetQuery(r.stmt.String())
 	request.SetChunkSize(int32(r.chunkSize))
+	if r.limiter != nil {
+		request.SetMaxPointN(int64(r.limiter.Remaining()))
+	}
 
 	// Marshal into protocol buffers.
 	buf, err := request.MarshalBinary()
@@ -163,6 +167,12 @@ func (r *RemoteMapper) Open() (err error) {
 	return nil
 }
 
+// SetPointLimiter sets the limiter of the query's points. The remote node is
+// sent the points remaining when the mapper is opened and enforces them on the
+// points it reads. The points the remote node reads for each chunk are counted
+// by the limiter so the query's other mappers stop once it is exhausted.
+func (r *RemoteMapper) SetPointLimiter(l *tsdb.PointLimiter) { r.limiter = l }
+
 // TagSets returns the TagSets
 func (r *RemoteMapper) TagSets() []string {
 	return r.tagsets
@@ -198,6 +208,10 @@ func (r *RemoteMapper) NextChunk() (chunk interface{}, err error) {
 		}
 	}
 
+	if err := r.limiter.Add(int(response.PointN())); err != nil {
+		return nil, err
+	}
+
 	if response.Data() == nil {
 		return nil, nil
 	}
@@ -259,3 +273,9 @@ func (r *RemoteMapper) NextChunk() (chunk interface{}, err error) {
 func (r *RemoteMapper) Close() {
 	r.conn.Close()
 }
+
+// Interrupt closes the connection to the remote node, causing a pending read
+// of a chunk to return.
+func (r *RemoteMapper) Interrupt() {
+	r.conn.Close()
+}
@@ -98,6 +98,40 @@ func TestShardWriter_RemoteMapper_Success(t *testing.T) {
 	}
 }
 
+// Ensure a RemoteMapper counts the points read by the remote shard in the query's limiter.
+func TestShardWriter_RemoteMapper_PointLimit(t *testing.T) {
+	c := &remoteShardResponder{buffer: bytes.NewBuffer(nil)}
+	for _, n := range []int64{10, 10} {
+		resp := &MapShardResponse{}
+		resp.SetCode(0)
+		d, _ := json.Marshal(&tsdb.MapperOutput{Name: "cpu"})
+		resp.SetData(d)
+		resp.SetPointN(n)
+
+		g, _ := resp.MarshalBinary()
+		WriteTLV(c.buffer, mapShardResponseMessage, g)
+	}
+
+	l := tsdb.NewPointLimiter(15)
+	r := NewRemoteMapper(c, 1234, mustParseStmt("SELECT * FROM CPU"), 10)
+	r.SetPointLimiter(l)
+	if err := r.Open(); err != nil {
+		t.Fatalf("failed to open remote mapper: %s", err.Error())
+	}
+
+	if _, err := r.NextChunk(); err != nil {
+		t.Fatalf("failed to get next chunk from mapper: %s", err.Error())
+	} else if n := l.Remaining(); n != 5 {
+		t.Fatalf("unexpected points remaining: %d", n)
+	}
+
+	if _, err := r.NextChunk(); err == nil {
+		t.Fatal("expected point limit error")
+	} else if _, ok := err.(*tsdb.QueryLimitError); !ok {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
 // mustParseStmt parses a single statement or panics.
 func mustParseStmt(stmt string) influxql.Statement {
 	q, err := influxql.ParseQuery(stmt)
@@ -7,6 +7,7 @@ import (
 
 	"github.com/influxdb/influxdb/meta"
 	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/tsdb"
 	"gopkg.in/fatih/pool.v2"
 )
 
@@ -15,6 +16,10 @@ const (
 	writeShardResponseMessage
 	mapShardRequestMessage
 	mapShardResponseMessage
+	showQueriesRequestMessage
+	showQueriesResponseMessage
+	killQueryRequestMessage
+	killQueryResponseMessage
 )
 
 // ShardWriter writes a set of points to a shard.
@@ -82,7 +87,9 @@ func (w *ShardWriter) WriteShard(shardID, ownerID uint64, points []models.Point)
 		return err
 	}
 
-	if response.Code() != 0 {
+	if response.Code() == writeShardPartialCode {
+		return tsdb.PartialWriteError{Reason: response.Message(), Dropped: response.Dropped()}
+	} else if response.Code() != 0 {
 		return fmt.Errorf("error code %d: %s", response.Code(), response.Message())
 	}
 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/influxdb/influxdb/cluster"
 	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/tsdb"
 )
 
 // Ensure the shard writer can successful write a single request.
@@ -134,6 +135,35 @@ func TestShardWriter_WriteShard_Error(t *testing.T) {
 	}
 }
 
+// Ensure the shard writer returns a partial write error when the server dropped points.
+func TestShardWriter_WriteShard_PartialWrite(t *testing.T) {
+	ts := newTestWriteService(func(shardID uint64, points []models.Point) error {
+		return tsdb.PartialWriteError{Reason: "max series per database exceeded", Dropped: 1}
+	})
+	s := cluster.NewService(cluster.Config{})
+	s.Listener = ts.muxln
+	s.TSDBStore = ts
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	defer ts.Close()
+
+	w := cluster.NewShardWriter(time.Minute)
+	w.MetaStore = &metaStore{host: ts.ln.Addr().String()}
+	now := time.Now()
+
+	var points []models.Point
+	points = append(points, models.MustNewPoint(
+		"cpu", models.Tags{"host": "server01"}, map[string]interface{}{"value": int64(100)}, now,
+	))
+
+	exp := tsdb.PartialWriteError{Reason: "max series per database exceeded", Dropped: 1}
+	if err := w.WriteShard(1, 2, points); err != exp {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+}
+
 // Ensure the shard writer returns an error when dialing times out.
 func TestShardWriter_Write_ErrDialTimeout(t *testing.T) {
 	ts := newTestWriteService(writeShardSuccess)
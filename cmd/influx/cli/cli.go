@@ -20,6 +20,7 @@ import (
 
 	"github.com/influxdb/influxdb/client"
 	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/importer/records"
 	"github.com/influxdb/influxdb/importer/v8"
 	"github.com/peterh/liner"
 )
@@ -51,6 +52,12 @@ type CommandLine struct {
 	PPS              int // Controls how many points per second the import will allow via throttling
 	Path             string
 	Compressed       bool
+	ImportFormat     string // v8, csv or jsonl, guessed from the path by default
+	Measurement      string // measurement of the imported records
+	Tags             string // tag columns of the imported records
+	Fields           string // field columns of the imported records, with their types
+	TimeColumn       string
+	TimeLayout       string
 	Quit             chan struct{}
 	osSignals        chan os.Signal
 	historyFile      *os.File
@@ -136,6 +143,16 @@ func (c *CommandLine) Run() {
 			return
 		}
 
+		if format := c.importFormat(); format != "v8" {
+			if err := c.importRecords(u, format); err != nil {
+				fmt.Printf("ERROR: %s\n", err)
+				c.Line.Close()
+				os.Exit(1)
+			}
+			c.Line.Close()
+			os.Exit(0)
+		}
+
 		config := v8.NewConfig()
 		config.Username = c.Username
 		config.Password = c.Password
@@ -245,6 +262,45 @@ func (c *CommandLine) ParseCommand(cmd string) bool {
 	return false
 }
 
+// importFormat returns the format of the file to import. Unless set, it's
+// guessed from the extension of the file, defaulting to a v0.8 export.
+func (c *CommandLine) importFormat() string {
+	if c.ImportFormat != "" {
+		return c.ImportFormat
+	}
+	switch filepath.Ext(strings.TrimSuffix(c.Path, ".gz")) {
+	case ".csv":
+		return records.CSV
+	case ".jsonl", ".ndjson":
+		return records.JSONLines
+	}
+	return "v8"
+}
+
+// importRecords imports a CSV or JSON lines file into the database.
+func (c *CommandLine) importRecords(u url.URL, format string) error {
+	m, err := records.ParseMapping(c.Measurement, c.Tags, c.Fields, c.TimeColumn, c.TimeLayout)
+	if err != nil {
+		return err
+	}
+
+	config := records.NewConfig()
+	config.Username = c.Username
+	config.Password = c.Password
+	config.URL = u
+	config.Database = c.Database
+	config.RetentionPolicy = c.RetentionPolicy
+	config.WriteConsistency = c.WriteConsistency
+	config.Path = c.Path
+	config.Format = format
+	config.Mapping = m
+	config.Version = c.ClientVersion
+	config.Compressed = c.Compressed || strings.HasSuffix(c.Path, ".gz")
+	config.PPS = c.PPS
+
+	return records.NewImporter(config).Import()
+}
+
 // Connect connects client to a server
 func (c *CommandLine) Connect(cmd string) error {
 	var cl *client.Client
@@ -46,6 +46,12 @@ func main() {
 	fs.IntVar(&c.PPS, "pps", defaultPPS, "How many points per second the import will allow.  By default it is zero and will not throttle importing.")
 	fs.StringVar(&c.Path, "path", "", "path to the file to import")
 	fs.BoolVar(&c.Compressed, "compressed", false, "set to true if the import file is compressed")
+	fs.StringVar(&c.ImportFormat, "import-format", "", "Format of the import file: v8, csv or jsonl.  By default it is guessed from the file extension.")
+	fs.StringVar(&c.Measurement, "measurement", "", "Measurement of the imported csv or jsonl records.")
+	fs.StringVar(&c.Tags, "tags", "", "Comma-separated tag columns of the imported records.")
+	fs.StringVar(&c.Fields, "fields", "", "Comma-separated field columns of the imported records, with an optional type, e.g. value:float,count:integer.")
+	fs.StringVar(&c.TimeColumn, "time-column", "", "Time column of the imported records.")
+	fs.StringVar(&c.TimeLayout, "time-layout", "", "Layout of the time column: unix, unix_ms, unix_us, unix_ns or a Go time layout.  By default it is RFC3339.")
 
 	// Define our own custom usage to print
 	fs.Usage = func() {
@@ -82,6 +88,18 @@ func main() {
        Path to file to import
   -compressed
        Set to true if the import file is compressed
+  -import-format 'v8|csv|jsonl'
+       Format of the import file.  By default it is guessed from the file extension.
+  -measurement 'measurement name'
+       Measurement of the imported csv or jsonl records.
+  -tags 'column,...'
+       Tag columns of the imported records.
+  -fields 'column[:float|integer|string|boolean],...'
+       Field columns of the imported records.  Without any, all other columns are fields.
+  -time-column 'column'
+       Time column of the imported records.  Without one, points get the current time.
+  -time-layout 'unix|unix_ms|unix_us|unix_ns|layout'
+       Layout of the time column.  By default it is RFC3339.
 
 Examples:
 
@@ -90,6 +108,9 @@ Examples:
 
     # Connect to a specific database on startup and set database context:
     $ influx -database 'metrics' -host 'localhost' -port '8086'
+
+    # Import a CSV file with host and region tags into the "cpu" measurement of the database "metrics":
+    $ influx -import -path 'cpu.csv' -database 'metrics' -measurement 'cpu' -tags 'host,region' -time-column 'time'
 `)
 	}
 	fs.Parse(os.Args[1:])
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/influxdb/influxdb/tsdb"
+	"github.com/influxdb/influxdb/tsdb/engine/tsm1"
+)
+
+// maxTSMFileSize is the size after which a new TSM file is started.
+const maxTSMFileSize = uint32(2048 * 1024 * 1024) // 2GB
+
+// tempExtension is appended to the path of a shard while it is converted.
+// The store skips shards which aren't named by their ID.
+const tempExtension = ".tsm1.tmp"
+
+// ShardInfo describes a shard in the data directory.
+type ShardInfo struct {
+	Database        string
+	RetentionPolicy string
+	ID              uint64
+	Path            string
+	Format          tsdb.EngineFormat
+	Size            int64
+}
+
+// FormatName returns the name of the shard's storage engine.
+func (si *ShardInfo) FormatName() string {
+	switch si.Format {
+	case tsdb.B1Format:
+		return "b1"
+	case tsdb.BZ1Format:
+		return "bz1"
+	case tsdb.TSM1Format:
+		return "tsm1"
+	}
+	return "unknown"
+}
+
+// CollectShards returns the shards in the data directory, sorted by path.
+func CollectShards(dataDir string) ([]*ShardInfo, error) {
+	paths, err := filepath.Glob(filepath.Join(dataDir, "*", "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var shards []*ShardInfo
+	for _, path := range paths {
+		// Shards are named by their ID.
+		id, err := strconv.ParseUint(filepath.Base(path), 10, 64)
+		if err != nil {
+			continue
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		// Detect the format the same way the store does.
+		e, err := tsdb.NewEngine(path, "", tsdb.NewEngineOptions())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+
+		rp := filepath.Dir(path)
+		shards = append(shards, &ShardInfo{
+			Database:        filepath.Base(filepath.Dir(rp)),
+			RetentionPolicy: filepath.Base(rp),
+			ID:              id,
+			Path:            path,
+			Format:          e.Format(),
+			Size:            fi.Size(),
+		})
+	}
+	return shards, nil
+}
+
+// ShardStats are the statistics of a converted shard.
+type ShardStats struct {
+	Series   int
+	Keys     int
+	Points   int64
+	Files    int
+	Size     int64 // Size of the TSM files.
+	Duration time.Duration
+}
+
+// Converter converts b1 and bz1 shards to tsm1 shards.
+type Converter struct {
+	// Directory holding the WAL of bz1 shards.
+	WALDir string
+
+	// Directory the original shards and their WAL are moved to.
+	BackupDir string
+}
+
+// Convert reads all points of the shard through its engine and writes them to
+// TSM files, which replace the shard once it's been backed up.
+func (c *Converter) Convert(si *ShardInfo) (*ShardStats, error) {
+	start := time.Now()
+	walPath := filepath.Join(c.WALDir, si.Database, si.RetentionPolicy, strconv.FormatUint(si.ID, 10))
+	tmpPath := si.Path + tempExtension
+
+	if err := os.RemoveAll(tmpPath); err != nil {
+		return nil, err
+	}
+	stats, err := c.writeTSM(si, walPath, tmpPath)
+	if err != nil {
+		os.RemoveAll(tmpPath)
+		return nil, err
+	}
+
+	// Move the original shard and its WAL out of the way before replacing it.
+	backupPath := filepath.Join(c.BackupDir, "data", si.Database, si.RetentionPolicy, filepath.Base(si.Path))
+	if err := move(si.Path, backupPath); err != nil {
+		return nil, fmt.Errorf("backup: %s", err)
+	}
+	if _, err := os.Stat(walPath); err == nil {
+		if err := move(walPath, filepath.Join(c.BackupDir, "wal", si.Database, si.RetentionPolicy, filepath.Base(walPath))); err != nil {
+			return nil, fmt.Errorf("backup wal: %s", err)
+		}
+	}
+	if err := os.Rename(tmpPath, si.Path); err != nil {
+		return nil, err
+	}
+
+	stats.Duration = time.Since(start)
+	return stats, nil
+}
+
+// writeTSM writes the points of the shard to TSM files in dir.
+func (c *Converter) writeTSM(si *ShardInfo, walPath, dir string) (*ShardStats, error) {
+	opt := tsdb.NewEngineOptions()
+	opt.Config.WALLoggingEnabled = false
+	e, err := tsdb.NewEngine(si.Path, walPath, opt)
+	if err != nil {
+		return nil, err
+	}
+	e.SetLogOutput(ioutil.Discard)
+	if err := e.Open(); err != nil {
+		return nil, err
+	}
+	defer e.Close()
+
+	index := tsdb.NewDatabaseIndex()
+	measurementFields := make(map[string]*tsdb.MeasurementFields)
+	if err := e.LoadMetadataIndex(nil, index, measurementFields); err != nil {
+		return nil, err
+	}
+
+	// TSM files must be written in key order, which isn't the order of the
+	// series as the field name is appended to the key.
+	stats := &ShardStats{}
+	var keys seriesFieldKeys
+	for name, mf := range measurementFields {
+		m := index.Measurement(name)
+		if m == nil {
+			continue
+		}
+		for _, series := range m.SeriesKeys() {
+			stats.Series++
+			for field := range mf.Fields {
+				keys = append(keys, seriesFieldKey{
+					key:    tsm1.SeriesFieldKey(series, field),
+					series: series,
+					field:  field,
+					codec:  mf.Codec,
+				})
+			}
+		}
+	}
+	sort.Sort(keys)
+	stats.Keys = len(keys)
+
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+
+	tx, err := e.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	w := &tsmFileWriter{dir: dir, stats: stats}
+	for _, k := range keys {
+		cur := tx.Cursor(k.series, []string{k.field}, k.codec, true)
+		if cur == nil {
+			continue
+		}
+
+		values := make(tsm1.Values, 0, tsdb.DefaultMaxPointsPerBlock)
+		for t, v := cur.SeekTo(0); t != tsdb.EOF; t, v = cur.Next() {
+			// Points without a value for the field are skipped.
+			if v == nil {
+				continue
+			}
+			values = append(values, tsm1.NewValue(time.Unix(0, t), v))
+
+			if len(values) == cap(values) {
+				if err := w.write(k.key, values); err != nil {
+					return nil, err
+				}
+				values = values[:0]
+			}
+		}
+		if err := w.write(k.key, values); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.close(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// tsmFileWriter writes blocks to TSM files, starting a new file when the
+// current one reaches the maximum size.
+type tsmFileWriter struct {
+	dir   string
+	path  string
+	w     tsm1.TSMWriter
+	stats *ShardStats
+}
+
+func (w *tsmFileWriter) write(key string, values tsm1.Values) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	if w.w == nil {
+		w.stats.Files++
+		w.path = filepath.Join(w.dir, fmt.Sprintf("%09d-%09d.%s", 1, w.stats.Files, tsm1.TSMFileExtension))
+		f, err := os.OpenFile(w.path, os.O_CREATE|os.O_RDWR|os.O_EXCL, 0666)
+		if err != nil {
+			return err
+		}
+		if w.w, err = tsm1.NewTSMWriter(f); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	if err := w.w.Write(key, values); err != nil {
+		return err
+	}
+	w.stats.Points += int64(len(values))
+
+	if w.w.Size() > maxTSMFileSize {
+		return w.close()
+	}
+	return nil
+}
+
+// close writes the index of the current file and closes it.
+func (w *tsmFileWriter) close() error {
+	if w.w == nil {
+		return nil
+	}
+	defer func() { w.w = nil }()
+
+	if err := w.w.WriteIndex(); err != nil {
+		w.w.Close()
+		return err
+	}
+	if err := w.w.Close(); err != nil {
+		return err
+	}
+
+	fi, err := os.Stat(w.path)
+	if err != nil {
+		return err
+	}
+	w.stats.Size += fi.Size()
+	return nil
+}
+
+// seriesFieldKey is the TSM key of a field of a series.
+type seriesFieldKey struct {
+	key    string
+	series string
+	field  string
+	codec  *tsdb.FieldCodec
+}
+
+type seriesFieldKeys []seriesFieldKey
+
+func (a seriesFieldKeys) Len() int           { return len(a) }
+func (a seriesFieldKeys) Less(i, j int) bool { return a[i].key < a[j].key }
+func (a seriesFieldKeys) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// move renames src to dst, creating the parent directory of dst. The file
+// is copied if it can't be renamed, e.g. when dst is on another device.
+func move(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0777); err != nil {
+		return err
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("%s already exists", dst)
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	fi, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		if err := os.MkdirAll(dst, fi.Mode()); err != nil {
+			return err
+		}
+		names, err := ioutil.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, n := range names {
+			if err := move(filepath.Join(src, n.Name()), filepath.Join(dst, n.Name())); err != nil {
+				return err
+			}
+		}
+		return os.Remove(src)
+	}
+
+	if err := copyFile(src, dst, fi.Mode()); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// copyFile copies the contents of src to a new file at dst.
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/tsdb"
+	"github.com/influxdb/influxdb/tsdb/engine/tsm1"
+)
+
+// Ensure b1 and bz1 shards are converted to tsm1 shards and backed up.
+func TestConverter_Convert(t *testing.T) {
+	for _, format := range []string{"b1", "bz1"} {
+		dir, err := ioutil.TempDir("", "influx_tsm")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		dataDir := filepath.Join(dir, "data")
+		walDir := filepath.Join(dir, "wal")
+		MustWriteShard(format, filepath.Join(dataDir, "db0", "default", "1"), filepath.Join(walDir, "db0", "default", "1"), []models.Point{
+			models.MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1.5, "idle": int64(10)}, time.Unix(10, 0)),
+			models.MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 2.5}, time.Unix(20, 0)),
+			models.MustNewPoint("cpu", map[string]string{"host": "serverB"}, map[string]interface{}{"value": 3.5}, time.Unix(10, 0)),
+			models.MustNewPoint("disk", nil, map[string]interface{}{"full": true, "name": "sda"}, time.Unix(30, 0)),
+		})
+
+		shards, err := CollectShards(dataDir)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(shards) != 1 {
+			t.Fatalf("%s: unexpected shard count: %d", format, len(shards))
+		} else if si := shards[0]; si.FormatName() != format || si.Database != "db0" || si.RetentionPolicy != "default" || si.ID != 1 {
+			t.Fatalf("%s: unexpected shard: %#v", format, si)
+		}
+
+		c := &Converter{WALDir: walDir, BackupDir: filepath.Join(dir, "backup")}
+		stats, err := c.Convert(shards[0])
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		} else if stats.Series != 3 || stats.Keys != 6 || stats.Points != 6 || stats.Files != 1 {
+			t.Fatalf("%s: unexpected stats: %#v", format, stats)
+		}
+
+		// The original shard is moved to the backup directory.
+		if _, err := os.Stat(filepath.Join(dir, "backup", "data", "db0", "default", "1")); err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+
+		// The converted shard is a tsm1 shard with the same points.
+		if shards, err := CollectShards(dataDir); err != nil {
+			t.Fatal(err)
+		} else if shards[0].Format != tsdb.TSM1Format {
+			t.Fatalf("%s: unexpected format: %s", format, shards[0].FormatName())
+		}
+
+		e := tsm1.NewDevEngine(shards[0].Path, filepath.Join(walDir, "db0", "default", "1"), tsdb.NewEngineOptions())
+		if err := e.Open(); err != nil {
+			t.Fatal(err)
+		}
+		tx, _ := e.Begin(false)
+		for _, tt := range []struct {
+			series string
+			field  string
+			exp    map[int64]interface{}
+		}{
+			{series: "cpu,host=serverA", field: "value", exp: map[int64]interface{}{10e9: 1.5, 20e9: 2.5}},
+			{series: "cpu,host=serverA", field: "idle", exp: map[int64]interface{}{10e9: int64(10)}},
+			{series: "cpu,host=serverB", field: "value", exp: map[int64]interface{}{10e9: 3.5}},
+			{series: "disk", field: "full", exp: map[int64]interface{}{30e9: true}},
+			{series: "disk", field: "name", exp: map[int64]interface{}{30e9: "sda"}},
+		} {
+			values := make(map[int64]interface{})
+			cur := tx.Cursor(tt.series, []string{tt.field}, nil, true)
+			for k, v := cur.SeekTo(0); k != tsdb.EOF; k, v = cur.Next() {
+				values[k] = v
+			}
+			if !reflect.DeepEqual(values, tt.exp) {
+				t.Errorf("%s: %s %s: unexpected values: %v", format, tt.series, tt.field, values)
+			}
+		}
+		tx.Rollback()
+		e.Close()
+	}
+}
+
+// MustWriteShard writes points to a new shard using the given engine. Panic on error.
+func MustWriteShard(format, path, walPath string, points []models.Point) {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		panic(err)
+	}
+
+	opt := tsdb.NewEngineOptions()
+	opt.EngineVersion = format
+	opt.Config.WALDir = filepath.Dir(walPath)
+	sh := tsdb.NewShard(1, tsdb.NewDatabaseIndex(), path, walPath, opt)
+	if err := sh.Open(); err != nil {
+		panic(err)
+	}
+	defer sh.Close()
+
+	if err := sh.WritePoints(points); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/influxdb/influxdb/tsdb"
+	_ "github.com/influxdb/influxdb/tsdb/engine"
+)
+
+const description = `
+Converts b1 and bz1 shards to tsm1 shards. The server must not be running
+while shards are converted.
+
+Each shard is read through its storage engine, including any points in its
+WAL, and written to new TSM files. The original shard and its WAL are then
+moved to the backup directory, which must be on the same filesystem as the
+data directory to avoid copying them.
+`
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: influx_tsm [options] [data directory]\n%s\n", description)
+	fmt.Fprintln(os.Stderr, "Options:")
+	flag.PrintDefaults()
+}
+
+func main() {
+	var (
+		walDir    = flag.String("wal", os.Getenv("HOME")+"/.influxdb/wal", "WAL directory of the server.")
+		backupDir = flag.String("backup", "", "Directory to move the original shards to. Required unless -dry-run is set.")
+		parallel  = flag.Int("parallel", runtime.NumCPU(), "Number of shards to convert at once.")
+		dryRun    = flag.Bool("dry-run", false, "List the shards which would be converted and exit.")
+	)
+	flag.Usage = usage
+	flag.Parse()
+
+	dataDir := os.Getenv("HOME") + "/.influxdb/data"
+	if flag.NArg() > 0 {
+		dataDir = flag.Arg(0)
+	}
+
+	if !*dryRun && *backupDir == "" {
+		fmt.Fprintf(os.Stderr, "Backup directory required\n\n")
+		usage()
+		os.Exit(1)
+	} else if *parallel < 1 {
+		fmt.Fprintf(os.Stderr, "Parallelism must be at least 1\n")
+		os.Exit(1)
+	}
+
+	shards, err := CollectShards(dataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read shards: %s\n", err)
+		os.Exit(1)
+	}
+
+	// Only bolt shards need to be converted.
+	var bolt []*ShardInfo
+	for _, si := range shards {
+		if si.Format != tsdb.TSM1Format {
+			bolt = append(bolt, si)
+		}
+	}
+
+	fmt.Printf("Data directory: %s\nWAL directory:  %s\n\n", dataDir, *walDir)
+	if len(bolt) == 0 {
+		fmt.Println("No shards to convert.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 8, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, "Database\tRetention\tShard\tFormat\tSize")
+	var size int64
+	for _, si := range bolt {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%d\n", si.Database, si.RetentionPolicy, si.ID, si.FormatName(), si.Size)
+		size += si.Size
+	}
+	tw.Flush()
+	fmt.Printf("\n%d shards to convert, %d bytes in total.\n", len(bolt), size)
+
+	if *dryRun {
+		return
+	}
+
+	// Convert the shards with a pool of workers.
+	c := &Converter{WALDir: *walDir, BackupDir: filepath.Clean(*backupDir)}
+	start := time.Now()
+	ch := make(chan *ShardInfo)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var total ShardStats
+	var failed int
+	for i := 0; i < *parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for si := range ch {
+				stats, err := c.Convert(si)
+
+				mu.Lock()
+				if err != nil {
+					failed++
+					fmt.Printf("Failed to convert %s: %s\n", si.Path, err)
+				} else {
+					fmt.Printf("Converted %s: %d series, %d keys, %d points, %d bytes in %d files, %s\n",
+						si.Path, stats.Series, stats.Keys, stats.Points, stats.Size, stats.Files, stats.Duration)
+					total.Series += stats.Series
+					total.Keys += stats.Keys
+					total.Points += stats.Points
+					total.Files += stats.Files
+					total.Size += stats.Size
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, si := range bolt {
+		ch <- si
+	}
+	close(ch)
+	wg.Wait()
+
+	fmt.Printf("\nConverted %d of %d shards in %s: %d series, %d points, %d bytes (was %d bytes).\n",
+		len(bolt)-failed, len(bolt), time.Since(start), total.Series, total.Points, total.Size, size)
+	fmt.Printf("Original shards were moved to %s.\n", c.BackupDir)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
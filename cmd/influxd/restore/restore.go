@@ -225,6 +225,12 @@ func (cmd *Command) printUsage() {
 
 restore uses a snapshot of a data node to rebuild a cluster.
 
+The meta and data directories are removed before the snapshot and its
+incremental backups are unpacked, so restoring onto a live node is not
+supported. The files of tsm1 shards are restored from all the backups, so
+data removed by compactions or deletes since the first backup may reappear.
+Take a new full backup after deleting data.
+
         -config <path>
                           Set the path to the configuration file.
 `)
@@ -24,17 +24,20 @@ import (
 	"github.com/influxdb/influxdb/services/precreator"
 	"github.com/influxdb/influxdb/services/retention"
 	"github.com/influxdb/influxdb/services/subscriber"
+	"github.com/influxdb/influxdb/services/statsd"
 	"github.com/influxdb/influxdb/services/udp"
 	"github.com/influxdb/influxdb/tsdb"
 )
 
 // Config represents the configuration format for the influxd binary.
 type Config struct {
-	Meta       *meta.Config      `toml:"meta"`
-	Data       tsdb.Config       `toml:"data"`
-	Cluster    cluster.Config    `toml:"cluster"`
-	Retention  retention.Config  `toml:"retention"`
-	Precreator precreator.Config `toml:"shard-precreation"`
+	Meta       *meta.Config         `toml:"meta"`
+	Data       tsdb.Config          `toml:"data"`
+	Query      tsdb.QueryConfig     `toml:"query"`
+	RateLimits tsdb.RateLimitConfig `toml:"rate-limits"`
+	Cluster    cluster.Config       `toml:"cluster"`
+	Retention  retention.Config     `toml:"retention"`
+	Precreator precreator.Config    `toml:"shard-precreation"`
 
 	Admin      admin.Config      `toml:"admin"`
 	Monitor    monitor.Config    `toml:"monitor"`
@@ -44,6 +47,7 @@ type Config struct {
 	Collectd   collectd.Config   `toml:"collectd"`
 	OpenTSDB   opentsdb.Config   `toml:"opentsdb"`
 	UDPs       []udp.Config      `toml:"udp"`
+	Statsds    []statsd.Config   `toml:"statsd"`
 
 	// Snapshot SnapshotConfig `toml:"snapshot"`
 	ContinuousQuery continuous_querier.Config `toml:"continuous_queries"`
@@ -59,6 +63,8 @@ func NewConfig() *Config {
 	c := &Config{}
 	c.Meta = meta.NewConfig()
 	c.Data = tsdb.NewConfig()
+	c.Query = tsdb.NewQueryConfig()
+	c.RateLimits = tsdb.NewRateLimitConfig()
 	c.Cluster = cluster.NewConfig()
 	c.Precreator = precreator.NewConfig()
 
@@ -114,11 +120,23 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	if c.Subscriber.Enabled {
+		if err := c.Subscriber.Validate(); err != nil {
+			return fmt.Errorf("invalid subscriber config: %v", err)
+		}
+	}
+
 	for _, g := range c.Graphites {
 		if err := g.Validate(); err != nil {
 			return fmt.Errorf("invalid graphite config: %v", err)
 		}
 	}
+
+	for _, c := range c.Statsds {
+		if err := c.Validate(); err != nil {
+			return fmt.Errorf("invalid statsd config: %v", err)
+		}
+	}
 	return nil
 }
 
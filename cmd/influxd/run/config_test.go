@@ -3,6 +3,7 @@ package run_test
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/influxdb/influxdb/cmd/influxd/run"
@@ -19,6 +20,13 @@ dir = "/tmp/meta"
 [data]
 dir = "/tmp/data"
 
+[query]
+query-timeout = "30s"
+max-select-series = 1000
+
+[rate-limits]
+user-points-per-second = 50000
+
 [cluster]
 
 [admin]
@@ -42,6 +50,9 @@ bind-address = ":2000"
 [[udp]]
 bind-address = ":4444"
 
+[[statsd]]
+bind-address = ":8125"
+
 [monitoring]
 enabled = true
 
@@ -59,6 +70,12 @@ enabled = true
 		t.Fatalf("unexpected meta dir: %s", c.Meta.Dir)
 	} else if c.Data.Dir != "/tmp/data" {
 		t.Fatalf("unexpected data dir: %s", c.Data.Dir)
+	} else if time.Duration(c.Query.QueryTimeout) != 30*time.Second {
+		t.Fatalf("unexpected query timeout: %s", c.Query.QueryTimeout)
+	} else if c.Query.MaxSelectSeriesN != 1000 {
+		t.Fatalf("unexpected max select series: %d", c.Query.MaxSelectSeriesN)
+	} else if c.RateLimits.UserPointsPerSecond != 50000 {
+		t.Fatalf("unexpected user points per second: %d", c.RateLimits.UserPointsPerSecond)
 	} else if c.Admin.BindAddress != ":8083" {
 		t.Fatalf("unexpected admin bind address: %s", c.Admin.BindAddress)
 	} else if c.HTTPD.BindAddress != ":8087" {
@@ -75,6 +92,8 @@ enabled = true
 		t.Fatalf("unexpected opentsdb bind address: %s", c.OpenTSDB.BindAddress)
 	} else if c.UDPs[0].BindAddress != ":4444" {
 		t.Fatalf("unexpected udp bind address: %s", c.UDPs[0].BindAddress)
+	} else if c.Statsds[0].BindAddress != ":8125" {
+		t.Fatalf("unexpected statsd bind address: %s", c.Statsds[0].BindAddress)
 	} else if c.Subscriber.Enabled != true {
 		t.Fatalf("unexpected subscriber enabled: %v", c.Subscriber.Enabled)
 	} else if c.ContinuousQuery.Enabled != true {
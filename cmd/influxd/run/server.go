@@ -25,6 +25,7 @@ import (
 	"github.com/influxdb/influxdb/services/retention"
 	"github.com/influxdb/influxdb/services/snapshotter"
 	"github.com/influxdb/influxdb/services/subscriber"
+	"github.com/influxdb/influxdb/services/statsd"
 	"github.com/influxdb/influxdb/services/udp"
 	"github.com/influxdb/influxdb/tcp"
 	"github.com/influxdb/influxdb/tsdb"
@@ -60,6 +61,8 @@ type Server struct {
 	PointsWriter  *cluster.PointsWriter
 	ShardWriter   *cluster.ShardWriter
 	ShardMapper   *cluster.ShardMapper
+	QueryManager  *cluster.QueryManager
+	RateLimiter   *tsdb.RateLimiter
 	HintedHandoff *hh.Service
 	Subscriber    *subscriber.Service
 
@@ -114,6 +117,10 @@ func NewServer(c *Config, buildInfo *BuildInfo) (*Server, error) {
 	s.ShardMapper.MetaStore = s.MetaStore
 	s.ShardMapper.TSDBStore = s.TSDBStore
 
+	// Set the query manager
+	s.QueryManager = cluster.NewQueryManager(time.Duration(c.Cluster.ShardMapperTimeout))
+	s.QueryManager.MetaStore = s.MetaStore
+
 	// Initialize query executor.
 	s.QueryExecutor = tsdb.NewQueryExecutor(s.TSDBStore)
 	s.QueryExecutor.MetaStore = s.MetaStore
@@ -121,6 +128,12 @@ func NewServer(c *Config, buildInfo *BuildInfo) (*Server, error) {
 	s.QueryExecutor.MonitorStatementExecutor = &monitor.StatementExecutor{Monitor: s.Monitor}
 	s.QueryExecutor.ShardMapper = s.ShardMapper
 	s.QueryExecutor.QueryLogEnabled = c.Data.QueryLogEnabled
+	s.QueryExecutor.MaxConcurrentQueries = c.Query.MaxConcurrentQueries
+	s.QueryExecutor.QueryLimits = c.Query.Limits()
+	s.QueryExecutor.RemoteQueryManager = s.QueryManager
+
+	// Initialize the rate limiter shared by the services.
+	s.RateLimiter = tsdb.NewRateLimiter(c.RateLimits)
 
 	// Set the shard writer
 	s.ShardWriter = cluster.NewShardWriter(time.Duration(c.Cluster.ShardWriterTimeout))
@@ -169,6 +182,9 @@ func NewServer(c *Config, buildInfo *BuildInfo) (*Server, error) {
 	for _, g := range c.UDPs {
 		s.appendUDPService(g)
 	}
+	for _, g := range c.Statsds {
+		s.appendStatsdService(g)
+	}
 	s.appendRetentionPolicyService(c.Retention)
 	for _, g := range c.Graphites {
 		if err := s.appendGraphiteService(g); err != nil {
@@ -183,6 +199,7 @@ func (s *Server) appendClusterService(c cluster.Config) {
 	srv := cluster.NewService(c)
 	srv.TSDBStore = s.TSDBStore
 	srv.MetaStore = s.MetaStore
+	srv.QueryExecutor = s.QueryExecutor
 	s.Services = append(s.Services, srv)
 	s.ClusterService = srv
 }
@@ -228,6 +245,8 @@ func (s *Server) appendHTTPDService(c httpd.Config) {
 	srv.Handler.MetaStore = s.MetaStore
 	srv.Handler.QueryExecutor = s.QueryExecutor
 	srv.Handler.PointsWriter = s.PointsWriter
+	srv.Handler.Monitor = s.Monitor
+	srv.Handler.RateLimiter = s.RateLimiter
 	srv.Handler.Version = s.buildInfo.Version
 
 	// If a ContinuousQuerier service has been started, attach it.
@@ -260,6 +279,11 @@ func (s *Server) appendOpenTSDBService(c opentsdb.Config) error {
 	}
 	srv.PointsWriter = s.PointsWriter
 	srv.MetaStore = s.MetaStore
+	srv.QueryExecutor = s.QueryExecutor
+	srv.TSDBStore = s.TSDBStore
+	if c.RateLimited {
+		srv.RateLimiter = s.RateLimiter
+	}
 	s.Services = append(s.Services, srv)
 	return nil
 }
@@ -276,6 +300,9 @@ func (s *Server) appendGraphiteService(c graphite.Config) error {
 	srv.PointsWriter = s.PointsWriter
 	srv.MetaStore = s.MetaStore
 	srv.Monitor = s.Monitor
+	if c.RateLimited {
+		srv.RateLimiter = s.RateLimiter
+	}
 	s.Services = append(s.Services, srv)
 	return nil
 }
@@ -301,6 +328,22 @@ func (s *Server) appendUDPService(c udp.Config) {
 	srv := udp.NewService(c)
 	srv.PointsWriter = s.PointsWriter
 	srv.MetaStore = s.MetaStore
+	if c.RateLimited {
+		srv.RateLimiter = s.RateLimiter
+	}
+	s.Services = append(s.Services, srv)
+}
+
+func (s *Server) appendStatsdService(c statsd.Config) {
+	if !c.Enabled {
+		return
+	}
+	srv := statsd.NewService(c)
+	srv.PointsWriter = s.PointsWriter
+	srv.MetaStore = s.MetaStore
+	if c.RateLimited {
+		srv.RateLimiter = s.RateLimiter
+	}
 	s.Services = append(s.Services, srv)
 }
 
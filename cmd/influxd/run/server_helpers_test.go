@@ -18,6 +18,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
 	"github.com/influxdb/influxdb/client/v2"
 	"github.com/influxdb/influxdb/cmd/influxd/run"
 	"github.com/influxdb/influxdb/meta"
@@ -181,6 +183,28 @@ func (s *Server) HTTPPost(url string, content []byte) (results string, err error
 	}
 }
 
+// HTTPPostProto posts a snappy compressed protobuf message to the server and
+// returns the decompressed response.
+func (s *Server) HTTPPostProto(url string, msg proto.Message) ([]byte, error) {
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.Post(url, "application/x-protobuf", bytes.NewReader(snappy.Encode(nil, b)))
+	if err != nil {
+		return nil, err
+	}
+	body := MustReadAll(resp.Body)
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil, nil
+	case http.StatusOK:
+		return snappy.Decode(nil, body)
+	default:
+		return nil, fmt.Errorf("unexpected status code: code=%d, body=%s", resp.StatusCode, body)
+	}
+}
+
 // Write executes a write against the server and returns the results.
 func (s *Server) Write(db, rp, body string, params url.Values) (results string, err error) {
 	if params == nil {
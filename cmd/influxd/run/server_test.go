@@ -4,12 +4,15 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
 	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/prometheus/remote"
 )
 
 // Ensure that HTTP responses include the InfluxDB version.
@@ -5726,3 +5729,58 @@ func TestServer_WhereTimeInclusive(t *testing.T) {
 		}
 	}
 }
+
+// Ensure samples written through the Prometheus endpoint can be read back.
+func TestServer_Prometheus_WriteRead(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig(), "")
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", newRetentionPolicyInfo("rp0", 1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MetaStore.SetDefaultRetentionPolicy("db0", "rp0"); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	if _, err := s.HTTPPostProto(s.URL()+"/api/v1/prom/write?db=db0", &remote.WriteRequest{
+		Timeseries: []*remote.TimeSeries{
+			{
+				Labels:  []*remote.LabelPair{{Name: "__name__", Value: "cpu"}, {Name: "host", Value: "serverA"}},
+				Samples: []*remote.Sample{{Value: 1.5, TimestampMs: now - 1000}, {Value: 2.5, TimestampMs: now}},
+			},
+			{
+				Labels:  []*remote.LabelPair{{Name: "__name__", Value: "cpu"}, {Name: "host", Value: "serverB"}},
+				Samples: []*remote.Sample{{Value: 3.5, TimestampMs: now}},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := s.HTTPPostProto(s.URL()+"/api/v1/prom/read?db=db0", &remote.ReadRequest{
+		Queries: []*remote.Query{{
+			StartTimestampMs: now - 60000,
+			EndTimestampMs:   now,
+			Matchers: []*remote.LabelMatcher{
+				{Type: remote.MatchType_EQUAL, Name: "__name__", Value: "cpu"},
+				{Type: remote.MatchType_NOT_EQUAL, Name: "host", Value: "serverB"},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var resp remote.ReadResponse
+	if err := proto.Unmarshal(b, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if exp := []*remote.TimeSeries{{
+		Labels:  []*remote.LabelPair{{Name: "__name__", Value: "cpu"}, {Name: "host", Value: "serverA"}},
+		Samples: []*remote.Sample{{Value: 1.5, TimestampMs: now - 1000}, {Value: 2.5, TimestampMs: now}},
+	}}; len(resp.Results) != 1 || !reflect.DeepEqual(resp.Results[0].Timeseries, exp) {
+		t.Fatalf("unexpected response: %s", resp.String())
+	}
+}
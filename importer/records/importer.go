@@ -0,0 +1,180 @@
+package records
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/influxdb/influxdb/client/v2"
+	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/pkg/ratelimit"
+)
+
+const (
+	batchSize = 5000
+
+	// progressPoints is the number of points processed between progress reports.
+	progressPoints = 100000
+)
+
+// Config is the config used to initialize an Importer.
+type Config struct {
+	Username         string
+	Password         string
+	URL              url.URL
+	Database         string
+	RetentionPolicy  string
+	WriteConsistency string
+	Path             string
+	Format           string // CSV or JSONLines
+	Mapping          *Mapping
+	Version          string
+	Compressed       bool
+	PPS              int
+}
+
+// NewConfig returns an initialized *Config.
+func NewConfig() *Config {
+	return &Config{}
+}
+
+// Importer imports the records of a CSV or JSON lines file as points.
+type Importer struct {
+	client   client.Client
+	config   *Config
+	throttle *ratelimit.Bucket
+	batch    []*client.Point
+
+	totalInserts  int
+	failedInserts int
+	lastReport    int
+	start         time.Time
+}
+
+// NewImporter returns an initialized Importer.
+func NewImporter(config *Config) *Importer {
+	return &Importer{
+		config:   config,
+		throttle: ratelimit.NewBucket(int64(config.PPS)),
+		batch:    make([]*client.Point, 0, batchSize),
+	}
+}
+
+// Import reads the points of the file specified in the Config and writes
+// them to the database in batches of batchSize.
+func (i *Importer) Import() error {
+	if i.config.Path == "" {
+		return fmt.Errorf("file argument required")
+	} else if i.config.Database == "" {
+		return fmt.Errorf("database argument required")
+	}
+
+	cl, err := client.NewHTTPClient(client.HTTPConfig{
+		Addr:      i.config.URL.String(),
+		Username:  i.config.Username,
+		Password:  i.config.Password,
+		UserAgent: fmt.Sprintf("influxDB importer/%s", i.config.Version),
+	})
+	if err != nil {
+		return fmt.Errorf("could not create client %s", err)
+	}
+	defer cl.Close()
+	i.client = cl
+
+	// Open the file
+	f, err := os.Open(i.config.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if i.config.Compressed {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	pr, err := NewReader(i.config.Format, r, i.config.Mapping, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		log.Printf("Processed %d inserts\n", i.totalInserts)
+		log.Printf("Failed %d inserts\n", i.failedInserts)
+	}()
+
+	i.start = time.Now()
+	for {
+		p, err := pr.ReadPoint()
+		if err == io.EOF {
+			break
+		} else if err, ok := err.(*models.LineError); ok {
+			log.Println("error reading record: ", err)
+			i.failedInserts++
+			continue
+		} else if err != nil {
+			i.batchWrite()
+			return fmt.Errorf("reading %s: %s", i.config.Path, err)
+		}
+
+		pt, err := client.NewPoint(p.Name(), p.Tags(), p.Fields(), p.Time())
+		if err != nil {
+			log.Println("error reading record: ", err)
+			i.failedInserts++
+			continue
+		}
+
+		if i.batch = append(i.batch, pt); len(i.batch) == batchSize {
+			i.batchWrite()
+		}
+	}
+
+	// Call batchWrite one last time to flush anything out in the batch
+	i.batchWrite()
+	return nil
+}
+
+// batchWrite writes the current batch, waiting as needed to stay under the
+// points per second of the config, and reports the progress of the import.
+func (i *Importer) batchWrite() {
+	if len(i.batch) == 0 {
+		return
+	}
+	defer func() { i.batch = i.batch[:0] }()
+
+	time.Sleep(i.throttle.Delay(len(i.batch)))
+	i.throttle.Take(len(i.batch))
+
+	bp, _ := client.NewBatchPoints(client.BatchPointsConfig{
+		Database:         i.config.Database,
+		RetentionPolicy:  i.config.RetentionPolicy,
+		WriteConsistency: i.config.WriteConsistency,
+	})
+	for _, pt := range i.batch {
+		bp.AddPoint(pt)
+	}
+
+	if err := i.client.Write(bp); err != nil {
+		log.Println("error writing batch: ", err)
+		i.failedInserts += len(i.batch)
+	} else {
+		i.totalInserts += len(i.batch)
+	}
+
+	// Give some status feedback every progressPoints points processed
+	if processed := i.totalInserts + i.failedInserts; processed/progressPoints > i.lastReport {
+		i.lastReport = processed / progressPoints
+		since := time.Since(i.start)
+		pps := float64(processed) / since.Seconds()
+		log.Printf("Processed %d points.  Time elapsed: %s.  Points per second (PPS): %d", processed, since.String(), int64(pps))
+	}
+}
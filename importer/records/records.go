@@ -0,0 +1,358 @@
+// Package records converts the records of CSV and JSON lines files into
+// points, using a mapping of their columns to the tags, fields and time of
+// the points.
+package records
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdb/influxdb/models"
+)
+
+// Formats of the files of records.
+const (
+	CSV       = "csv"
+	JSONLines = "jsonl"
+)
+
+// Types of the field columns.
+const (
+	Float   = "float"
+	Integer = "integer"
+	String  = "string"
+	Boolean = "boolean"
+)
+
+// Layouts of the time column for times since the epoch.
+const (
+	UnixLayout   = "unix"    // seconds
+	UnixMsLayout = "unix_ms" // milliseconds
+	UnixUsLayout = "unix_us" // microseconds
+	UnixNsLayout = "unix_ns" // nanoseconds
+)
+
+// Mapping maps the columns of records to the tags, fields and time of points.
+type Mapping struct {
+	Measurement string
+	Tags        []string          // tag columns
+	Fields      map[string]string // field columns and their types
+	TimeColumn  string            // time column, points are given the current time without one
+	TimeLayout  string            // layout of the time column, RFC3339 by default
+}
+
+// ParseMapping returns the mapping of a measurement described by lists of
+// comma-separated columns. Field columns may be followed by their type, such
+// as "value:float,count:integer", and default to floats. Without any field
+// column, all the columns which aren't tags or the time are fields with their
+// type guessed from their values.
+func ParseMapping(measurement, tags, fields, timeColumn, timeLayout string) (*Mapping, error) {
+	if measurement == "" {
+		return nil, fmt.Errorf("measurement is required")
+	}
+
+	m := &Mapping{
+		Measurement: measurement,
+		Fields:      make(map[string]string),
+		TimeColumn:  timeColumn,
+		TimeLayout:  timeLayout,
+	}
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			m.Tags = append(m.Tags, tag)
+		}
+	}
+	for _, field := range strings.Split(fields, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+
+		typ := Float
+		if i := strings.LastIndex(field, ":"); i != -1 {
+			field, typ = field[:i], field[i+1:]
+		}
+		switch typ {
+		case Float, Integer, String, Boolean:
+		default:
+			return nil, fmt.Errorf("unknown type %q of field %q", typ, field)
+		}
+		m.Fields[field] = typ
+	}
+	return m, nil
+}
+
+// text is a value of a column read as text, whose type is guessed if it's an
+// unmapped field.
+type text string
+
+// point returns the point of a record, given as the values of its columns.
+// Empty values are skipped.
+func (m *Mapping) point(record map[string]interface{}, defaultTime time.Time) (models.Point, error) {
+	tags := make(map[string]string)
+	for _, col := range m.Tags {
+		if s := toString(record[col]); s != "" {
+			tags[col] = s
+		}
+	}
+
+	t := defaultTime
+	if m.TimeColumn != "" {
+		s := toString(record[m.TimeColumn])
+		if s == "" {
+			return nil, fmt.Errorf("missing time column %q", m.TimeColumn)
+		}
+
+		var err error
+		if t, err = parseTime(s, m.TimeLayout); err != nil {
+			return nil, err
+		}
+	}
+
+	fields := make(map[string]interface{})
+	if len(m.Fields) > 0 {
+		for col, typ := range m.Fields {
+			s := toString(record[col])
+			if s == "" {
+				continue
+			}
+
+			v, err := parseValue(s, typ)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s field %q: %s", typ, col, err)
+			}
+			fields[col] = v
+		}
+	} else {
+		for col, v := range record {
+			if col == m.TimeColumn || m.isTag(col) {
+				continue
+			}
+			if v = guessValue(v); v != nil {
+				fields[col] = v
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("missing fields")
+	}
+
+	return models.NewPoint(m.Measurement, tags, fields, t)
+}
+
+// isTag returns true if col is a tag column.
+func (m *Mapping) isTag(col string) bool {
+	for _, tag := range m.Tags {
+		if tag == col {
+			return true
+		}
+	}
+	return false
+}
+
+// toString returns the text of a value, or an empty string for missing values.
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case text:
+		return string(v)
+	case string:
+		return v
+	case json.Number:
+		return string(v)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+// parseValue parses the text of a field of type typ.
+func parseValue(s, typ string) (interface{}, error) {
+	switch typ {
+	case Integer:
+		return strconv.ParseInt(s, 10, 64)
+	case String:
+		return s, nil
+	case Boolean:
+		return strconv.ParseBool(s)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// guessValue returns the value of an unmapped field. Values read as text are
+// numbers or booleans if they parse as such.
+func guessValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case text:
+		if v == "" {
+			return nil
+		} else if f, err := strconv.ParseFloat(string(v), 64); err == nil {
+			return f
+		} else if b, err := strconv.ParseBool(string(v)); err == nil {
+			return b
+		}
+		return string(v)
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case string, bool:
+		return v
+	}
+	return nil
+}
+
+// parseTime parses the text of a time with a layout.
+func parseTime(s, layout string) (time.Time, error) {
+	var unit int64
+	switch layout {
+	case "":
+		return time.Parse(time.RFC3339Nano, s)
+	case UnixLayout:
+		unit = int64(time.Second)
+	case UnixMsLayout:
+		unit = int64(time.Millisecond)
+	case UnixUsLayout:
+		unit = int64(time.Microsecond)
+	case UnixNsLayout:
+		unit = int64(time.Nanosecond)
+	default:
+		return time.Parse(layout, s)
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: %s", s, err)
+	}
+	return time.Unix(0, n*unit).UTC(), nil
+}
+
+// Reader is implemented by the readers of points from records.
+type Reader interface {
+	// ReadPoint returns the point of the next record. A record which can't
+	// be converted returns a *models.LineError and reading can continue with
+	// the following records. io.EOF is returned once all the records are read.
+	ReadPoint() (models.Point, error)
+}
+
+// NewReader returns a reader of the points of a file in the given format.
+// Points without a time column are given defaultTime.
+func NewReader(format string, r io.Reader, m *Mapping, defaultTime time.Time) (Reader, error) {
+	switch format {
+	case CSV:
+		return NewCSVReader(r, m, defaultTime), nil
+	case JSONLines:
+		return NewJSONReader(r, m, defaultTime), nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// CSVReader reads points from a CSV file. The first record of the file is a
+// header naming the columns.
+type CSVReader struct {
+	r           *csv.Reader
+	m           *Mapping
+	defaultTime time.Time
+	columns     []string
+	line        int // line of the last record, assuming records span one line
+}
+
+// NewCSVReader returns a reader of the points of r.
+func NewCSVReader(r io.Reader, m *Mapping, defaultTime time.Time) *CSVReader {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	return &CSVReader{r: cr, m: m, defaultTime: defaultTime}
+}
+
+// ReadPoint returns the point of the next record.
+func (r *CSVReader) ReadPoint() (models.Point, error) {
+	if r.columns == nil {
+		columns, err := r.r.Read()
+		if err != nil {
+			return nil, err
+		}
+		r.columns = columns
+		r.line++
+	}
+
+	values, err := r.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	r.line++
+
+	record := make(map[string]interface{}, len(values))
+	for i, v := range values {
+		if i < len(r.columns) {
+			record[r.columns[i]] = text(v)
+		}
+	}
+
+	pt, err := r.m.point(record, r.defaultTime)
+	if err != nil {
+		return nil, &models.LineError{Line: r.line, Text: strings.Join(values, ","), Err: err}
+	}
+	return pt, nil
+}
+
+// JSONReader reads points from a JSON lines file, where each line is an
+// object whose keys are the columns.
+type JSONReader struct {
+	r           *bufio.Reader
+	m           *Mapping
+	defaultTime time.Time
+	line        int
+}
+
+// NewJSONReader returns a reader of the points of r.
+func NewJSONReader(r io.Reader, m *Mapping, defaultTime time.Time) *JSONReader {
+	return &JSONReader{r: bufio.NewReader(r), m: m, defaultTime: defaultTime}
+}
+
+// ReadPoint returns the point of the next line.
+func (r *JSONReader) ReadPoint() (models.Point, error) {
+	for {
+		b, err := r.r.ReadBytes('\n')
+		if len(b) == 0 {
+			return nil, err
+		} else if err != nil && err != io.EOF {
+			return nil, err
+		}
+		r.line++
+
+		// Skip blank lines.
+		b = bytes.TrimSpace(b)
+		if len(b) == 0 {
+			continue
+		}
+
+		pt, err := r.point(b)
+		if err != nil {
+			return nil, &models.LineError{Line: r.line, Text: string(b), Err: err}
+		}
+		return pt, nil
+	}
+}
+
+// point returns the point of a line.
+func (r *JSONReader) point(b []byte) (models.Point, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var record map[string]interface{}
+	if err := dec.Decode(&record); err != nil {
+		return nil, err
+	}
+	for col, v := range record {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("unsupported value of column %q", col)
+		}
+	}
+	return r.m.point(record, r.defaultTime)
+}
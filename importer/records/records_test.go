@@ -0,0 +1,89 @@
+package records_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/importer/records"
+	"github.com/influxdb/influxdb/models"
+)
+
+// Ensure a mapping is parsed from its lists of columns.
+func TestParseMapping(t *testing.T) {
+	m, err := records.ParseMapping("cpu", "host, region", "value,count:integer", "time", "unix")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(m.Tags) != 2 || m.Tags[0] != "host" || m.Tags[1] != "region" {
+		t.Fatalf("unexpected tags: %v", m.Tags)
+	} else if len(m.Fields) != 2 || m.Fields["value"] != records.Float || m.Fields["count"] != records.Integer {
+		t.Fatalf("unexpected fields: %v", m.Fields)
+	}
+
+	if _, err := records.ParseMapping("", "", "", "", ""); err == nil || err.Error() != "measurement is required" {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, err := records.ParseMapping("cpu", "", "value:double", "", ""); err == nil || err.Error() != `unknown type "double" of field "value"` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure points are read from CSV records.
+func TestCSVReader(t *testing.T) {
+	m, _ := records.ParseMapping("cpu", "host", "value,count:integer,ok:boolean", "time", "2006-01-02 15:04:05")
+	r := records.NewCSVReader(strings.NewReader(
+		"time,host,value,count,ok,ignored\n"+
+			"2015-01-01 00:00:00,serverA,1.5,2,true,x\n"+
+			"2015-01-01 00:00:01,serverA,bad,2,true,x\n"+
+			"2015-01-01 00:00:02,serverB,3,,false,x\n",
+	), m, time.Time{})
+
+	exp := []string{
+		`cpu,host=serverA count=2i,ok=true,value=1.5 1420070400000000000`,
+		`line 3: unable to parse '2015-01-01 00:00:01,serverA,bad,2,true,x': invalid float field "value": strconv.ParseFloat: parsing "bad": invalid syntax`,
+		`cpu,host=serverB ok=false,value=3 1420070402000000000`,
+	}
+	for i, s := range exp {
+		if pt, err := r.ReadPoint(); err != nil {
+			if _, ok := err.(*models.LineError); !ok || err.Error() != s {
+				t.Fatalf("%d. unexpected error: %v", i, err)
+			}
+		} else if pt.String() != s {
+			t.Fatalf("%d. unexpected point: %s", i, pt)
+		}
+	}
+
+	if _, err := r.ReadPoint(); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+// Ensure points are read from JSON lines with the types of their values.
+func TestJSONReader(t *testing.T) {
+	m, _ := records.ParseMapping("cpu", "host", "", "time", "unix_ms")
+	r := records.NewJSONReader(strings.NewReader(
+		`{"time": 1420070400000, "host": "serverA", "value": 1.5, "up": true, "msg": "ok"}`+"\n"+
+			"\n"+
+			`{"time": 1420070400000, "host": "serverA", "value": [1]}`+"\n"+
+			`{"host": "serverB", "value": 2}`,
+	), m, time.Time{})
+
+	exp := []string{
+		`cpu,host=serverA msg="ok",up=true,value=1.5 1420070400000000000`,
+		`line 3: unable to parse '{"time": 1420070400000, "host": "serverA", "value": [1]}': unsupported value of column "value"`,
+		`line 4: unable to parse '{"host": "serverB", "value": 2}': missing time column "time"`,
+	}
+	for i, s := range exp {
+		if pt, err := r.ReadPoint(); err != nil {
+			if _, ok := err.(*models.LineError); !ok || err.Error() != s {
+				t.Fatalf("%d. unexpected error: %v", i, err)
+			}
+		} else if pt.String() != s {
+			t.Fatalf("%d. unexpected point: %s", i, pt)
+		}
+	}
+
+	if _, err := r.ReadPoint(); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
@@ -81,6 +81,7 @@ func (*Query) node()     {}
 func (Statements) node() {}
 
 func (*AlterRetentionPolicyStatement) node()  {}
+func (*AlterUserStatement) node()             {}
 func (*CreateContinuousQueryStatement) node() {}
 func (*CreateDatabaseStatement) node()        {}
 func (*CreateRetentionPolicyStatement) node() {}
@@ -98,12 +99,14 @@ func (*DropSubscriptionStatement) node()      {}
 func (*DropUserStatement) node()              {}
 func (*GrantStatement) node()                 {}
 func (*GrantAdminStatement) node()            {}
+func (*KillQueryStatement) node()             {}
 func (*RevokeStatement) node()                {}
 func (*RevokeAdminStatement) node()           {}
 func (*SelectStatement) node()                {}
 func (*SetPasswordUserStatement) node()       {}
 func (*ShowContinuousQueriesStatement) node() {}
 func (*ShowGrantsForUserStatement) node()     {}
+func (*ShowQueriesStatement) node()           {}
 func (*ShowServersStatement) node()           {}
 func (*ShowDatabasesStatement) node()         {}
 func (*ShowFieldKeysStatement) node()         {}
@@ -137,6 +140,7 @@ func (*SortField) node()       {}
 func (SortFields) node()       {}
 func (Sources) node()          {}
 func (*StringLiteral) node()   {}
+func (*SubQuery) node()        {}
 func (*Target) node()          {}
 func (*TimeLiteral) node()     {}
 func (*VarRef) node()          {}
@@ -193,6 +197,7 @@ type ExecutionPrivilege struct {
 type ExecutionPrivileges []ExecutionPrivilege
 
 func (*AlterRetentionPolicyStatement) stmt()  {}
+func (*AlterUserStatement) stmt()             {}
 func (*CreateContinuousQueryStatement) stmt() {}
 func (*CreateDatabaseStatement) stmt()        {}
 func (*CreateRetentionPolicyStatement) stmt() {}
@@ -209,8 +214,10 @@ func (*DropSubscriptionStatement) stmt()      {}
 func (*DropUserStatement) stmt()              {}
 func (*GrantStatement) stmt()                 {}
 func (*GrantAdminStatement) stmt()            {}
+func (*KillQueryStatement) stmt()             {}
 func (*ShowContinuousQueriesStatement) stmt() {}
 func (*ShowGrantsForUserStatement) stmt()     {}
+func (*ShowQueriesStatement) stmt()           {}
 func (*ShowServersStatement) stmt()           {}
 func (*ShowDatabasesStatement) stmt()         {}
 func (*ShowFieldKeysStatement) stmt()         {}
@@ -257,6 +264,7 @@ type Source interface {
 }
 
 func (*Measurement) source() {}
+func (*SubQuery) source()    {}
 
 // Sources represents a list of sources.
 type Sources []Source
@@ -300,6 +308,11 @@ func (field *SortField) String() string {
 	return buf.String()
 }
 
+// IsTime returns true if the field sorts by time.
+func (field *SortField) IsTime() bool {
+	return field.Name == "" || strings.ToLower(field.Name) == "time"
+}
+
 // SortFields represents an ordered list of ORDER BY fields
 type SortFields []*SortField
 
@@ -498,6 +511,15 @@ type GrantStatement struct {
 	// Database to grant the privilege to.
 	On string
 
+	// Retention policy to grant the privilege to. Empty means all retention
+	// policies of the database.
+	RetentionPolicy string
+
+	// Measurement, or measurements matching Regex, to grant the privilege to.
+	// Both empty means all measurements.
+	Measurement string
+	Regex       *RegexLiteral
+
 	// Who to grant the privilege to.
 	User string
 }
@@ -508,7 +530,7 @@ func (s *GrantStatement) String() string {
 	_, _ = buf.WriteString("GRANT ")
 	_, _ = buf.WriteString(s.Privilege.String())
 	_, _ = buf.WriteString(" ON ")
-	_, _ = buf.WriteString(QuoteIdent(s.On))
+	writePrivilegeTarget(&buf, s.On, s.RetentionPolicy, s.Measurement, s.Regex)
 	_, _ = buf.WriteString(" TO ")
 	_, _ = buf.WriteString(QuoteIdent(s.User))
 	return buf.String()
@@ -519,6 +541,28 @@ func (s *GrantStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}
 }
 
+// writePrivilegeTarget writes the target of a GRANT or REVOKE statement.
+// e.g., db, db.rp, db.rp.cpu, db..cpu or db.rp./cpu.*/
+func writePrivilegeTarget(buf *bytes.Buffer, db, rp, name string, re *RegexLiteral) {
+	_, _ = buf.WriteString(QuoteIdent(db))
+	if rp == "" && name == "" && re == nil {
+		return
+	}
+
+	_, _ = buf.WriteString(".")
+	if rp != "" {
+		_, _ = buf.WriteString(QuoteIdent(rp))
+	}
+
+	if name != "" {
+		_, _ = buf.WriteString(".")
+		_, _ = buf.WriteString(QuoteIdent(name))
+	} else if re != nil {
+		_, _ = buf.WriteString(".")
+		_, _ = buf.WriteString(re.String())
+	}
+}
+
 // GrantAdminStatement represents a command for granting admin privilege.
 type GrantAdminStatement struct {
 	// Who to grant the privilege to.
@@ -562,6 +606,70 @@ func (s *SetPasswordUserStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}
 }
 
+// Names of the query limits which can be overridden for a user.
+const (
+	QueryTimeoutLimit     = "query_timeout"
+	MaxSelectSeriesLimit  = "max_select_series"
+	MaxSelectPointLimit   = "max_select_point"
+	MaxSelectBucketsLimit = "max_select_buckets"
+)
+
+// Names of the rate limits which can be overridden for a user. Rates are per
+// second.
+const (
+	WriteLimit      = "write_limit"
+	WriteBytesLimit = "write_bytes_limit"
+	QueryLimit      = "query_limit"
+)
+
+// AlterUserStatement represents a command for overriding the query and rate
+// limits of a user.
+type AlterUserStatement struct {
+	// Name of the user to be altered.
+	Name string
+
+	// Limits to override, by name. Query timeouts are in nanoseconds.
+	// A nil value resets the limit to the one configured for the node.
+	Limits map[string]*int64
+}
+
+// String returns a string representation of the alter user statement.
+func (s *AlterUserStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("ALTER USER ")
+	_, _ = buf.WriteString(QuoteIdent(s.Name))
+	_, _ = buf.WriteString(" SET ")
+
+	names := make([]string, 0, len(s.Limits))
+	for name := range s.Limits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		if i > 0 {
+			_, _ = buf.WriteString(", ")
+		}
+		_, _ = buf.WriteString(name)
+		_, _ = buf.WriteString(" = ")
+
+		v := s.Limits[name]
+		if v == nil {
+			_, _ = buf.WriteString("DEFAULT")
+		} else if name == QueryTimeoutLimit {
+			_, _ = buf.WriteString(FormatDuration(time.Duration(*v)))
+		} else {
+			_, _ = buf.WriteString(strconv.FormatInt(*v, 10))
+		}
+	}
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute an AlterUserStatement.
+func (s *AlterUserStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}
+}
+
 // RevokeStatement represents a command to revoke a privilege from a user.
 type RevokeStatement struct {
 	// The privilege to be revoked.
@@ -570,6 +678,12 @@ type RevokeStatement struct {
 	// Database to revoke the privilege from.
 	On string
 
+	// Retention policy and measurement, or measurements matching Regex, to
+	// revoke the privilege from. All empty means the database privilege.
+	RetentionPolicy string
+	Measurement     string
+	Regex           *RegexLiteral
+
 	// Who to revoke privilege from.
 	User string
 }
@@ -580,7 +694,7 @@ func (s *RevokeStatement) String() string {
 	_, _ = buf.WriteString("REVOKE ")
 	_, _ = buf.WriteString(s.Privilege.String())
 	_, _ = buf.WriteString(" ON ")
-	_, _ = buf.WriteString(QuoteIdent(s.On))
+	writePrivilegeTarget(&buf, s.On, s.RetentionPolicy, s.Measurement, s.Regex)
 	_, _ = buf.WriteString(" FROM ")
 	_, _ = buf.WriteString(QuoteIdent(s.User))
 	return buf.String()
@@ -709,6 +823,9 @@ const (
 	NumberFill
 	// PreviousFill means that empty aggregate windows will be filled with whatever the previous aggregate window had
 	PreviousFill
+	// LinearFill means that empty aggregate windows will be filled with values interpolated
+	// between the neighbouring non-empty windows
+	LinearFill
 )
 
 // SelectStatement represents a command for extracting data from the database.
@@ -754,6 +871,9 @@ type SelectStatement struct {
 
 	// The value to fill empty aggregate buckets with, if any
 	FillValue interface{}
+
+	// The timezone GROUP BY time intervals are aligned to. UTC if nil.
+	Location *time.Location
 }
 
 // SourceNames returns a list of source names.
@@ -793,6 +913,34 @@ func (s *SelectStatement) IsSimpleDerivative() bool {
 	return false
 }
 
+// HasTransform returns true if one of the function calls in the statement
+// transforms the values of a field or aggregate, e.g. difference(value).
+func (s *SelectStatement) HasTransform() bool {
+	for _, f := range s.FunctionCalls() {
+		if IsTransformCall(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSimpleTransform returns true if one of the function calls is a derivative
+// or a transform with a variable ref as the first arg. These are calculated
+// from the raw values of the field.
+func (s *SelectStatement) IsSimpleTransform() bool {
+	if s.IsSimpleDerivative() {
+		return true
+	}
+	for _, f := range s.FunctionCalls() {
+		if IsTransformCall(f) && len(f.Args) > 0 {
+			if _, ok := f.Args[0].(*VarRef); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // HasSimpleCount return true if one of the function calls is a count function with a
 // variable ref as the first arg
 func (s *SelectStatement) HasSimpleCount() bool {
@@ -823,7 +971,23 @@ func (s *SelectStatement) HasSimpleCount() bool {
 
 // TimeAscending returns true if the time field is sorted in chronological order.
 func (s *SelectStatement) TimeAscending() bool {
-	return len(s.SortFields) == 0 || s.SortFields[0].Ascending
+	for _, f := range s.SortFields {
+		if f.IsTime() {
+			return f.Ascending
+		}
+	}
+	return true
+}
+
+// HasValueSort returns true if the statement sorts by a field or tag value.
+// The LIMIT and OFFSET then apply to the sorted rows of all series.
+func (s *SelectStatement) HasValueSort() bool {
+	for _, f := range s.SortFields {
+		if !f.IsTime() {
+			return true
+		}
+	}
+	return false
 }
 
 // Clone returns a deep copy of the statement.
@@ -841,6 +1005,7 @@ func (s *SelectStatement) Clone() *SelectStatement {
 		Fill:       s.Fill,
 		FillValue:  s.FillValue,
 		IsRawQuery: s.IsRawQuery,
+		Location:   s.Location,
 	}
 	if s.Target != nil {
 		clone.Target = &Target{
@@ -884,6 +1049,8 @@ func cloneSource(s Source) Source {
 			m.Regex = &RegexLiteral{Val: regexp.MustCompile(s.Regex.Val.String())}
 		}
 		return m
+	case *SubQuery:
+		return &SubQuery{Statement: s.Statement.Clone()}
 	default:
 		panic("unreachable")
 	}
@@ -952,6 +1119,11 @@ func (s *SelectStatement) ColumnNames() []string {
 	for _, field := range s.Fields {
 		switch f := field.Expr.(type) {
 		case *Call:
+			if f.Name == "histogram" {
+				// Each row holds the start of a bucket and its count.
+				columnNames = append(columnNames, "bucket", field.Name())
+				continue
+			}
 			if f.Name == "top" || f.Name == "bottom" {
 				if len(f.Args) == 2 {
 					columnNames = append(columnNames, f.Name)
@@ -1013,6 +1185,8 @@ func (s *SelectStatement) String() string {
 		_, _ = buf.WriteString(fmt.Sprintf(" fill(%v)", s.FillValue))
 	case PreviousFill:
 		_, _ = buf.WriteString(" fill(previous)")
+	case LinearFill:
+		_, _ = buf.WriteString(" fill(linear)")
 	}
 	if len(s.SortFields) > 0 {
 		_, _ = buf.WriteString(" ORDER BY ")
@@ -1031,6 +1205,9 @@ func (s *SelectStatement) String() string {
 	if s.SOffset > 0 {
 		_, _ = fmt.Fprintf(&buf, " SOFFSET %d", s.SOffset)
 	}
+	if s.Location != nil {
+		_, _ = fmt.Fprintf(&buf, " tz(%s)", QuoteString(s.Location.String()))
+	}
 	return buf.String()
 }
 
@@ -1100,6 +1277,94 @@ func (s *SelectStatement) validate(tr targetRequirement) error {
 		return err
 	}
 
+	if err := s.validateTransforms(); err != nil {
+		return err
+	}
+
+	if err := s.validateSortFields(); err != nil {
+		return err
+	}
+
+	if err := s.validateMathFuncs(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateMathFuncs ensures math functions have the expected number of arguments.
+func (s *SelectStatement) validateMathFuncs() error {
+	var err error
+	WalkFunc(s.Fields, func(n Node) {
+		call, ok := n.(*Call)
+		if !ok || err != nil {
+			return
+		}
+
+		fn, ok := MathFuncs[call.Name]
+		if !ok {
+			return
+		} else if exp, got := fn.Args, len(call.Args); got != exp {
+			err = fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", call.Name, exp, got)
+			return
+		}
+
+		for _, arg := range call.Args {
+			switch arg.(type) {
+			case *VarRef, *Call, *BinaryExpr, *ParenExpr, *NumberLiteral:
+			default:
+				err = fmt.Errorf("invalid argument to %s: %s", call.Name, arg)
+				return
+			}
+		}
+	})
+	return err
+}
+
+// validateSortFields ensures each ORDER BY field is returned by the statement
+// or is a tag that it groups by, and that sorting by value is limited since
+// the sorted values are held in memory.
+func (s *SelectStatement) validateSortFields() error {
+	if !s.HasValueSort() {
+		return nil
+	} else if s.HasWildcard() {
+		return s.validateSortLimit()
+	}
+
+	names := make(map[string]struct{})
+	for _, n := range s.ColumnNames() {
+		names[n] = struct{}{}
+	}
+	if s.IsRawQuery {
+		for _, n := range s.NamesInSelect() {
+			names[n] = struct{}{}
+		}
+	}
+	_, tags := s.Dimensions.Normalize()
+	for _, n := range tags {
+		names[n] = struct{}{}
+	}
+
+	for _, f := range s.SortFields {
+		if f.IsTime() {
+			continue
+		} else if _, ok := names[f.Name]; !ok {
+			return fmt.Errorf("ORDER BY %s must be a selected field or a GROUP BY tag", f.Name)
+		}
+	}
+	return s.validateSortLimit()
+}
+
+// validateSortLimit ensures a statement sorted by value has a LIMIT.
+func (s *SelectStatement) validateSortLimit() error {
+	if s.Limit > 0 {
+		return nil
+	}
+	for _, f := range s.SortFields {
+		if !f.IsTime() {
+			return fmt.Errorf("ORDER BY %s requires a LIMIT", f.Name)
+		}
+	}
 	return nil
 }
 
@@ -1120,10 +1385,12 @@ func (s *SelectStatement) validateDimensions() error {
 			// If we already have a duration
 			if expr.Name != "time" {
 				return errors.New("only time() calls allowed in dimensions")
-			} else if len(expr.Args) != 1 {
-				return errors.New("time dimension expected one argument")
+			} else if len(expr.Args) != 1 && len(expr.Args) != 2 {
+				return errors.New("time dimension expected one or two arguments")
 			} else if lit, ok := expr.Args[0].(*DurationLiteral); !ok {
 				return errors.New("time dimension must have one duration argument")
+			} else if _, ok := expr.Args[len(expr.Args)-1].(*DurationLiteral); !ok {
+				return errors.New("time dimension offset must be a duration")
 			} else if dur != 0 {
 				return errors.New("multiple time dimensions not allowed")
 			} else {
@@ -1210,6 +1477,49 @@ func (s *SelectStatement) validTopBottomAggr(expr *Call) error {
 	return nil
 }
 
+// validTransformArg determines if the first argument of a derivative or transform is valid.
+// When grouping by time it must be an aggregate, e.g. difference(max(value)).
+func (s *SelectStatement) validTransformArg(expr *Call) error {
+	// Validate that if they have grouping by time, they need a sub-call like min/max, etc.
+	groupByInterval, err := s.GroupByInterval()
+	if err != nil {
+		return fmt.Errorf("invalid group interval: %v", err)
+	}
+
+	switch c := expr.Args[0].(type) {
+	case *VarRef:
+		if groupByInterval > 0 || expr.Name == "holt_winters" {
+			return fmt.Errorf("aggregate function required inside the call to %s", expr.Name)
+		}
+	case *Call:
+		switch c.Name {
+		case "top", "bottom":
+			if err := s.validTopBottomAggr(c); err != nil {
+				return err
+			}
+		case "percentile", "percentile_approx":
+			if err := s.validPercentileAggr(c); err != nil {
+				return err
+			}
+		case "histogram":
+			return fmt.Errorf("%s cannot be used inside the call to %s", c.Name, expr.Name)
+		default:
+			if IsTransformCall(c) || c.Name == "derivative" || c.Name == "non_negative_derivative" {
+				return fmt.Errorf("%s cannot be used inside the call to %s", c.Name, expr.Name)
+			}
+			if exp, got := 1, len(c.Args); got != exp && groupByInterval > 0 {
+				return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", c.Name, exp, got)
+			}
+		}
+	default:
+		if groupByInterval > 0 {
+			return fmt.Errorf("aggregate function required inside the call to %s", expr.Name)
+		}
+		return fmt.Errorf("expected field argument in %s()", expr.Name)
+	}
+	return nil
+}
+
 // validPercentileAggr determines if PERCENTILE have valid arguments.
 func (s *SelectStatement) validPercentileAggr(expr *Call) error {
 	if err := s.validSelectWithAggregate(); err != nil {
@@ -1218,9 +1528,35 @@ func (s *SelectStatement) validPercentileAggr(expr *Call) error {
 	if exp, got := 2, len(expr.Args); got != exp {
 		return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", expr.Name, exp, got)
 	}
-	_, ok := expr.Args[1].(*NumberLiteral)
+	lit, ok := expr.Args[1].(*NumberLiteral)
 	if !ok {
-		return fmt.Errorf("expected float argument in percentile()")
+		return fmt.Errorf("expected float argument in %s()", expr.Name)
+	}
+	if expr.Name == "percentile_approx" && (lit.Val < 0 || lit.Val > 100) {
+		return fmt.Errorf("percentile in %s() must be between 0 and 100, found %s", expr.Name, lit)
+	}
+	return nil
+}
+
+// validHistogramAggr determines if HISTOGRAM has valid arguments.
+func (s *SelectStatement) validHistogramAggr(expr *Call) error {
+	if err := s.validSelectWithAggregate(); err != nil {
+		return err
+	}
+	if exp, got := 2, len(expr.Args); got != exp {
+		return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", expr.Name, exp, got)
+	}
+	if _, ok := expr.Args[0].(*VarRef); !ok {
+		return fmt.Errorf("expected field argument in %s()", expr.Name)
+	}
+	if lit, ok := expr.Args[1].(*NumberLiteral); !ok || lit.Val <= 0 {
+		return fmt.Errorf("bucket width in %s() must be a positive number, found %s", expr.Name, expr.Args[1])
+	}
+
+	// Each interval returns a row for every bucket so it can't be
+	// combined with the values of other fields.
+	if len(s.Fields) != 1 || len(s.FunctionCalls()) != 1 {
+		return fmt.Errorf("%s cannot be used with other fields", expr.Name)
 	}
 	return nil
 }
@@ -1236,39 +1572,95 @@ func (s *SelectStatement) validateAggregates(tr targetRequirement) error {
 				if min, max, got := 1, 2, len(expr.Args); got > max || got < min {
 					return fmt.Errorf("invalid number of arguments for %s, expected at least %d but no more than %d, got %d", expr.Name, min, max, got)
 				}
-				// Validate that if they have grouping by time, they need a sub-call like min/max, etc.
-				groupByInterval, err := s.GroupByInterval()
-				if err != nil {
-					return fmt.Errorf("invalid group interval: %v", err)
+				if err := s.validTransformArg(expr); err != nil {
+					return err
+				}
+			case "cumulative_sum", "difference":
+				if err := s.validSelectWithAggregate(); err != nil {
+					return err
+				}
+				if exp, got := 1, len(expr.Args); got != exp {
+					return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", expr.Name, exp, got)
+				}
+				if err := s.validTransformArg(expr); err != nil {
+					return err
+				}
+			case "moving_average":
+				if err := s.validSelectWithAggregate(); err != nil {
+					return err
+				}
+				if exp, got := 2, len(expr.Args); got != exp {
+					return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", expr.Name, exp, got)
+				}
+				if err := s.validTransformArg(expr); err != nil {
+					return err
+				}
+				if lit, ok := expr.Args[1].(*NumberLiteral); !ok || lit.Val < 1 || lit.Val != float64(int(lit.Val)) {
+					return fmt.Errorf("second argument to %s must be a positive integer, found %s", expr.Name, expr.Args[1])
+				}
+			case "elapsed":
+				if err := s.validSelectWithAggregate(); err != nil {
+					return err
+				}
+				if min, max, got := 1, 2, len(expr.Args); got > max || got < min {
+					return fmt.Errorf("invalid number of arguments for %s, expected at least %d but no more than %d, got %d", expr.Name, min, max, got)
+				}
+				if err := s.validTransformArg(expr); err != nil {
+					return err
 				}
-				if groupByInterval > 0 {
-					c, ok := expr.Args[0].(*Call)
-					if !ok {
-						return fmt.Errorf("aggregate function required inside the call to %s", expr.Name)
+				if len(expr.Args) == 2 {
+					if lit, ok := expr.Args[1].(*DurationLiteral); !ok || lit.Val <= 0 {
+						return fmt.Errorf("second argument to %s must be a positive duration, found %s", expr.Name, expr.Args[1])
 					}
-					switch c.Name {
-					case "top", "bottom":
-						if err := s.validTopBottomAggr(c); err != nil {
-							return err
-						}
-					case "percentile":
-						if err := s.validPercentileAggr(c); err != nil {
-							return err
-						}
-					default:
-						if exp, got := 1, len(c.Args); got != exp {
-							return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", c.Name, exp, got)
-						}
+				}
+			case "holt_winters":
+				if err := s.validSelectWithAggregate(); err != nil {
+					return err
+				}
+				if exp, got := 3, len(expr.Args); got != exp {
+					return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", expr.Name, exp, got)
+				}
+				if groupByInterval, err := s.GroupByInterval(); err != nil {
+					return fmt.Errorf("invalid group interval: %v", err)
+				} else if groupByInterval == 0 {
+					return fmt.Errorf("%s requires a GROUP BY time interval", expr.Name)
+				}
+				if err := s.validTransformArg(expr); err != nil {
+					return err
+				}
+				if lit, ok := expr.Args[1].(*NumberLiteral); !ok || lit.Val < 1 || lit.Val != float64(int(lit.Val)) {
+					return fmt.Errorf("second argument to %s must be a positive integer, found %s", expr.Name, expr.Args[1])
+				}
+				if lit, ok := expr.Args[2].(*NumberLiteral); !ok || lit.Val < 0 || lit.Val != float64(int(lit.Val)) {
+					return fmt.Errorf("third argument to %s must be a non-negative integer, found %s", expr.Name, expr.Args[2])
+				}
+			case "integral":
+				if err := s.validSelectWithAggregate(); err != nil {
+					return err
+				}
+				if min, max, got := 1, 2, len(expr.Args); got > max || got < min {
+					return fmt.Errorf("invalid number of arguments for %s, expected at least %d but no more than %d, got %d", expr.Name, min, max, got)
+				}
+				if _, ok := expr.Args[0].(*VarRef); !ok {
+					return fmt.Errorf("expected field argument in %s()", expr.Name)
+				}
+				if len(expr.Args) == 2 {
+					if lit, ok := expr.Args[1].(*DurationLiteral); !ok || lit.Val <= 0 {
+						return fmt.Errorf("second argument to %s must be a positive duration, found %s", expr.Name, expr.Args[1])
 					}
 				}
 			case "top", "bottom":
 				if err := s.validTopBottomAggr(expr); err != nil {
 					return err
 				}
-			case "percentile":
+			case "percentile", "percentile_approx":
 				if err := s.validPercentileAggr(expr); err != nil {
 					return err
 				}
+			case "histogram":
+				if err := s.validHistogramAggr(expr); err != nil {
+					return err
+				}
 			default:
 				if err := s.validSelectWithAggregate(); err != nil {
 					return err
@@ -1305,14 +1697,30 @@ func (s *SelectStatement) validateAggregates(tr targetRequirement) error {
 	}
 
 	// If we have an aggregate function with a group by time without a where clause, it's an invalid statement
-	if tr == targetNotRequired { // ignore create continuous query statements
-		if !s.IsRawQuery && groupByDuration > 0 && !HasTimeExpr(s.Condition) {
+	if tr == targetNotRequired { // ignore create continuous query statements and subqueries
+		if s.requiresTimeCondition() && !HasTimeExpr(s.Condition) {
 			return fmt.Errorf("aggregate functions with GROUP BY time require a WHERE time clause")
 		}
 	}
 	return nil
 }
 
+// requiresTimeCondition returns true if the statement, or a subquery it reads
+// from, has an aggregate grouped by time without its own time condition.
+func (s *SelectStatement) requiresTimeCondition() bool {
+	if d, _ := s.GroupByInterval(); !s.IsRawQuery && d > 0 {
+		return true
+	}
+
+	// Subqueries without a time condition use the time range of their parent.
+	for _, src := range s.Sources {
+		if sq, ok := src.(*SubQuery); ok && !HasTimeExpr(sq.Statement.Condition) && sq.Statement.requiresTimeCondition() {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *SelectStatement) HasDistinct() bool {
 	// determine if we have a call named distinct
 	for _, f := range s.Fields {
@@ -1446,6 +1854,22 @@ func (s *SelectStatement) validateDerivative() error {
 	return nil
 }
 
+// validateTransforms ensures a transform is the only field in the statement.
+func (s *SelectStatement) validateTransforms() error {
+	for _, c := range s.FunctionCalls() {
+		if !IsTransformCall(c) {
+			continue
+		}
+
+		// The transforms are applied to the rows of the executor, which
+		// only supports a single column of values.
+		if len(s.Fields) != 1 || len(s.FunctionCalls()) != 1 {
+			return fmt.Errorf("%s cannot be used with other fields", c.Name)
+		}
+	}
+	return nil
+}
+
 // GroupByIterval extracts the time interval, if specified.
 func (s *SelectStatement) GroupByInterval() (time.Duration, error) {
 	// return if we've already pulled it out
@@ -1453,28 +1877,103 @@ func (s *SelectStatement) GroupByInterval() (time.Duration, error) {
 		return s.groupByInterval, nil
 	}
 
-	// Ignore if there are no dimensions.
-	if len(s.Dimensions) == 0 {
+	call, err := s.groupByTimeCall()
+	if call == nil || err != nil {
+		return 0, err
+	}
+	s.groupByInterval = call.Args[0].(*DurationLiteral).Val
+	return s.groupByInterval, nil
+}
+
+// GroupByOffset extracts the offset of the time intervals, if specified.
+// The offset is always between zero and the interval.
+func (s *SelectStatement) GroupByOffset() (time.Duration, error) {
+	call, err := s.groupByTimeCall()
+	if call == nil || len(call.Args) < 2 || err != nil {
+		return 0, err
+	}
+
+	interval := call.Args[0].(*DurationLiteral).Val
+	offset := call.Args[1].(*DurationLiteral).Val
+	if interval == 0 {
 		return 0, nil
 	}
+	offset %= interval
+	if offset < 0 {
+		offset += interval
+	}
+	return offset, nil
+}
 
+// groupByTimeCall returns the time() call in the dimensions, if specified.
+func (s *SelectStatement) groupByTimeCall() (*Call, error) {
 	for _, d := range s.Dimensions {
 		if call, ok := d.Expr.(*Call); ok && call.Name == "time" {
-			// Make sure there is exactly one argument.
-			if len(call.Args) != 1 {
-				return 0, errors.New("time dimension expected one argument")
+			// Make sure there is an interval and optionally an offset.
+			if len(call.Args) != 1 && len(call.Args) != 2 {
+				return nil, errors.New("time dimension expected one or two arguments")
 			}
 
-			// Ensure the argument is a duration.
-			lit, ok := call.Args[0].(*DurationLiteral)
-			if !ok {
-				return 0, errors.New("time dimension must have one duration argument")
+			// Ensure the arguments are durations.
+			if _, ok := call.Args[0].(*DurationLiteral); !ok {
+				return nil, errors.New("time dimension must have one duration argument")
+			} else if _, ok := call.Args[len(call.Args)-1].(*DurationLiteral); !ok {
+				return nil, errors.New("time dimension offset must be a duration")
 			}
-			s.groupByInterval = lit.Val
-			return lit.Val, nil
+			return call, nil
 		}
 	}
-	return 0, nil
+	return nil, nil
+}
+
+// TimeWindow returns the bounds of the GROUP BY time interval containing t,
+// in nanoseconds since the epoch. Intervals are aligned to the epoch in the
+// statement's timezone and shifted by the interval offset. Intervals which
+// cross a daylight savings change are lengthened or shortened so that they
+// still start and end at the same wall clock time.
+func (s *SelectStatement) TimeWindow(t int64) (start, end int64) {
+	interval, _ := s.GroupByInterval()
+	offset, _ := s.GroupByOffset()
+	d := int64(interval)
+	if d <= 0 {
+		return t, t
+	}
+
+	// Find the start of the interval in local time.
+	zone := s.zoneOffset(t)
+	dt := (t + zone - int64(offset)) % d
+	if dt < 0 {
+		dt += d
+	}
+	start = t - dt
+
+	// The zone offset at the start may differ if it is before a daylight
+	// savings change. Only adjust for it if the change is within the interval.
+	if o := zone - s.zoneOffset(start); o != 0 && abs(o) < d && start+o <= t {
+		start += o
+	}
+
+	end = start + d
+	if o := s.zoneOffset(start) - s.zoneOffset(end); o != 0 && abs(o) < d {
+		end += o
+	}
+	return start, end
+}
+
+// zoneOffset returns the offset of the statement's timezone from UTC at t, in nanoseconds.
+func (s *SelectStatement) zoneOffset(t int64) int64 {
+	if s.Location == nil {
+		return 0
+	}
+	_, offset := time.Unix(0, t).In(s.Location).Zone()
+	return int64(offset) * int64(time.Second)
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
 }
 
 // SetTimeRange sets the start and end time of the select statement to [start, end). i.e. start inclusive, end exclusive.
@@ -1637,6 +2136,13 @@ func walkNames(exp Expr) []string {
 	case *VarRef:
 		return []string{expr.Val}
 	case *Call:
+		if IsMathFunc(expr) {
+			var ret []string
+			for _, arg := range expr.Args {
+				ret = append(ret, walkNames(arg)...)
+			}
+			return ret
+		}
 		if len(expr.Args) == 0 {
 			return nil
 		}
@@ -1682,6 +2188,14 @@ func walkFunctionCalls(exp Expr) []*Call {
 	case *VarRef:
 		return nil
 	case *Call:
+		// Math functions are applied to the results of the calls in their arguments.
+		if IsMathFunc(expr) {
+			var ret []*Call
+			for _, arg := range expr.Args {
+				ret = append(ret, walkFunctionCalls(arg)...)
+			}
+			return ret
+		}
 		return []*Call{expr}
 	case *BinaryExpr:
 		var ret []*Call
@@ -1946,6 +2460,45 @@ func (s *ShowServersStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}
 }
 
+// ShowQueriesStatement represents a command for listing the queries running
+// in the cluster.
+type ShowQueriesStatement struct{}
+
+// String returns a string representation of the show queries statement.
+func (s *ShowQueriesStatement) String() string { return "SHOW QUERIES" }
+
+// RequiredPrivileges returns the privilege required to execute a ShowQueriesStatement.
+func (s *ShowQueriesStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}
+}
+
+// KillQueryStatement represents a command for killing a running query.
+type KillQueryStatement struct {
+	// ID of the query to be killed.
+	QueryID uint64
+
+	// ID of the node running the query. If zero, the query is killed on
+	// the local node.
+	NodeID uint64
+}
+
+// String returns a string representation of the kill query statement.
+func (s *KillQueryStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("KILL QUERY ")
+	_, _ = buf.WriteString(strconv.FormatUint(s.QueryID, 10))
+	if s.NodeID != 0 {
+		_, _ = buf.WriteString(" ON ")
+		_, _ = buf.WriteString(strconv.FormatUint(s.NodeID, 10))
+	}
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a KillQueryStatement.
+func (s *KillQueryStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}
+}
+
 // ShowDatabasesStatement represents a command for listing all databases in the cluster.
 type ShowDatabasesStatement struct{}
 
@@ -2449,6 +3002,10 @@ func (a Fields) Names() []string {
 	for _, f := range a {
 		switch expr := f.Expr.(type) {
 		case *Call:
+			if IsMathFunc(expr) {
+				names = append(names, walkNames(expr)...)
+				continue
+			}
 			names = append(names, expr.Name)
 		case *VarRef:
 			names = append(names, expr.Val)
@@ -2596,6 +3153,16 @@ func (m *Measurement) String() string {
 	return buf.String()
 }
 
+// SubQuery represents a SELECT statement used as a datasource.
+type SubQuery struct {
+	Statement *SelectStatement
+}
+
+// String returns a string representation of the subquery.
+func (s *SubQuery) String() string {
+	return fmt.Sprintf("(%s)", s.Statement.String())
+}
+
 // VarRef represents a reference to a variable.
 type VarRef struct {
 	Val string
@@ -2646,7 +3213,7 @@ func (c *Call) Fields() []string {
 			}
 		}
 		return keys
-	case "min", "max", "first", "last", "sum", "mean":
+	case "min", "max", "first", "last", "sum", "mean", "mode", "integral", "percentile_approx", "histogram":
 		// maintain the order the user specified in the query
 		keyMap := make(map[string]struct{})
 		keys := []string{}
@@ -3006,6 +3573,10 @@ func Walk(v Visitor, node Node) {
 	case *CreateContinuousQueryStatement:
 		Walk(v, n.Source)
 
+	case *DeleteStatement:
+		Walk(v, n.Source)
+		Walk(v, n.Condition)
+
 	case *Dimension:
 		Walk(v, n.Expr)
 
@@ -3073,6 +3644,9 @@ func Walk(v Visitor, node Node) {
 			Walk(v, s)
 		}
 
+	case *SubQuery:
+		Walk(v, n.Statement)
+
 	case *Target:
 		if n != nil {
 			Walk(v, n.Measurement)
@@ -3129,6 +3703,14 @@ func Rewrite(r Rewriter, node Node) Node {
 	case *Dimension:
 		n.Expr = Rewrite(r, n.Expr).(Expr)
 
+	case Sources:
+		for i, s := range n {
+			n[i] = Rewrite(r, s).(Source)
+		}
+
+	case *SubQuery:
+		n.Statement = Rewrite(r, n.Statement).(*SelectStatement)
+
 	case *BinaryExpr:
 		n.LHS = Rewrite(r, n.LHS).(Expr)
 		n.RHS = Rewrite(r, n.RHS).(Expr)
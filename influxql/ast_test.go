@@ -114,6 +114,42 @@ func TestSelectStatement_GroupByInterval(t *testing.T) {
 	}
 }
 
+// Ensure the SELECT statement returns GROUP BY time windows aligned to the offset and timezone.
+func TestSelectStatement_TimeWindow(t *testing.T) {
+	for i, tt := range []struct {
+		q          string
+		t          string
+		start, end string
+	}{
+		{q: `GROUP BY time(1d)`, t: "2016-03-27T12:00:00Z", start: "2016-03-27T00:00:00Z", end: "2016-03-28T00:00:00Z"},
+		{q: `GROUP BY time(1d, 6h)`, t: "2016-03-27T12:00:00Z", start: "2016-03-27T06:00:00Z", end: "2016-03-28T06:00:00Z"},
+		{q: `GROUP BY time(1d, 6h)`, t: "2016-03-27T03:00:00Z", start: "2016-03-26T06:00:00Z", end: "2016-03-27T06:00:00Z"},
+		{q: `GROUP BY time(1d, 30h)`, t: "2016-03-27T03:00:00Z", start: "2016-03-26T06:00:00Z", end: "2016-03-27T06:00:00Z"},
+		{q: `GROUP BY time(1d) tz('Europe/Berlin')`, t: "2016-03-20T12:00:00Z", start: "2016-03-19T23:00:00Z", end: "2016-03-20T23:00:00Z"},
+
+		// Daylight savings time starts.
+		{q: `GROUP BY time(1d) tz('Europe/Berlin')`, t: "2016-03-27T12:00:00Z", start: "2016-03-26T23:00:00Z", end: "2016-03-27T22:00:00Z"},
+		{q: `GROUP BY time(1d) tz('Europe/Berlin')`, t: "2016-03-28T12:00:00Z", start: "2016-03-27T22:00:00Z", end: "2016-03-28T22:00:00Z"},
+
+		// Daylight savings time ends.
+		{q: `GROUP BY time(1d) tz('Europe/Berlin')`, t: "2016-10-30T12:00:00Z", start: "2016-10-29T22:00:00Z", end: "2016-10-30T23:00:00Z"},
+		{q: `GROUP BY time(1h) tz('Europe/Berlin')`, t: "2016-10-30T01:30:00Z", start: "2016-10-30T01:00:00Z", end: "2016-10-30T02:00:00Z"},
+	} {
+		stmt, err := influxql.ParseStatement(`SELECT mean(value) FROM cpu WHERE time > now() - 1d ` + tt.q)
+		if err != nil {
+			t.Fatalf("%d. %s: invalid statement: %s", i, tt.q, err)
+		}
+
+		start, end := stmt.(*influxql.SelectStatement).TimeWindow(mustParseTime(tt.t).UnixNano())
+		if got := time.Unix(0, start).UTC().Format(time.RFC3339); got != tt.start {
+			t.Errorf("%d. %s: unexpected start for %s:\n\nexp=%s\n\ngot=%s\n\n", i, tt.q, tt.t, tt.start, got)
+		}
+		if got := time.Unix(0, end).UTC().Format(time.RFC3339); got != tt.end {
+			t.Errorf("%d. %s: unexpected end for %s:\n\nexp=%s\n\ngot=%s\n\n", i, tt.q, tt.t, tt.end, got)
+		}
+	}
+}
+
 // Ensure the SELECT statement can have its start and end time set
 func TestSelectStatement_SetTimeRange(t *testing.T) {
 	q := "SELECT sum(value) from foo where time < now() GROUP BY time(10m)"
@@ -446,6 +482,14 @@ func TestSelectStatement_IsRawQuerySet(t *testing.T) {
 			stmt:  "select mean(value) from foo group by bar",
 			isRaw: false,
 		},
+		{
+			stmt:  "select abs(value), pow(value, 2) from foo",
+			isRaw: true,
+		},
+		{
+			stmt:  "select log10(mean(value)) from foo",
+			isRaw: false,
+		},
 		{
 			stmt:  "select mean(value) from foo group by *",
 			isRaw: false,
@@ -592,6 +636,33 @@ func TestSelectStatement_IsSimpleDerivative(t *testing.T) {
 	}
 }
 
+func TestSelectStatement_IsSimpleTransform(t *testing.T) {
+	var tests = []struct {
+		stmt      string
+		transform bool
+	}{
+		{stmt: `SELECT value FROM cpu`, transform: false},
+		{stmt: `SELECT derivative(value) FROM cpu`, transform: true},
+		{stmt: `SELECT difference(value) FROM cpu`, transform: true},
+		{stmt: `SELECT abs(cumulative_sum(value)) FROM cpu`, transform: true},
+		{stmt: `SELECT moving_average(value, 3) FROM cpu`, transform: true},
+		{stmt: `SELECT elapsed(value, 1s) FROM cpu`, transform: true},
+		{stmt: `SELECT difference(mean(value)) FROM cpu where time < now() GROUP BY time(5ms)`, transform: false},
+		{stmt: `SELECT holt_winters(mean(value), 1, 0) FROM cpu where time < now() GROUP BY time(5ms)`, transform: false},
+	}
+
+	for i, tt := range tests {
+		stmt, err := influxql.NewParser(strings.NewReader(tt.stmt)).ParseStatement()
+		if err != nil {
+			t.Fatalf("invalid statement: %q: %s", tt.stmt, err)
+		}
+
+		if got := stmt.(*influxql.SelectStatement).IsSimpleTransform(); tt.transform != got {
+			t.Errorf("%d. %q: unexpected transform detection: exp=%v got=%v", i, tt.stmt, tt.transform, got)
+		}
+	}
+}
+
 func TestSelectStatement_HasSimpleCount(t *testing.T) {
 	var tests = []struct {
 		stmt  string
@@ -849,6 +920,12 @@ func TestParseString(t *testing.T) {
 		{
 			stmt: `ALTER RETENTION POLICY "my rp" ON "a database" DEFAULT`,
 		},
+		{
+			stmt: `ALTER USER "my user" SET max_select_point = DEFAULT, query_timeout = 1m`,
+		},
+		{
+			stmt: `ALTER USER "my user" SET query_limit = 10, write_limit = 50000`,
+		},
 		{
 			stmt: `SHOW RETENTION POLICIES ON "a database"`,
 		},
@@ -881,6 +958,15 @@ func TestParseString(t *testing.T) {
 		{
 			stmt: `GRANT ALL PRIVILEGES ON "db with spaces" TO "user with spaces"`,
 		},
+		{
+			stmt: `GRANT READ ON "db with spaces"."rp with spaces"."cpu load" TO "user with spaces"`,
+		},
+		{
+			stmt: `GRANT WRITE ON db0.rp0./cpu\/.*/ TO jdoe`,
+		},
+		{
+			stmt: `REVOKE READ ON db0..cpu FROM jdoe`,
+		},
 		{
 			stmt: `GRANT ALL PRIVILEGES TO "user with spaces"`,
 		},
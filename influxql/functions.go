@@ -0,0 +1,91 @@
+package influxql
+
+import (
+	"math"
+)
+
+// MathFunc is a scalar function evaluated against each value returned by a
+// SELECT statement. Unlike aggregates, math functions may be applied to raw
+// fields or to the results of aggregates, e.g. log10(mean(value)).
+type MathFunc struct {
+	// Number of arguments the function takes.
+	Args int
+
+	// Evaluates the function for the arguments as floats.
+	Fn func(args []float64) float64
+}
+
+// MathFuncs is the registry of math functions available in SELECT statements.
+var MathFuncs = map[string]*MathFunc{
+	"abs":   {Args: 1, Fn: func(a []float64) float64 { return math.Abs(a[0]) }},
+	"floor": {Args: 1, Fn: func(a []float64) float64 { return math.Floor(a[0]) }},
+	"ceil":  {Args: 1, Fn: func(a []float64) float64 { return math.Ceil(a[0]) }},
+	"round": {Args: 1, Fn: func(a []float64) float64 { return round(a[0]) }},
+	"sqrt":  {Args: 1, Fn: func(a []float64) float64 { return math.Sqrt(a[0]) }},
+	"pow":   {Args: 2, Fn: func(a []float64) float64 { return math.Pow(a[0], a[1]) }},
+	"ln":    {Args: 1, Fn: func(a []float64) float64 { return math.Log(a[0]) }},
+	"log":   {Args: 2, Fn: func(a []float64) float64 { return math.Log(a[0]) / math.Log(a[1]) }},
+	"log2":  {Args: 1, Fn: func(a []float64) float64 { return math.Log2(a[0]) }},
+	"log10": {Args: 1, Fn: func(a []float64) float64 { return math.Log10(a[0]) }},
+	"exp":   {Args: 1, Fn: func(a []float64) float64 { return math.Exp(a[0]) }},
+	"sin":   {Args: 1, Fn: func(a []float64) float64 { return math.Sin(a[0]) }},
+	"cos":   {Args: 1, Fn: func(a []float64) float64 { return math.Cos(a[0]) }},
+	"tan":   {Args: 1, Fn: func(a []float64) float64 { return math.Tan(a[0]) }},
+	"atan2": {Args: 2, Fn: func(a []float64) float64 { return math.Atan2(a[0], a[1]) }},
+	"clamp": {Args: 3, Fn: func(a []float64) float64 { return math.Max(a[1], math.Min(a[2], a[0])) }},
+}
+
+// IsMathFunc returns true if the call is to a registered math function.
+func IsMathFunc(call *Call) bool {
+	_, ok := MathFuncs[call.Name]
+	return ok
+}
+
+// round returns the nearest integer to v, rounding half away from zero.
+func round(v float64) float64 {
+	if v < 0 {
+		return math.Ceil(v - 0.5)
+	}
+	return math.Floor(v + 0.5)
+}
+
+// newMathFuncProcessor returns a processor evaluating fn against the values of args.
+// Nil is returned if any argument isn't a number or the result isn't a finite number.
+func newMathFuncProcessor(fn *MathFunc, args []Processor) Processor {
+	return func(values []interface{}) interface{} {
+		a := make([]float64, len(args))
+		for i, arg := range args {
+			switch v := arg(values).(type) {
+			case float64:
+				a[i] = v
+			case int64:
+				a[i] = float64(v)
+			default:
+				return nil
+			}
+		}
+
+		v := fn.Fn(a)
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+		return v
+	}
+}
+
+// transformFuncs are the functions which are applied to the series of values
+// of a field or aggregate in time order, e.g. difference(value). They return
+// one value per point instead of reducing the points to a single value.
+var transformFuncs = map[string]struct{}{
+	"cumulative_sum": {},
+	"moving_average": {},
+	"difference":     {},
+	"elapsed":        {},
+	"holt_winters":   {},
+}
+
+// IsTransformCall returns true if the call is to a transform function.
+func IsTransformCall(call *Call) bool {
+	_, ok := transformFuncs[call.Name]
+	return ok
+}
@@ -96,8 +96,10 @@ func (p *Parser) ParseStatement() (Statement, error) {
 		return p.parseAlterStatement()
 	case SET:
 		return p.parseSetPasswordUserStatement()
+	case KILL:
+		return p.parseKillQueryStatement()
 	default:
-		return nil, newParseError(tokstr(tok, lit), []string{"SELECT", "DELETE", "SHOW", "CREATE", "DROP", "GRANT", "REVOKE", "ALTER", "SET"}, pos)
+		return nil, newParseError(tokstr(tok, lit), []string{"SELECT", "DELETE", "SHOW", "CREATE", "DROP", "GRANT", "REVOKE", "ALTER", "SET", "KILL"}, pos)
 	}
 }
 
@@ -114,6 +116,8 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 		return p.parseShowDatabasesStatement()
 	case SERVERS:
 		return p.parseShowServersStatement()
+	case QUERIES:
+		return p.parseShowQueriesStatement()
 	case FIELD:
 		tok, pos, lit := p.scanIgnoreWhitespace()
 		if tok == KEYS {
@@ -162,6 +166,7 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 		"FIELD",
 		"GRANTS",
 		"MEASUREMENTS",
+		"QUERIES",
 		"RETENTION",
 		"SERIES",
 		"SERVERS",
@@ -238,9 +243,97 @@ func (p *Parser) parseAlterStatement() (Statement, error) {
 			return nil, newParseError(tokstr(tok, lit), []string{"POLICY"}, pos)
 		}
 		return p.parseAlterRetentionPolicyStatement()
+	} else if tok == USER {
+		return p.parseAlterUserStatement()
 	}
 
-	return nil, newParseError(tokstr(tok, lit), []string{"RETENTION"}, pos)
+	return nil, newParseError(tokstr(tok, lit), []string{"RETENTION", "USER"}, pos)
+}
+
+// userLimits are the names of the limits accepted by ALTER USER.
+var userLimits = []string{
+	QueryTimeoutLimit, MaxSelectSeriesLimit, MaxSelectPointLimit, MaxSelectBucketsLimit,
+	WriteLimit, WriteBytesLimit, QueryLimit,
+}
+
+// isUserLimit returns true if name is the name of a limit.
+func isUserLimit(name string) bool {
+	for _, l := range userLimits {
+		if name == l {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAlterUserStatement parses a string and returns an AlterUserStatement.
+// This function assumes the ALTER USER tokens have already been consumed.
+func (p *Parser) parseAlterUserStatement() (*AlterUserStatement, error) {
+	stmt := &AlterUserStatement{Limits: make(map[string]*int64)}
+
+	// Parse the user name.
+	ident, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = ident
+
+	// Consume the required SET token.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != SET {
+		return nil, newParseError(tokstr(tok, lit), []string{"SET"}, pos)
+	}
+
+	// Parse the comma-separated list of limits.
+	for {
+		tok, pos, name := p.scanIgnoreWhitespace()
+		switch {
+		case tok == WRITE || tok == QUERY:
+			// WRITE LIMIT and QUERY LIMIT are shorthands for the rate limits.
+			if name = WriteLimit; tok == QUERY {
+				name = QueryLimit
+			}
+			if tok, pos, lit := p.scanIgnoreWhitespace(); tok != LIMIT {
+				return nil, newParseError(tokstr(tok, lit), []string{"LIMIT"}, pos)
+			}
+		case tok == IDENT && isUserLimit(name):
+			if tok, pos, lit := p.scanIgnoreWhitespace(); tok != EQ {
+				return nil, newParseError(tokstr(tok, lit), []string{"="}, pos)
+			}
+		default:
+			return nil, newParseError(tokstr(tok, name), append([]string{"WRITE LIMIT", "QUERY LIMIT"}, userLimits...), pos)
+		}
+
+		// DEFAULT resets the limit to the one configured for the node.
+		if tok, _, _ := p.scanIgnoreWhitespace(); tok == DEFAULT {
+			stmt.Limits[name] = nil
+		} else {
+			p.unscan()
+
+			var v int64
+			if name == QueryTimeoutLimit {
+				d, err := p.parseDuration()
+				if err != nil {
+					return nil, err
+				}
+				v = int64(d)
+			} else {
+				n, err := p.parseInt(0, math.MaxInt32)
+				if err != nil {
+					return nil, err
+				}
+				v = int64(n)
+			}
+			stmt.Limits[name] = &v
+		}
+
+		// If there's no comma next then stop parsing limits.
+		if tok, _, _ := p.scanIgnoreWhitespace(); tok != COMMA {
+			p.unscan()
+			break
+		}
+	}
+
+	return stmt, nil
 }
 
 // parseSetPasswordUserStatement parses a string and returns a set statement.
@@ -675,12 +768,12 @@ func (p *Parser) parseRevokeStatement() (Statement, error) {
 func (p *Parser) parseRevokeOnStatement() (*RevokeStatement, error) {
 	stmt := &RevokeStatement{}
 
-	// Parse the name of the database.
-	lit, err := p.parseIdent()
+	// Parse the database and optional retention policy and measurement.
+	db, rp, name, re, err := p.parsePrivilegeTarget()
 	if err != nil {
 		return nil, err
 	}
-	stmt.On = lit
+	stmt.On, stmt.RetentionPolicy, stmt.Measurement, stmt.Regex = db, rp, name, re
 
 	// Parse FROM clause.
 	tok, pos, lit := p.scanIgnoreWhitespace()
@@ -716,6 +809,35 @@ func (p *Parser) parseRevokeAdminStatement() (*RevokeAdminStatement, error) {
 	return stmt, nil
 }
 
+// parsePrivilegeTarget parses the target of a GRANT or REVOKE statement.
+// e.g., db, db.rp, db.rp.cpu, db..cpu or db.rp./cpu.*/
+func (p *Parser) parsePrivilegeTarget() (db, rp, name string, re *RegexLiteral, err error) {
+	idents, err := p.parseSegmentedIdents()
+	if err != nil {
+		return "", "", "", nil, err
+	}
+
+	// A trailing dot means the segments are followed by a regex.
+	if tok, pos, _ := p.s.curr(); tok == DOT {
+		if len(idents) == 1 {
+			msg := fmt.Sprintf("expected retention policy before regex in %s", QuoteIdent(idents[0]))
+			return "", "", "", nil, &ParseError{Message: msg, Pos: pos}
+		}
+		if re, err = p.parseRegex(); err != nil {
+			return "", "", "", nil, err
+		}
+	}
+
+	switch len(idents) {
+	case 1:
+		return idents[0], "", "", nil, nil
+	case 2:
+		return idents[0], idents[1], "", re, nil
+	default:
+		return idents[0], idents[1], idents[2], nil, nil
+	}
+}
+
 // parseGrantStatement parses a string and returns a grant statement.
 // This function assumes the GRANT token has already been consumed.
 func (p *Parser) parseGrantStatement() (Statement, error) {
@@ -754,12 +876,12 @@ func (p *Parser) parseGrantStatement() (Statement, error) {
 func (p *Parser) parseGrantOnStatement() (*GrantStatement, error) {
 	stmt := &GrantStatement{}
 
-	// Parse the name of the database.
-	lit, err := p.parseIdent()
+	// Parse the database and optional retention policy and measurement.
+	db, rp, name, re, err := p.parsePrivilegeTarget()
 	if err != nil {
 		return nil, err
 	}
-	stmt.On = lit
+	stmt.On, stmt.RetentionPolicy, stmt.Measurement, stmt.Regex = db, rp, name, re
 
 	// Parse TO clause.
 	tok, pos, lit := p.scanIgnoreWhitespace()
@@ -834,7 +956,7 @@ func (p *Parser) parseSelectStatement(tr targetRequirement) (*SelectStatement, e
 	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != FROM {
 		return nil, newParseError(tokstr(tok, lit), []string{"FROM"}, pos)
 	}
-	if stmt.Sources, err = p.parseSources(); err != nil {
+	if stmt.Sources, err = p.parseSelectSources(); err != nil {
 		return nil, err
 	}
 
@@ -878,10 +1000,15 @@ func (p *Parser) parseSelectStatement(tr targetRequirement) (*SelectStatement, e
 		return nil, err
 	}
 
+	// Parse timezone: "tz(<string>)".
+	if stmt.Location, err = p.parseLocation(); err != nil {
+		return nil, err
+	}
+
 	// Set if the query is a raw data query or one with an aggregate
 	stmt.IsRawQuery = true
 	WalkFunc(stmt.Fields, func(n Node) {
-		if _, ok := n.(*Call); ok {
+		if call, ok := n.(*Call); ok && !IsMathFunc(call) {
 			stmt.IsRawQuery = false
 		}
 	})
@@ -899,6 +1026,7 @@ type targetRequirement int
 const (
 	targetRequired targetRequirement = iota
 	targetNotRequired
+	targetNotAllowed
 )
 
 // parseTarget parses a string and returns a Target.
@@ -948,30 +1076,26 @@ func (p *Parser) parseTarget(tr targetRequirement) (*Target, error) {
 // parseDeleteStatement parses a delete string and returns a DeleteStatement.
 // This function assumes the DELETE token has already been consumed.
 func (p *Parser) parseDeleteStatement() (*DeleteStatement, error) {
-	// TODO remove and do not skip test once we wire up DELETE FROM.
-	// See issues https://github.com/influxdb/influxdb/issues/1647
-	// and https://github.com/influxdb/influxdb/issues/4404
-	return nil, errors.New("DELETE FROM is currently not supported. Use DROP SERIES or DROP MEASUREMENT instead")
-	//stmt := &DeleteStatement{}
-
-	//// Parse source
-	//if tok, pos, lit := p.scanIgnoreWhitespace(); tok != FROM {
-	//	return nil, newParseError(tokstr(tok, lit), []string{"FROM"}, pos)
-	//}
-	//source, err := p.parseSource()
-	//if err != nil {
-	//	return nil, err
-	//}
-	//stmt.Source = source
-
-	//// Parse condition: "WHERE EXPR".
-	//condition, err := p.parseCondition()
-	//if err != nil {
-	//	return nil, err
-	//}
-	//stmt.Condition = condition
-
-	//return stmt, nil
+	stmt := &DeleteStatement{}
+
+	// Parse source
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != FROM {
+		return nil, newParseError(tokstr(tok, lit), []string{"FROM"}, pos)
+	}
+	source, err := p.parseSource()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Source = source
+
+	// Parse condition: "WHERE EXPR".
+	condition, err := p.parseCondition()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Condition = condition
+
+	return stmt, nil
 }
 
 // parseShowSeriesStatement parses a string and returns a ShowSeriesStatement.
@@ -1348,6 +1472,39 @@ func (p *Parser) parseShowServersStatement() (*ShowServersStatement, error) {
 	return stmt, nil
 }
 
+// parseShowQueriesStatement parses a string and returns a ShowQueriesStatement.
+// This function assumes the "SHOW QUERIES" tokens have already been consumed.
+func (p *Parser) parseShowQueriesStatement() (*ShowQueriesStatement, error) {
+	return &ShowQueriesStatement{}, nil
+}
+
+// parseKillQueryStatement parses a string and returns a KillQueryStatement.
+// This function assumes the KILL token has already been consumed.
+func (p *Parser) parseKillQueryStatement() (*KillQueryStatement, error) {
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != QUERY {
+		return nil, newParseError(tokstr(tok, lit), []string{"QUERY"}, pos)
+	}
+
+	stmt := &KillQueryStatement{}
+	var err error
+
+	// Parse the query's ID.
+	if stmt.QueryID, err = p.parseUInt64(); err != nil {
+		return nil, err
+	}
+
+	// Parse optional ON clause with the ID of the node running the query.
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == ON {
+		if stmt.NodeID, err = p.parseUInt64(); err != nil {
+			return nil, err
+		}
+	} else {
+		p.unscan()
+	}
+
+	return stmt, nil
+}
+
 // parseGrantsForUserStatement parses a string and returns a ShowGrantsForUserStatement.
 // This function assumes the "SHOW GRANTS" tokens have already been consumed.
 func (p *Parser) parseGrantsForUserStatement() (*ShowGrantsForUserStatement, error) {
@@ -1881,6 +2038,69 @@ func (p *Parser) peekRune() rune {
 	return r
 }
 
+// parseSelectSources parses a comma delimited list of sources for a SELECT
+// statement. Unlike other statements, a SELECT may read from a subquery.
+func (p *Parser) parseSelectSources() (Sources, error) {
+	var sources Sources
+
+	for {
+		// Peek rather than scan so a regex source can still be read.
+		if isWhitespace(p.peekRune()) {
+			p.consumeWhitespace()
+		}
+
+		var s Source
+		var err error
+		if p.peekRune() == '(' {
+			p.scan()
+			s, err = p.parseSubQuery()
+		} else {
+			s, err = p.parseSource()
+		}
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, s)
+
+		if tok, _, _ := p.scanIgnoreWhitespace(); tok != COMMA {
+			p.unscan()
+			break
+		}
+	}
+
+	// A subquery must be the only source of the statement.
+	if len(sources) > 1 {
+		for _, s := range sources {
+			if _, ok := s.(*SubQuery); ok {
+				return nil, fmt.Errorf("a subquery cannot be combined with other sources")
+			}
+		}
+	}
+
+	return sources, nil
+}
+
+// parseSubQuery parses a parenthesized SELECT statement used as a source.
+// This function assumes the LPAREN has been consumed.
+func (p *Parser) parseSubQuery() (*SubQuery, error) {
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != SELECT {
+		return nil, newParseError(tokstr(tok, lit), []string{"SELECT"}, pos)
+	}
+
+	stmt, err := p.parseSelectStatement(targetNotAllowed)
+	if err != nil {
+		return nil, err
+	} else if stmt.Target != nil {
+		return nil, fmt.Errorf("subquery cannot have an INTO clause")
+	}
+
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != RPAREN {
+		return nil, newParseError(tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	return &SubQuery{Statement: stmt}, nil
+}
+
 func (p *Parser) parseSource() (Source, error) {
 	m := &Measurement{}
 
@@ -1998,6 +2218,13 @@ func (p *Parser) parseDimension() (*Dimension, error) {
 
 // parseFill parses the fill call and its options.
 func (p *Parser) parseFill() (FillOption, interface{}, error) {
+	// Ensure the next call is fill() so other calls can follow the dimensions.
+	if tok, _, lit := p.scanIgnoreWhitespace(); tok != IDENT || strings.ToLower(lit) != "fill" {
+		p.unscan()
+		return NullFill, nil, nil
+	}
+	p.unscan()
+
 	// Parse the expression first.
 	expr, err := p.ParseExpr()
 	if err != nil {
@@ -2014,7 +2241,7 @@ func (p *Parser) parseFill() (FillOption, interface{}, error) {
 		return NullFill, nil, nil
 	}
 	if len(lit.Args) != 1 {
-		return NullFill, nil, errors.New("fill requires an argument, e.g.: 0, null, none, previous")
+		return NullFill, nil, errors.New("fill requires an argument, e.g.: 0, null, none, previous, linear")
 	}
 	switch lit.Args[0].String() {
 	case "null":
@@ -2023,6 +2250,8 @@ func (p *Parser) parseFill() (FillOption, interface{}, error) {
 		return NoFill, nil, nil
 	case "previous":
 		return PreviousFill, nil, nil
+	case "linear":
+		return LinearFill, nil, nil
 	default:
 		num, ok := lit.Args[0].(*NumberLiteral)
 		if !ok {
@@ -2032,6 +2261,34 @@ func (p *Parser) parseFill() (FillOption, interface{}, error) {
 	}
 }
 
+// parseLocation parses the timezone call and its argument.
+func (p *Parser) parseLocation() (*time.Location, error) {
+	// Check for the tz() call.
+	if tok, _, lit := p.scanIgnoreWhitespace(); tok != IDENT || strings.ToLower(lit) != "tz" {
+		p.unscan()
+		return nil, nil
+	}
+
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != LPAREN {
+		return nil, newParseError(tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	// Parse the timezone name.
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	if tok != STRING {
+		return nil, newParseError(tokstr(tok, lit), []string{"string"}, pos)
+	}
+	loc, err := time.LoadLocation(lit)
+	if err != nil {
+		return nil, &ParseError{Message: fmt.Sprintf("unable to find time zone %s", lit), Pos: pos}
+	}
+
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != RPAREN {
+		return nil, newParseError(tokstr(tok, lit), []string{")"}, pos)
+	}
+	return loc, nil
+}
+
 // parseOptionalTokenAndInt parses the specified token followed
 // by an int, if it exists.
 func (p *Parser) parseOptionalTokenAndInt(t Token) (int, error) {
@@ -2103,11 +2360,6 @@ func (p *Parser) parseSortFields() (SortFields, error) {
 		if err != nil {
 			return nil, err
 		}
-
-		if lit != "time" {
-			return nil, errors.New("only ORDER BY time supported at this time")
-		}
-
 		fields = append(fields, field)
 	// Parse error...
 	default:
@@ -2131,10 +2383,6 @@ func (p *Parser) parseSortFields() (SortFields, error) {
 		fields = append(fields, field)
 	}
 
-	if len(fields) > 1 {
-		return nil, errors.New("only ORDER BY time supported at this time")
-	}
-
 	return fields, nil
 }
 
@@ -212,6 +212,40 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// SELECT statement with a subquery
+		{
+			s: `SELECT max(m) FROM (SELECT mean(value) AS m FROM cpu GROUP BY time(1m), host) WHERE time > now() - 1d GROUP BY time(1h)`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: false,
+				Fields: []*influxql.Field{
+					{Expr: &influxql.Call{Name: "max", Args: []influxql.Expr{&influxql.VarRef{Val: "m"}}}},
+				},
+				Sources: []influxql.Source{&influxql.SubQuery{
+					Statement: &influxql.SelectStatement{
+						IsRawQuery: false,
+						Fields: []*influxql.Field{
+							{Expr: &influxql.Call{Name: "mean", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}, Alias: "m"},
+						},
+						Sources: []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+						Dimensions: []*influxql.Dimension{
+							{Expr: &influxql.Call{Name: "time", Args: []influxql.Expr{&influxql.DurationLiteral{Val: time.Minute}}}},
+							{Expr: &influxql.VarRef{Val: "host"}},
+						},
+					},
+				}},
+				Condition: &influxql.BinaryExpr{
+					Op:  influxql.GT,
+					LHS: &influxql.VarRef{Val: "time"},
+					RHS: &influxql.BinaryExpr{
+						Op:  influxql.SUB,
+						LHS: &influxql.Call{Name: "now"},
+						RHS: &influxql.DurationLiteral{Val: 24 * time.Hour},
+					},
+				},
+				Dimensions: []*influxql.Dimension{{Expr: &influxql.Call{Name: "time", Args: []influxql.Expr{&influxql.DurationLiteral{Val: time.Hour}}}}},
+			},
+		},
+
 		// SELECT statement (lowercase)
 		{
 			s: `select my_field from myseries`,
@@ -249,6 +283,24 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// SELECT statement ordered by a field value
+		{
+			s: `SELECT mean(value) FROM cpu GROUP BY host ORDER BY mean DESC, time ASC LIMIT 10`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: false,
+				Fields: []*influxql.Field{
+					{Expr: &influxql.Call{Name: "mean", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}},
+				},
+				Sources:    []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+				Dimensions: []*influxql.Dimension{{Expr: &influxql.VarRef{Val: "host"}}},
+				SortFields: []*influxql.SortField{
+					{Name: "mean"},
+					{Name: "time", Ascending: true},
+				},
+				Limit: 10,
+			},
+		},
+
 		// SELECT statement with SLIMIT and SOFFSET
 		{
 			s: `SELECT field1 FROM myseries SLIMIT 10 SOFFSET 5`,
@@ -644,20 +696,78 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
-		// See issues https://github.com/influxdb/influxdb/issues/1647
-		// and https://github.com/influxdb/influxdb/issues/4404
+		// SELECT statement with linear fill
+		{
+			s: fmt.Sprintf(`SELECT mean(value) FROM cpu where time < '%s' GROUP BY time(5m) fill(linear)`, now.UTC().Format(time.RFC3339Nano)),
+			stmt: &influxql.SelectStatement{
+				Fields: []*influxql.Field{{
+					Expr: &influxql.Call{
+						Name: "mean",
+						Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}}},
+				Sources: []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+				Condition: &influxql.BinaryExpr{
+					Op:  influxql.LT,
+					LHS: &influxql.VarRef{Val: "time"},
+					RHS: &influxql.TimeLiteral{Val: now.UTC()},
+				},
+				Dimensions: []*influxql.Dimension{{Expr: &influxql.Call{Name: "time", Args: []influxql.Expr{&influxql.DurationLiteral{Val: 5 * time.Minute}}}}},
+				Fill:       influxql.LinearFill,
+			},
+		},
+
+		// SELECT statement with a GROUP BY time offset and timezone
+		{
+			s: fmt.Sprintf(`SELECT mean(value) FROM cpu where time < '%s' GROUP BY time(1d, 6h) fill(none) tz('Europe/Berlin')`, now.UTC().Format(time.RFC3339Nano)),
+			stmt: &influxql.SelectStatement{
+				Fields: []*influxql.Field{{
+					Expr: &influxql.Call{
+						Name: "mean",
+						Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}}},
+				Sources: []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+				Condition: &influxql.BinaryExpr{
+					Op:  influxql.LT,
+					LHS: &influxql.VarRef{Val: "time"},
+					RHS: &influxql.TimeLiteral{Val: now.UTC()},
+				},
+				Dimensions: []*influxql.Dimension{{Expr: &influxql.Call{Name: "time", Args: []influxql.Expr{&influxql.DurationLiteral{Val: 24 * time.Hour}, &influxql.DurationLiteral{Val: 6 * time.Hour}}}}},
+				Fill:       influxql.NoFill,
+				Location:   mustLoadLocation("Europe/Berlin"),
+			},
+		},
+
 		// DELETE statement
-		//{
-		//	s: `DELETE FROM myseries WHERE host = 'hosta.influxdb.org'`,
-		//	stmt: &influxql.DeleteStatement{
-		//		Source: &influxql.Measurement{Name: "myseries"},
-		//		Condition: &influxql.BinaryExpr{
-		//			Op:  influxql.EQ,
-		//			LHS: &influxql.VarRef{Val: "host"},
-		//			RHS: &influxql.StringLiteral{Val: "hosta.influxdb.org"},
-		//		},
-		//	},
-		//},
+		{
+			s: `DELETE FROM myseries WHERE host = 'hosta.influxdb.org'`,
+			stmt: &influxql.DeleteStatement{
+				Source: &influxql.Measurement{Name: "myseries"},
+				Condition: &influxql.BinaryExpr{
+					Op:  influxql.EQ,
+					LHS: &influxql.VarRef{Val: "host"},
+					RHS: &influxql.StringLiteral{Val: "hosta.influxdb.org"},
+				},
+			},
+		},
+
+		// DELETE statement with a time range
+		{
+			s: fmt.Sprintf(`DELETE FROM myseries WHERE host = 'hosta.influxdb.org' AND time < '%s'`, now.UTC().Format(time.RFC3339Nano)),
+			stmt: &influxql.DeleteStatement{
+				Source: &influxql.Measurement{Name: "myseries"},
+				Condition: &influxql.BinaryExpr{
+					Op: influxql.AND,
+					LHS: &influxql.BinaryExpr{
+						Op:  influxql.EQ,
+						LHS: &influxql.VarRef{Val: "host"},
+						RHS: &influxql.StringLiteral{Val: "hosta.influxdb.org"},
+					},
+					RHS: &influxql.BinaryExpr{
+						Op:  influxql.LT,
+						LHS: &influxql.VarRef{Val: "time"},
+						RHS: &influxql.TimeLiteral{Val: now.UTC()},
+					},
+				},
+			},
+		},
 
 		// SHOW SERVERS
 		{
@@ -665,6 +775,22 @@ func TestParser_ParseStatement(t *testing.T) {
 			stmt: &influxql.ShowServersStatement{},
 		},
 
+		// SHOW QUERIES
+		{
+			s:    `SHOW QUERIES`,
+			stmt: &influxql.ShowQueriesStatement{},
+		},
+
+		// KILL QUERY statement
+		{
+			s:    `KILL QUERY 4`,
+			stmt: &influxql.KillQueryStatement{QueryID: 4},
+		},
+		{
+			s:    `KILL QUERY 4 ON 2`,
+			stmt: &influxql.KillQueryStatement{QueryID: 4, NodeID: 2},
+		},
+
 		// SHOW GRANTS
 		{
 			s:    `SHOW GRANTS FOR jdoe`,
@@ -1391,6 +1517,52 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// GRANT READ on a retention policy
+		{
+			s: `GRANT READ ON testdb.rp0 TO jdoe`,
+			stmt: &influxql.GrantStatement{
+				Privilege:       influxql.ReadPrivilege,
+				On:              "testdb",
+				RetentionPolicy: "rp0",
+				User:            "jdoe",
+			},
+		},
+
+		// GRANT WRITE on a measurement
+		{
+			s: `GRANT WRITE ON testdb.rp0.cpu TO jdoe`,
+			stmt: &influxql.GrantStatement{
+				Privilege:       influxql.WritePrivilege,
+				On:              "testdb",
+				RetentionPolicy: "rp0",
+				Measurement:     "cpu",
+				User:            "jdoe",
+			},
+		},
+
+		// GRANT READ on a measurement of all retention policies
+		{
+			s: `GRANT READ ON "testdb".."cpu" TO jdoe`,
+			stmt: &influxql.GrantStatement{
+				Privilege:   influxql.ReadPrivilege,
+				On:          "testdb",
+				Measurement: "cpu",
+				User:        "jdoe",
+			},
+		},
+
+		// GRANT ALL on measurements matching a regex
+		{
+			s: `GRANT ALL ON testdb.rp0./cpu.*/ TO jdoe`,
+			stmt: &influxql.GrantStatement{
+				Privilege:       influxql.AllPrivileges,
+				On:              "testdb",
+				RetentionPolicy: "rp0",
+				Regex:           &influxql.RegexLiteral{Val: regexp.MustCompile("cpu.*")},
+				User:            "jdoe",
+			},
+		},
+
 		// GRANT ALL admin privilege
 		{
 			s: `GRANT ALL TO jdoe`,
@@ -1447,6 +1619,29 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// REVOKE READ on a measurement
+		{
+			s: `REVOKE READ ON testdb.rp0.cpu FROM jdoe`,
+			stmt: &influxql.RevokeStatement{
+				Privilege:       influxql.ReadPrivilege,
+				On:              "testdb",
+				RetentionPolicy: "rp0",
+				Measurement:     "cpu",
+				User:            "jdoe",
+			},
+		},
+
+		// REVOKE ALL on measurements matching a regex of all retention policies
+		{
+			s: `REVOKE ALL ON testdb../cpu.*/ FROM jdoe`,
+			stmt: &influxql.RevokeStatement{
+				Privilege: influxql.AllPrivileges,
+				On:        "testdb",
+				Regex:     &influxql.RegexLiteral{Val: regexp.MustCompile("cpu.*")},
+				User:      "jdoe",
+			},
+		},
+
 		// REVOKE ALL admin privilege
 		{
 			s: `REVOKE ALL FROM jdoe`,
@@ -1538,6 +1733,43 @@ func TestParser_ParseStatement(t *testing.T) {
 			stmt: newAlterRetentionPolicyStatement("default", "testdb", -1, 4, false),
 		},
 
+		// ALTER USER
+		{
+			s: `ALTER USER jdoe SET max_select_series = 1000, query_timeout = 30s`,
+			stmt: &influxql.AlterUserStatement{
+				Name: "jdoe",
+				Limits: map[string]*int64{
+					influxql.MaxSelectSeriesLimit: int64ptr(1000),
+					influxql.QueryTimeoutLimit:    int64ptr(int64(30 * time.Second)),
+				},
+			},
+		},
+
+		// ALTER USER resetting a limit
+		{
+			s: `ALTER USER jdoe SET max_select_point = DEFAULT, max_select_buckets = 0`,
+			stmt: &influxql.AlterUserStatement{
+				Name: "jdoe",
+				Limits: map[string]*int64{
+					influxql.MaxSelectPointLimit:   nil,
+					influxql.MaxSelectBucketsLimit: int64ptr(0),
+				},
+			},
+		},
+
+		// ALTER USER setting rate limits
+		{
+			s: `ALTER USER jdoe SET WRITE LIMIT 50000, QUERY LIMIT DEFAULT, write_bytes_limit = 1048576`,
+			stmt: &influxql.AlterUserStatement{
+				Name: "jdoe",
+				Limits: map[string]*int64{
+					influxql.WriteLimit:      int64ptr(50000),
+					influxql.QueryLimit:      nil,
+					influxql.WriteBytesLimit: int64ptr(1048576),
+				},
+			},
+		},
+
 		// SHOW STATS
 		{
 			s: `SHOW STATS`,
@@ -1605,15 +1837,20 @@ func TestParser_ParseStatement(t *testing.T) {
 		},
 
 		// Errors
-		{s: ``, err: `found EOF, expected SELECT, DELETE, SHOW, CREATE, DROP, GRANT, REVOKE, ALTER, SET at line 1, char 1`},
+		{s: ``, err: `found EOF, expected SELECT, DELETE, SHOW, CREATE, DROP, GRANT, REVOKE, ALTER, SET, KILL at line 1, char 1`},
 		{s: `SELECT`, err: `found EOF, expected identifier, string, number, bool at line 1, char 8`},
 		{s: `SELECT time FROM myseries`, err: `at least 1 non-time field must be queried`},
-		{s: `blah blah`, err: `found blah, expected SELECT, DELETE, SHOW, CREATE, DROP, GRANT, REVOKE, ALTER, SET at line 1, char 1`},
+		{s: `blah blah`, err: `found blah, expected SELECT, DELETE, SHOW, CREATE, DROP, GRANT, REVOKE, ALTER, SET, KILL at line 1, char 1`},
 		{s: `SELECT field1 X`, err: `found X, expected FROM at line 1, char 15`},
 		{s: `SELECT field1 FROM "series" WHERE X +;`, err: `found ;, expected identifier, string, number, bool at line 1, char 38`},
 		{s: `SELECT field1 FROM myseries GROUP`, err: `found EOF, expected BY at line 1, char 35`},
 		{s: `SELECT field1 FROM myseries LIMIT`, err: `found EOF, expected number at line 1, char 35`},
 		{s: `SELECT field1 FROM myseries LIMIT 10.5`, err: `fractional parts not allowed in LIMIT at line 1, char 35`},
+		{s: `SELECT m FROM (SELECT value FROM cpu`, err: `found EOF, expected ) at line 1, char 38`},
+		{s: `SELECT m FROM (SHOW MEASUREMENTS)`, err: `found SHOW, expected SELECT at line 1, char 16`},
+		{s: `SELECT m FROM cpu, (SELECT value FROM cpu)`, err: `a subquery cannot be combined with other sources`},
+		{s: `SELECT m FROM (SELECT value INTO foo FROM cpu)`, err: `subquery cannot have an INTO clause`},
+		{s: `SELECT m FROM (SELECT mean(value) AS m FROM cpu GROUP BY time(1m))`, err: `aggregate functions with GROUP BY time require a WHERE time clause`},
 		{s: `SELECT top() FROM myseries`, err: `invalid number of arguments for top, expected at least 2, got 0`},
 		{s: `SELECT top(field1) FROM myseries`, err: `invalid number of arguments for top, expected at least 2, got 1`},
 		{s: `SELECT top(field1,foo) FROM myseries`, err: `expected integer as last argument in top(), found foo`},
@@ -1636,7 +1873,13 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `SELECT field1 FROM myseries ORDER BY /`, err: `found /, expected identifier, ASC, DESC at line 1, char 38`},
 		{s: `SELECT field1 FROM myseries ORDER BY 1`, err: `found 1, expected identifier, ASC, DESC at line 1, char 38`},
 		{s: `SELECT field1 FROM myseries ORDER BY time ASC,`, err: `found EOF, expected identifier at line 1, char 47`},
-		{s: `SELECT field1 FROM myseries ORDER BY time, field1`, err: `only ORDER BY time supported at this time`},
+		{s: `SELECT pow(value) FROM cpu`, err: `invalid number of arguments for pow, expected 2, got 1`},
+		{s: `SELECT abs('foo') FROM cpu`, err: `invalid argument to abs: 'foo'`},
+		{s: `SELECT abs(value), mean(value) FROM cpu`, err: `mixing aggregate and non-aggregate queries is not supported`},
+		{s: `SELECT field1 FROM myseries ORDER BY time, field2`, err: `ORDER BY field2 must be a selected field or a GROUP BY tag`},
+		{s: `SELECT mean(field1) FROM myseries ORDER BY field1 DESC`, err: `ORDER BY field1 must be a selected field or a GROUP BY tag`},
+		{s: `SELECT mean(field1) FROM myseries GROUP BY host ORDER BY host DESC`, err: `ORDER BY host requires a LIMIT`},
+		{s: `SELECT * FROM myseries ORDER BY field1 SLIMIT 1`, err: `ORDER BY field1 requires a LIMIT`},
 		{s: `SELECT field1 AS`, err: `found EOF, expected identifier at line 1, char 18`},
 		{s: `SELECT field1 FROM foo group by time(1s)`, err: `GROUP BY requires at least one aggregate function`},
 		{s: `SELECT count(value), value FROM foo`, err: `mixing aggregate and non-aggregate queries is not supported`},
@@ -1645,9 +1888,13 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `SELECT count(value) FROM foo group by time(1s) where host = 'hosta.influxdb.org'`, err: `aggregate functions with GROUP BY time require a WHERE time clause`},
 		{s: `SELECT count(value) FROM foo group by time`, err: `time() is a function and expects at least one argument`},
 		{s: `SELECT count(value) FROM foo group by 'time'`, err: `only time and tag dimensions allowed`},
-		{s: `SELECT count(value) FROM foo where time > now() and time < now() group by time()`, err: `time dimension expected one argument`},
+		{s: `SELECT count(value) FROM foo where time > now() and time < now() group by time()`, err: `time dimension expected one or two arguments`},
 		{s: `SELECT count(value) FROM foo where time > now() and time < now() group by time(b)`, err: `time dimension must have one duration argument`},
 		{s: `SELECT count(value) FROM foo where time > now() and time < now() group by time(1s), time(2s)`, err: `multiple time dimensions not allowed`},
+		{s: `SELECT count(value) FROM foo where time > now() and time < now() group by time(1s, 1)`, err: `time dimension offset must be a duration`},
+		{s: `SELECT count(value) FROM foo where time > now() and time < now() group by time(1s, 1s, 1s)`, err: `time dimension expected one or two arguments`},
+		{s: `SELECT count(value) FROM foo where time > now() group by time(1d) tz('Nowhere/Special')`, err: `unable to find time zone Nowhere/Special at line 1, char 69`},
+		{s: `SELECT count(value) FROM foo where time > now() group by time(1d) tz(1)`, err: `found 1, expected string at line 1, char 70`},
 		{s: `SELECT field1 FROM 12`, err: `found 12, expected identifier at line 1, char 20`},
 		{s: `SELECT 1000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000 FROM myseries`, err: `unable to parse number at line 1, char 8`},
 		{s: `SELECT 10.5h FROM myseries`, err: `found h, expected FROM at line 1, char 12`},
@@ -1677,18 +1924,38 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `SELECT non_negative_derivative(bottom(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for bottom, expected at least 2, got 1`},
 		{s: `SELECT non_negative_derivative(max()) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for max, expected 1, got 0`},
 		{s: `SELECT non_negative_derivative(percentile(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for percentile, expected 2, got 1`},
+		{s: `SELECT difference(value) FROM myseries group by time(1h)`, err: `aggregate function required inside the call to difference`},
+		{s: `SELECT cumulative_sum(value, 1) FROM myseries`, err: `invalid number of arguments for cumulative_sum, expected 1, got 2`},
+		{s: `SELECT cumulative_sum(difference(value)) FROM myseries`, err: `difference cannot be used inside the call to cumulative_sum`},
+		{s: `SELECT difference(1) FROM myseries`, err: `expected field argument in difference()`},
+		{s: `SELECT difference(value), value FROM myseries`, err: `mixing aggregate and non-aggregate queries is not supported`},
+		{s: `SELECT difference(max(value)), mean(value) FROM myseries`, err: `difference cannot be used with other fields`},
+		{s: `SELECT moving_average(value) FROM myseries`, err: `invalid number of arguments for moving_average, expected 2, got 1`},
+		{s: `SELECT moving_average(value, 0) FROM myseries`, err: `second argument to moving_average must be a positive integer, found 0.000`},
+		{s: `SELECT moving_average(value, 1.5) FROM myseries`, err: `second argument to moving_average must be a positive integer, found 1.500`},
+		{s: `SELECT elapsed(value, 5) FROM myseries`, err: `second argument to elapsed must be a positive duration, found 5.000`},
+		{s: `SELECT holt_winters(value, 10, 0) FROM myseries where time > now() - 1d group by time(1h)`, err: `aggregate function required inside the call to holt_winters`},
+		{s: `SELECT holt_winters(mean(value), 10, 0) FROM myseries`, err: `holt_winters requires a GROUP BY time interval`},
+		{s: `SELECT holt_winters(mean(value), 10) FROM myseries where time > now() - 1d group by time(1h)`, err: `invalid number of arguments for holt_winters, expected 3, got 2`},
+		{s: `SELECT holt_winters(mean(value), 0, 0) FROM myseries where time > now() - 1d group by time(1h)`, err: `second argument to holt_winters must be a positive integer, found 0.000`},
+		{s: `SELECT holt_winters(mean(value), 10, -1) FROM myseries where time > now() - 1d group by time(1h)`, err: `third argument to holt_winters must be a non-negative integer, found -1.000`},
+		{s: `SELECT integral(mean(value)) FROM myseries`, err: `expected field argument in integral()`},
+		{s: `SELECT integral(value, 1) FROM myseries`, err: `second argument to integral must be a positive duration, found 1.000`},
+		{s: `SELECT mode(value, 1) FROM myseries`, err: `invalid number of arguments for mode, expected 1, got 2`},
+		{s: `SELECT percentile_approx(value) FROM myseries`, err: `invalid number of arguments for percentile_approx, expected 2, got 1`},
+		{s: `SELECT percentile_approx(value, host) FROM myseries`, err: `expected float argument in percentile_approx()`},
+		{s: `SELECT percentile_approx(value, 101) FROM myseries`, err: `percentile in percentile_approx() must be between 0 and 100, found 101.000`},
+		{s: `SELECT histogram(value) FROM myseries`, err: `invalid number of arguments for histogram, expected 2, got 1`},
+		{s: `SELECT histogram(value, 0) FROM myseries`, err: `bucket width in histogram() must be a positive number, found 0.000`},
+		{s: `SELECT histogram(value, 10), max(value) FROM myseries`, err: `histogram cannot be used with other fields`},
+		{s: `SELECT difference(histogram(value, 10)) FROM myseries`, err: `histogram cannot be used inside the call to difference`},
 		{s: `SELECT field1 from myseries WHERE host =~ 'asd' LIMIT 1`, err: `found asd, expected regex at line 1, char 42`},
 		{s: `SELECT value > 2 FROM cpu`, err: `invalid operator > in SELECT clause at line 1, char 8; operator is intended for WHERE clause`},
 		{s: `SELECT value = 2 FROM cpu`, err: `invalid operator = in SELECT clause at line 1, char 8; operator is intended for WHERE clause`},
 		{s: `SELECT s =~ /foo/ FROM cpu`, err: `invalid operator =~ in SELECT clause at line 1, char 8; operator is intended for WHERE clause`},
-		// See issues https://github.com/influxdb/influxdb/issues/1647
-		// and https://github.com/influxdb/influxdb/issues/4404
-		//{s: `DELETE`, err: `found EOF, expected FROM at line 1, char 8`},
-		//{s: `DELETE FROM`, err: `found EOF, expected identifier at line 1, char 13`},
-		//{s: `DELETE FROM myseries WHERE`, err: `found EOF, expected identifier, string, number, bool at line 1, char 28`},
-		{s: `DELETE`, err: `DELETE FROM is currently not supported. Use DROP SERIES or DROP MEASUREMENT instead`},
-		{s: `DELETE FROM`, err: `DELETE FROM is currently not supported. Use DROP SERIES or DROP MEASUREMENT instead`},
-		{s: `DELETE FROM myseries WHERE`, err: `DELETE FROM is currently not supported. Use DROP SERIES or DROP MEASUREMENT instead`},
+		{s: `DELETE`, err: `found EOF, expected FROM at line 1, char 8`},
+		{s: `DELETE FROM`, err: `found EOF, expected identifier at line 1, char 13`},
+		{s: `DELETE FROM myseries WHERE`, err: `found EOF, expected identifier, string, number, bool at line 1, char 28`},
 		{s: `DROP MEASUREMENT`, err: `found EOF, expected identifier at line 1, char 18`},
 		{s: `DROP SERIES`, err: `found EOF, expected FROM, WHERE at line 1, char 13`},
 		{s: `DROP SERIES FROM`, err: `found EOF, expected identifier at line 1, char 18`},
@@ -1696,6 +1963,10 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `DROP SERVER`, err: `found EOF, expected number at line 1, char 13`},
 		{s: `DROP SERVER abc`, err: `found abc, expected number at line 1, char 13`},
 		{s: `DROP SERVER 1 1`, err: `found 1, expected FORCE at line 1, char 15`},
+		{s: `KILL`, err: `found EOF, expected QUERY at line 1, char 6`},
+		{s: `KILL QUERY`, err: `found EOF, expected number at line 1, char 12`},
+		{s: `KILL QUERY 4 ON`, err: `found EOF, expected number at line 1, char 17`},
+		{s: `KILL QUERY 4 ON abc`, err: `found abc, expected number at line 1, char 17`},
 		{s: `SHOW CONTINUOUS`, err: `found EOF, expected QUERIES at line 1, char 17`},
 		{s: `SHOW RETENTION`, err: `found EOF, expected POLICIES at line 1, char 16`},
 		{s: `SHOW RETENTION ON`, err: `found ON, expected POLICIES at line 1, char 16`},
@@ -1703,7 +1974,7 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `SHOW RETENTION POLICIES mydb`, err: `found mydb, expected ON at line 1, char 25`},
 		{s: `SHOW RETENTION POLICIES ON`, err: `found EOF, expected identifier at line 1, char 28`},
 		{s: `SHOW SHARD`, err: `found EOF, expected GROUPS at line 1, char 12`},
-		{s: `SHOW FOO`, err: `found FOO, expected CONTINUOUS, DATABASES, DIAGNOSTICS, FIELD, GRANTS, MEASUREMENTS, RETENTION, SERIES, SERVERS, SHARD, SHARDS, STATS, SUBSCRIPTIONS, TAG, USERS at line 1, char 6`},
+		{s: `SHOW FOO`, err: `found FOO, expected CONTINUOUS, DATABASES, DIAGNOSTICS, FIELD, GRANTS, MEASUREMENTS, QUERIES, RETENTION, SERIES, SERVERS, SHARD, SHARDS, STATS, SUBSCRIPTIONS, TAG, USERS at line 1, char 6`},
 		{s: `SHOW STATS FOR`, err: `found EOF, expected string at line 1, char 16`},
 		{s: `SHOW DIAGNOSTICS FOR`, err: `found EOF, expected string at line 1, char 22`},
 		{s: `SHOW GRANTS`, err: `found EOF, expected FOR at line 1, char 13`},
@@ -1770,6 +2041,9 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `GRANT WRITE ON testdb`, err: `found EOF, expected TO at line 1, char 23`},
 		{s: `GRANT WRITE ON testdb TO`, err: `found EOF, expected identifier at line 1, char 26`},
 		{s: `GRANT WRITE TO`, err: `found TO, expected ON at line 1, char 13`},
+		{s: `GRANT READ ON testdb./cpu/ TO jdoe`, err: `expected retention policy before regex in testdb at line 1, char 21`},
+		{s: `GRANT READ ON testdb.rp0.cpu.mem TO jdoe`, err: `too many segments in "testdb"."rp0"."cpu".mem at line 1, char 1`},
+		{s: `GRANT READ ON testdb.rp0 /cpu/ TO jdoe`, err: `found /, expected TO at line 1, char 26`},
 		{s: `GRANT ALL`, err: `found EOF, expected ON, TO at line 1, char 11`},
 		{s: `GRANT ALL PRIVILEGES`, err: `found EOF, expected ON, TO at line 1, char 22`},
 		{s: `GRANT ALL FROM`, err: `found FROM, expected ON, TO at line 1, char 11`},
@@ -1831,11 +2105,19 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION 0`, err: `invalid value 0: must be 1 <= n <= 2147483647 at line 1, char 67`},
 		{s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION bad`, err: `found bad, expected number at line 1, char 67`},
 		{s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION 1 foo`, err: `found foo, expected DEFAULT at line 1, char 69`},
-		{s: `ALTER`, err: `found EOF, expected RETENTION at line 1, char 7`},
+		{s: `ALTER`, err: `found EOF, expected RETENTION, USER at line 1, char 7`},
 		{s: `ALTER RETENTION`, err: `found EOF, expected POLICY at line 1, char 17`},
 		{s: `ALTER RETENTION POLICY`, err: `found EOF, expected identifier at line 1, char 24`},
 		{s: `ALTER RETENTION POLICY policy1`, err: `found EOF, expected ON at line 1, char 32`}, {s: `ALTER RETENTION POLICY policy1 ON`, err: `found EOF, expected identifier at line 1, char 35`},
 		{s: `ALTER RETENTION POLICY policy1 ON testdb`, err: `found EOF, expected DURATION, RETENTION, DEFAULT at line 1, char 42`},
+		{s: `ALTER USER`, err: `found EOF, expected identifier at line 1, char 12`},
+		{s: `ALTER USER jdoe`, err: `found EOF, expected SET at line 1, char 17`},
+		{s: `ALTER USER jdoe SET`, err: `found EOF, expected WRITE LIMIT, QUERY LIMIT, query_timeout, max_select_series, max_select_point, max_select_buckets, write_limit, write_bytes_limit, query_limit at line 1, char 21`},
+		{s: `ALTER USER jdoe SET max_points = 10`, err: `found max_points, expected WRITE LIMIT, QUERY LIMIT, query_timeout, max_select_series, max_select_point, max_select_buckets, write_limit, write_bytes_limit, query_limit at line 1, char 21`},
+		{s: `ALTER USER jdoe SET max_select_series 10`, err: `found 10, expected = at line 1, char 39`},
+		{s: `ALTER USER jdoe SET WRITE 10`, err: `found 10, expected LIMIT at line 1, char 27`},
+		{s: `ALTER USER jdoe SET max_select_series = 1s`, err: `found 1s, expected number at line 1, char 41`},
+		{s: `ALTER USER jdoe SET query_timeout = 10`, err: `found 10, expected duration at line 1, char 37`},
 		{s: `SET`, err: `found EOF, expected PASSWORD at line 1, char 5`},
 		{s: `SET PASSWORD`, err: `found EOF, expected FOR at line 1, char 14`},
 		{s: `SET PASSWORD something`, err: `found something, expected FOR at line 1, char 14`},
@@ -1855,6 +2137,11 @@ func TestParser_ParseStatement(t *testing.T) {
 		// We are memoizing a field so for testing we need to...
 		if s, ok := tt.stmt.(*influxql.SelectStatement); ok {
 			s.GroupByInterval()
+			for _, src := range s.Sources {
+				if sq, ok := src.(*influxql.SubQuery); ok {
+					sq.Statement.GroupByInterval()
+				}
+			}
 		} else if st, ok := stmt.(*influxql.CreateContinuousQueryStatement); ok { // if it's a CQ, there is a non-exported field that gets memoized during parsing that needs to be set
 			if st != nil && st.Source != nil {
 				tt.stmt.(*influxql.CreateContinuousQueryStatement).Source.GroupByInterval()
@@ -2270,6 +2557,9 @@ func newAlterRetentionPolicyStatement(name string, DB string, d time.Duration, r
 	return stmt
 }
 
+// int64ptr returns a pointer to v.
+func int64ptr(v int64) *int64 { return &v }
+
 // mustMarshalJSON encodes a value to JSON.
 func mustMarshalJSON(v interface{}) []byte {
 	b, err := json.Marshal(v)
@@ -2283,6 +2573,12 @@ func mustParseDuration(s string) time.Duration {
 	return d
 }
 
+func mustLoadLocation(s string) *time.Location {
+	loc, err := time.LoadLocation(s)
+	panicIfErr(err)
+	return loc
+}
+
 func panicIfErr(err error) {
 	if err != nil {
 		panic(err)
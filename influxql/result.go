@@ -72,6 +72,13 @@ func GetProcessor(expr Expr, startIndex int) (Processor, int) {
 	case *VarRef:
 		return newEchoProcessor(startIndex), startIndex + 1
 	case *Call:
+		if fn, ok := MathFuncs[expr.Name]; ok {
+			args := make([]Processor, len(expr.Args))
+			for i, arg := range expr.Args {
+				args[i], startIndex = GetProcessor(arg, startIndex)
+			}
+			return newMathFuncProcessor(fn, args), startIndex
+		}
 		return newEchoProcessor(startIndex), startIndex + 1
 	case *BinaryExpr:
 		return getBinaryProcessor(expr, startIndex)
@@ -94,6 +94,7 @@ const (
 	INTO
 	KEY
 	KEYS
+	KILL
 	LIMIT
 	MEASUREMENT
 	MEASUREMENTS
@@ -213,6 +214,7 @@ var tokens = [...]string{
 	INTO:          "INTO",
 	KEY:           "KEY",
 	KEYS:          "KEYS",
+	KILL:          "KILL",
 	LIMIT:         "LIMIT",
 	MEASUREMENT:   "MEASUREMENT",
 	MEASUREMENTS:  "MEASUREMENTS",
@@ -43,3 +43,29 @@ func NewStatistics(key, name string, tags map[string]string) *expvar.Map {
 
 	return statMap
 }
+
+// gauges holds the keys of the values declared as gauges, by statistic name.
+var gauges = make(map[string]map[string]struct{})
+
+// DeclareGauges declares the values of the statistics with the given name
+// which can go down, such as the number of active connections. All other
+// values are counters when statistics are exported as metrics.
+func DeclareGauges(name string, keys ...string) {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	if gauges[name] == nil {
+		gauges[name] = make(map[string]struct{})
+	}
+	for _, k := range keys {
+		gauges[name][k] = struct{}{}
+	}
+}
+
+// IsGauge returns true if the value of the named statistics was declared as a gauge.
+func IsGauge(name, key string) bool {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	_, ok := gauges[name][key]
+	return ok
+}
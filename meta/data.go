@@ -1,6 +1,7 @@
 package meta
 
 import (
+	"regexp"
 	"sort"
 	"time"
 
@@ -601,6 +602,87 @@ func (data *Data) SetAdminPrivilege(name string, admin bool) error {
 	return nil
 }
 
+// SetUserQueryLimits overrides the query and rate limits of a user. Limits
+// which are nil are reset to the limits configured for the node.
+func (data *Data) SetUserQueryLimits(name string, limits map[string]*int64) error {
+	ui := data.User(name)
+	if ui == nil {
+		return ErrUserNotFound
+	}
+
+	for k, v := range limits {
+		if isRateLimit(k) {
+			ui.RateLimits = setLimit(ui.RateLimits, k, v)
+		} else {
+			ui.QueryLimits = setLimit(ui.QueryLimits, k, v)
+		}
+	}
+
+	return nil
+}
+
+// setLimit sets the limit of name in m, or removes it if v is nil, and
+// returns m. The map is created if it's nil.
+func setLimit(m map[string]int64, name string, v *int64) map[string]int64 {
+	if v == nil {
+		delete(m, name)
+		return m
+	}
+
+	if m == nil {
+		m = make(map[string]int64)
+	}
+	m[name] = *v
+	return m
+}
+
+// isRateLimit returns true if name is the name of a rate limit.
+func isRateLimit(name string) bool {
+	switch name {
+	case influxql.WriteLimit, influxql.WriteBytesLimit, influxql.QueryLimit:
+		return true
+	}
+	return false
+}
+
+// SetMeasurementPrivilege sets the privilege of a user on a retention policy
+// or on measurements. Setting no privileges removes it.
+func (data *Data) SetMeasurementPrivilege(name string, p MeasurementPrivilege) error {
+	ui := data.User(name)
+	if ui == nil {
+		return ErrUserNotFound
+	}
+
+	// Remove the existing privilege on the same target.
+	other := ui.MeasurementPrivileges[:0]
+	for _, mp := range ui.MeasurementPrivileges {
+		if !mp.SameTarget(&p) {
+			other = append(other, mp)
+		}
+	}
+	ui.MeasurementPrivileges = other
+
+	if p.Privilege == influxql.NoPrivileges {
+		return nil
+	}
+
+	ui.MeasurementPrivileges = append(ui.MeasurementPrivileges, p)
+	sort.Sort(MeasurementPrivileges(ui.MeasurementPrivileges))
+
+	return nil
+}
+
+// UserMeasurementPrivileges gets the retention policy and measurement
+// privileges for a user.
+func (data *Data) UserMeasurementPrivileges(name string) ([]MeasurementPrivilege, error) {
+	ui := data.User(name)
+	if ui == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return ui.MeasurementPrivileges, nil
+}
+
 // UserPrivileges gets the privileges for a user.
 func (data *Data) UserPrivileges(name string) (map[string]influxql.Privilege, error) {
 	ui := data.User(name)
@@ -1222,6 +1304,16 @@ type UserInfo struct {
 	Hash       string
 	Admin      bool
 	Privileges map[string]influxql.Privilege
+
+	// Overrides of the node's query limits, by name. Query timeouts are in
+	// nanoseconds.
+	QueryLimits map[string]int64
+
+	// Overrides of the node's write and query rate limits, by name.
+	RateLimits map[string]int64
+
+	// Privileges on retention policies and measurements, sorted by target.
+	MeasurementPrivileges []MeasurementPrivilege
 }
 
 // Authorize returns true if the user is authorized and false if not.
@@ -1233,6 +1325,23 @@ func (ui *UserInfo) Authorize(privilege influxql.Privilege, database string) boo
 	return ok && (p == privilege || p == influxql.AllPrivileges)
 }
 
+// AuthorizeMeasurement returns true if the user is authorized to access a
+// measurement of a retention policy, either by a privilege on its database or
+// by a privilege on the measurement. An empty measurement name requires a
+// privilege on all measurements of the retention policy.
+func (ui *UserInfo) AuthorizeMeasurement(privilege influxql.Privilege, database, retentionPolicy, measurement string) bool {
+	if ui.Authorize(privilege, database) {
+		return true
+	}
+
+	for _, p := range ui.MeasurementPrivileges {
+		if (p.Privilege == privilege || p.Privilege == influxql.AllPrivileges) && p.Matches(database, retentionPolicy, measurement) {
+			return true
+		}
+	}
+	return false
+}
+
 // clone returns a deep copy of si.
 func (ui UserInfo) clone() UserInfo {
 	other := ui
@@ -1244,6 +1353,25 @@ func (ui UserInfo) clone() UserInfo {
 		}
 	}
 
+	if ui.QueryLimits != nil {
+		other.QueryLimits = make(map[string]int64)
+		for k, v := range ui.QueryLimits {
+			other.QueryLimits[k] = v
+		}
+	}
+
+	if ui.RateLimits != nil {
+		other.RateLimits = make(map[string]int64)
+		for k, v := range ui.RateLimits {
+			other.RateLimits[k] = v
+		}
+	}
+
+	if ui.MeasurementPrivileges != nil {
+		other.MeasurementPrivileges = make([]MeasurementPrivilege, len(ui.MeasurementPrivileges))
+		copy(other.MeasurementPrivileges, ui.MeasurementPrivileges)
+	}
+
 	return other
 }
 
@@ -1262,6 +1390,24 @@ func (ui UserInfo) marshal() *internal.UserInfo {
 		})
 	}
 
+	for name, value := range ui.QueryLimits {
+		pb.QueryLimits = append(pb.QueryLimits, &internal.UserQueryLimit{
+			Name:  proto.String(name),
+			Value: proto.Int64(value),
+		})
+	}
+
+	for name, value := range ui.RateLimits {
+		pb.RateLimits = append(pb.RateLimits, &internal.UserQueryLimit{
+			Name:  proto.String(name),
+			Value: proto.Int64(value),
+		})
+	}
+
+	for _, p := range ui.MeasurementPrivileges {
+		pb.MeasurementPrivileges = append(pb.MeasurementPrivileges, p.marshal())
+	}
+
 	return pb
 }
 
@@ -1275,6 +1421,133 @@ func (ui *UserInfo) unmarshal(pb *internal.UserInfo) {
 	for _, p := range pb.GetPrivileges() {
 		ui.Privileges[p.GetDatabase()] = influxql.Privilege(p.GetPrivilege())
 	}
+
+	if len(pb.GetQueryLimits()) > 0 {
+		ui.QueryLimits = make(map[string]int64)
+		for _, l := range pb.GetQueryLimits() {
+			ui.QueryLimits[l.GetName()] = l.GetValue()
+		}
+	}
+
+	if len(pb.GetRateLimits()) > 0 {
+		ui.RateLimits = make(map[string]int64)
+		for _, l := range pb.GetRateLimits() {
+			ui.RateLimits[l.GetName()] = l.GetValue()
+		}
+	}
+
+	ui.MeasurementPrivileges = nil
+	for _, x := range pb.GetMeasurementPrivileges() {
+		var p MeasurementPrivilege
+		if err := p.unmarshal(x); err != nil {
+			continue
+		}
+		ui.MeasurementPrivileges = append(ui.MeasurementPrivileges, p)
+	}
+}
+
+// MeasurementPrivilege represents a privilege of a user on a retention policy,
+// on a measurement, or on the measurements matching a regex.
+type MeasurementPrivilege struct {
+	Database string
+
+	// Retention policy the privilege is on. Empty means all retention
+	// policies of the database.
+	RetentionPolicy string
+
+	// Measurement, or measurements matching Regex, the privilege is on. Both
+	// empty means all measurements of the retention policy.
+	Measurement string
+	Regex       *regexp.Regexp
+
+	Privilege influxql.Privilege
+}
+
+// Matches returns true if the privilege is on a measurement of a retention
+// policy. An empty measurement name only matches privileges on all
+// measurements.
+func (p *MeasurementPrivilege) Matches(database, retentionPolicy, measurement string) bool {
+	if p.Database != database {
+		return false
+	} else if p.RetentionPolicy != "" && p.RetentionPolicy != retentionPolicy {
+		return false
+	}
+
+	switch {
+	case p.Regex != nil:
+		return measurement != "" && p.Regex.MatchString(measurement)
+	case p.Measurement != "":
+		return p.Measurement == measurement
+	default:
+		return true
+	}
+}
+
+// SameTarget returns true if both privileges are on the same database,
+// retention policy and measurements.
+func (p *MeasurementPrivilege) SameTarget(other *MeasurementPrivilege) bool {
+	return p.Database == other.Database &&
+		p.RetentionPolicy == other.RetentionPolicy &&
+		p.Measurement == other.Measurement &&
+		p.regexString() == other.regexString()
+}
+
+// MeasurementPrivileges is a collection of MeasurementPrivilege sorted by target.
+type MeasurementPrivileges []MeasurementPrivilege
+
+func (a MeasurementPrivileges) Len() int           { return len(a) }
+func (a MeasurementPrivileges) Less(i, j int) bool { return a[i].target() < a[j].target() }
+func (a MeasurementPrivileges) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// target returns a string used to sort privileges by target.
+func (p *MeasurementPrivilege) target() string {
+	return p.Database + "\x00" + p.RetentionPolicy + "\x00" + p.Measurement + "\x00" + p.regexString()
+}
+
+// regexString returns the source text of the regex or an empty string.
+func (p *MeasurementPrivilege) regexString() string {
+	if p.Regex == nil {
+		return ""
+	}
+	return p.Regex.String()
+}
+
+// marshal serializes to a protobuf representation.
+func (p *MeasurementPrivilege) marshal() *internal.MeasurementPrivilege {
+	pb := &internal.MeasurementPrivilege{
+		Database:  proto.String(p.Database),
+		Privilege: proto.Int32(int32(p.Privilege)),
+	}
+
+	if p.RetentionPolicy != "" {
+		pb.RetentionPolicy = proto.String(p.RetentionPolicy)
+	}
+	if p.Measurement != "" {
+		pb.Measurement = proto.String(p.Measurement)
+	}
+	if p.Regex != nil {
+		pb.Regex = proto.String(p.Regex.String())
+	}
+
+	return pb
+}
+
+// unmarshal deserializes from a protobuf representation.
+func (p *MeasurementPrivilege) unmarshal(pb *internal.MeasurementPrivilege) error {
+	p.Database = pb.GetDatabase()
+	p.RetentionPolicy = pb.GetRetentionPolicy()
+	p.Measurement = pb.GetMeasurement()
+	p.Privilege = influxql.Privilege(pb.GetPrivilege())
+
+	p.Regex = nil
+	if pb.Regex != nil {
+		re, err := regexp.Compile(pb.GetRegex())
+		if err != nil {
+			return err
+		}
+		p.Regex = re
+	}
+	return nil
 }
 
 // MarshalTime converts t to nanoseconds since epoch. A zero time returns 0.
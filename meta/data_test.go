@@ -5,6 +5,7 @@ package meta_test
 import (
 	"fmt"
 	"reflect"
+	"regexp"
 	"testing"
 	"time"
 
@@ -646,6 +647,118 @@ func TestData_UpdateUser_ErrUserNotFound(t *testing.T) {
 	}
 }
 
+// Ensure the query limits of a user can be overridden and reset.
+func TestData_SetUserQueryLimits(t *testing.T) {
+	var data meta.Data
+	if err := data.CreateUser("susy", "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	n, d := int64(1000), int64(time.Minute)
+	if err := data.SetUserQueryLimits("susy", map[string]*int64{
+		influxql.MaxSelectSeriesLimit: &n,
+		influxql.QueryTimeoutLimit:    &d,
+	}); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(data.User("susy").QueryLimits, map[string]int64{
+		influxql.MaxSelectSeriesLimit: 1000,
+		influxql.QueryTimeoutLimit:    int64(time.Minute),
+	}) {
+		t.Fatalf("unexpected limits: %#v", data.User("susy").QueryLimits)
+	}
+
+	// Reset one of the limits.
+	if err := data.SetUserQueryLimits("susy", map[string]*int64{influxql.QueryTimeoutLimit: nil}); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(data.User("susy").QueryLimits, map[string]int64{influxql.MaxSelectSeriesLimit: 1000}) {
+		t.Fatalf("unexpected limits: %#v", data.User("susy").QueryLimits)
+	}
+
+	// Rate limits are kept apart from the query limits.
+	w := int64(50)
+	if err := data.SetUserQueryLimits("susy", map[string]*int64{influxql.WriteLimit: &w}); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(data.User("susy").RateLimits, map[string]int64{influxql.WriteLimit: 50}) {
+		t.Fatalf("unexpected rate limits: %#v", data.User("susy").RateLimits)
+	} else if _, ok := data.User("susy").QueryLimits[influxql.WriteLimit]; ok {
+		t.Fatalf("unexpected limits: %#v", data.User("susy").QueryLimits)
+	}
+
+	if err := data.SetUserQueryLimits("bob", nil); err != meta.ErrUserNotFound {
+		t.Fatal(err)
+	}
+}
+
+// Ensure a retention policy or measurement privilege can be set on a user.
+func TestData_SetMeasurementPrivilege(t *testing.T) {
+	var data meta.Data
+	if err := data.CreateUser("susy", "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []meta.MeasurementPrivilege{
+		{Database: "db0", RetentionPolicy: "rp0", Measurement: "mem", Privilege: influxql.WritePrivilege},
+		{Database: "db0", Measurement: "cpu", Privilege: influxql.ReadPrivilege},
+		{Database: "db0", Measurement: "cpu", Privilege: influxql.AllPrivileges},
+		{Database: "db0", RetentionPolicy: "rp0", Regex: regexp.MustCompile(`^disk`), Privilege: influxql.ReadPrivilege},
+		{Database: "db0", RetentionPolicy: "rp0", Measurement: "mem", Privilege: influxql.NoPrivileges},
+	} {
+		if err := data.SetMeasurementPrivilege("susy", p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Privileges on the same target are overwritten and removed by no privileges.
+	if a, err := data.UserMeasurementPrivileges("susy"); err != nil {
+		t.Fatal(err)
+	} else if len(a) != 2 {
+		t.Fatalf("unexpected privileges: %#v", a)
+	} else if a[0].Measurement != "cpu" || a[0].Privilege != influxql.AllPrivileges {
+		t.Fatalf("unexpected privilege(0): %#v", a[0])
+	} else if a[1].Regex.String() != "^disk" || a[1].Privilege != influxql.ReadPrivilege {
+		t.Fatalf("unexpected privilege(1): %#v", a[1])
+	}
+
+	if err := data.SetMeasurementPrivilege("bob", meta.MeasurementPrivilege{Database: "db0"}); err != meta.ErrUserNotFound {
+		t.Fatal(err)
+	}
+}
+
+// Ensure a user is authorized by database, retention policy and measurement privileges.
+func TestUserInfo_AuthorizeMeasurement(t *testing.T) {
+	ui := &meta.UserInfo{
+		Name:       "susy",
+		Privileges: map[string]influxql.Privilege{"db0": influxql.ReadPrivilege},
+		MeasurementPrivileges: []meta.MeasurementPrivilege{
+			{Database: "db1", RetentionPolicy: "rp0", Privilege: influxql.ReadPrivilege},
+			{Database: "db1", Measurement: "cpu", Privilege: influxql.AllPrivileges},
+			{Database: "db1", RetentionPolicy: "rp1", Regex: regexp.MustCompile(`^mem`), Privilege: influxql.WritePrivilege},
+		},
+	}
+
+	for i, tt := range []struct {
+		privilege  influxql.Privilege
+		db, rp, m  string
+		authorized bool
+	}{
+		{privilege: influxql.ReadPrivilege, db: "db0", rp: "rp0", m: "cpu", authorized: true},
+		{privilege: influxql.WritePrivilege, db: "db0", rp: "rp0", m: "cpu", authorized: false},
+		{privilege: influxql.ReadPrivilege, db: "db1", rp: "rp0", m: "disk", authorized: true},
+		{privilege: influxql.ReadPrivilege, db: "db1", rp: "rp0", m: "", authorized: true},
+		{privilege: influxql.WritePrivilege, db: "db1", rp: "rp0", m: "disk", authorized: false},
+		{privilege: influxql.WritePrivilege, db: "db1", rp: "rp2", m: "cpu", authorized: true},
+		{privilege: influxql.ReadPrivilege, db: "db1", rp: "rp2", m: "", authorized: false},
+		{privilege: influxql.WritePrivilege, db: "db1", rp: "rp1", m: "mem_free", authorized: true},
+		{privilege: influxql.WritePrivilege, db: "db1", rp: "rp0", m: "mem_free", authorized: false},
+		{privilege: influxql.WritePrivilege, db: "db1", rp: "rp1", m: "", authorized: false},
+		{privilege: influxql.ReadPrivilege, db: "db2", rp: "rp0", m: "cpu", authorized: false},
+	} {
+		if authorized := ui.AuthorizeMeasurement(tt.privilege, tt.db, tt.rp, tt.m); authorized != tt.authorized {
+			t.Errorf("%d. %s on %s.%s.%s: unexpected authorization: %v", i, tt.privilege, tt.db, tt.rp, tt.m, authorized)
+		}
+	}
+}
+
 // Ensure the data can be deeply copied.
 func TestData_Clone(t *testing.T) {
 	data := meta.Data{
@@ -695,6 +808,14 @@ func TestData_Clone(t *testing.T) {
 				Hash:       "ABC123",
 				Admin:      true,
 				Privileges: map[string]influxql.Privilege{"db0": influxql.AllPrivileges},
+				QueryLimits: map[string]int64{
+					influxql.MaxSelectSeriesLimit: 1000,
+					influxql.QueryTimeoutLimit:    int64(time.Minute),
+				},
+				RateLimits: map[string]int64{influxql.WriteLimit: 50},
+				MeasurementPrivileges: []meta.MeasurementPrivilege{
+					{Database: "db1", RetentionPolicy: "rp0", Measurement: "cpu", Privilege: influxql.ReadPrivilege},
+				},
 			},
 		},
 	}
@@ -766,6 +887,14 @@ func TestData_MarshalBinary(t *testing.T) {
 				Hash:       "ABC123",
 				Admin:      true,
 				Privileges: map[string]influxql.Privilege{"db0": influxql.AllPrivileges},
+				QueryLimits: map[string]int64{
+					influxql.MaxSelectSeriesLimit: 1000,
+					influxql.QueryTimeoutLimit:    int64(time.Minute),
+				},
+				RateLimits: map[string]int64{influxql.WriteLimit: 50},
+				MeasurementPrivileges: []meta.MeasurementPrivilege{
+					{Database: "db1", RetentionPolicy: "rp0", Measurement: "cpu", Privilege: influxql.ReadPrivilege},
+				},
 			},
 		},
 	}
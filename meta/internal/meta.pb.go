@@ -20,6 +20,8 @@ It has these top-level messages:
 	ContinuousQueryInfo
 	UserInfo
 	UserPrivilege
+	UserQueryLimit
+	MeasurementPrivilege
 	Command
 	CreateNodeCommand
 	DeleteNodeCommand
@@ -43,6 +45,8 @@ It has these top-level messages:
 	CreateSubscriptionCommand
 	DropSubscriptionCommand
 	RemovePeerCommand
+	SetUserQueryLimitsCommand
+	SetMeasurementPrivilegeCommand
 	Response
 	ResponseHeader
 	ErrorResponse
@@ -128,6 +132,8 @@ const (
 	Command_CreateSubscriptionCommand        Command_Type = 21
 	Command_DropSubscriptionCommand          Command_Type = 22
 	Command_RemovePeerCommand                Command_Type = 23
+	Command_SetUserQueryLimitsCommand        Command_Type = 24
+	Command_SetMeasurementPrivilegeCommand   Command_Type = 25
 )
 
 var Command_Type_name = map[int32]string{
@@ -153,6 +159,8 @@ var Command_Type_name = map[int32]string{
 	21: "CreateSubscriptionCommand",
 	22: "DropSubscriptionCommand",
 	23: "RemovePeerCommand",
+	24: "SetUserQueryLimitsCommand",
+	25: "SetMeasurementPrivilegeCommand",
 }
 var Command_Type_value = map[string]int32{
 	"CreateNodeCommand":                1,
@@ -177,6 +185,8 @@ var Command_Type_value = map[string]int32{
 	"CreateSubscriptionCommand":        21,
 	"DropSubscriptionCommand":          22,
 	"RemovePeerCommand":                23,
+	"SetUserQueryLimitsCommand":        24,
+	"SetMeasurementPrivilegeCommand":   25,
 }
 
 func (x Command_Type) Enum() *Command_Type {
@@ -549,11 +559,14 @@ func (m *ContinuousQueryInfo) GetQuery() string {
 }
 
 type UserInfo struct {
-	Name             *string          `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
-	Hash             *string          `protobuf:"bytes,2,req,name=Hash" json:"Hash,omitempty"`
-	Admin            *bool            `protobuf:"varint,3,req,name=Admin" json:"Admin,omitempty"`
-	Privileges       []*UserPrivilege `protobuf:"bytes,4,rep,name=Privileges" json:"Privileges,omitempty"`
-	XXX_unrecognized []byte           `json:"-"`
+	Name                  *string                 `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	Hash                  *string                 `protobuf:"bytes,2,req,name=Hash" json:"Hash,omitempty"`
+	Admin                 *bool                   `protobuf:"varint,3,req,name=Admin" json:"Admin,omitempty"`
+	Privileges            []*UserPrivilege        `protobuf:"bytes,4,rep,name=Privileges" json:"Privileges,omitempty"`
+	QueryLimits           []*UserQueryLimit       `protobuf:"bytes,5,rep,name=QueryLimits" json:"QueryLimits,omitempty"`
+	MeasurementPrivileges []*MeasurementPrivilege `protobuf:"bytes,6,rep,name=MeasurementPrivileges" json:"MeasurementPrivileges,omitempty"`
+	RateLimits            []*UserQueryLimit       `protobuf:"bytes,7,rep,name=RateLimits" json:"RateLimits,omitempty"`
+	XXX_unrecognized      []byte                  `json:"-"`
 }
 
 func (m *UserInfo) Reset()         { *m = UserInfo{} }
@@ -588,6 +601,27 @@ func (m *UserInfo) GetPrivileges() []*UserPrivilege {
 	return nil
 }
 
+func (m *UserInfo) GetQueryLimits() []*UserQueryLimit {
+	if m != nil {
+		return m.QueryLimits
+	}
+	return nil
+}
+
+func (m *UserInfo) GetMeasurementPrivileges() []*MeasurementPrivilege {
+	if m != nil {
+		return m.MeasurementPrivileges
+	}
+	return nil
+}
+
+func (m *UserInfo) GetRateLimits() []*UserQueryLimit {
+	if m != nil {
+		return m.RateLimits
+	}
+	return nil
+}
+
 type UserPrivilege struct {
 	Database         *string `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
 	Privilege        *int32  `protobuf:"varint,2,req,name=Privilege" json:"Privilege,omitempty"`
@@ -612,6 +646,78 @@ func (m *UserPrivilege) GetPrivilege() int32 {
 	return 0
 }
 
+type UserQueryLimit struct {
+	Name             *string `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	Value            *int64  `protobuf:"varint,2,opt,name=Value" json:"Value,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *UserQueryLimit) Reset()         { *m = UserQueryLimit{} }
+func (m *UserQueryLimit) String() string { return proto.CompactTextString(m) }
+func (*UserQueryLimit) ProtoMessage()    {}
+
+func (m *UserQueryLimit) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *UserQueryLimit) GetValue() int64 {
+	if m != nil && m.Value != nil {
+		return *m.Value
+	}
+	return 0
+}
+
+type MeasurementPrivilege struct {
+	Database         *string `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	RetentionPolicy  *string `protobuf:"bytes,2,opt,name=RetentionPolicy" json:"RetentionPolicy,omitempty"`
+	Measurement      *string `protobuf:"bytes,3,opt,name=Measurement" json:"Measurement,omitempty"`
+	Regex            *string `protobuf:"bytes,4,opt,name=Regex" json:"Regex,omitempty"`
+	Privilege        *int32  `protobuf:"varint,5,req,name=Privilege" json:"Privilege,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *MeasurementPrivilege) Reset()         { *m = MeasurementPrivilege{} }
+func (m *MeasurementPrivilege) String() string { return proto.CompactTextString(m) }
+func (*MeasurementPrivilege) ProtoMessage()    {}
+
+func (m *MeasurementPrivilege) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *MeasurementPrivilege) GetRetentionPolicy() string {
+	if m != nil && m.RetentionPolicy != nil {
+		return *m.RetentionPolicy
+	}
+	return ""
+}
+
+func (m *MeasurementPrivilege) GetMeasurement() string {
+	if m != nil && m.Measurement != nil {
+		return *m.Measurement
+	}
+	return ""
+}
+
+func (m *MeasurementPrivilege) GetRegex() string {
+	if m != nil && m.Regex != nil {
+		return *m.Regex
+	}
+	return ""
+}
+
+func (m *MeasurementPrivilege) GetPrivilege() int32 {
+	if m != nil && m.Privilege != nil {
+		return *m.Privilege
+	}
+	return 0
+}
+
 type Command struct {
 	Type             *Command_Type             `protobuf:"varint,1,req,name=type,enum=internal.Command_Type" json:"type,omitempty"`
 	XXX_extensions   map[int32]proto.Extension `json:"-"`
@@ -1411,6 +1517,70 @@ var E_RemovePeerCommand_Command = &proto.ExtensionDesc{
 	Tag:           "bytes,123,opt,name=command",
 }
 
+type SetUserQueryLimitsCommand struct {
+	Username         *string           `protobuf:"bytes,1,req,name=Username" json:"Username,omitempty"`
+	Limits           []*UserQueryLimit `protobuf:"bytes,2,rep,name=Limits" json:"Limits,omitempty"`
+	XXX_unrecognized []byte            `json:"-"`
+}
+
+func (m *SetUserQueryLimitsCommand) Reset()         { *m = SetUserQueryLimitsCommand{} }
+func (m *SetUserQueryLimitsCommand) String() string { return proto.CompactTextString(m) }
+func (*SetUserQueryLimitsCommand) ProtoMessage()    {}
+
+func (m *SetUserQueryLimitsCommand) GetUsername() string {
+	if m != nil && m.Username != nil {
+		return *m.Username
+	}
+	return ""
+}
+
+func (m *SetUserQueryLimitsCommand) GetLimits() []*UserQueryLimit {
+	if m != nil {
+		return m.Limits
+	}
+	return nil
+}
+
+var E_SetUserQueryLimitsCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetUserQueryLimitsCommand)(nil),
+	Field:         124,
+	Name:          "internal.SetUserQueryLimitsCommand.command",
+	Tag:           "bytes,124,opt,name=command",
+}
+
+type SetMeasurementPrivilegeCommand struct {
+	Username         *string               `protobuf:"bytes,1,req,name=Username" json:"Username,omitempty"`
+	Privilege        *MeasurementPrivilege `protobuf:"bytes,2,req,name=Privilege" json:"Privilege,omitempty"`
+	XXX_unrecognized []byte                `json:"-"`
+}
+
+func (m *SetMeasurementPrivilegeCommand) Reset()         { *m = SetMeasurementPrivilegeCommand{} }
+func (m *SetMeasurementPrivilegeCommand) String() string { return proto.CompactTextString(m) }
+func (*SetMeasurementPrivilegeCommand) ProtoMessage()    {}
+
+func (m *SetMeasurementPrivilegeCommand) GetUsername() string {
+	if m != nil && m.Username != nil {
+		return *m.Username
+	}
+	return ""
+}
+
+func (m *SetMeasurementPrivilegeCommand) GetPrivilege() *MeasurementPrivilege {
+	if m != nil {
+		return m.Privilege
+	}
+	return nil
+}
+
+var E_SetMeasurementPrivilegeCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetMeasurementPrivilegeCommand)(nil),
+	Field:         125,
+	Name:          "internal.SetMeasurementPrivilegeCommand.command",
+	Tag:           "bytes,125,opt,name=command",
+}
+
 type Response struct {
 	OK               *bool   `protobuf:"varint,1,req,name=OK" json:"OK,omitempty"`
 	Error            *string `protobuf:"bytes,2,opt,name=Error" json:"Error,omitempty"`
@@ -1686,4 +1856,6 @@ func init() {
 	proto.RegisterExtension(E_CreateSubscriptionCommand_Command)
 	proto.RegisterExtension(E_DropSubscriptionCommand_Command)
 	proto.RegisterExtension(E_RemovePeerCommand_Command)
+	proto.RegisterExtension(E_SetUserQueryLimitsCommand_Command)
+	proto.RegisterExtension(E_SetMeasurementPrivilegeCommand_Command)
 }
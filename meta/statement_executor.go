@@ -38,8 +38,11 @@ type StatementExecutor struct {
 		DropUser(name string) error
 		SetPrivilege(username, database string, p influxql.Privilege) error
 		SetAdminPrivilege(username string, admin bool) error
+		SetUserQueryLimits(username string, limits map[string]*int64) error
 		UserPrivileges(username string) (map[string]influxql.Privilege, error)
 		UserPrivilege(username, database string) (*influxql.Privilege, error)
+		SetMeasurementPrivilege(username string, p MeasurementPrivilege) error
+		UserMeasurementPrivileges(username string) ([]MeasurementPrivilege, error)
 
 		CreateContinuousQuery(database, name, query string) error
 		DropContinuousQuery(database, name string) error
@@ -66,6 +69,8 @@ func (e *StatementExecutor) ExecuteStatement(stmt influxql.Statement) *influxql.
 		return e.executeCreateUserStatement(stmt)
 	case *influxql.SetPasswordUserStatement:
 		return e.executeSetPasswordUserStatement(stmt)
+	case *influxql.AlterUserStatement:
+		return e.executeAlterUserStatement(stmt)
 	case *influxql.DropUserStatement:
 		return e.executeDropUserStatement(stmt)
 	case *influxql.ShowUsersStatement:
@@ -151,9 +156,21 @@ func (e *StatementExecutor) executeShowGrantsForUserStatement(q *influxql.ShowGr
 		return &influxql.Result{Err: err}
 	}
 
-	row := &models.Row{Columns: []string{"database", "privilege"}}
+	mpriv, err := e.Store.UserMeasurementPrivileges(q.Name)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+
+	row := &models.Row{Columns: []string{"database", "retention_policy", "measurement", "privilege"}}
 	for d, p := range priv {
-		row.Values = append(row.Values, []interface{}{d, p.String()})
+		row.Values = append(row.Values, []interface{}{d, "", "", p.String()})
+	}
+	for _, p := range mpriv {
+		measurement := p.Measurement
+		if p.Regex != nil {
+			measurement = (&influxql.RegexLiteral{Val: p.Regex}).String()
+		}
+		row.Values = append(row.Values, []interface{}{p.Database, p.RetentionPolicy, measurement, p.Privilege.String()})
 	}
 	return &influxql.Result{Series: []*models.Row{row}}
 }
@@ -200,6 +217,10 @@ func (e *StatementExecutor) executeSetPasswordUserStatement(q *influxql.SetPassw
 	return &influxql.Result{Err: e.Store.UpdateUser(q.Name, q.Password)}
 }
 
+func (e *StatementExecutor) executeAlterUserStatement(q *influxql.AlterUserStatement) *influxql.Result {
+	return &influxql.Result{Err: e.Store.SetUserQueryLimits(q.Name, q.Limits)}
+}
+
 func (e *StatementExecutor) executeDropUserStatement(q *influxql.DropUserStatement) *influxql.Result {
 	return &influxql.Result{Err: e.Store.DropUser(q.Name)}
 }
@@ -218,6 +239,11 @@ func (e *StatementExecutor) executeShowUsersStatement(q *influxql.ShowUsersState
 }
 
 func (e *StatementExecutor) executeGrantStatement(stmt *influxql.GrantStatement) *influxql.Result {
+	if stmt.RetentionPolicy != "" || stmt.Measurement != "" || stmt.Regex != nil {
+		p := newMeasurementPrivilege(stmt.On, stmt.RetentionPolicy, stmt.Measurement, stmt.Regex)
+		p.Privilege = stmt.Privilege
+		return &influxql.Result{Err: e.Store.SetMeasurementPrivilege(stmt.User, p)}
+	}
 	return &influxql.Result{Err: e.Store.SetPrivilege(stmt.User, stmt.On, stmt.Privilege)}
 }
 
@@ -226,6 +252,10 @@ func (e *StatementExecutor) executeGrantAdminStatement(stmt *influxql.GrantAdmin
 }
 
 func (e *StatementExecutor) executeRevokeStatement(stmt *influxql.RevokeStatement) *influxql.Result {
+	if stmt.RetentionPolicy != "" || stmt.Measurement != "" || stmt.Regex != nil {
+		return e.executeRevokeMeasurementStatement(stmt)
+	}
+
 	priv := influxql.NoPrivileges
 
 	// Revoking all privileges means there's no need to look at existing user privileges.
@@ -241,6 +271,43 @@ func (e *StatementExecutor) executeRevokeStatement(stmt *influxql.RevokeStatemen
 	return &influxql.Result{Err: e.Store.SetPrivilege(stmt.User, stmt.On, priv)}
 }
 
+// executeRevokeMeasurementStatement revokes a privilege on a retention policy
+// or on measurements.
+func (e *StatementExecutor) executeRevokeMeasurementStatement(stmt *influxql.RevokeStatement) *influxql.Result {
+	p := newMeasurementPrivilege(stmt.On, stmt.RetentionPolicy, stmt.Measurement, stmt.Regex)
+
+	// Revoking all privileges means there's no need to look at existing user privileges.
+	if stmt.Privilege != influxql.AllPrivileges {
+		a, err := e.Store.UserMeasurementPrivileges(stmt.User)
+		if err != nil {
+			return &influxql.Result{Err: err}
+		}
+		for _, other := range a {
+			if other.SameTarget(&p) {
+				// Bit clear (AND NOT) the user's privilege with the revoked privilege.
+				p.Privilege = other.Privilege &^ stmt.Privilege
+				break
+			}
+		}
+	}
+
+	return &influxql.Result{Err: e.Store.SetMeasurementPrivilege(stmt.User, p)}
+}
+
+// newMeasurementPrivilege returns a privilege targeting the measurements of
+// a GRANT or REVOKE statement, without any privilege set.
+func newMeasurementPrivilege(database, retentionPolicy, measurement string, re *influxql.RegexLiteral) MeasurementPrivilege {
+	p := MeasurementPrivilege{
+		Database:        database,
+		RetentionPolicy: retentionPolicy,
+		Measurement:     measurement,
+	}
+	if re != nil {
+		p.Regex = re.Val
+	}
+	return p
+}
+
 func (e *StatementExecutor) executeRevokeAdminStatement(stmt *influxql.RevokeAdminStatement) *influxql.Result {
 	return &influxql.Result{Err: e.Store.SetAdminPrivilege(stmt.User, false)}
 }
@@ -3,6 +3,7 @@ package meta_test
 import (
 	"errors"
 	"reflect"
+	"regexp"
 	"testing"
 	"time"
 
@@ -98,15 +99,52 @@ func TestStatementExecutor_ExecuteStatement_ShowGrantsFor(t *testing.T) {
 			"golja": influxql.WritePrivilege,
 		}, nil
 	}
+	e.Store.UserMeasurementPrivilegesFn = func(username string) ([]meta.MeasurementPrivilege, error) {
+		return nil, nil
+	}
 
 	if res := e.ExecuteStatement(influxql.MustParseStatement(`SHOW GRANTS FOR dejan`)); res.Err != nil {
 		t.Fatal(res.Err)
 	} else if !reflect.DeepEqual(res.Series, models.Rows{
 		{
-			Columns: []string{"database", "privilege"},
+			Columns: []string{"database", "retention_policy", "measurement", "privilege"},
 			Values: [][]interface{}{
-				{"dejan", "READ"},
-				{"golja", "WRITE"},
+				{"dejan", "", "", "READ"},
+				{"golja", "", "", "WRITE"},
+			},
+		},
+	}) {
+		t.Fatalf("unexpected rows: %s", spew.Sdump(res.Series))
+	}
+}
+
+// Ensure a SHOW GRANTS FOR statement lists retention policy and measurement privileges.
+func TestStatementExecutor_ExecuteStatement_ShowGrantsFor_Measurement(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.UserPrivilegesFn = func(username string) (map[string]influxql.Privilege, error) {
+		return map[string]influxql.Privilege{"db0": influxql.ReadPrivilege}, nil
+	}
+	e.Store.UserMeasurementPrivilegesFn = func(username string) ([]meta.MeasurementPrivilege, error) {
+		if username != "dejan" {
+			t.Fatalf("unexpected username: %s", username)
+		}
+		return []meta.MeasurementPrivilege{
+			{Database: "db1", RetentionPolicy: "rp0", Privilege: influxql.AllPrivileges},
+			{Database: "db1", Measurement: "cpu", Privilege: influxql.WritePrivilege},
+			{Database: "db1", RetentionPolicy: "rp0", Regex: regexp.MustCompile(`^mem`), Privilege: influxql.ReadPrivilege},
+		}, nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`SHOW GRANTS FOR dejan`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if !reflect.DeepEqual(res.Series, models.Rows{
+		{
+			Columns: []string{"database", "retention_policy", "measurement", "privilege"},
+			Values: [][]interface{}{
+				{"db0", "", "", "READ"},
+				{"db1", "rp0", "", "ALL PRIVILEGES"},
+				{"db1", "", "cpu", "WRITE"},
+				{"db1", "rp0", "/^mem/", "READ"},
 			},
 		},
 	}) {
@@ -351,6 +389,29 @@ func TestStatementExecutor_ExecuteStatement_Grant(t *testing.T) {
 	}
 }
 
+// Ensure a GRANT statement on measurements can be executed.
+func TestStatementExecutor_ExecuteStatement_Grant_Measurement(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.SetMeasurementPrivilegeFn = func(username string, p meta.MeasurementPrivilege) error {
+		if username != "susy" {
+			t.Fatalf("unexpected username: %s", username)
+		} else if p.Database != "foo" || p.RetentionPolicy != "" || p.Measurement != "" {
+			t.Fatalf("unexpected target: %#v", p)
+		} else if p.Regex == nil || p.Regex.String() != "cpu.*" {
+			t.Fatalf("unexpected regex: %v", p.Regex)
+		} else if p.Privilege != influxql.ReadPrivilege {
+			t.Fatalf("unexpected privilege: %s", p.Privilege)
+		}
+		return nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`GRANT READ ON foo../cpu.*/ TO susy`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if res.Series != nil {
+		t.Fatalf("unexpected rows: %#v", res.Series)
+	}
+}
+
 // Ensure a GRANT statement returns errors from the store.
 func TestStatementExecutor_ExecuteStatement_Grant_Err(t *testing.T) {
 	e := NewStatementExecutor()
@@ -394,6 +455,37 @@ func TestStatementExecutor_ExecuteStatement_GrantAdmin_Err(t *testing.T) {
 	}
 }
 
+// Ensure an ALTER USER statement can be executed.
+func TestStatementExecutor_ExecuteStatement_AlterUser(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.SetUserQueryLimitsFn = func(username string, limits map[string]*int64) error {
+		if username != "susy" {
+			t.Fatalf("unexpected username: %s", username)
+		} else if len(limits) != 2 || *limits[influxql.MaxSelectSeriesLimit] != 100 || limits[influxql.QueryTimeoutLimit] != nil {
+			t.Fatalf("unexpected limits: %#v", limits)
+		}
+		return nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`ALTER USER susy SET max_select_series = 100, query_timeout = DEFAULT`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if res.Series != nil {
+		t.Fatalf("unexpected rows: %#v", res.Series)
+	}
+}
+
+// Ensure an ALTER USER statement returns errors from the store.
+func TestStatementExecutor_ExecuteStatement_AlterUser_Err(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.SetUserQueryLimitsFn = func(username string, limits map[string]*int64) error {
+		return errors.New("marker")
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`ALTER USER susy SET max_select_point = 10`)); res.Err == nil || res.Err.Error() != "marker" {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+}
+
 // Ensure a REVOKE statement can be executed.
 func TestStatementExecutor_ExecuteStatement_Revoke(t *testing.T) {
 	e := NewStatementExecutor()
@@ -415,6 +507,33 @@ func TestStatementExecutor_ExecuteStatement_Revoke(t *testing.T) {
 	}
 }
 
+// Ensure a REVOKE statement on a measurement clears the revoked privilege.
+func TestStatementExecutor_ExecuteStatement_Revoke_Measurement(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.UserMeasurementPrivilegesFn = func(username string) ([]meta.MeasurementPrivilege, error) {
+		return []meta.MeasurementPrivilege{
+			{Database: "foo", Measurement: "cpu", Privilege: influxql.ReadPrivilege},
+			{Database: "foo", RetentionPolicy: "rp0", Measurement: "cpu", Privilege: influxql.AllPrivileges},
+		}, nil
+	}
+	e.Store.SetMeasurementPrivilegeFn = func(username string, p meta.MeasurementPrivilege) error {
+		if username != "susy" {
+			t.Fatalf("unexpected username: %s", username)
+		} else if p.Database != "foo" || p.RetentionPolicy != "rp0" || p.Measurement != "cpu" || p.Regex != nil {
+			t.Fatalf("unexpected target: %#v", p)
+		} else if p.Privilege != influxql.ReadPrivilege {
+			t.Fatalf("unexpected privilege: %s", p.Privilege)
+		}
+		return nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`REVOKE WRITE ON foo.rp0.cpu FROM susy`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if res.Series != nil {
+		t.Fatalf("unexpected rows: %#v", res.Series)
+	}
+}
+
 // Ensure a REVOKE statement returns errors from the store.
 func TestStatementExecutor_ExecuteStatement_Revoke_Err(t *testing.T) {
 	e := NewStatementExecutor()
@@ -1112,8 +1231,11 @@ type StatementExecutorStore struct {
 	DropUserFn                          func(name string) error
 	SetPrivilegeFn                      func(username, database string, p influxql.Privilege) error
 	SetAdminPrivilegeFn                 func(username string, admin bool) error
+	SetUserQueryLimitsFn                func(username string, limits map[string]*int64) error
 	UserPrivilegesFn                    func(username string) (map[string]influxql.Privilege, error)
 	UserPrivilegeFn                     func(username, database string) (*influxql.Privilege, error)
+	SetMeasurementPrivilegeFn           func(username string, p meta.MeasurementPrivilege) error
+	UserMeasurementPrivilegesFn         func(username string) ([]meta.MeasurementPrivilege, error)
 	ContinuousQueriesFn                 func() ([]meta.ContinuousQueryInfo, error)
 	CreateContinuousQueryFn             func(database, name, query string) error
 	DropContinuousQueryFn               func(database, name string) error
@@ -1208,6 +1330,10 @@ func (s *StatementExecutorStore) SetAdminPrivilege(username string, admin bool)
 	return s.SetAdminPrivilegeFn(username, admin)
 }
 
+func (s *StatementExecutorStore) SetUserQueryLimits(username string, limits map[string]*int64) error {
+	return s.SetUserQueryLimitsFn(username, limits)
+}
+
 func (s *StatementExecutorStore) UserPrivileges(username string) (map[string]influxql.Privilege, error) {
 	return s.UserPrivilegesFn(username)
 }
@@ -1216,6 +1342,14 @@ func (s *StatementExecutorStore) UserPrivilege(username, database string) (*infl
 	return s.UserPrivilegeFn(username, database)
 }
 
+func (s *StatementExecutorStore) SetMeasurementPrivilege(username string, p meta.MeasurementPrivilege) error {
+	return s.SetMeasurementPrivilegeFn(username, p)
+}
+
+func (s *StatementExecutorStore) UserMeasurementPrivileges(username string) ([]meta.MeasurementPrivilege, error) {
+	return s.UserMeasurementPrivilegesFn(username)
+}
+
 func (s *StatementExecutorStore) ContinuousQueries() ([]meta.ContinuousQueryInfo, error) {
 	return s.ContinuousQueriesFn()
 }
@@ -1571,6 +1571,41 @@ func (s *Store) SetAdminPrivilege(username string, admin bool) error {
 	)
 }
 
+// SetUserQueryLimits overrides the query limits of a user. Limits which are
+// nil are reset to the limits configured for the node.
+func (s *Store) SetUserQueryLimits(username string, limits map[string]*int64) error {
+	cmd := &internal.SetUserQueryLimitsCommand{Username: proto.String(username)}
+	for name, value := range limits {
+		l := &internal.UserQueryLimit{Name: proto.String(name)}
+		if value != nil {
+			l.Value = proto.Int64(*value)
+		}
+		cmd.Limits = append(cmd.Limits, l)
+	}
+	return s.exec(internal.Command_SetUserQueryLimitsCommand, internal.E_SetUserQueryLimitsCommand_Command, cmd)
+}
+
+// SetMeasurementPrivilege sets a privilege for a user on a retention policy or
+// on measurements.
+func (s *Store) SetMeasurementPrivilege(username string, p MeasurementPrivilege) error {
+	return s.exec(internal.Command_SetMeasurementPrivilegeCommand, internal.E_SetMeasurementPrivilegeCommand_Command,
+		&internal.SetMeasurementPrivilegeCommand{
+			Username:  proto.String(username),
+			Privilege: p.marshal(),
+		},
+	)
+}
+
+// UserMeasurementPrivileges returns the retention policy and measurement
+// privileges of a user.
+func (s *Store) UserMeasurementPrivileges(username string) (a []MeasurementPrivilege, err error) {
+	err = s.read(func(data *Data) error {
+		a, err = data.UserMeasurementPrivileges(username)
+		return err
+	})
+	return
+}
+
 // UserPrivileges returns a list of all databases.
 func (s *Store) UserPrivileges(username string) (p map[string]influxql.Privilege, err error) {
 	err = s.read(func(data *Data) error {
@@ -1866,6 +1901,10 @@ func (fsm *storeFSM) Apply(l *raft.Log) interface{} {
 			return fsm.applySetPrivilegeCommand(&cmd)
 		case internal.Command_SetAdminPrivilegeCommand:
 			return fsm.applySetAdminPrivilegeCommand(&cmd)
+		case internal.Command_SetUserQueryLimitsCommand:
+			return fsm.applySetUserQueryLimitsCommand(&cmd)
+		case internal.Command_SetMeasurementPrivilegeCommand:
+			return fsm.applySetMeasurementPrivilegeCommand(&cmd)
 		case internal.Command_SetDataCommand:
 			return fsm.applySetDataCommand(&cmd)
 		case internal.Command_UpdateNodeCommand:
@@ -2217,6 +2256,42 @@ func (fsm *storeFSM) applySetAdminPrivilegeCommand(cmd *internal.Command) interf
 	return nil
 }
 
+func (fsm *storeFSM) applySetUserQueryLimitsCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetUserQueryLimitsCommand_Command)
+	v := ext.(*internal.SetUserQueryLimitsCommand)
+
+	limits := make(map[string]*int64)
+	for _, l := range v.GetLimits() {
+		limits[l.GetName()] = l.Value
+	}
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetUserQueryLimits(v.GetUsername(), limits); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applySetMeasurementPrivilegeCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetMeasurementPrivilegeCommand_Command)
+	v := ext.(*internal.SetMeasurementPrivilegeCommand)
+
+	var p MeasurementPrivilege
+	if err := p.unmarshal(v.GetPrivilege()); err != nil {
+		return err
+	}
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetMeasurementPrivilege(v.GetUsername(), p); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
 func (fsm *storeFSM) applySetDataCommand(cmd *internal.Command) interface{} {
 	ext, _ := proto.GetExtension(cmd, internal.E_SetDataCommand_Command)
 	v := ext.(*internal.SetDataCommand)
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/influxdb/influxdb"
+	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
 	"github.com/influxdb/influxdb/tcp"
 	"github.com/influxdb/influxdb/toml"
@@ -810,6 +811,52 @@ func TestStore_UpdateUser(t *testing.T) {
 	}
 }
 
+// Ensure the store can override the query limits of a user.
+func TestStore_SetUserQueryLimits(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	if _, err := s.CreateUser("susy", "pass", false); err != nil {
+		t.Fatal(err)
+	}
+
+	n := int64(1000)
+	if err := s.SetUserQueryLimits("susy", map[string]*int64{influxql.MaxSelectPointLimit: &n}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify the limit was set.
+	if ui, err := s.User("susy"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(ui.QueryLimits, map[string]int64{influxql.MaxSelectPointLimit: 1000}) {
+		t.Fatalf("unexpected limits: %#v", ui.QueryLimits)
+	}
+}
+
+// Ensure the store can set a measurement privilege on a user.
+func TestStore_SetMeasurementPrivilege(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	if _, err := s.CreateUser("susy", "pass", false); err != nil {
+		t.Fatal(err)
+	}
+
+	p := meta.MeasurementPrivilege{Database: "db0", RetentionPolicy: "rp0", Measurement: "cpu", Privilege: influxql.ReadPrivilege}
+	if err := s.SetMeasurementPrivilege("susy", p); err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify the privilege was set.
+	if a, err := s.UserMeasurementPrivileges("susy"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(a, []meta.MeasurementPrivilege{p}) {
+		t.Fatalf("unexpected privileges: %#v", a)
+	}
+}
+
 // Ensure Authentication works.
 func TestStore_Authentication(t *testing.T) {
 	t.Parallel()
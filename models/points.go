@@ -1,10 +1,12 @@
 package models
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/binary"
 	"fmt"
 	"hash/fnv"
+	"io"
 	"math"
 	"sort"
 	"strconv"
@@ -188,6 +190,92 @@ func ParsePointsWithPrecision(buf []byte, defaultTime time.Time, precision strin
 
 }
 
+// LineError is returned by PointReader for a line which failed to parse.
+type LineError struct {
+	Line int    // line number, starting at 1
+	Text string // text of the line
+	Err  error
+}
+
+// Error returns the text of the error.
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: unable to parse '%s': %v", e.Line, e.Text, e.Err)
+}
+
+// PointReader parses points from a reader one line at a time, so the input
+// doesn't have to be held in memory at once.
+type PointReader struct {
+	r           *bufio.Reader
+	defaultTime time.Time
+	precision   string
+	line        int // lines read so far
+}
+
+// NewPointReader returns a reader of the points of r. Points without a
+// timestamp are given defaultTime and timestamps are read with precision.
+func NewPointReader(r io.Reader, defaultTime time.Time, precision string) *PointReader {
+	return &PointReader{
+		r:           bufio.NewReader(r),
+		defaultTime: defaultTime,
+		precision:   precision,
+	}
+}
+
+// ReadPoint returns the next point. A line which fails to parse returns a
+// *LineError and reading can continue with the following lines. io.EOF is
+// returned once all the lines are read.
+func (r *PointReader) ReadPoint() (Point, error) {
+	for {
+		block, err := r.readLine()
+		if len(block) == 0 {
+			return nil, err
+		} else if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		line := r.line + 1
+		r.line += bytes.Count(block, []byte{'\n'})
+
+		// strip the newline if one is present
+		if block[len(block)-1] == '\n' {
+			block = block[:len(block)-1]
+		}
+
+		// Skip blank lines and comments.
+		start := skipWhitespace(block, 0)
+		if start >= len(block) || block[start] == '#' {
+			continue
+		}
+
+		pt, err := parsePoint(block[start:], r.defaultTime, r.precision)
+		if err != nil {
+			return nil, &LineError{Line: line, Text: string(block[start:]), Err: err}
+		}
+		return pt, nil
+	}
+}
+
+// readLine returns the next line, which spans several lines of the input if
+// a quoted field value contains newlines. The line is a new slice as points
+// reference the buffer they are parsed from.
+func (r *PointReader) readLine() ([]byte, error) {
+	var buf []byte
+	for {
+		b, err := r.r.ReadSlice('\n')
+		buf = append(buf, b...)
+		if err == bufio.ErrBufferFull {
+			continue
+		} else if err != nil {
+			return buf, err
+		}
+
+		// The newline ends the line unless it is within quotes.
+		if i, _ := scanLine(buf, 0); i < len(buf) {
+			return buf, nil
+		}
+	}
+}
+
 func parsePoint(buf []byte, defaultTime time.Time, precision string) (Point, error) {
 	// scan the first block which is measurement[,tag1=value1,tag2=value=2...]
 	pos, key, err := scanKey(buf, 0)
@@ -3,6 +3,7 @@ package models_test
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"math"
 	"math/rand"
 	"reflect"
@@ -1683,3 +1684,43 @@ func TestParsePointsQuotesInFieldKey(t *testing.T) {
 		t.Fatalf("expected parsing failure but got no error")
 	}
 }
+
+func TestPointReader(t *testing.T) {
+	buf := "cpu value=1 1\n" +
+		"\n" +
+		"# comment\n" +
+		"cpu value=\n" +
+		"cpu,host=A str=\"a\nb\" 2\n" +
+		"cpu value=3 3"
+	r := models.NewPointReader(strings.NewReader(buf), time.Unix(0, 0), "n")
+
+	if pt, err := r.ReadPoint(); err != nil {
+		t.Fatal(err)
+	} else if pt.String() != "cpu value=1 1" {
+		t.Fatalf("unexpected point: %s", pt)
+	}
+
+	// Lines which fail to parse are reported with their line number.
+	if _, err := r.ReadPoint(); err == nil {
+		t.Fatal("expected error")
+	} else if err, ok := err.(*models.LineError); !ok || err.Line != 4 || err.Text != "cpu value=" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Quoted values may contain newlines.
+	if pt, err := r.ReadPoint(); err != nil {
+		t.Fatal(err)
+	} else if pt.Fields()["str"] != "a\nb" {
+		t.Fatalf("unexpected fields: %v", pt.Fields())
+	}
+
+	if pt, err := r.ReadPoint(); err != nil {
+		t.Fatal(err)
+	} else if pt.String() != "cpu value=3 3" {
+		t.Fatalf("unexpected point: %s", pt)
+	}
+
+	if _, err := r.ReadPoint(); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+package monitor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/influxdb/influxdb"
+)
+
+// metricPrefix is prepended to the names of all metrics.
+const metricPrefix = "influxdb_"
+
+// WritePrometheus writes the statistics and diagnostics in the Prometheus text
+// exposition format.
+//
+// Each value of a statistic is a metric named after the statistic and the
+// value, with the tags of the statistic as labels. Values are counters unless
+// they were declared as gauges with influxdb.DeclareGauges.
+//
+// Numeric columns of diagnostics are exported as gauges, labelled with the
+// string columns of their row. The string columns are also exported as the
+// labels of an info metric with a value of 1.
+func (m *Monitor) WritePrometheus(w io.Writer) error {
+	stats, err := m.Statistics(nil)
+	if err != nil {
+		return err
+	}
+	diags, err := m.Diagnostics()
+	if err != nil {
+		return err
+	}
+
+	families := make(metricFamilies)
+	for _, s := range stats {
+		labels := formatLabels(s.Tags)
+		for k, v := range s.Values {
+			name := metricName(s.Name, k)
+			if influxdb.IsGauge(s.Name, k) {
+				families.add(name, "gauge", labels, v)
+			} else {
+				families.add(name+"_total", "counter", labels, v)
+			}
+		}
+	}
+
+	for name, d := range diags {
+		for _, row := range d.Rows {
+			tags := make(map[string]string)
+			values := make(map[string]interface{})
+			for i, col := range d.Columns {
+				if i >= len(row) {
+					break
+				}
+				if v, ok := diagnosticValue(row[i]); ok {
+					values[col] = v
+				} else {
+					tags[col] = fmt.Sprint(row[i])
+				}
+			}
+
+			labels := formatLabels(tags)
+			if len(tags) > 0 {
+				families.add(metricName(name, "info"), "gauge", labels, 1)
+			}
+			for col, v := range values {
+				families.add(metricName(name, col), "gauge", labels, v)
+			}
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	families.write(bw)
+	return bw.Flush()
+}
+
+// metricFamilies holds the samples of each metric by name.
+type metricFamilies map[string]*metricFamily
+
+type metricFamily struct {
+	typ     string
+	samples map[string]interface{} // value by formatted labels
+}
+
+// add adds a sample to a metric. The first type given for a name is kept.
+func (a metricFamilies) add(name, typ, labels string, value interface{}) {
+	f := a[name]
+	if f == nil {
+		f = &metricFamily{typ: typ, samples: make(map[string]interface{})}
+		a[name] = f
+	}
+	f.samples[labels] = value
+}
+
+// write writes the metrics sorted by name and labels.
+func (a metricFamilies) write(w io.Writer) {
+	names := make([]string, 0, len(a))
+	for name := range a {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := a[name]
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, f.typ)
+
+		labels := make([]string, 0, len(f.samples))
+		for l := range f.samples {
+			labels = append(labels, l)
+		}
+		sort.Strings(labels)
+		for _, l := range labels {
+			fmt.Fprintf(w, "%s%s %s\n", name, l, formatValue(f.samples[l]))
+		}
+	}
+}
+
+// diagnosticValue returns the numeric value of a diagnostic. Times are
+// returned as Unix seconds and durations as seconds.
+func diagnosticValue(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case int, int32, int64, uint32, uint64, float64:
+		return v, true
+	case time.Time:
+		return float64(v.UnixNano()) / float64(time.Second), true
+	case time.Duration:
+		return v.Seconds(), true
+	case string:
+		// Durations are formatted as strings by some diagnostics.
+		if d, err := time.ParseDuration(v); err == nil {
+			return d.Seconds(), true
+		}
+	}
+	return nil, false
+}
+
+// metricName returns the metric name of the key of a statistic or diagnostic.
+func metricName(name, key string) string {
+	return metricPrefix + sanitizeName(name) + "_" + sanitizeName(key)
+}
+
+// sanitizeName converts a camel case name to snake case and replaces
+// characters which aren't allowed in metric and label names.
+func sanitizeName(s string) string {
+	var buf []rune
+	var prev rune
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r):
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) {
+				buf = append(buf, '_')
+			}
+			buf = append(buf, unicode.ToLower(r))
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			buf = append(buf, r)
+		default:
+			// Collapse runs of invalid characters into a single underscore.
+			if prev != '_' {
+				buf = append(buf, '_')
+			}
+			r = '_'
+		}
+		prev = r
+	}
+	return strings.Trim(string(buf), "_")
+}
+
+// labelValueEscaper escapes the characters of label values which must be escaped.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels returns the labels sorted by name in the exposition format.
+func formatLabels(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	labels := make([]string, len(keys))
+	for i, k := range keys {
+		labels[i] = sanitizeName(k) + `="` + labelValueEscaper.Replace(tags[k]) + `"`
+	}
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+// formatValue returns a sample value in the exposition format.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,71 @@
+package monitor
+
+import (
+	"bytes"
+	"expvar"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb"
+)
+
+// Ensure statistics and diagnostics are written in the Prometheus text format.
+func TestMonitor_WritePrometheus(t *testing.T) {
+	monitor := openMonitor(t)
+	defer monitor.Close()
+
+	statMap := influxdb.NewStatistics("prom_test:tcp", "promTest", map[string]string{"proto": "tcp", "bind": `:"8086"`})
+	statMap.Add("pointsRx", 10)
+	statMap.Add("connsActive", 2)
+	statMap.Set("diskBytes", expvar.Func(func() interface{} { return int64(512) }))
+	influxdb.DeclareGauges("promTest", "connsActive", "diskBytes")
+
+	monitor.RegisterDiagnosticsClient("promdiag", DiagsClientFunc(func() (*Diagnostic, error) {
+		d := NewDiagnostic([]string{"Node ID", "started", "uptime", "queueSize"})
+		d.AddRow([]interface{}{"node1", time.Unix(10, 0), "1m30s", int64(4)})
+		return d, nil
+	}))
+
+	var buf bytes.Buffer
+	if err := monitor.WritePrometheus(&buf); err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+
+	for _, exp := range []string{
+		"# TYPE influxdb_prom_test_points_rx_total counter\ninfluxdb_prom_test_points_rx_total{bind=\":\\\"8086\\\"\",proto=\"tcp\"} 10\n",
+		"# TYPE influxdb_prom_test_conns_active gauge\ninfluxdb_prom_test_conns_active{bind=\":\\\"8086\\\"\",proto=\"tcp\"} 2\n",
+		"# TYPE influxdb_prom_test_disk_bytes gauge\ninfluxdb_prom_test_disk_bytes{bind=\":\\\"8086\\\"\",proto=\"tcp\"} 512\n",
+		"# TYPE influxdb_promdiag_info gauge\ninfluxdb_promdiag_info{node_id=\"node1\"} 1\n",
+		"# TYPE influxdb_promdiag_started gauge\ninfluxdb_promdiag_started{node_id=\"node1\"} 10\n",
+		"# TYPE influxdb_promdiag_uptime gauge\ninfluxdb_promdiag_uptime{node_id=\"node1\"} 90\n",
+		"# TYPE influxdb_promdiag_queue_size gauge\ninfluxdb_promdiag_queue_size{node_id=\"node1\"} 4\n",
+		"# TYPE influxdb_runtime_heap_alloc gauge\n",
+		"# TYPE influxdb_runtime_num_gc_total counter\n",
+		"# TYPE influxdb_build_info gauge\n",
+	} {
+		if !strings.Contains(s, exp) {
+			t.Errorf("missing metric:\n%s\ngot:\n%s", exp, s)
+		}
+	}
+}
+
+// Ensure names are converted to valid metric names.
+func TestSanitizeName(t *testing.T) {
+	for _, tt := range []struct {
+		s   string
+		exp string
+	}{
+		{s: "pointsWrittenOK", exp: "points_written_ok"},
+		{s: "HeapInUse", exp: "heap_in_use"},
+		{s: "GOMAXPROCS", exp: "gomaxprocs"},
+		{s: "Build Time", exp: "build_time"},
+		{s: "graphite:tcp::2003", exp: "graphite_tcp_2003"},
+		{s: "hh_processor", exp: "hh_processor"},
+	} {
+		if got := sanitizeName(tt.s); got != tt.exp {
+			t.Errorf("%s: unexpected name: exp=%s got=%s", tt.s, tt.exp, got)
+		}
+	}
+}
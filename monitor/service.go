@@ -25,6 +25,12 @@ const (
 	MonitorRetentionPolicyDuration = 7 * 24 * time.Hour
 )
 
+func init() {
+	// Go memory statistics which can go down. The others are counters.
+	influxdb.DeclareGauges("runtime", "Alloc", "Sys", "HeapAlloc", "HeapSys", "HeapIdle",
+		"HeapInUse", "HeapReleased", "HeapObjects", "NumGoroutine")
+}
+
 // DiagsClient is the interface modules implement if they register diags with monitor.
 type DiagsClient interface {
 	Diagnostics() (*Diagnostic, error)
@@ -233,6 +239,14 @@ func (m *Monitor) Statistics(tags map[string]string) ([]*Statistic, error) {
 						if err != nil {
 							return
 						}
+					case expvar.Func:
+						// Values computed on demand must be numeric.
+						switch fv := v().(type) {
+						case int64, float64:
+							f = fv
+						default:
+							return
+						}
 					default:
 						return
 					}
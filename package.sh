@@ -74,6 +74,7 @@ BINS=(
     influx
     influx_stress
     influx_inspect
+    influx_tsm
     )
 
 ###########################################################################
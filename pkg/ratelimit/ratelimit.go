@@ -0,0 +1,158 @@
+// Package ratelimit provides token bucket rate limiters.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket which refills at a fixed rate per second. A bucket
+// holds at most one second of tokens. It is safe for concurrent use.
+type Bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+
+	now func() time.Time
+}
+
+// NewBucket returns a full bucket refilling at rate tokens per second. A rate
+// of zero or less doesn't limit anything.
+func NewBucket(rate int64) *Bucket {
+	b := &Bucket{rate: float64(rate), tokens: float64(rate), now: time.Now}
+	b.last = b.now()
+	return b
+}
+
+// Rate returns the number of tokens added per second.
+func (b *Bucket) Rate() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return int64(b.rate)
+}
+
+// SetRate changes the number of tokens added per second.
+func (b *Bucket) SetRate(rate int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.rate = float64(rate)
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+}
+
+// Delay returns how long to wait until n tokens can be taken. Taking more
+// tokens than the bucket holds only requires the bucket to be full.
+func (b *Bucket) Delay(n int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rate <= 0 {
+		return 0
+	}
+	b.refill()
+
+	need := float64(n)
+	if need > b.rate {
+		need = b.rate
+	}
+	if b.tokens >= need {
+		return 0
+	}
+	return time.Duration((need - b.tokens) / b.rate * float64(time.Second))
+}
+
+// Take takes n tokens from the bucket, even if it doesn't have enough of
+// them. The tokens missing are paid back by the next refills.
+func (b *Bucket) Take(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rate <= 0 {
+		return
+	}
+	b.refill()
+	b.tokens -= float64(n)
+}
+
+// Full returns true if the bucket holds all the tokens it can, so that it
+// doesn't differ from a new bucket.
+func (b *Bucket) Full() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	return b.tokens >= b.rate
+}
+
+// refill adds the tokens accumulated since the last refill.
+func (b *Bucket) refill() {
+	now := b.now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+}
+
+// pruneInterval is how often a group removes its buckets which are full.
+const pruneInterval = time.Minute
+
+// Group is a set of buckets by key, such as one bucket per user. Buckets which
+// are full are removed from time to time, so a group doesn't grow with every
+// key it has seen. The bucket of a key is only valid until the next call to
+// Bucket.
+type Group struct {
+	mu      sync.Mutex
+	buckets map[string]*Bucket
+	pruned  time.Time
+
+	now func() time.Time
+}
+
+// NewGroup returns a new, empty group.
+func NewGroup() *Group {
+	g := &Group{buckets: make(map[string]*Bucket), now: time.Now}
+	g.pruned = g.now()
+	return g
+}
+
+// Bucket returns the bucket of key refilling at rate tokens per second. The
+// bucket is created on first use and its rate updated if it changed.
+func (g *Group) Bucket(key string, rate int64) *Bucket {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if now := g.now(); now.Sub(g.pruned) >= pruneInterval {
+		g.prune()
+		g.pruned = now
+	}
+
+	b := g.buckets[key]
+	if b == nil {
+		b = NewBucket(rate)
+		b.now = g.now
+		b.last = b.now()
+		g.buckets[key] = b
+	} else if b.Rate() != rate {
+		b.SetRate(rate)
+	}
+	return b
+}
+
+// Len returns the number of buckets in the group.
+func (g *Group) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.buckets)
+}
+
+// prune removes the buckets which are full. They are created again when used.
+func (g *Group) prune() {
+	for key, b := range g.buckets {
+		if b.Full() {
+			delete(g.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewBucket(100)
+	b.now = func() time.Time { return now }
+	b.last = now
+
+	// A full bucket allows a second worth of tokens.
+	if d := b.Delay(100); d != 0 {
+		t.Fatalf("unexpected delay: %s", d)
+	}
+	b.Take(100)
+
+	// An empty bucket refills at its rate.
+	if d := b.Delay(50); d != 500*time.Millisecond {
+		t.Fatalf("unexpected delay: %s", d)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if d := b.Delay(50); d != 0 {
+		t.Fatalf("unexpected delay: %s", d)
+	}
+
+	// Taking more tokens than the bucket holds puts it in debt.
+	now = now.Add(time.Second)
+	if d := b.Delay(300); d != 0 {
+		t.Fatalf("unexpected delay: %s", d)
+	}
+	b.Take(300)
+	if d := b.Delay(100); d != 3*time.Second {
+		t.Fatalf("unexpected delay: %s", d)
+	}
+}
+
+func TestBucket_Unlimited(t *testing.T) {
+	b := NewBucket(0)
+	b.Take(1000)
+	if d := b.Delay(1000); d != 0 {
+		t.Fatalf("unexpected delay: %s", d)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	g := NewGroup()
+	b := g.Bucket("susy", 10)
+	if g.Bucket("susy", 10) != b {
+		t.Fatal("expected the same bucket")
+	} else if g.Bucket("bob", 10) == b {
+		t.Fatal("expected a different bucket")
+	}
+
+	// Rate changes are applied to the existing bucket.
+	if g.Bucket("susy", 20) != b {
+		t.Fatal("expected the same bucket")
+	} else if b.Rate() != 20 {
+		t.Fatalf("unexpected rate: %d", b.Rate())
+	}
+}
+
+func TestGroup_Prune(t *testing.T) {
+	now := time.Unix(0, 0)
+	g := NewGroup()
+	g.now = func() time.Time { return now }
+	g.pruned = now
+
+	g.Bucket("susy", 10).Take(10)
+	g.Bucket("bob", 10).Take(10)
+
+	// Buckets which refilled are removed once the prune interval elapsed.
+	now = now.Add(pruneInterval)
+	g.Bucket("bob", 10).Take(10)
+	if n := g.Len(); n != 1 {
+		t.Fatalf("unexpected bucket count: %d", n)
+	}
+
+	// The bucket in use isn't removed.
+	b := g.Bucket("bob", 10)
+	if d := b.Delay(10); d != time.Second {
+		t.Fatalf("unexpected delay: %s", d)
+	}
+}
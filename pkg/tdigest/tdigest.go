@@ -0,0 +1,170 @@
+// Package tdigest implements the t-digest, a sketch of a distribution of
+// values which estimates quantiles using a bounded amount of memory.
+//
+// Values are summarized by centroids, each holding the mean and number of
+// values it represents. Centroids near the median represent many values while
+// those at the tails represent few, so extreme quantiles are most accurate.
+// Digests built from separate sets of values can be merged.
+package tdigest
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultCompression is the compression used by New. The number of
+// centroids kept is roughly proportional to the compression.
+const DefaultCompression = 100
+
+// Centroid is the mean of a number of values.
+type Centroid struct {
+	Mean  float64
+	Count float64
+}
+
+type centroids []Centroid
+
+func (a centroids) Len() int           { return len(a) }
+func (a centroids) Less(i, j int) bool { return a[i].Mean < a[j].Mean }
+func (a centroids) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// TDigest is a sketch of a distribution of values.
+// The exported fields allow a digest to be encoded and sent between servers.
+type TDigest struct {
+	Compression float64
+	Centroids   []Centroid
+	Min         float64
+	Max         float64
+
+	compressed bool
+}
+
+// New returns a new instance of TDigest with the default compression.
+func New() *TDigest {
+	return NewWithCompression(DefaultCompression)
+}
+
+// NewWithCompression returns a new instance of TDigest with the given compression.
+func NewWithCompression(compression float64) *TDigest {
+	return &TDigest{
+		Compression: compression,
+		Min:         math.Inf(1),
+		Max:         math.Inf(-1),
+	}
+}
+
+// Add adds a value to the digest.
+func (d *TDigest) Add(v float64) {
+	d.add(Centroid{Mean: v, Count: 1}, v, v)
+}
+
+// Merge adds the values summarized by other to the digest.
+func (d *TDigest) Merge(other *TDigest) {
+	for _, c := range other.Centroids {
+		d.add(c, other.Min, other.Max)
+	}
+}
+
+func (d *TDigest) add(c Centroid, min, max float64) {
+	if math.IsNaN(c.Mean) || c.Count <= 0 {
+		return
+	}
+
+	d.Centroids = append(d.Centroids, c)
+	d.compressed = false
+	if min < d.Min {
+		d.Min = min
+	}
+	if max > d.Max {
+		d.Max = max
+	}
+
+	// Bound the memory used by values waiting to be merged.
+	if len(d.Centroids) > int(10*d.Compression) {
+		d.Compress()
+	}
+}
+
+// Count returns the number of values in the digest.
+func (d *TDigest) Count() float64 {
+	var n float64
+	for _, c := range d.Centroids {
+		n += c.Count
+	}
+	return n
+}
+
+// Compress merges adjacent centroids while the quantiles they span are
+// no more than one unit apart on the scale.
+func (d *TDigest) Compress() {
+	if d.compressed {
+		return
+	}
+	d.compressed = true
+
+	if len(d.Centroids) < 2 {
+		return
+	}
+	sort.Stable(centroids(d.Centroids))
+
+	total := d.Count()
+	compression := d.Compression
+	if compression <= 0 {
+		compression = DefaultCompression
+	}
+
+	out := d.Centroids[:1]
+	var before float64 // number of values before the last centroid of out.
+	for _, c := range d.Centroids[1:] {
+		last := &out[len(out)-1]
+		n := last.Count + c.Count
+		q0 := before / total
+		q1 := (before + n) / total
+		if scale(q1, compression)-scale(q0, compression) <= 1 {
+			last.Mean += (c.Mean - last.Mean) * c.Count / n
+			last.Count = n
+			continue
+		}
+		before += last.Count
+		out = append(out, c)
+	}
+	d.Centroids = out
+}
+
+// scale maps quantile q onto a scale of width compression which is
+// stretched at the tails, so centroids there span smaller quantile ranges.
+func scale(q, compression float64) float64 {
+	return compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// Quantile returns the estimated value at quantile q, between 0 and 1.
+// NaN is returned if the digest is empty.
+func (d *TDigest) Quantile(q float64) float64 {
+	d.Compress()
+	if len(d.Centroids) == 0 || q < 0 || q > 1 {
+		return math.NaN()
+	} else if len(d.Centroids) == 1 {
+		return d.Centroids[0].Mean
+	}
+
+	// Interpolate between the centers of the centroids, using the minimum
+	// and maximum as the edges of the first and last centroid.
+	index := q * d.Count()
+	first := d.Centroids[0]
+	if index < first.Count/2 {
+		return d.Min + (first.Mean-d.Min)*index/(first.Count/2)
+	}
+
+	center := first.Count / 2
+	for i := 1; i < len(d.Centroids); i++ {
+		prev, c := d.Centroids[i-1], d.Centroids[i]
+		next := center + (prev.Count+c.Count)/2
+		if index <= next {
+			return prev.Mean + (c.Mean-prev.Mean)*(index-center)/(next-center)
+		}
+		center = next
+	}
+
+	last := d.Centroids[len(d.Centroids)-1]
+	return last.Mean + (d.Max-last.Mean)*math.Min(1, (index-center)/(last.Count/2))
+}
@@ -0,0 +1,81 @@
+package tdigest_test
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/influxdb/influxdb/pkg/tdigest"
+)
+
+func TestTDigest_Quantile_Empty(t *testing.T) {
+	if v := tdigest.New().Quantile(0.5); !math.IsNaN(v) {
+		t.Fatalf("unexpected quantile: %v", v)
+	}
+}
+
+func TestTDigest_Quantile_Exact(t *testing.T) {
+	d := tdigest.New()
+	for _, v := range []float64{5, 1, 4, 2, 3} {
+		d.Add(v)
+	}
+
+	for _, tt := range []struct {
+		q   float64
+		exp float64
+	}{
+		{q: 0, exp: 1},
+		{q: 0.5, exp: 3},
+		{q: 1, exp: 5},
+	} {
+		if v := d.Quantile(tt.q); v != tt.exp {
+			t.Errorf("quantile %v: exp %v got %v", tt.q, tt.exp, v)
+		}
+	}
+}
+
+// Ensure digests merged from separately encoded digests estimate quantiles
+// within a small error and hold far fewer centroids than values.
+func TestTDigest_Merge(t *testing.T) {
+	rnd := rand.New(rand.NewSource(0))
+
+	var values []float64
+	merged := tdigest.New()
+	for i := 0; i < 10; i++ {
+		d := tdigest.New()
+		for j := 0; j < 10000; j++ {
+			v := rnd.NormFloat64()*10 + float64(i)
+			values = append(values, v)
+			d.Add(v)
+		}
+		d.Compress()
+
+		// Merge the digest as if it was received from another server.
+		b, err := json.Marshal(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var other tdigest.TDigest
+		if err := json.Unmarshal(b, &other); err != nil {
+			t.Fatal(err)
+		}
+		merged.Merge(&other)
+	}
+	sort.Float64s(values)
+
+	if n := merged.Count(); n != float64(len(values)) {
+		t.Fatalf("unexpected count: %v", n)
+	}
+	for _, q := range []float64{0.001, 0.01, 0.25, 0.5, 0.75, 0.99, 0.999} {
+		// Compare the quantile of the estimate within the values.
+		v := merged.Quantile(q)
+		if got := float64(sort.SearchFloat64s(values, v)) / float64(len(values)); math.Abs(got-q) > 0.001 {
+			t.Errorf("quantile %v: estimate %v is at quantile %v", q, v, got)
+		}
+	}
+	if n := len(merged.Centroids); n > tdigest.DefaultCompression {
+		t.Fatalf("too many centroids: %d", n)
+	}
+}
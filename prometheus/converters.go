@@ -0,0 +1,232 @@
+// Package prometheus converts Prometheus remote storage requests to points and queries.
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/prometheus/remote"
+)
+
+//go:generate protoc --gogo_out=. remote/remote.proto
+
+const (
+	// MetricNameLabel is the label holding the name of a Prometheus metric,
+	// which is used as the measurement name.
+	MetricNameLabel = "__name__"
+
+	// FieldName is the field holding the value of a sample.
+	FieldName = "value"
+)
+
+var (
+	// ErrNaNDropped is returned when samples which can't be stored as a
+	// float field were dropped from a write request.
+	ErrNaNDropped = errors.New("dropped NaN or Inf samples since they are not supported")
+
+	// ErrMetricNameRequired is returned when a time series has no metric name.
+	ErrMetricNameRequired = errors.New("time series requires a metric name")
+)
+
+// WriteRequestToPoints converts the samples of a Prometheus write request to
+// points. The metric name is used as the measurement, the other labels as tags
+// and the value of the sample as the "value" field.
+//
+// Samples with a NaN or Inf value are dropped. The points of the other samples
+// are returned with ErrNaNDropped in that case.
+func WriteRequestToPoints(req *remote.WriteRequest) ([]models.Point, error) {
+	var points []models.Point
+	var dropped bool
+	for _, ts := range req.GetTimeseries() {
+		var name string
+		tags := make(map[string]string, len(ts.GetLabels()))
+		for _, l := range ts.GetLabels() {
+			if l.Name == MetricNameLabel {
+				name = l.Value
+				continue
+			}
+			tags[l.Name] = l.Value
+		}
+		if name == "" {
+			return nil, ErrMetricNameRequired
+		}
+
+		for _, s := range ts.GetSamples() {
+			if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+				dropped = true
+				continue
+			}
+
+			p, err := models.NewPoint(name, tags, map[string]interface{}{FieldName: s.Value}, time.Unix(0, s.TimestampMs*int64(time.Millisecond)))
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, p)
+		}
+	}
+
+	if dropped {
+		return points, ErrNaNDropped
+	}
+	return points, nil
+}
+
+// ReadRequestToInfluxQLQuery converts a Prometheus read request to a query of
+// the database and retention policy. The request must hold a single query.
+//
+// The metric name matcher selects the measurements and the other label
+// matchers are converted to conditions on tags. Series are grouped by all tags.
+func ReadRequestToInfluxQLQuery(req *remote.ReadRequest, db, rp string) (*influxql.Query, error) {
+	if len(req.GetQueries()) != 1 {
+		return nil, errors.New("read request must contain exactly one query")
+	}
+	q := req.Queries[0]
+
+	// Select all measurements unless a metric name is matched.
+	src := &influxql.Measurement{Database: db, RetentionPolicy: rp, Regex: &influxql.RegexLiteral{Val: regexp.MustCompile(".+")}}
+	cond := timeCondition(q.StartTimestampMs, q.EndTimestampMs)
+	for _, m := range q.GetMatchers() {
+		if m.Name == MetricNameLabel {
+			if err := setMeasurement(src, m); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		expr, err := tagCondition(m)
+		if err != nil {
+			return nil, err
+		}
+		cond = &influxql.BinaryExpr{Op: influxql.AND, LHS: cond, RHS: expr}
+	}
+
+	stmt := &influxql.SelectStatement{
+		Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: FieldName}}},
+		Sources:    []influxql.Source{src},
+		Condition:  cond,
+		Dimensions: []*influxql.Dimension{{Expr: &influxql.Wildcard{}}},
+		IsRawQuery: true,
+	}
+	return &influxql.Query{Statements: []influxql.Statement{stmt}}, nil
+}
+
+// timeCondition returns a condition for the time range in milliseconds, inclusive.
+func timeCondition(start, end int64) influxql.Expr {
+	return &influxql.BinaryExpr{
+		Op: influxql.AND,
+		LHS: &influxql.BinaryExpr{
+			Op:  influxql.GTE,
+			LHS: &influxql.VarRef{Val: "time"},
+			RHS: &influxql.TimeLiteral{Val: time.Unix(0, start*int64(time.Millisecond)).UTC()},
+		},
+		RHS: &influxql.BinaryExpr{
+			Op:  influxql.LTE,
+			LHS: &influxql.VarRef{Val: "time"},
+			RHS: &influxql.TimeLiteral{Val: time.Unix(0, end*int64(time.Millisecond)).UTC()},
+		},
+	}
+}
+
+// setMeasurement sets the measurements matched by a metric name matcher.
+// Measurements can't be excluded by a source, so only equal and regex
+// matchers are supported.
+func setMeasurement(src *influxql.Measurement, m *remote.LabelMatcher) error {
+	switch m.Type {
+	case remote.MatchType_EQUAL:
+		src.Name, src.Regex = m.Value, nil
+	case remote.MatchType_REGEX_MATCH:
+		re, err := compileRegex(m.Value)
+		if err != nil {
+			return err
+		}
+		src.Name, src.Regex = "", &influxql.RegexLiteral{Val: re}
+	default:
+		return fmt.Errorf("unsupported match type for %s: %s", MetricNameLabel, m.Type)
+	}
+	return nil
+}
+
+// tagCondition returns the condition of a label matcher.
+func tagCondition(m *remote.LabelMatcher) (influxql.Expr, error) {
+	expr := &influxql.BinaryExpr{LHS: &influxql.VarRef{Val: m.Name}}
+	switch m.Type {
+	case remote.MatchType_EQUAL, remote.MatchType_NOT_EQUAL:
+		expr.Op = influxql.EQ
+		if m.Type == remote.MatchType_NOT_EQUAL {
+			expr.Op = influxql.NEQ
+		}
+		expr.RHS = &influxql.StringLiteral{Val: m.Value}
+	case remote.MatchType_REGEX_MATCH, remote.MatchType_REGEX_NO_MATCH:
+		re, err := compileRegex(m.Value)
+		if err != nil {
+			return nil, err
+		}
+		expr.Op = influxql.EQREGEX
+		if m.Type == remote.MatchType_REGEX_NO_MATCH {
+			expr.Op = influxql.NEQREGEX
+		}
+		expr.RHS = &influxql.RegexLiteral{Val: re}
+	default:
+		return nil, fmt.Errorf("unknown match type: %s", m.Type)
+	}
+	return expr, nil
+}
+
+// compileRegex compiles a Prometheus regex, which must match the whole value.
+func compileRegex(s string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("^(?:" + s + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %s", s, err)
+	}
+	return re, nil
+}
+
+// RowToTimeSeries converts a row returned by a query built by
+// ReadRequestToInfluxQLQuery to a Prometheus time series.
+func RowToTimeSeries(row *models.Row) (*remote.TimeSeries, error) {
+	ts := &remote.TimeSeries{
+		Labels: []*remote.LabelPair{{Name: MetricNameLabel, Value: row.Name}},
+	}
+
+	// Labels are sorted by name with the metric name first.
+	keys := make([]string, 0, len(row.Tags))
+	for k := range row.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		// Series without the tag are grouped under an empty value.
+		if v := row.Tags[k]; v != "" {
+			ts.Labels = append(ts.Labels, &remote.LabelPair{Name: k, Value: v})
+		}
+	}
+
+	for _, values := range row.Values {
+		if len(values) != 2 || values[1] == nil {
+			continue
+		}
+
+		t, ok := values[0].(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("unexpected time type: %T", values[0])
+		}
+
+		s := &remote.Sample{TimestampMs: t.UnixNano() / int64(time.Millisecond)}
+		switch v := values[1].(type) {
+		case float64:
+			s.Value = v
+		case int64:
+			s.Value = float64(v)
+		default:
+			return nil, fmt.Errorf("unsupported value type for %s: %T", row.Name, values[1])
+		}
+		ts.Samples = append(ts.Samples, s)
+	}
+	return ts, nil
+}
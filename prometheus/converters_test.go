@@ -0,0 +1,111 @@
+package prometheus_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/prometheus"
+	"github.com/influxdb/influxdb/prometheus/remote"
+)
+
+// Ensure samples are converted to points of the metric's measurement.
+func TestWriteRequestToPoints(t *testing.T) {
+	points, err := prometheus.WriteRequestToPoints(&remote.WriteRequest{
+		Timeseries: []*remote.TimeSeries{
+			{
+				Labels:  []*remote.LabelPair{{Name: "__name__", Value: "cpu"}, {Name: "host", Value: "serverA"}, {Name: "region", Value: "west"}},
+				Samples: []*remote.Sample{{Value: 1.5, TimestampMs: 1000}, {Value: math.Inf(1), TimestampMs: 2000}, {Value: -2, TimestampMs: 3000}},
+			},
+			{
+				Labels:  []*remote.LabelPair{{Name: "__name__", Value: "up"}},
+				Samples: []*remote.Sample{{Value: 1, TimestampMs: 1500}},
+			},
+		},
+	})
+	if err != prometheus.ErrNaNDropped {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := []string{
+		"cpu,host=serverA,region=west value=1.5 1000000000",
+		"cpu,host=serverA,region=west value=-2 3000000000",
+		"up value=1 1500000000",
+	}
+	if len(points) != len(exp) {
+		t.Fatalf("unexpected point count: %d", len(points))
+	}
+	for i, p := range points {
+		if p.String() != exp[i] {
+			t.Errorf("%d. unexpected point: exp=%s got=%s", i, exp[i], p.String())
+		}
+	}
+
+	// Series must have a metric name.
+	if _, err := prometheus.WriteRequestToPoints(&remote.WriteRequest{
+		Timeseries: []*remote.TimeSeries{{Samples: []*remote.Sample{{Value: 1}}}},
+	}); err != prometheus.ErrMetricNameRequired {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure label matchers are converted to sources and conditions.
+func TestReadRequestToInfluxQLQuery(t *testing.T) {
+	for i, tt := range []struct {
+		matchers []*remote.LabelMatcher
+		s        string
+		err      string
+	}{
+		{
+			matchers: nil,
+			s:        `SELECT value FROM db0.rp0./.+/ WHERE time >= '1970-01-01T00:00:01Z' AND time <= '1970-01-01T00:00:02Z' GROUP BY *`,
+		},
+		{
+			matchers: []*remote.LabelMatcher{
+				{Type: remote.MatchType_EQUAL, Name: "__name__", Value: "cpu"},
+				{Type: remote.MatchType_EQUAL, Name: "host", Value: "serverA"},
+				{Type: remote.MatchType_NOT_EQUAL, Name: "region", Value: "west"},
+			},
+			s: `SELECT value FROM db0.rp0.cpu WHERE time >= '1970-01-01T00:00:01Z' AND time <= '1970-01-01T00:00:02Z' AND host = 'serverA' AND region != 'west' GROUP BY *`,
+		},
+		{
+			matchers: []*remote.LabelMatcher{
+				{Type: remote.MatchType_REGEX_MATCH, Name: "__name__", Value: "cpu|mem"},
+				{Type: remote.MatchType_REGEX_NO_MATCH, Name: "host", Value: "server[AB]"},
+			},
+			s: `SELECT value FROM db0.rp0./^(?:cpu|mem)$/ WHERE time >= '1970-01-01T00:00:01Z' AND time <= '1970-01-01T00:00:02Z' AND host !~ /^(?:server[AB])$/ GROUP BY *`,
+		},
+		{
+			matchers: []*remote.LabelMatcher{{Type: remote.MatchType_NOT_EQUAL, Name: "__name__", Value: "cpu"}},
+			err:      `unsupported match type for __name__: NOT_EQUAL`,
+		},
+		{
+			matchers: []*remote.LabelMatcher{{Type: remote.MatchType_REGEX_MATCH, Name: "host", Value: "("}},
+			err:      "invalid regex \"(\": error parsing regexp: missing closing ): `^(?:()$`",
+		},
+	} {
+		q, err := prometheus.ReadRequestToInfluxQLQuery(&remote.ReadRequest{
+			Queries: []*remote.Query{{StartTimestampMs: 1000, EndTimestampMs: 2000, Matchers: tt.matchers}},
+		}, "db0", "rp0")
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%d. unexpected error: exp=%s got=%v", i, tt.err, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%d. unexpected error: %s", i, err)
+			continue
+		}
+
+		if s := q.String(); s != tt.s {
+			t.Errorf("%d. unexpected query:\n\nexp=%s\n\ngot=%s\n\n", i, tt.s, s)
+		} else if !q.Statements[0].(*influxql.SelectStatement).IsRawQuery {
+			t.Errorf("%d. expected raw query", i)
+		}
+	}
+
+	// Only a single query is supported.
+	if _, err := prometheus.ReadRequestToInfluxQLQuery(&remote.ReadRequest{}, "db0", "rp0"); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -28,6 +28,9 @@ const (
 	// DefaultTypesDB is the default location of the collectd types db file.
 	DefaultTypesDB = "/usr/share/collectd/types.db"
 
+	// DefaultSecurityLevel is the default security level of the packets.
+	DefaultSecurityLevel = SecurityLevelNone
+
 	// DefaultReadBuffer is the default buffer size for the UDP listener.
 	// Sets the size of the operating system's receive buffer associated with
 	// the UDP traffic. Keep in mind that the OS must be able
@@ -53,6 +56,12 @@ type Config struct {
 	BatchDuration   toml.Duration `toml:"batch-timeout"`
 	ReadBuffer      int           `toml:"read-buffer"`
 	TypesDB         string        `toml:"typesdb"`
+
+	// SecurityLevel is the level of the network protocol security required
+	// from packets: none, sign or encrypt. The passwords of the users
+	// signing and encrypting packets are read from AuthFile.
+	SecurityLevel string `toml:"security-level"`
+	AuthFile      string `toml:"auth-file"`
 }
 
 // NewConfig returns a new instance of Config with defaults.
@@ -66,5 +75,6 @@ func NewConfig() Config {
 		BatchPending:    DefaultBatchPending,
 		BatchDuration:   DefaultBatchDuration,
 		TypesDB:         DefaultTypesDB,
+		SecurityLevel:   DefaultSecurityLevel,
 	}
 }
@@ -15,6 +15,8 @@ enabled = true
 bind-address = ":9000"
 database = "xxx"
 typesdb = "yyy"
+security-level = "encrypt"
+auth-file = "/etc/collectd/auth_file"
 `, &c); err != nil {
 		t.Fatal(err)
 	}
@@ -28,5 +30,9 @@ typesdb = "yyy"
 		t.Fatalf("unexpected database: %s", c.Database)
 	} else if c.TypesDB != "yyy" {
 		t.Fatalf("unexpected types db: %s", c.TypesDB)
+	} else if c.SecurityLevel != "encrypt" {
+		t.Fatalf("unexpected security level: %s", c.SecurityLevel)
+	} else if c.AuthFile != "/etc/collectd/auth_file" {
+		t.Fatalf("unexpected auth file: %s", c.AuthFile)
 	}
 }
@@ -0,0 +1,207 @@
+package collectd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Security levels of the collectd network protocol.
+const (
+	// SecurityLevelNone accepts all packets. Signed and encrypted parts are
+	// verified and decrypted if an auth file is configured.
+	SecurityLevelNone = "none"
+
+	// SecurityLevelSign only accepts signed or encrypted packets.
+	SecurityLevelSign = "sign"
+
+	// SecurityLevelEncrypt only accepts encrypted packets.
+	SecurityLevelEncrypt = "encrypt"
+)
+
+// Types of the security parts of the collectd network protocol.
+const (
+	typeSignSHA256    = 0x0200
+	typeEncryptAES256 = 0x0210
+)
+
+var (
+	// ErrMalformedPacket is returned when a packet's parts can't be read.
+	ErrMalformedPacket = errors.New("malformed packet")
+
+	// ErrUnsignedPacket is returned when a packet isn't signed or encrypted
+	// at the sign security level.
+	ErrUnsignedPacket = errors.New("unsigned packet")
+
+	// ErrUnencryptedPacket is returned when a packet isn't encrypted at the
+	// encrypt security level.
+	ErrUnencryptedPacket = errors.New("unencrypted packet")
+
+	// ErrUnknownUser is returned when a packet is signed or encrypted by a
+	// user missing from the auth file.
+	ErrUnknownUser = errors.New("unknown user")
+
+	// ErrInvalidSignature is returned when the signature of a packet doesn't
+	// match its content.
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrDecryptionFailed is returned when the hash of a decrypted packet
+	// doesn't match its content.
+	ErrDecryptionFailed = errors.New("decryption failed")
+)
+
+// ReadAuthFile reads the users and passwords of an auth file in the format of
+// collectd, one "username: password" per line.
+func ReadAuthFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	users := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		i := strings.Index(line, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid line in auth file %s: %q", path, line)
+		}
+		users[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// packetOpener verifies the signatures and decrypts the parts of packets.
+type packetOpener struct {
+	level string
+	users map[string]string // passwords by user, nil without auth file
+}
+
+// open returns the parts of a packet accepted at the security level, with
+// the signature parts removed and the encrypted parts decrypted.
+func (o *packetOpener) open(buf []byte) ([]byte, error) {
+	var out bytes.Buffer
+	if err := o.openParts(&out, buf, false, false); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// openParts writes the parts of buf to out. The parts following a valid
+// signature are signed, and the decrypted parts are encrypted.
+func (o *packetOpener) openParts(out *bytes.Buffer, buf []byte, signed, encrypted bool) error {
+	for len(buf) > 0 {
+		if len(buf) < 4 {
+			return ErrMalformedPacket
+		}
+		typ := binary.BigEndian.Uint16(buf[0:2])
+		length := int(binary.BigEndian.Uint16(buf[2:4]))
+		if length < 4 || length > len(buf) {
+			return ErrMalformedPacket
+		}
+		part, rest := buf[:length], buf[length:]
+
+		switch typ {
+		case typeSignSHA256:
+			// Signatures are ignored if they can't be verified.
+			if o.users == nil {
+				break
+			}
+			if err := o.verify(part[4:], rest); err != nil {
+				return err
+			}
+			signed = true
+
+		case typeEncryptAES256:
+			payload, err := o.decrypt(part[4:])
+			if err != nil {
+				return err
+			}
+			if err := o.openParts(out, payload, signed, true); err != nil {
+				return err
+			}
+
+		default:
+			if o.level == SecurityLevelEncrypt && !encrypted {
+				return ErrUnencryptedPacket
+			} else if o.level == SecurityLevelSign && !signed && !encrypted {
+				return ErrUnsignedPacket
+			}
+			out.Write(part)
+		}
+		buf = rest
+	}
+	return nil
+}
+
+// verify verifies the HMAC-SHA256 signature of the parts following a
+// signature part, which is keyed by the password of the user.
+func (o *packetOpener) verify(data, rest []byte) error {
+	if len(data) < sha256.Size {
+		return ErrMalformedPacket
+	}
+	sum, user := data[:sha256.Size], data[sha256.Size:]
+
+	password, ok := o.users[string(user)]
+	if !ok {
+		return ErrUnknownUser
+	}
+
+	mac := hmac.New(sha256.New, []byte(password))
+	mac.Write(user)
+	mac.Write(rest)
+	if !hmac.Equal(mac.Sum(nil), sum) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// decrypt decrypts an AES-256-OFB encrypted part, keyed by the SHA-256 of
+// the password of the user. The decrypted data starts with the SHA-1 of the
+// encrypted parts.
+func (o *packetOpener) decrypt(data []byte) ([]byte, error) {
+	if len(data) < 2 {
+		return nil, ErrMalformedPacket
+	}
+	n := int(binary.BigEndian.Uint16(data[0:2]))
+	if len(data) < 2+n+aes.BlockSize+sha1.Size {
+		return nil, ErrMalformedPacket
+	}
+	user, iv, ciphertext := data[2:2+n], data[2+n:2+n+aes.BlockSize], data[2+n+aes.BlockSize:]
+
+	password, ok := o.users[string(user)]
+	if !ok {
+		return nil, ErrUnknownUser
+	}
+
+	key := sha256.Sum256([]byte(password))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewOFB(block, iv).XORKeyStream(plaintext, ciphertext)
+
+	sum, payload := plaintext[:sha1.Size], plaintext[sha1.Size:]
+	if h := sha1.Sum(payload); !hmac.Equal(h[:], sum) {
+		return nil, ErrDecryptionFailed
+	}
+	return payload, nil
+}
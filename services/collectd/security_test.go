@@ -0,0 +1,112 @@
+package collectd
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// Ensure packets are verified and decrypted at each security level.
+func TestPacketOpener_Open(t *testing.T) {
+	t.Parallel()
+
+	parts := append(testPart(0x0000, []byte("server01\x00")), testPart(0x0002, []byte("cpu\x00"))...)
+	signed := testSign(parts, "alice", "secret")
+	encrypted := testEncrypt(parts, "alice", "secret")
+	users := map[string]string{"alice": "secret", "bob": "password"}
+
+	for i, tt := range []struct {
+		level  string
+		users  map[string]string
+		packet []byte
+		err    error
+	}{
+		{level: SecurityLevelNone, packet: parts},
+		{level: SecurityLevelNone, packet: signed},
+		{level: SecurityLevelNone, users: users, packet: signed},
+		{level: SecurityLevelNone, users: users, packet: encrypted},
+		{level: SecurityLevelNone, packet: encrypted, err: ErrUnknownUser},
+		{level: SecurityLevelNone, packet: parts[:5], err: ErrMalformedPacket},
+
+		{level: SecurityLevelSign, users: users, packet: parts, err: ErrUnsignedPacket},
+		{level: SecurityLevelSign, users: users, packet: signed},
+		{level: SecurityLevelSign, users: users, packet: encrypted},
+		{level: SecurityLevelSign, users: users, packet: testSign(parts, "bob", "secret"), err: ErrInvalidSignature},
+		{level: SecurityLevelSign, users: users, packet: testSign(parts, "eve", "secret"), err: ErrUnknownUser},
+		{level: SecurityLevelSign, users: users, packet: signed[:20], err: ErrMalformedPacket},
+
+		{level: SecurityLevelEncrypt, users: users, packet: parts, err: ErrUnencryptedPacket},
+		{level: SecurityLevelEncrypt, users: users, packet: signed, err: ErrUnencryptedPacket},
+		{level: SecurityLevelEncrypt, users: users, packet: encrypted},
+		{level: SecurityLevelEncrypt, users: users, packet: testEncrypt(parts, "bob", "secret"), err: ErrDecryptionFailed},
+	} {
+		o := &packetOpener{level: tt.level, users: tt.users}
+		buf, err := o.open(tt.packet)
+		if err != tt.err {
+			t.Errorf("%d. unexpected error: exp=%v, got=%v", i, tt.err, err)
+		} else if err == nil && !bytes.Equal(buf, parts) {
+			t.Errorf("%d. unexpected parts: %x", i, buf)
+		}
+	}
+}
+
+// Ensure the users of an auth file are read.
+func TestReadAuthFile(t *testing.T) {
+	t.Parallel()
+
+	f, err := ioutil.TempFile("", "collectd-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("# collectd users\nalice: secret\n\nbob:pass:word \n")
+	f.Close()
+
+	users, err := ReadAuthFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	} else if len(users) != 2 || users["alice"] != "secret" || users["bob"] != "pass:word" {
+		t.Fatalf("unexpected users: %v", users)
+	}
+}
+
+// testPart returns a part of the collectd network protocol.
+func testPart(typ uint16, data []byte) []byte {
+	buf := make([]byte, 4, 4+len(data))
+	binary.BigEndian.PutUint16(buf[0:2], typ)
+	binary.BigEndian.PutUint16(buf[2:4], uint16(4+len(data)))
+	return append(buf, data...)
+}
+
+// testSign returns parts signed by a user the way collectd does.
+func testSign(parts []byte, user, password string) []byte {
+	mac := hmac.New(sha256.New, []byte(password))
+	mac.Write([]byte(user))
+	mac.Write(parts)
+	return append(testPart(typeSignSHA256, append(mac.Sum(nil), user...)), parts...)
+}
+
+// testEncrypt returns parts encrypted by a user the way collectd does.
+func testEncrypt(parts []byte, user, password string) []byte {
+	sum := sha1.Sum(parts)
+	plaintext := append(sum[:], parts...)
+
+	key := sha256.Sum256([]byte(password))
+	block, _ := aes.NewCipher(key[:])
+	iv := bytes.Repeat([]byte{0x42}, aes.BlockSize)
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewOFB(block, iv).XORKeyStream(ciphertext, plaintext)
+
+	data := make([]byte, 2)
+	binary.BigEndian.PutUint16(data, uint16(len(user)))
+	data = append(data, user...)
+	data = append(data, iv...)
+	return testPart(typeEncryptAES256, append(data, ciphertext...))
+}
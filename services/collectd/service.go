@@ -30,8 +30,26 @@ const (
 	statPointsTransmitted    = "pointsTx"
 	statBatchesTransmitFail  = "batchesTxFail"
 	statDroppedPointsInvalid = "droppedPointsInvalid"
+
+	// Packets dropped by the network protocol security.
+	statDroppedPacketsMalformed        = "droppedPacketsMalformed"
+	statDroppedPacketsUnsigned         = "droppedPacketsUnsigned"
+	statDroppedPacketsUnencrypted      = "droppedPacketsUnencrypted"
+	statDroppedPacketsUnknownUser      = "droppedPacketsUnknownUser"
+	statDroppedPacketsInvalidSignature = "droppedPacketsInvalidSignature"
+	statDroppedPacketsDecryptionFail   = "droppedPacketsDecryptionFail"
 )
 
+// droppedPacketStats are the stats of the packets dropped for each error.
+var droppedPacketStats = map[error]string{
+	ErrMalformedPacket:   statDroppedPacketsMalformed,
+	ErrUnsignedPacket:    statDroppedPacketsUnsigned,
+	ErrUnencryptedPacket: statDroppedPacketsUnencrypted,
+	ErrUnknownUser:       statDroppedPacketsUnknownUser,
+	ErrInvalidSignature:  statDroppedPacketsInvalidSignature,
+	ErrDecryptionFailed:  statDroppedPacketsDecryptionFail,
+}
+
 // pointsWriter is an internal interface to make testing easier.
 type pointsWriter interface {
 	WritePoints(p *cluster.WritePointsRequest) error
@@ -58,6 +76,7 @@ type Service struct {
 	batcher *tsdb.PointBatcher
 	typesdb gollectd.Types
 	addr    net.Addr
+	opener  *packetOpener
 
 	// expvar-based stats.
 	statMap *expvar.Map
@@ -102,6 +121,25 @@ func (s *Service) Open() error {
 		return err
 	}
 
+	// Read the users of the auth file to verify and decrypt packets.
+	s.opener = &packetOpener{level: s.Config.SecurityLevel}
+	switch s.Config.SecurityLevel {
+	case "", SecurityLevelNone:
+	case SecurityLevelSign, SecurityLevelEncrypt:
+		if s.Config.AuthFile == "" {
+			return fmt.Errorf("auth file is required at security level %q", s.Config.SecurityLevel)
+		}
+	default:
+		return fmt.Errorf("unknown security level: %q", s.Config.SecurityLevel)
+	}
+	if s.Config.AuthFile != "" {
+		users, err := ReadAuthFile(s.Config.AuthFile)
+		if err != nil {
+			return fmt.Errorf("Open(): %s", err)
+		}
+		s.opener.users = users
+	}
+
 	if s.typesdb == nil {
 		// Open collectd types.
 		typesdb, err := gollectd.TypesDBFile(s.Config.TypesDB)
@@ -227,6 +265,15 @@ func (s *Service) serve() {
 }
 
 func (s *Service) handleMessage(buffer []byte) {
+	buffer, err := s.opener.open(buffer)
+	if err != nil {
+		if stat, ok := droppedPacketStats[err]; ok {
+			s.statMap.Add(stat, 1)
+		}
+		s.Logger.Printf("Collectd dropped packet: %s", err)
+		return
+	}
+
 	packets, err := gollectd.Packets(buffer, s.typesdb)
 	if err != nil {
 		s.statMap.Add(statPointsParseFail, 1)
@@ -38,7 +38,7 @@ type ContinuousQuerier interface {
 
 // queryExecutor is an internal interface to make testing easier.
 type queryExecutor interface {
-	ExecuteQuery(query *influxql.Query, database string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error)
+	ExecuteQuery(query *influxql.Query, database string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error)
 }
 
 // metaStore is an internal interface to make testing easier.
@@ -269,12 +269,10 @@ func (s *Service) ExecuteContinuousQuery(dbi *meta.DatabaseInfo, cqi *meta.Conti
 	}
 
 	// Calculate and set the time range for the query.
-	startTime := now.Round(interval)
-	if startTime.UnixNano() > now.UnixNano() {
-		startTime = startTime.Add(-interval)
-	}
+	start, end := cq.q.TimeWindow(now.UnixNano())
+	startTime := time.Unix(0, start)
 
-	if err := cq.q.SetTimeRange(startTime, startTime.Add(interval)); err != nil {
+	if err := cq.q.SetTimeRange(startTime, time.Unix(0, end)); err != nil {
 		s.Logger.Printf("error setting time range: %s\n", err)
 	}
 
@@ -295,7 +293,8 @@ func (s *Service) ExecuteContinuousQuery(dbi *meta.DatabaseInfo, cqi *meta.Conti
 		if now.Sub(startTime) > recomputeNoOlderThan {
 			return nil
 		}
-		newStartTime := startTime.Add(-interval)
+		start, _ := cq.q.TimeWindow(startTime.UnixNano() - 1)
+		newStartTime := time.Unix(0, start)
 
 		if err := cq.q.SetTimeRange(newStartTime, startTime); err != nil {
 			s.Logger.Printf("error setting time range: %s\n", err)
@@ -323,7 +322,7 @@ func (s *Service) runContinuousQueryAndWriteResult(cq *ContinuousQuery) error {
 	defer close(closing)
 
 	// Execute the SELECT.
-	ch, err := s.QueryExecutor.ExecuteQuery(q, cq.Database, NoChunkingSize, closing)
+	ch, err := s.QueryExecutor.ExecuteQuery(q, cq.Database, nil, NoChunkingSize, closing)
 	if err != nil {
 		return err
 	}
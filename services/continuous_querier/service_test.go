@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
 	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/toml"
 )
 
 var (
@@ -53,7 +55,7 @@ func TestContinuousQueryService_Run(t *testing.T) {
 
 	// Set a callback for ExecuteQuery.
 	qe := s.QueryExecutor.(*QueryExecutor)
-	qe.ExecuteQueryFn = func(query *influxql.Query, database string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+	qe.ExecuteQueryFn = func(query *influxql.Query, database string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
 		callCnt++
 		if callCnt >= expectCallCnt {
 			done <- struct{}{}
@@ -102,7 +104,7 @@ func TestContinuousQueryService_NotLeader(t *testing.T) {
 	done := make(chan struct{})
 	qe := s.QueryExecutor.(*QueryExecutor)
 	// Set a callback for ExecuteQuery. Shouldn't get called because we're not the leader.
-	qe.ExecuteQueryFn = func(query *influxql.Query, database string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+	qe.ExecuteQueryFn = func(query *influxql.Query, database string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
 		done <- struct{}{}
 		return nil, errUnexpected
 	}
@@ -127,7 +129,7 @@ func TestContinuousQueryService_MetaStoreFailsToGetDatabases(t *testing.T) {
 	done := make(chan struct{})
 	qe := s.QueryExecutor.(*QueryExecutor)
 	// Set ExecuteQuery callback, which shouldn't get called because of meta store failure.
-	qe.ExecuteQueryFn = func(query *influxql.Query, database string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+	qe.ExecuteQueryFn = func(query *influxql.Query, database string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
 		done <- struct{}{}
 		return nil, errUnexpected
 	}
@@ -170,6 +172,38 @@ func TestExecuteContinuousQuery_InvalidQueries(t *testing.T) {
 	}
 }
 
+// Test ExecuteContinuousQuery computes time ranges aligned to the query's timezone.
+func TestExecuteContinuousQuery_TimeZone(t *testing.T) {
+	s := NewTestService(t)
+	s.Config.RecomputePreviousN = 1
+	s.Config.RecomputeNoOlderThan = toml.Duration(48 * time.Hour)
+
+	var conds []string
+	qe := s.QueryExecutor.(*QueryExecutor)
+	qe.ExecuteQueryFn = func(query *influxql.Query, database string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+		conds = append(conds, query.Statements[0].(*influxql.SelectStatement).Condition.String())
+		return nil, nil
+	}
+
+	dbis, _ := s.MetaStore.Databases()
+	dbi := dbis[0]
+	cqi := dbi.ContinuousQueries[0]
+	cqi.Query = `CREATE CONTINUOUS QUERY cq ON db BEGIN SELECT count(cpu) INTO cpu_count FROM cpu GROUP BY time(1d) tz('Europe/Berlin') END`
+
+	now, _ := time.Parse(time.RFC3339, "2016-03-27T12:00:00Z")
+	if err := s.ExecuteContinuousQuery(&dbi, &cqi, now); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []string{
+		`time >= '2016-03-26T23:00:00Z' AND time < '2016-03-27T22:00:00Z'`,
+		`time >= '2016-03-25T23:00:00Z' AND time < '2016-03-26T23:00:00Z'`,
+	}
+	if !reflect.DeepEqual(conds, exp) {
+		t.Fatalf("unexpected time ranges:\n\nexp=%v\n\ngot=%v", exp, conds)
+	}
+}
+
 // Test ExecuteContinuousQuery when QueryExecutor returns an error.
 func TestExecuteContinuousQuery_QueryExecutor_Error(t *testing.T) {
 	s := NewTestService(t)
@@ -317,7 +351,7 @@ func (ms *MetaStore) CreateContinuousQuery(database, name, query string) error {
 
 // QueryExecutor is a mock query executor.
 type QueryExecutor struct {
-	ExecuteQueryFn func(query *influxql.Query, database string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error)
+	ExecuteQueryFn func(query *influxql.Query, database string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error)
 	Results        []*influxql.Result
 	ResultInterval time.Duration
 	Err            error
@@ -334,11 +368,11 @@ func NewQueryExecutor(t *testing.T) *QueryExecutor {
 }
 
 // ExecuteQuery returns a channel that the caller can read query results from.
-func (qe *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+func (qe *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
 
 	// If the test set a callback, call it.
 	if qe.ExecuteQueryFn != nil {
-		if _, err := qe.ExecuteQueryFn(query, database, chunkSize, make(chan struct{})); err != nil {
+		if _, err := qe.ExecuteQueryFn(query, database, user, chunkSize, make(chan struct{})); err != nil {
 			return nil, err
 		}
 	}
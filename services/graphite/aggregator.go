@@ -0,0 +1,238 @@
+package graphite
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxAggregationIntervals is the number of intervals of an aggregation
+// rule kept to aggregate late metrics, the same as the default of carbon.
+const DefaultMaxAggregationIntervals = 5
+
+// Aggregation methods of the rules.
+const (
+	AggregateSum   = "sum"
+	AggregateAvg   = "avg"
+	AggregateMin   = "min"
+	AggregateMax   = "max"
+	AggregateCount = "count"
+	AggregateLast  = "last"
+)
+
+// AggregationRule is a rule of carbon-aggregator, in the format:
+//   output_template (frequency) = method input_pattern
+// such as "<env>.requests.all (60) = sum <env>.requests.*". The metrics whose
+// names match the input pattern are aggregated into the metric named by the
+// output template, once per frequency seconds. Fields between angle brackets
+// in the pattern match a node of the name and are substituted in the template.
+type AggregationRule struct {
+	output    string
+	frequency time.Duration
+	method    string
+	regex     *regexp.Regexp
+}
+
+// ruleRegexp matches the format of an aggregation rule.
+var ruleRegexp = regexp.MustCompile(`^(\S+)\s+\((\d+)\)\s*=\s*(\S+)\s+(\S+)$`)
+
+// fieldRegexp matches the fields of the patterns and templates of the rules.
+var fieldRegexp = regexp.MustCompile(`<([^<>.]+)>`)
+
+// ParseAggregationRule parses an aggregation rule.
+func ParseAggregationRule(s string) (*AggregationRule, error) {
+	m := ruleRegexp.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return nil, fmt.Errorf("invalid aggregation rule: %q", s)
+	}
+
+	frequency, _ := strconv.Atoi(m[2])
+	if frequency <= 0 {
+		return nil, fmt.Errorf("invalid frequency of aggregation rule: %q", s)
+	}
+
+	switch m[3] {
+	case AggregateSum, AggregateAvg, AggregateMin, AggregateMax, AggregateCount, AggregateLast:
+	default:
+		return nil, fmt.Errorf("unknown method %q of aggregation rule: %q", m[3], s)
+	}
+
+	// Build a regular expression matching the input pattern, where fields
+	// and wildcards match a single node of the name.
+	var buf []string
+	for _, node := range strings.Split(m[4], ".") {
+		var re string
+		for node != "" {
+			if loc := fieldRegexp.FindStringSubmatchIndex(node); loc != nil && loc[0] == 0 {
+				re += fmt.Sprintf(`(?P<%s>[^.;]+)`, node[loc[2]:loc[3]])
+				node = node[loc[1]:]
+			} else if node[0] == '*' {
+				re += `[^.;]*`
+				node = node[1:]
+			} else {
+				re += regexp.QuoteMeta(node[:1])
+				node = node[1:]
+			}
+		}
+		buf = append(buf, re)
+	}
+	regex, err := regexp.Compile(`^` + strings.Join(buf, `\.`) + `$`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern of aggregation rule: %q: %s", s, err)
+	}
+
+	return &AggregationRule{
+		output:    m[1],
+		frequency: time.Duration(frequency) * time.Second,
+		method:    m[3],
+		regex:     regex,
+	}, nil
+}
+
+// Output returns the name of the metric a metric is aggregated into, or an
+// empty string if the rule doesn't match the metric.
+func (r *AggregationRule) Output(name string) string {
+	m := r.regex.FindStringSubmatch(name)
+	if m == nil {
+		return ""
+	}
+
+	fields := make(map[string]string)
+	for i, field := range r.regex.SubexpNames() {
+		if field != "" {
+			fields[field] = m[i]
+		}
+	}
+	return fieldRegexp.ReplaceAllStringFunc(r.output, func(s string) string {
+		return fields[s[1:len(s)-1]]
+	})
+}
+
+// aggregationBucket accumulates the values of a metric over an interval.
+type aggregationBucket struct {
+	rule  *AggregationRule
+	name  string
+	start time.Time
+
+	sum, min, max, last float64
+	count               int
+
+	dirty bool // updated since last flushed
+}
+
+// add adds a value to the bucket.
+func (b *aggregationBucket) add(v float64) {
+	if b.count == 0 || v < b.min {
+		b.min = v
+	}
+	if b.count == 0 || v > b.max {
+		b.max = v
+	}
+	b.sum += v
+	b.last = v
+	b.count++
+	b.dirty = true
+}
+
+// value returns the aggregated value of the bucket.
+func (b *aggregationBucket) value() float64 {
+	switch b.rule.method {
+	case AggregateAvg:
+		return b.sum / float64(b.count)
+	case AggregateMin:
+		return b.min
+	case AggregateMax:
+		return b.max
+	case AggregateCount:
+		return float64(b.count)
+	case AggregateLast:
+		return b.last
+	}
+	return b.sum
+}
+
+// Aggregator pre-aggregates metrics with the rules of carbon-aggregator. The
+// aggregated metric of an interval is flushed once the interval is over, and
+// flushed again if late metrics of the interval are received, until it's
+// older than MaxIntervals intervals. It is safe for concurrent use.
+type Aggregator struct {
+	mu      sync.Mutex
+	rules   []*AggregationRule
+	buckets map[string]*aggregationBucket
+
+	MaxIntervals int
+}
+
+// NewAggregator returns an aggregator of the given rules.
+func NewAggregator(rules []*AggregationRule) *Aggregator {
+	return &Aggregator{
+		rules:        rules,
+		buckets:      make(map[string]*aggregationBucket),
+		MaxIntervals: DefaultMaxAggregationIntervals,
+	}
+}
+
+// Add adds a metric to the buckets of the rules matching it. It returns true
+// if any rule matched the metric.
+func (a *Aggregator) Add(m Metric) bool {
+	if a == nil {
+		return false
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var matched bool
+	for _, r := range a.rules {
+		name := r.Output(m.Name)
+		if name == "" {
+			continue
+		}
+		matched = true
+
+		start := m.Timestamp.Truncate(r.frequency)
+		key := fmt.Sprintf("%p %s %d", r, name, start.UnixNano())
+		b := a.buckets[key]
+		if b == nil {
+			b = &aggregationBucket{rule: r, name: name, start: start}
+			a.buckets[key] = b
+		}
+		b.add(m.Value)
+	}
+	return matched
+}
+
+// Flush returns the aggregated metrics of the intervals over at now which
+// were updated since they were last flushed, and expires the old intervals.
+func (a *Aggregator) Flush(now time.Time) []Metric {
+	if a == nil {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var metrics []Metric
+	for key, b := range a.buckets {
+		end := b.start.Add(b.rule.frequency)
+		if end.After(now) {
+			continue
+		}
+
+		if b.dirty {
+			if v := b.value(); !math.IsNaN(v) {
+				metrics = append(metrics, Metric{Name: b.name, Value: v, Timestamp: b.start})
+			}
+			b.dirty = false
+		}
+
+		if !end.Add(time.Duration(a.MaxIntervals) * b.rule.frequency).After(now) {
+			delete(a.buckets, key)
+		}
+	}
+	return metrics
+}
@@ -0,0 +1,110 @@
+package graphite_test
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/services/graphite"
+)
+
+// Ensure aggregation rules are parsed and map metric names to their output.
+func TestParseAggregationRule(t *testing.T) {
+	r, err := graphite.ParseAggregationRule("<env>.requests.all (60) = sum <env>.requests.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		output string
+	}{
+		{name: "prod.requests.get", output: "prod.requests.all"},
+		{name: "prod.requests.get;host=a", output: ""},
+		{name: "prod.requests.get.ok", output: ""},
+		{name: "prod.errors.get", output: ""},
+	} {
+		if output := r.Output(tt.name); output != tt.output {
+			t.Errorf("%s: unexpected output: %q", tt.name, output)
+		}
+	}
+
+	for _, s := range []string{
+		"foo.bar = sum foo.*",
+		"foo.bar (0) = sum foo.*",
+		"foo.bar (60) = median foo.*",
+	} {
+		if _, err := graphite.ParseAggregationRule(s); err == nil {
+			t.Errorf("%s: expected error", s)
+		}
+	}
+}
+
+// Ensure metrics are aggregated per interval and flushed once it's over.
+func TestAggregator(t *testing.T) {
+	sum, _ := graphite.ParseAggregationRule("<env>.requests.all (60) = sum <env>.requests.*")
+	avg, _ := graphite.ParseAggregationRule("<env>.requests.avg (60) = avg <env>.requests.*")
+	a := graphite.NewAggregator([]*graphite.AggregationRule{sum, avg})
+
+	start := time.Unix(1420070400, 0)
+	for _, m := range []graphite.Metric{
+		{Name: "prod.requests.get", Value: 1, Timestamp: start},
+		{Name: "prod.requests.post", Value: 3, Timestamp: start.Add(30 * time.Second)},
+		{Name: "dev.requests.get", Value: 5, Timestamp: start.Add(59 * time.Second)},
+		{Name: "prod.requests.get", Value: 10, Timestamp: start.Add(60 * time.Second)},
+	} {
+		if !a.Add(m) {
+			t.Fatalf("expected metric to match: %s", m.Name)
+		}
+	}
+	if a.Add(graphite.Metric{Name: "prod.errors.get", Value: 1, Timestamp: start}) {
+		t.Fatal("expected metric not to match")
+	}
+
+	// Nothing is flushed until the first interval is over.
+	if metrics := a.Flush(start.Add(59 * time.Second)); len(metrics) != 0 {
+		t.Fatalf("unexpected metrics: %v", metrics)
+	}
+
+	metrics := a.Flush(start.Add(60 * time.Second))
+	sort.Sort(metricsByName(metrics))
+	exp := []graphite.Metric{
+		{Name: "dev.requests.all", Value: 5, Timestamp: start},
+		{Name: "dev.requests.avg", Value: 5, Timestamp: start},
+		{Name: "prod.requests.all", Value: 4, Timestamp: start},
+		{Name: "prod.requests.avg", Value: 2, Timestamp: start},
+	}
+	if len(metrics) != len(exp) {
+		t.Fatalf("unexpected metrics: %v", metrics)
+	}
+	for i := range exp {
+		if metrics[i].Name != exp[i].Name || metrics[i].Value != exp[i].Value || !metrics[i].Timestamp.Equal(exp[i].Timestamp) {
+			t.Fatalf("%d. unexpected metric: %v", i, metrics[i])
+		}
+	}
+
+	// Intervals are only flushed again when late metrics are received.
+	if metrics := a.Flush(start.Add(61 * time.Second)); len(metrics) != 0 {
+		t.Fatalf("unexpected metrics: %v", metrics)
+	}
+	a.Add(graphite.Metric{Name: "prod.requests.get", Value: 2, Timestamp: start.Add(10 * time.Second)})
+	if metrics := a.Flush(start.Add(62 * time.Second)); len(metrics) != 2 {
+		t.Fatalf("unexpected metrics: %v", metrics)
+	}
+}
+
+// Ensure a nil aggregator doesn't aggregate anything.
+func TestAggregator_Nil(t *testing.T) {
+	var a *graphite.Aggregator
+	if a.Add(graphite.Metric{Name: "foo", Value: 1, Timestamp: time.Now()}) {
+		t.Fatal("expected metric not to match")
+	} else if metrics := a.Flush(time.Now()); len(metrics) != 0 {
+		t.Fatalf("unexpected metrics: %v", metrics)
+	}
+}
+
+type metricsByName []graphite.Metric
+
+func (a metricsByName) Len() int           { return len(a) }
+func (a metricsByName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+func (a metricsByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
@@ -63,6 +63,20 @@ type Config struct {
 	Tags             []string      `toml:"tags"`
 	Separator        string        `toml:"separator"`
 	UDPReadBuffer    int           `toml:"udp-read-buffer"`
+
+	// PickleBindAddress is the address of the listener of the carbon pickle
+	// protocol, used by carbon-relay. The listener is disabled if empty.
+	PickleBindAddress string `toml:"pickle-bind-address"`
+
+	// Aggregations are carbon-aggregator rules pre-aggregating the metrics
+	// matching them. The metrics aggregated are also written, unless
+	// DropAggregated is set.
+	Aggregations   []string `toml:"aggregations"`
+	DropAggregated bool     `toml:"drop-aggregated"`
+
+	// RateLimited subjects the points received to the per-database limits
+	// of the [rate-limits] section.
+	RateLimited bool `toml:"rate-limited"`
 }
 
 // WithDefaults takes the given config and returns a new config with any required
@@ -119,9 +133,26 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	if _, err := c.AggregationRules(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// AggregationRules returns the config's aggregation rules.
+func (c *Config) AggregationRules() ([]*AggregationRule, error) {
+	var rules []*AggregationRule
+	for _, s := range c.Aggregations {
+		r, err := ParseAggregationRule(s)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
 func (c *Config) validateTemplates() error {
 	// map to keep track of filters we see
 	filters := map[string]struct{}{}
@@ -165,3 +165,30 @@ func TestConfigValidateFilterDuplicates(t *testing.T) {
 	}
 
 }
+
+func TestConfig_ParseAggregations(t *testing.T) {
+	var c graphite.Config
+	if _, err := toml.Decode(`
+pickle-bind-address = ":2004"
+aggregations = ["<env>.requests.all (60) = sum <env>.requests.*"]
+drop-aggregated = true
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.PickleBindAddress != ":2004" {
+		t.Fatalf("unexpected pickle bind address: %s", c.PickleBindAddress)
+	} else if !c.DropAggregated {
+		t.Fatalf("unexpected drop aggregated: %v", c.DropAggregated)
+	} else if rules, err := c.AggregationRules(); err != nil || len(rules) != 1 {
+		t.Fatalf("unexpected aggregation rules: %v, %v", rules, err)
+	}
+}
+
+func TestConfigValidateAggregations(t *testing.T) {
+	c := &graphite.Config{}
+	c.Aggregations = []string{"foo.all (60) = median foo.*"}
+	if err := c.Validate(); err == nil {
+		t.Errorf("config validate expected error. got nil")
+	}
+}
@@ -92,71 +92,87 @@ func NewParser(templates []string, defaultTags models.Tags) (*Parser, error) {
 		})
 }
 
-// Parse performs Graphite parsing of a single line.
-func (p *Parser) Parse(line string) (models.Point, error) {
-	// Break into 3 fields (name, value, timestamp).
-	fields := strings.Fields(line)
-	if len(fields) != 2 && len(fields) != 3 {
-		return nil, fmt.Errorf("received %q which doesn't have required fields", line)
+// Metric is a metric received in the graphite format, before its name is
+// parsed into the measurement and tags of a point.
+type Metric struct {
+	Name      string
+	Value     float64
+	Timestamp time.Time
+}
+
+// newMetric returns a metric with a timestamp in seconds since the epoch. A
+// timestamp of -1 is the current time.
+func newMetric(name string, value, unixTime float64) (Metric, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return Metric{}, &UnsupposedValueError{Field: name, Value: value}
 	}
 
-	// decode the name and tags
-	template := p.matcher.Match(fields[0])
-	measurement, tags, field, err := template.Apply(fields[0])
+	// -1 is a special value that gets converted to current UTC time
+	// See https://github.com/graphite-project/carbon/issues/54
+	timestamp := time.Now().UTC()
+	if unixTime != float64(-1) {
+		// Check if we have fractional seconds
+		timestamp = time.Unix(int64(unixTime), int64((unixTime-math.Floor(unixTime))*float64(time.Second)))
+		if timestamp.Before(MinDate) || timestamp.After(MaxDate) {
+			return Metric{}, fmt.Errorf("timestamp out of range")
+		}
+	}
+	return Metric{Name: name, Value: value, Timestamp: timestamp}, nil
+}
+
+// Parse performs Graphite parsing of a single line.
+func (p *Parser) Parse(line string) (models.Point, error) {
+	m, err := p.ParseMetric(line)
 	if err != nil {
 		return nil, err
 	}
+	return p.Point(m)
+}
 
-	// Could not extract measurement, use the raw value
-	if measurement == "" {
-		measurement = fields[0]
+// ParseMetric parses the name, value and timestamp of a single line.
+func (p *Parser) ParseMetric(line string) (Metric, error) {
+	// Break into 3 fields (name, value, timestamp).
+	fields := strings.Fields(line)
+	if len(fields) != 2 && len(fields) != 3 {
+		return Metric{}, fmt.Errorf("received %q which doesn't have required fields", line)
 	}
 
 	// Parse value.
 	v, err := strconv.ParseFloat(fields[1], 64)
 	if err != nil {
-		return nil, fmt.Errorf(`field "%s" value: %s`, fields[0], err)
-	}
-
-	if math.IsNaN(v) || math.IsInf(v, 0) {
-		return nil, &UnsupposedValueError{Field: fields[0], Value: v}
-	}
-
-	fieldValues := map[string]interface{}{}
-	if field != "" {
-		fieldValues[field] = v
-	} else {
-		fieldValues["value"] = v
+		return Metric{}, fmt.Errorf(`field "%s" value: %s`, fields[0], err)
 	}
 
 	// If no 3rd field, use now as timestamp
-	timestamp := time.Now().UTC()
-
+	unixTime := float64(-1)
 	if len(fields) == 3 {
 		// Parse timestamp.
-		unixTime, err := strconv.ParseFloat(fields[2], 64)
-		if err != nil {
-			return nil, fmt.Errorf(`field "%s" time: %s`, fields[0], err)
+		if unixTime, err = strconv.ParseFloat(fields[2], 64); err != nil {
+			return Metric{}, fmt.Errorf(`field "%s" time: %s`, fields[0], err)
 		}
+	}
+	return newMetric(fields[0], v, unixTime)
+}
 
-		// -1 is a special value that gets converted to current UTC time
-		// See https://github.com/graphite-project/carbon/issues/54
-		if unixTime != float64(-1) {
-			// Check if we have fractional seconds
-			timestamp = time.Unix(int64(unixTime), int64((unixTime-math.Floor(unixTime))*float64(time.Second)))
-			if timestamp.Before(MinDate) || timestamp.After(MaxDate) {
-				return nil, fmt.Errorf("timestamp out of range")
-			}
-		}
+// Point returns the point of a metric, applying the template matching its name.
+func (p *Parser) Point(m Metric) (models.Point, error) {
+	measurement, tags, field, err := p.applyTemplate(m.Name)
+	if err != nil {
+		return nil, err
 	}
 
-	// Set the default tags on the point if they are not already set
-	for k, v := range p.tags {
-		if _, ok := tags[k]; !ok {
-			tags[k] = v
-		}
+	// Could not extract measurement, use the raw value without its tags
+	if measurement == "" {
+		measurement = strings.SplitN(m.Name, ";", 2)[0]
 	}
-	return models.NewPoint(measurement, tags, fieldValues, timestamp)
+
+	fieldValues := map[string]interface{}{}
+	if field != "" {
+		fieldValues[field] = m.Value
+	} else {
+		fieldValues["value"] = m.Value
+	}
+	return models.NewPoint(measurement, tags, fieldValues, m.Timestamp)
 }
 
 // ApplyTemplate extracts the template fields from the given line and
@@ -167,16 +183,37 @@ func (p *Parser) ApplyTemplate(line string) (string, map[string]string, string,
 	if len(fields) == 0 {
 		return "", make(map[string]string), "", nil
 	}
+	return p.applyTemplate(fields[0])
+}
+
+// applyTemplate applies the template matching a metric name, which may be
+// followed by tags in the "name;tag1=value1;tag2=value2" syntax of graphite.
+// Tags of the name override the tags of the template.
+func (p *Parser) applyTemplate(name string) (string, map[string]string, string, error) {
+	parts := strings.Split(name, ";")
+
 	// decode the name and tags
-	template := p.matcher.Match(fields[0])
-	name, tags, field, err := template.Apply(fields[0])
+	template := p.matcher.Match(parts[0])
+	measurement, tags, field, err := template.Apply(parts[0])
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	for _, kv := range parts[1:] {
+		i := strings.Index(kv, "=")
+		if i <= 0 || i == len(kv)-1 {
+			return "", nil, "", fmt.Errorf("invalid tag %q in %q", kv, name)
+		}
+		tags[kv[:i]] = kv[i+1:]
+	}
+
 	// Set the default tags on the point if they are not already set
 	for k, v := range p.tags {
 		if _, ok := tags[k]; !ok {
 			tags[k] = v
 		}
 	}
-	return name, tags, field, err
+	return measurement, tags, field, nil
 }
 
 // template represents a pattern and tags to map a graphite metric string to a influxdb Point
@@ -666,3 +666,47 @@ func TestApplyTemplateFieldError(t *testing.T) {
 			"'field' can only be used once in each template: current.users.logged_in")
 	}
 }
+
+func TestApplyTemplateTaggedName(t *testing.T) {
+	o := graphite.Options{
+		Separator: "_",
+		Templates: []string{"cpu.* measurement.field region=us-west,host=b"},
+	}
+	p, err := graphite.NewParserWithOptions(o)
+	if err != nil {
+		t.Fatalf("unexpected error creating parser, got %v", err)
+	}
+
+	measurement, tags, field, err := p.ApplyTemplate("cpu.load;host=a;dc=x")
+	if err != nil {
+		t.Fatalf("unexpected error applying template, got %v", err)
+	}
+	if measurement != "cpu" {
+		t.Errorf("Parser.ApplyTemplate unexpected result. got %s, exp %s", measurement, "cpu")
+	}
+	if field != "load" {
+		t.Errorf("Parser.ApplyTemplate unexpected result. got %s, exp %s", field, "load")
+	}
+	if tags["host"] != "a" || tags["dc"] != "x" || tags["region"] != "us-west" {
+		t.Errorf("Parser.ApplyTemplate unexpected tags. got %v", tags)
+	}
+
+	if _, _, _, err := p.ApplyTemplate("cpu.load;host"); err == nil || err.Error() != `invalid tag "host" in "cpu.load;host"` {
+		t.Errorf("Parser.ApplyTemplate unexpected error. got %v", err)
+	}
+}
+
+func TestParseTaggedName(t *testing.T) {
+	p, err := graphite.NewParser(nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating parser, got %v", err)
+	}
+
+	point, err := p.Parse("cpu.load;host=a 1.5 1420070400")
+	if err != nil {
+		t.Fatalf("unexpected error parsing tagged name, got %v", err)
+	}
+	if exp := "cpu.load,host=a value=1.5 1420070400000000000"; point.String() != exp {
+		t.Errorf("Parser.Parse unexpected result. got %s, exp %s", point.String(), exp)
+	}
+}
@@ -0,0 +1,465 @@
+package graphite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// MaxPickleSize is the maximum size of a message of the pickle protocol, the
+// same as the limit of carbon.
+const MaxPickleSize = 1 << 20
+
+// ReadPickle reads a message of the carbon pickle protocol: a 4-byte big-endian
+// length followed by a pickled list of (path, (timestamp, value)) tuples. It
+// returns the metrics of the message, and the size of the message read.
+func ReadPickle(r io.Reader) ([]Metric, int, error) {
+	var n uint32
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, 0, err
+	} else if n > MaxPickleSize {
+		return nil, 4, fmt.Errorf("pickle message too large: %d bytes", n)
+	}
+
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, 4, err
+	}
+
+	v, err := unpickle(buf)
+	if err != nil {
+		return nil, 4 + len(buf), err
+	}
+
+	list, ok := v.(*pickleList)
+	if !ok {
+		return nil, 4 + len(buf), fmt.Errorf("pickle message isn't a list")
+	}
+
+	metrics := make([]Metric, 0, len(list.items))
+	for _, item := range list.items {
+		m, err := pickledMetric(item)
+		if err != nil {
+			return nil, 4 + len(buf), err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, 4 + len(buf), nil
+}
+
+// pickledMetric returns the metric of a (path, (timestamp, value)) tuple.
+func pickledMetric(v interface{}) (Metric, error) {
+	t, ok := v.(pickleTuple)
+	if !ok || len(t) != 2 {
+		return Metric{}, fmt.Errorf("invalid pickled metric: %v", v)
+	}
+	name, ok := t[0].(string)
+	if !ok {
+		return Metric{}, fmt.Errorf("invalid pickled metric name: %v", t[0])
+	}
+	dp, ok := t[1].(pickleTuple)
+	if !ok || len(dp) != 2 {
+		return Metric{}, fmt.Errorf("invalid pickled datapoint of %q: %v", name, t[1])
+	}
+
+	timestamp, ok := pickleFloat(dp[0])
+	if !ok {
+		return Metric{}, fmt.Errorf(`field "%s" time: invalid value %v`, name, dp[0])
+	}
+	value, ok := pickleFloat(dp[1])
+	if !ok {
+		return Metric{}, fmt.Errorf(`field "%s" value: invalid value %v`, name, dp[1])
+	}
+	return newMetric(name, value, timestamp)
+}
+
+// pickleFloat returns the value of a pickled number.
+func pickleFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f, true
+	case string:
+		// Some senders pickle the values as strings.
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// pickleList is a pickled list. Lists are pointers as they are modified
+// after being memoized.
+type pickleList struct {
+	items []interface{}
+}
+
+// pickleTuple is a pickled tuple.
+type pickleTuple []interface{}
+
+// pickleMark is the marker pushed on the stack by the MARK opcode.
+type pickleMark struct{}
+
+// errPickleStack is returned for pickles referencing missing stack items.
+var errPickleStack = errors.New("invalid pickle: stack underflow")
+
+// unpickle decodes the value of a pickle. Only the opcodes of the protocols
+// used by carbon for numbers, strings, tuples and lists are supported.
+func unpickle(b []byte) (interface{}, error) {
+	var (
+		r     = bytes.NewReader(b)
+		stack []interface{}
+		memo  = make(map[int]interface{})
+	)
+
+	pop := func() (interface{}, error) {
+		if len(stack) == 0 {
+			return nil, errPickleStack
+		}
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v, nil
+	}
+
+	// popMark pops the items pushed since the last mark.
+	popMark := func() ([]interface{}, error) {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if _, ok := stack[i].(pickleMark); ok {
+				items := append([]interface{}{}, stack[i+1:]...)
+				stack = stack[:i]
+				return items, nil
+			}
+		}
+		return nil, errPickleStack
+	}
+
+	// top returns the last item of the stack.
+	top := func() (interface{}, error) {
+		if len(stack) == 0 {
+			return nil, errPickleStack
+		}
+		return stack[len(stack)-1], nil
+	}
+
+	for {
+		op, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("invalid pickle: %s", err)
+		}
+
+		switch op {
+		case 0x80: // PROTO
+			if _, err := readPickleN(r, 1); err != nil {
+				return nil, err
+			}
+		case 0x95: // FRAME
+			if _, err := readPickleN(r, 8); err != nil {
+				return nil, err
+			}
+		case '.': // STOP
+			return pop()
+		case '(': // MARK
+			stack = append(stack, pickleMark{})
+		case '0': // POP
+			if _, err := pop(); err != nil {
+				return nil, err
+			}
+		case '1': // POP_MARK
+			if _, err := popMark(); err != nil {
+				return nil, err
+			}
+
+		case 'N': // NONE
+			stack = append(stack, nil)
+		case 0x88: // NEWTRUE
+			stack = append(stack, true)
+		case 0x89: // NEWFALSE
+			stack = append(stack, false)
+
+		case 'I': // INT
+			line, err := readPickleLine(r)
+			if err != nil {
+				return nil, err
+			}
+			switch line {
+			case "00":
+				stack = append(stack, false)
+			case "01":
+				stack = append(stack, true)
+			default:
+				n, err := strconv.ParseInt(line, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid pickle: %s", err)
+				}
+				stack = append(stack, n)
+			}
+		case 'J': // BININT
+			b, err := readPickleN(r, 4)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, int64(int32(binary.LittleEndian.Uint32(b))))
+		case 'K': // BININT1
+			b, err := readPickleN(r, 1)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, int64(b[0]))
+		case 'M': // BININT2
+			b, err := readPickleN(r, 2)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, int64(binary.LittleEndian.Uint16(b)))
+		case 'L': // LONG
+			line, err := readPickleLine(r)
+			if err != nil {
+				return nil, err
+			}
+			n, ok := new(big.Int).SetString(strings.TrimSuffix(line, "L"), 10)
+			if !ok {
+				return nil, fmt.Errorf("invalid pickle: invalid long %q", line)
+			}
+			stack = append(stack, pickleInt(n))
+		case 0x8a: // LONG1
+			b, err := readPickleN(r, 1)
+			if err != nil {
+				return nil, err
+			}
+			b, err = readPickleN(r, uint64(b[0]))
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, pickleInt(decodeLong(b)))
+		case 'F': // FLOAT
+			line, err := readPickleLine(r)
+			if err != nil {
+				return nil, err
+			}
+			f, err := strconv.ParseFloat(line, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pickle: %s", err)
+			}
+			stack = append(stack, f)
+		case 'G': // BINFLOAT
+			b, err := readPickleN(r, 8)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, math.Float64frombits(binary.BigEndian.Uint64(b)))
+
+		case 'S', 'V': // STRING, UNICODE
+			line, err := readPickleLine(r)
+			if err != nil {
+				return nil, err
+			}
+			if op == 'S' && len(line) >= 2 && (line[0] == '\'' || line[0] == '"') {
+				line = line[1 : len(line)-1]
+			}
+			stack = append(stack, line)
+		case 'T', 'X', 'B': // BINSTRING, BINUNICODE, BINBYTES
+			b, err := readPickleN(r, 4)
+			if err != nil {
+				return nil, err
+			}
+			b, err = readPickleN(r, uint64(binary.LittleEndian.Uint32(b)))
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, string(b))
+		case 'U', 'C', 0x8c: // SHORT_BINSTRING, SHORT_BINBYTES, SHORT_BINUNICODE
+			b, err := readPickleN(r, 1)
+			if err != nil {
+				return nil, err
+			}
+			b, err = readPickleN(r, uint64(b[0]))
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, string(b))
+		case 0x8d: // BINUNICODE8
+			b, err := readPickleN(r, 8)
+			if err != nil {
+				return nil, err
+			}
+			b, err = readPickleN(r, binary.LittleEndian.Uint64(b))
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, string(b))
+
+		case ']': // EMPTY_LIST
+			stack = append(stack, &pickleList{})
+		case 'l': // LIST
+			items, err := popMark()
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, &pickleList{items: items})
+		case 'a': // APPEND
+			v, err := pop()
+			if err != nil {
+				return nil, err
+			}
+			l, err := top()
+			if err != nil {
+				return nil, err
+			}
+			list, ok := l.(*pickleList)
+			if !ok {
+				return nil, fmt.Errorf("invalid pickle: append to %T", l)
+			}
+			list.items = append(list.items, v)
+		case 'e': // APPENDS
+			items, err := popMark()
+			if err != nil {
+				return nil, err
+			}
+			l, err := top()
+			if err != nil {
+				return nil, err
+			}
+			list, ok := l.(*pickleList)
+			if !ok {
+				return nil, fmt.Errorf("invalid pickle: append to %T", l)
+			}
+			list.items = append(list.items, items...)
+
+		case ')': // EMPTY_TUPLE
+			stack = append(stack, pickleTuple{})
+		case 't': // TUPLE
+			items, err := popMark()
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, pickleTuple(items))
+		case 0x85, 0x86, 0x87: // TUPLE1, TUPLE2, TUPLE3
+			n := int(op-0x85) + 1
+			if len(stack) < n {
+				return nil, errPickleStack
+			}
+			t := append(pickleTuple{}, stack[len(stack)-n:]...)
+			stack = append(stack[:len(stack)-n], t)
+
+		case 'p', 'q', 'r', 0x94: // PUT, BINPUT, LONG_BINPUT, MEMOIZE
+			var i int
+			switch op {
+			case 'p':
+				line, err := readPickleLine(r)
+				if err != nil {
+					return nil, err
+				}
+				if i, err = strconv.Atoi(line); err != nil {
+					return nil, fmt.Errorf("invalid pickle: %s", err)
+				}
+			case 'q':
+				b, err := readPickleN(r, 1)
+				if err != nil {
+					return nil, err
+				}
+				i = int(b[0])
+			case 'r':
+				b, err := readPickleN(r, 4)
+				if err != nil {
+					return nil, err
+				}
+				i = int(binary.LittleEndian.Uint32(b))
+			default:
+				i = len(memo)
+			}
+			v, err := top()
+			if err != nil {
+				return nil, err
+			}
+			memo[i] = v
+		case 'g', 'h', 'j': // GET, BINGET, LONG_BINGET
+			var i int
+			switch op {
+			case 'g':
+				line, err := readPickleLine(r)
+				if err != nil {
+					return nil, err
+				}
+				if i, err = strconv.Atoi(line); err != nil {
+					return nil, fmt.Errorf("invalid pickle: %s", err)
+				}
+			case 'h':
+				b, err := readPickleN(r, 1)
+				if err != nil {
+					return nil, err
+				}
+				i = int(b[0])
+			default:
+				b, err := readPickleN(r, 4)
+				if err != nil {
+					return nil, err
+				}
+				i = int(binary.LittleEndian.Uint32(b))
+			}
+			v, ok := memo[i]
+			if !ok {
+				return nil, fmt.Errorf("invalid pickle: missing memo %d", i)
+			}
+			stack = append(stack, v)
+
+		default:
+			return nil, fmt.Errorf("invalid pickle: unsupported opcode 0x%x", op)
+		}
+	}
+}
+
+// readPickleN reads the n bytes of the argument of an opcode. n is checked
+// against the unread bytes before it's converted, as lengths read from the
+// message may not fit in an int.
+func readPickleN(r *bytes.Reader, n uint64) ([]byte, error) {
+	if n > uint64(r.Len()) {
+		return nil, fmt.Errorf("invalid pickle: %s", io.ErrUnexpectedEOF)
+	}
+	b := make([]byte, n)
+	r.Read(b)
+	return b, nil
+}
+
+// readPickleLine reads the newline terminated argument of an opcode.
+func readPickleLine(r *bytes.Reader) (string, error) {
+	var buf bytes.Buffer
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return "", fmt.Errorf("invalid pickle: %s", io.ErrUnexpectedEOF)
+		} else if c == '\n' {
+			return buf.String(), nil
+		}
+		buf.WriteByte(c)
+	}
+}
+
+// decodeLong decodes a little-endian two's complement integer.
+func decodeLong(b []byte) *big.Int {
+	n := new(big.Int)
+	for i := len(b) - 1; i >= 0; i-- {
+		n.Lsh(n, 8)
+		n.Or(n, big.NewInt(int64(b[i])))
+	}
+	if len(b) > 0 && b[len(b)-1]&0x80 != 0 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+	return n
+}
+
+// pickleInt returns an integer as an int64 if it fits in one.
+func pickleInt(n *big.Int) interface{} {
+	if n.BitLen() < 64 {
+		return n.Int64()
+	}
+	return n
+}
@@ -0,0 +1,69 @@
+package graphite_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/services/graphite"
+)
+
+// Ensure the metrics of pickle protocol messages are read.
+func TestReadPickle(t *testing.T) {
+	for i, pickle := range []string{
+		// Protocol 0
+		"(lp0\n(Vcpu.load;host=a\np1\n(I1420070400\nF1.5\ntp2\ntp3\na(Vmem.free\np4\n(F1420070400.5\nI100\ntp5\ntp6\na(Vbig\np7\n(I1420070400\nL1180591620717411303424L\ntp8\ntp9\na.",
+		// Protocol 2
+		"\x80\x02]q\x00(X\x0f\x00\x00\x00cpu.load;host=aq\x01J\x00\x8e\xa4TG?\xf8\x00\x00\x00\x00\x00\x00\x86q\x02\x86q\x03X\x08\x00\x00\x00mem.freeq\x04GA\xd5)#\x80 \x00\x00Kd\x86q\x05\x86q\x06X\x03\x00\x00\x00bigq\x07J\x00\x8e\xa4T\x8a\x09\x00\x00\x00\x00\x00\x00\x00\x00@\x86q\x08\x86q\x09e.",
+		// Protocol 4
+		"\x80\x04\x95]\x00\x00\x00\x00\x00\x00\x00]\x94(\x8c\x0fcpu.load;host=a\x94J\x00\x8e\xa4TG?\xf8\x00\x00\x00\x00\x00\x00\x86\x94\x86\x94\x8c\x08mem.free\x94GA\xd5)#\x80 \x00\x00Kd\x86\x94\x86\x94\x8c\x03big\x94J\x00\x8e\xa4T\x8a\x09\x00\x00\x00\x00\x00\x00\x00\x00@\x86\x94\x86\x94e.",
+	} {
+		var buf bytes.Buffer
+		binary.Write(&buf, binary.BigEndian, uint32(len(pickle)))
+		buf.WriteString(pickle)
+
+		metrics, n, err := graphite.ReadPickle(&buf)
+		if err != nil {
+			t.Fatalf("%d. unexpected error: %s", i, err)
+		} else if n != 4+len(pickle) {
+			t.Fatalf("%d. unexpected size: %d", i, n)
+		} else if len(metrics) != 3 {
+			t.Fatalf("%d. unexpected metrics: %v", i, metrics)
+		}
+
+		if m := metrics[0]; m.Name != "cpu.load;host=a" || m.Value != 1.5 || !m.Timestamp.Equal(time.Unix(1420070400, 0)) {
+			t.Fatalf("%d. unexpected metric: %v", i, m)
+		} else if m := metrics[1]; m.Name != "mem.free" || m.Value != 100 || !m.Timestamp.Equal(time.Unix(1420070400, 5e8)) {
+			t.Fatalf("%d. unexpected metric: %v", i, m)
+		} else if m := metrics[2]; m.Name != "big" || m.Value != 1180591620717411303424 {
+			t.Fatalf("%d. unexpected metric: %v", i, m)
+		}
+	}
+}
+
+// Ensure messages over the maximum size are rejected.
+func TestReadPickle_TooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, uint32(graphite.MaxPickleSize+1))
+	if _, _, err := graphite.ReadPickle(&buf); err == nil || err.Error() != "pickle message too large: 1048577 bytes" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure lengths beyond the message are rejected.
+func TestReadPickle_InvalidLength(t *testing.T) {
+	for i, pickle := range []string{
+		"\x80\x04\x8d\xff\xff\xff\xff\xff\xff\xff\xff.", // BINUNICODE8
+		"\x80\x02X\xff\xff\xff\xff.",                    // BINUNICODE
+		"\x80\x02T\xff\xff\xff\xff.",                    // BINSTRING
+	} {
+		var buf bytes.Buffer
+		binary.Write(&buf, binary.BigEndian, uint32(len(pickle)))
+		buf.WriteString(pickle)
+
+		if _, _, err := graphite.ReadPickle(&buf); err == nil || err.Error() != "invalid pickle: unexpected EOF" {
+			t.Fatalf("%d. unexpected error: %v", i, err)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"expvar"
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"net"
@@ -36,6 +37,8 @@ const (
 	statBatchesTransmitFail = "batchesTxFail"
 	statConnectionsActive   = "connsActive"
 	statConnectionsHandled  = "connsHandled"
+	statPointsRateLimited   = "pointsRateLimited"
+	statPointsAggregated    = "pointsAggregated"
 )
 
 type tcpConnection struct {
@@ -60,8 +63,14 @@ type Service struct {
 	consistencyLevel cluster.ConsistencyLevel
 	udpReadBuffer    int
 
-	batcher *tsdb.PointBatcher
-	parser  *Parser
+	pickleBindAddress string
+	pickleLn          net.Listener
+	pickleAddr        net.Addr
+
+	batcher        *tsdb.PointBatcher
+	parser         *Parser
+	aggregator     *Aggregator
+	dropAggregated bool
 
 	logger           *log.Logger
 	statMap          *expvar.Map
@@ -86,6 +95,9 @@ type Service struct {
 		WaitForLeader(d time.Duration) error
 		CreateDatabaseIfNotExists(name string) (*meta.DatabaseInfo, error)
 	}
+
+	// RateLimiter drops the points over the limits of the database.
+	RateLimiter *tsdb.RateLimiter
 }
 
 // NewService returns an instance of the Graphite service.
@@ -104,6 +116,17 @@ func NewService(c Config) (*Service, error) {
 		logger:         log.New(os.Stderr, "[graphite] ", log.LstdFlags),
 		tcpConnections: make(map[string]*tcpConnection),
 		done:           make(chan struct{}),
+
+		pickleBindAddress: d.PickleBindAddress,
+		dropAggregated:    d.DropAggregated,
+	}
+
+	rules, err := d.AggregationRules()
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) > 0 {
+		s.aggregator = NewAggregator(rules)
 	}
 
 	consistencyLevel, err := cluster.ParseConsistencyLevel(d.ConsistencyLevel)
@@ -137,6 +160,7 @@ func (s *Service) Open() error {
 	key := strings.Join([]string{"graphite", s.protocol, s.bindAddress}, ":")
 	tags := map[string]string{"proto": s.protocol, "bind": s.bindAddress}
 	s.statMap = influxdb.NewStatistics(key, "graphite", tags)
+	influxdb.DeclareGauges("graphite", statConnectionsActive)
 
 	// Register diagnostics if a Monitor service is available.
 	if s.Monitor != nil {
@@ -171,8 +195,19 @@ func (s *Service) Open() error {
 	if err != nil {
 		return err
 	}
-
 	s.logger.Printf("Listening on %s: %s", strings.ToUpper(s.protocol), s.addr.String())
+
+	if s.pickleBindAddress != "" {
+		if s.pickleAddr, err = s.openPickleServer(); err != nil {
+			return err
+		}
+		s.logger.Printf("Listening on pickle protocol: %s", s.pickleAddr.String())
+	}
+
+	if s.aggregator != nil {
+		s.wg.Add(1)
+		go s.flushAggregations()
+	}
 	return nil
 }
 func (s *Service) closeAllConnections() {
@@ -196,6 +231,9 @@ func (s *Service) Close() error {
 	if s.udpConn != nil {
 		s.udpConn.Close()
 	}
+	if s.pickleLn != nil {
+		s.pickleLn.Close()
+	}
 
 	if s.batcher != nil {
 		s.batcher.Stop()
@@ -217,6 +255,11 @@ func (s *Service) Addr() net.Addr {
 	return s.addr
 }
 
+// PickleAddr returns the address the pickle protocol listener binds to.
+func (s *Service) PickleAddr() net.Addr {
+	return s.pickleAddr
+}
+
 // openTCPServer opens the Graphite input in TCP mode and starts processing data.
 func (s *Service) openTCPServer() (net.Addr, error) {
 	ln, err := net.Listen("tcp", s.bindAddress)
@@ -330,13 +373,104 @@ func (s *Service) openUDPServer() (net.Addr, error) {
 	return s.udpConn.LocalAddr(), nil
 }
 
+// openPickleServer opens the listener of the carbon pickle protocol.
+func (s *Service) openPickleServer() (net.Addr, error) {
+	ln, err := net.Listen("tcp", s.pickleBindAddress)
+	if err != nil {
+		return nil, err
+	}
+	s.pickleLn = ln
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if opErr, ok := err.(*net.OpError); ok && !opErr.Temporary() {
+				s.logger.Println("graphite pickle listener closed")
+				return
+			}
+			if err != nil {
+				s.logger.Println("error accepting pickle connection", err.Error())
+				continue
+			}
+
+			s.wg.Add(1)
+			go s.handlePickleConnection(conn)
+		}
+	}()
+	return ln.Addr(), nil
+}
+
+// handlePickleConnection services a connection of the carbon pickle protocol.
+func (s *Service) handlePickleConnection(conn net.Conn) {
+	defer s.wg.Done()
+	defer conn.Close()
+	defer s.statMap.Add(statConnectionsActive, -1)
+	defer s.untrackConnection(conn)
+	s.statMap.Add(statConnectionsActive, 1)
+	s.statMap.Add(statConnectionsHandled, 1)
+	s.trackConnection(conn)
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		metrics, n, err := ReadPickle(reader)
+		s.statMap.Add(statBytesReceived, int64(n))
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			// The stream can't be resynchronized after an invalid message.
+			s.logger.Printf("unable to read pickle message: %s", err)
+			s.statMap.Add(statPointsParseFail, 1)
+			return
+		}
+
+		s.statMap.Add(statPointsReceived, int64(len(metrics)))
+		for _, m := range metrics {
+			s.handleMetric(m, n/len(metrics))
+		}
+	}
+}
+
+// flushAggregations periodically writes the metrics aggregated by the rules.
+func (s *Service) flushAggregations() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			for _, m := range s.aggregator.Flush(now) {
+				point, err := s.parser.Point(m)
+				if err != nil {
+					s.logger.Printf("unable to parse aggregated metric: %s: %s", m.Name, err)
+					s.statMap.Add(statPointsParseFail, 1)
+					continue
+				}
+
+				select {
+				case s.batcher.In() <- point:
+					s.statMap.Add(statPointsAggregated, 1)
+				case <-s.done:
+					return
+				}
+			}
+		}
+	}
+}
+
 func (s *Service) handleLine(line string) {
 	if line == "" {
 		return
 	}
 
 	// Parse it.
-	point, err := s.parser.Parse(line)
+	m, err := s.parser.ParseMetric(line)
 	if err != nil {
 		switch err := err.(type) {
 		case *UnsupposedValueError:
@@ -350,6 +484,26 @@ func (s *Service) handleLine(line string) {
 		s.statMap.Add(statPointsParseFail, 1)
 		return
 	}
+	s.handleMetric(m, len(line))
+}
+
+// handleMetric aggregates a metric of size bytes and writes its point.
+func (s *Service) handleMetric(m Metric, size int) {
+	if err := s.RateLimiter.Write(nil, s.database, 1, size); err != nil {
+		s.statMap.Add(statPointsRateLimited, 1)
+		return
+	}
+
+	if s.aggregator.Add(m) && s.dropAggregated {
+		return
+	}
+
+	point, err := s.parser.Point(m)
+	if err != nil {
+		s.logger.Printf("unable to parse metric: %s: %s", m.Name, err)
+		s.statMap.Add(statPointsParseFail, 1)
+		return
+	}
 
 	s.batcher.In() <- point
 }
@@ -89,6 +89,67 @@ func Test_ServerGraphiteTCP(t *testing.T) {
 	wg.Wait()
 }
 
+func Test_ServerGraphitePickle(t *testing.T) {
+	t.Parallel()
+
+	config := graphite.Config{}
+	config.Database = "graphitedb"
+	config.BatchSize = 0 // No batching.
+	config.BatchTimeout = toml.Duration(time.Second)
+	config.BindAddress = ":0"
+	config.PickleBindAddress = ":0"
+
+	service, err := graphite.NewService(config)
+	if err != nil {
+		t.Fatalf("failed to create Graphite service: %s", err.Error())
+	}
+
+	// Allow test to wait until points are written.
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	pointsWriter := PointsWriter{
+		WritePointsFn: func(req *cluster.WritePointsRequest) error {
+			defer wg.Done()
+
+			pt, _ := models.NewPoint(
+				"cpu",
+				map[string]string{"host": "a"},
+				map[string]interface{}{"value": 23.456},
+				time.Unix(1420070400, 0))
+
+			if len(req.Points) != 1 {
+				t.Fatalf("expected 1 point, got %d", len(req.Points))
+			} else if req.Points[0].String() != pt.String() {
+				t.Fatalf("expected point %v, got %v", pt.String(), req.Points[0].String())
+			}
+			return nil
+		},
+	}
+	service.PointsWriter = &pointsWriter
+	service.MetaStore = &DatabaseCreator{}
+
+	if err := service.Open(); err != nil {
+		t.Fatalf("failed to open Graphite service: %s", err.Error())
+	}
+	defer service.Close()
+
+	// Send a pickled [("cpu;host=a", (1420070400, 23.456))] to the pickle endpoint.
+	_, port, _ := net.SplitHostPort(service.PickleAddr().String())
+	conn, err := net.Dial("tcp", "127.0.0.1:"+port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := "\x80\x02]q\x00X\x0a\x00\x00\x00cpu;host=aq\x01J\x00\x8e\xa4TG@7t\xbcj~\xf9\xdb\x86q\x02\x86q\x03a."
+	_, err = fmt.Fprintf(conn, "\x00\x00\x00%c%s", len(data), data)
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wg.Wait()
+}
+
 func Test_ServerGraphiteUDP(t *testing.T) {
 	t.Parallel()
 
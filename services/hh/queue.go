@@ -24,7 +24,7 @@ const (
 	footerSize         = 8
 )
 
-// queue is a bounded, disk-backed, append-only type that combines queue and
+// Queue is a bounded, disk-backed, append-only type that combines queue and
 // log semantics.  byte slices can be appended and read back in-order.
 // The queue maintains a pointer to the current head
 // byte slice and can re-read from the head until it has been advanced.
@@ -53,7 +53,7 @@ const (
 //                                                     ┌─────┐
 //                                                     │Tail │
 //                                                     └─────┘
-type queue struct {
+type Queue struct {
 	mu sync.RWMutex
 
 	// Directory to create segments
@@ -80,10 +80,10 @@ type queuePos struct {
 
 type segments []*segment
 
-// newQueue create a queue that will store segments in dir and that will
+// NewQueue creates a queue that will store segments in dir and that will
 // consume more than maxSize on disk.
-func newQueue(dir string, maxSize int64) (*queue, error) {
-	return &queue{
+func NewQueue(dir string, maxSize int64) (*Queue, error) {
+	return &Queue{
 		dir:            dir,
 		maxSegmentSize: defaultSegmentSize,
 		maxSize:        maxSize,
@@ -92,7 +92,7 @@ func newQueue(dir string, maxSize int64) (*queue, error) {
 }
 
 // Open opens the queue for reading and writing
-func (l *queue) Open() error {
+func (l *Queue) Open() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
@@ -124,7 +124,7 @@ func (l *queue) Open() error {
 }
 
 // Close stops the queue for reading and writing
-func (l *queue) Close() error {
+func (l *Queue) Close() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
@@ -141,7 +141,7 @@ func (l *queue) Close() error {
 
 // Remove removes all underlying file-based resources for the queue.
 // It is an error to call this on an open queue.
-func (l *queue) Remove() error {
+func (l *Queue) Remove() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
@@ -154,7 +154,7 @@ func (l *queue) Remove() error {
 
 // SetMaxSegmentSize updates the max segment size for new and existing
 // segments.
-func (l *queue) SetMaxSegmentSize(size int64) error {
+func (l *Queue) SetMaxSegmentSize(size int64) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
@@ -174,7 +174,7 @@ func (l *queue) SetMaxSegmentSize(size int64) error {
 	return nil
 }
 
-func (l *queue) PurgeOlderThan(when time.Time) error {
+func (l *Queue) PurgeOlderThan(when time.Time) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
@@ -209,7 +209,7 @@ func (l *queue) PurgeOlderThan(when time.Time) error {
 }
 
 // LastModified returns the last time the queue was modified.
-func (l *queue) LastModified() (time.Time, error) {
+func (l *Queue) LastModified() (time.Time, error) {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 
@@ -219,7 +219,7 @@ func (l *queue) LastModified() (time.Time, error) {
 	return time.Time{}.UTC(), nil
 }
 
-func (l *queue) Position() (*queuePos, error) {
+func (l *Queue) Position() (*queuePos, error) {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 
@@ -234,7 +234,7 @@ func (l *queue) Position() (*queuePos, error) {
 }
 
 // diskUsage returns the total size on disk used by the queue
-func (l *queue) diskUsage() int64 {
+func (l *Queue) diskUsage() int64 {
 	var size int64
 	for _, s := range l.segments {
 		size += s.diskUsage()
@@ -243,7 +243,7 @@ func (l *queue) diskUsage() int64 {
 }
 
 // addSegment creates a new empty segment file
-func (l *queue) addSegment() (*segment, error) {
+func (l *Queue) addSegment() (*segment, error) {
 	nextID, err := l.nextSegmentID()
 	if err != nil {
 		return nil, err
@@ -259,7 +259,7 @@ func (l *queue) addSegment() (*segment, error) {
 }
 
 // loadSegments loads all segments on disk
-func (l *queue) loadSegments() (segments, error) {
+func (l *Queue) loadSegments() (segments, error) {
 	segments := []*segment{}
 
 	files, err := ioutil.ReadDir(l.dir)
@@ -290,7 +290,7 @@ func (l *queue) loadSegments() (segments, error) {
 }
 
 // nextSegmentID returns the next segment ID that is free
-func (l *queue) nextSegmentID() (uint64, error) {
+func (l *Queue) nextSegmentID() (uint64, error) {
 	segments, err := ioutil.ReadDir(l.dir)
 	if err != nil {
 		return 0, err
@@ -318,7 +318,7 @@ func (l *queue) nextSegmentID() (uint64, error) {
 }
 
 // Append appends a byte slice to the end of the queue
-func (l *queue) Append(b []byte) error {
+func (l *Queue) Append(b []byte) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
@@ -344,7 +344,7 @@ func (l *queue) Append(b []byte) error {
 }
 
 // Current returns the current byte slice at the head of the queue
-func (l *queue) Current() ([]byte, error) {
+func (l *Queue) Current() ([]byte, error) {
 	if l.head == nil {
 		return nil, ErrNotOpen
 	}
@@ -353,7 +353,7 @@ func (l *queue) Current() ([]byte, error) {
 }
 
 // Advance moves the head point to the next byte slice in the queue
-func (l *queue) Advance() error {
+func (l *Queue) Advance() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	if l.head == nil {
@@ -370,7 +370,7 @@ func (l *queue) Advance() error {
 	return nil
 }
 
-func (l *queue) trimHead() error {
+func (l *Queue) trimHead() error {
 	if len(l.segments) > 1 {
 		l.segments = l.segments[1:]
 
@@ -0,0 +1,421 @@
+package httpd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNoCredentials is returned by an Authenticator when a request doesn't
+// contain credentials it can verify. The next authenticator is then tried.
+var ErrNoCredentials = errors.New("no credentials")
+
+// Authenticator identifies the user making a request.
+type Authenticator interface {
+	// Authenticate returns the name of the user whose credentials are in
+	// the request, or an error if the credentials are invalid.
+	Authenticate(r *http.Request) (string, error)
+}
+
+// DefaultJWTUsernameClaim is the claim holding the name of the user in a JWT.
+const DefaultJWTUsernameClaim = "username"
+
+// JWTAuthenticator authenticates requests with a JSON Web Token passed as a
+// bearer token in the Authorization header. Tokens must be signed using
+// HS256 with the shared secret or RS256 with the private key of the public
+// key, and must have an expiration time.
+type JWTAuthenticator struct {
+	// Secret used to verify HS256 signatures. HS256 tokens are rejected if empty.
+	Secret []byte
+
+	// Key used to verify RS256 signatures. RS256 tokens are rejected if nil.
+	PublicKey *rsa.PublicKey
+
+	// Claim holding the name of the user. Defaults to DefaultJWTUsernameClaim.
+	UsernameClaim string
+
+	// Returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Authenticate returns the user named by the bearer token of the request.
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (string, error) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return "", ErrNoCredentials
+	}
+
+	claims, err := a.verify(strings.TrimSpace(strings.TrimPrefix(auth, "Bearer ")))
+	if err != nil {
+		return "", err
+	}
+
+	// Check the token is valid at the current time.
+	now := time.Now()
+	if a.Now != nil {
+		now = a.Now()
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return "", errors.New("token expiration required")
+	} else if now.Unix() >= int64(exp) {
+		return "", errors.New("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Unix() < int64(nbf) {
+		return "", errors.New("token not yet valid")
+	}
+
+	claim := a.UsernameClaim
+	if claim == "" {
+		claim = DefaultJWTUsernameClaim
+	}
+	username, _ := claims[claim].(string)
+	if username == "" {
+		return "", fmt.Errorf("token %s claim required", claim)
+	}
+	return username, nil
+}
+
+// verify checks the signature of the token and returns its claims.
+func (a *JWTAuthenticator) verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed token signature")
+	}
+
+	// Only the algorithms with a configured key are accepted so a token
+	// can't choose how it is verified.
+	signed := []byte(parts[0] + "." + parts[1])
+	switch header.Alg {
+	case "HS256":
+		if len(a.Secret) == 0 {
+			return nil, fmt.Errorf("unsupported token signing method: %s", header.Alg)
+		}
+		mac := hmac.New(sha256.New, a.Secret)
+		mac.Write(signed)
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return nil, errors.New("invalid token signature")
+		}
+	case "RS256":
+		if a.PublicKey == nil {
+			return nil, fmt.Errorf("unsupported token signing method: %s", header.Alg)
+		}
+		h := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(a.PublicKey, crypto.SHA256, h[:], sig); err != nil {
+			return nil, errors.New("invalid token signature")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported token signing method: %s", header.Alg)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// decodeJWTSegment decodes a base64url encoded JSON segment of a token into v.
+func decodeJWTSegment(s string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return errors.New("malformed token")
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return errors.New("malformed token")
+	}
+	return nil
+}
+
+// ParseRSAPublicKey parses a PEM encoded RSA public key or certificate.
+func ParseRSAPublicKey(b []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		pub := &rsa.PublicKey{}
+		_, err = asn1.Unmarshal(block.Bytes, pub)
+		key = pub
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an RSA public key")
+	}
+	return pub, nil
+}
+
+// DefaultLDAPTimeout is the default time allowed to bind to an LDAP server.
+const DefaultLDAPTimeout = 5 * time.Second
+
+// LDAP result codes.
+const (
+	ldapSuccess            = 0
+	ldapInvalidCredentials = 49
+)
+
+// LDAPAuthenticator authenticates the username and password of a request
+// with a simple bind to an LDAP server. Credentials the server rejects are
+// passed on to the next authenticator, so users in the meta store can
+// still log in.
+type LDAPAuthenticator struct {
+	// URL of the server, e.g. ldap://localhost:389 or ldaps://localhost:636.
+	URL string
+
+	// DN to bind as, where %s is replaced by the escaped username,
+	// e.g. uid=%s,ou=people,dc=example,dc=com.
+	BindDN string
+
+	// TLS configuration for ldaps servers.
+	TLSConfig *tls.Config
+
+	// Time allowed to bind. Defaults to DefaultLDAPTimeout.
+	Timeout time.Duration
+}
+
+// Authenticate binds to the server with the credentials of the request.
+func (a *LDAPAuthenticator) Authenticate(r *http.Request) (string, error) {
+	username, password, err := parseCredentials(r)
+	if err != nil || username == "" || password == "" {
+		// An empty password is an unauthenticated bind, which servers allow.
+		return "", ErrNoCredentials
+	}
+
+	code, err := a.bind(fmt.Sprintf(a.BindDN, escapeDN(username)), password)
+	if err != nil {
+		return "", err
+	}
+	switch code {
+	case ldapSuccess:
+		return username, nil
+	case ldapInvalidCredentials:
+		return "", ErrNoCredentials
+	default:
+		return "", fmt.Errorf("ldap bind failed with result code %d", code)
+	}
+}
+
+// bind sends a simple bind request and returns the result code of the response.
+func (a *LDAPAuthenticator) bind(dn, password string) (int, error) {
+	u, err := url.Parse(a.URL)
+	if err != nil {
+		return 0, err
+	}
+	timeout := a.Timeout
+	if timeout == 0 {
+		timeout = DefaultLDAPTimeout
+	}
+
+	var conn net.Conn
+	dialer := &net.Dialer{Timeout: timeout}
+	switch u.Scheme {
+	case "ldap":
+		conn, err = dialer.Dial("tcp", hostPort(u.Host, "389"))
+	case "ldaps":
+		config := a.TLSConfig
+		if config == nil {
+			config = &tls.Config{ServerName: strings.Split(u.Host, ":")[0]}
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", hostPort(u.Host, "636"), config)
+	default:
+		return 0, fmt.Errorf("unsupported ldap url scheme: %s", u.Scheme)
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	// BindRequest ::= [APPLICATION 0] SEQUENCE { version, name, simple [0] }
+	req := berElement(0x60, append(append(
+		berElement(0x02, []byte{3}),
+		berElement(0x04, []byte(dn))...),
+		berElement(0x80, []byte(password))...,
+	))
+	if _, err := conn.Write(ldapMessage(1, req)); err != nil {
+		return 0, err
+	}
+
+	tag, msg, err := readBERElement(bufio.NewReader(conn))
+	if err != nil {
+		return 0, err
+	} else if tag != 0x30 {
+		return 0, errors.New("invalid ldap response")
+	}
+
+	// Skip the message id to the BindResponse, which starts with the result code.
+	if _, _, msg, err = parseBERElement(msg); err != nil {
+		return 0, err
+	}
+	tag, resp, _, err := parseBERElement(msg)
+	if err != nil {
+		return 0, err
+	} else if tag != 0x61 {
+		return 0, errors.New("invalid ldap bind response")
+	}
+	tag, code, _, err := parseBERElement(resp)
+	if err != nil {
+		return 0, err
+	} else if tag != 0x0a || len(code) != 1 {
+		return 0, errors.New("invalid ldap result code")
+	}
+
+	// UnbindRequest ::= [APPLICATION 2] NULL
+	conn.Write(ldapMessage(2, berElement(0x42, nil)))
+	return int(code[0]), nil
+}
+
+// hostPort returns host with port appended if it doesn't have one.
+func hostPort(host, port string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// escapeDN escapes the special characters of an attribute value in a DN.
+func escapeDN(s string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case strings.IndexByte(`,+"\<>;=`, c) >= 0,
+			c == '#' && i == 0,
+			c == ' ' && (i == 0 || i == len(s)-1):
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c == 0:
+			buf.WriteString(`\00`)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
+
+// ldapMessage returns an LDAPMessage holding the protocol operation op.
+func ldapMessage(id byte, op []byte) []byte {
+	return berElement(0x30, append(berElement(0x02, []byte{id}), op...))
+}
+
+// berElement returns the BER encoding of an element with the given tag and contents.
+func berElement(tag byte, content []byte) []byte {
+	b := []byte{tag}
+	if n := len(content); n < 0x80 {
+		b = append(b, byte(n))
+	} else {
+		var l []byte
+		for ; n > 0; n >>= 8 {
+			l = append([]byte{byte(n)}, l...)
+		}
+		b = append(append(b, 0x80|byte(len(l))), l...)
+	}
+	return append(b, content...)
+}
+
+// maxBERElementSize is the largest element read from a connection.
+const maxBERElementSize = 1 << 20
+
+// readBERElement reads an element from r and returns its tag and contents.
+func readBERElement(r *bufio.Reader) (byte, []byte, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	size := int(n)
+	if n&0x80 != 0 {
+		if n&0x7f > 3 {
+			return 0, nil, errors.New("ber element too large")
+		}
+		size = 0
+		for i := 0; i < int(n&0x7f); i++ {
+			b, err := r.ReadByte()
+			if err != nil {
+				return 0, nil, err
+			}
+			size = size<<8 | int(b)
+		}
+	}
+	if size > maxBERElementSize {
+		return 0, nil, errors.New("ber element too large")
+	}
+
+	content := make([]byte, size)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return 0, nil, err
+	}
+	return tag, content, nil
+}
+
+// parseBERElement parses the element at the start of b and returns its tag,
+// contents and the remaining bytes.
+func parseBERElement(b []byte) (tag byte, content, rest []byte, err error) {
+	if len(b) < 2 {
+		return 0, nil, nil, errors.New("short ber element")
+	}
+
+	tag, size, i := b[0], int(b[1]), 2
+	if size&0x80 != 0 {
+		n := size & 0x7f
+		if n > 3 || len(b) < i+n {
+			return 0, nil, nil, errors.New("invalid ber element length")
+		}
+		size = 0
+		for ; n > 0; n-- {
+			size = size<<8 | int(b[i])
+			i++
+		}
+	}
+	if len(b)-i < size {
+		return 0, nil, nil, errors.New("short ber element")
+	}
+	return tag, b[i : i+size], b[i+size:], nil
+}
@@ -0,0 +1,260 @@
+package httpd_test
+
+import (
+	"bufio"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/services/httpd"
+)
+
+// Ensure the JWT authenticator validates tokens.
+func TestJWTAuthenticator_Authenticate(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1000, 0)
+	a := &httpd.JWTAuthenticator{
+		Secret:    []byte("secret"),
+		PublicKey: &key.PublicKey,
+		Now:       func() time.Time { return now },
+	}
+
+	for i, tt := range []struct {
+		header   string
+		username string
+		err      string
+	}{
+		{header: "", err: "no credentials"},
+		{header: "Basic Zm9vOmJhcg==", err: "no credentials"},
+		{header: "Bearer " + MustSignJWT("HS256", []byte("secret"), map[string]interface{}{"username": "susy", "exp": 1001}), username: "susy"},
+		{header: "Bearer " + MustSignJWT("RS256", key, map[string]interface{}{"username": "bob", "exp": 1001, "nbf": 1000}), username: "bob"},
+		{header: "Bearer " + MustSignJWT("HS256", []byte("wrong"), map[string]interface{}{"username": "susy", "exp": 1001}), err: "invalid token signature"},
+		{header: "Bearer " + MustSignJWT("RS256", other, map[string]interface{}{"username": "susy", "exp": 1001}), err: "invalid token signature"},
+		{header: "Bearer " + MustSignJWT("none", nil, map[string]interface{}{"username": "susy", "exp": 1001}), err: "unsupported token signing method: none"},
+		{header: "Bearer " + MustSignJWT("HS256", []byte("secret"), map[string]interface{}{"username": "susy", "exp": 1000}), err: "token expired"},
+		{header: "Bearer " + MustSignJWT("HS256", []byte("secret"), map[string]interface{}{"username": "susy"}), err: "token expiration required"},
+		{header: "Bearer " + MustSignJWT("HS256", []byte("secret"), map[string]interface{}{"username": "susy", "exp": 1001, "nbf": 1001}), err: "token not yet valid"},
+		{header: "Bearer " + MustSignJWT("HS256", []byte("secret"), map[string]interface{}{"sub": "susy", "exp": 1001}), err: "token username claim required"},
+		{header: "Bearer foo.bar", err: "malformed token"},
+	} {
+		r := MustNewRequest("GET", "/query", nil)
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+
+		username, err := a.Authenticate(r)
+		if errstring(err) != tt.err {
+			t.Errorf("%d. unexpected error: exp=%s got=%s", i, tt.err, errstring(err))
+		} else if username != tt.username {
+			t.Errorf("%d. unexpected username: exp=%s got=%s", i, tt.username, username)
+		}
+	}
+
+	// HS256 tokens are rejected without a secret so an RS256 public key
+	// can't be used as an HMAC secret.
+	a.Secret = nil
+	r := MustNewRequest("GET", "/query", nil)
+	r.Header.Set("Authorization", "Bearer "+MustSignJWT("HS256", []byte("secret"), map[string]interface{}{"username": "susy", "exp": 1001}))
+	if _, err := a.Authenticate(r); errstring(err) != "unsupported token signing method: HS256" {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure PEM encoded RSA public keys can be parsed.
+func TestParseRSAPublicKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkix, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs1, err := asn1.Marshal(key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, block := range []*pem.Block{
+		{Type: "PUBLIC KEY", Bytes: pkix},
+		{Type: "RSA PUBLIC KEY", Bytes: pkcs1},
+	} {
+		if pub, err := httpd.ParseRSAPublicKey(pem.EncodeToMemory(block)); err != nil {
+			t.Fatalf("%s: %s", block.Type, err)
+		} else if !reflect.DeepEqual(pub, &key.PublicKey) {
+			t.Fatalf("%s: unexpected key", block.Type)
+		}
+	}
+
+	if _, err := httpd.ParseRSAPublicKey([]byte("foo")); err == nil || err.Error() != "no PEM data found" {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure the LDAP authenticator binds to the server as the user.
+func TestLDAPAuthenticator_Authenticate(t *testing.T) {
+	s := NewLDAPServer(t, map[string]string{`uid=susy\,admin,ou=people,dc=example,dc=com`: "pass"})
+	defer s.Close()
+
+	a := &httpd.LDAPAuthenticator{
+		URL:    "ldap://" + s.Addr().String(),
+		BindDN: "uid=%s,ou=people,dc=example,dc=com",
+	}
+
+	for i, tt := range []struct {
+		url      string
+		username string
+		err      string
+	}{
+		{url: "/query?u=susy,admin&p=pass", username: "susy,admin"},
+		{url: "/query?u=susy,admin&p=wrong", err: "no credentials"},
+		{url: "/query?u=bob&p=pass", err: "no credentials"},
+		{url: "/query", err: "no credentials"},
+	} {
+		username, err := a.Authenticate(MustNewRequest("GET", tt.url, nil))
+		if errstring(err) != tt.err {
+			t.Errorf("%d. unexpected error: exp=%s got=%s", i, tt.err, errstring(err))
+		} else if username != tt.username {
+			t.Errorf("%d. unexpected username: exp=%s got=%s", i, tt.username, username)
+		}
+	}
+
+	// Unauthenticated binds aren't attempted.
+	r := MustNewRequest("GET", "/query", nil)
+	r.SetBasicAuth("susy,admin", "")
+	if _, err := a.Authenticate(r); err != httpd.ErrNoCredentials {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := s.Binds(); n != 3 {
+		t.Fatalf("unexpected bind count: %d", n)
+	}
+}
+
+// MustSignJWT returns a token with the claims signed by key. Panic on error.
+func MustSignJWT(alg string, key interface{}, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	if err != nil {
+		panic(err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		panic(err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var sig []byte
+	switch alg {
+	case "HS256":
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case "RS256":
+		h := sha256.Sum256([]byte(signed))
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, h[:]); err != nil {
+			panic(err)
+		}
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// LDAPServer is a stub LDAP server which answers simple bind requests.
+type LDAPServer struct {
+	net.Listener
+	binds chan struct{}
+}
+
+// NewLDAPServer returns a running server accepting the passwords of the DNs.
+func NewLDAPServer(t *testing.T, passwords map[string]string) *LDAPServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &LDAPServer{Listener: ln, binds: make(chan struct{}, 100)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn, passwords)
+		}
+	}()
+	return s
+}
+
+// Binds returns the number of bind requests received.
+func (s *LDAPServer) Binds() int { return len(s.binds) }
+
+func (s *LDAPServer) handle(conn net.Conn, passwords map[string]string) {
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+
+	// Read the message id and the version, name and password of the request.
+	msg := readBER(br)
+	id, msg := parseBER(msg)
+	req, _ := parseBER(msg)
+	_, req = parseBER(req)
+	name, req := parseBER(req)
+	password, _ := parseBER(req)
+	s.binds <- struct{}{}
+
+	code := byte(49)
+	if p, ok := passwords[string(name)]; ok && p == string(password) {
+		code = 0
+	}
+	resp := []byte{0x0a, 1, code, 0x04, 0, 0x04, 0}
+	msg = append([]byte{0x02, byte(len(id))}, id...)
+	msg = append(append(msg, 0x61, byte(len(resp))), resp...)
+	conn.Write(append([]byte{0x30, byte(len(msg))}, msg...))
+
+	// Wait for the unbind request.
+	readBER(br)
+}
+
+// readBER reads the contents of a BER element with a short length.
+func readBER(r *bufio.Reader) []byte {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil
+	}
+	b := make([]byte, header[1])
+	io.ReadFull(r, b)
+	return b
+}
+
+// parseBER returns the contents of the BER element at the start of b and the remaining bytes.
+func parseBER(b []byte) (content, rest []byte) {
+	if len(b) < 2 || len(b) < 2+int(b[1]) {
+		return nil, nil
+	}
+	return b[2 : 2+b[1]], b[2+b[1]:]
+}
+
+// errstring converts an error to its string representation.
+func errstring(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
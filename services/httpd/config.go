@@ -10,6 +10,22 @@ type Config struct {
 	PprofEnabled     bool   `toml:"pprof-enabled"`
 	HTTPSEnabled     bool   `toml:"https-enabled"`
 	HTTPSCertificate string `toml:"https-certificate"`
+
+	// Line protocol writes are parsed and written in batches of this size.
+	WriteBatchSize int `toml:"write-batch-size"`
+
+	// Bearer tokens are accepted if either a shared secret (HS256) or the
+	// path to a PEM encoded public key (RS256) is set.
+	JWTSharedSecret  string `toml:"jwt-shared-secret"`
+	JWTPublicKey     string `toml:"jwt-public-key"`
+	JWTUsernameClaim string `toml:"jwt-username-claim"`
+
+	LDAPEnabled bool   `toml:"ldap-enabled"`
+	LDAPURL     string `toml:"ldap-url"`
+	LDAPBindDN  string `toml:"ldap-bind-dn"`
+
+	// Create non-admin users authenticated by a bearer token or LDAP.
+	ProvisionUsers bool `toml:"auth-provision-users"`
 }
 
 // NewConfig returns a new Config with default settings.
@@ -20,5 +36,7 @@ func NewConfig() Config {
 		LogEnabled:       true,
 		HTTPSEnabled:     false,
 		HTTPSCertificate: "/etc/ssl/influxdb.pem",
+		WriteBatchSize:   DefaultWriteBatchSize,
+		JWTUsernameClaim: DefaultJWTUsernameClaim,
 	}
 }
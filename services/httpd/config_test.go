@@ -19,6 +19,13 @@ write-tracing = true
 pprof-enabled = true
 https-enabled = true
 https-certificate = "/dev/null"
+jwt-shared-secret = "secret"
+jwt-public-key = "/etc/influxdb/jwt.pem"
+jwt-username-claim = "sub"
+ldap-enabled = true
+ldap-url = "ldap://localhost:389"
+ldap-bind-dn = "uid=%s,dc=example,dc=com"
+auth-provision-users = true
 `, &c); err != nil {
 		t.Fatal(err)
 	}
@@ -40,6 +47,20 @@ https-certificate = "/dev/null"
 		t.Fatalf("unexpected https enabled: %v", c.HTTPSEnabled)
 	} else if c.HTTPSCertificate != "/dev/null" {
 		t.Fatalf("unexpected https certificate: %v", c.HTTPSCertificate)
+	} else if c.JWTSharedSecret != "secret" {
+		t.Fatalf("unexpected jwt shared secret: %s", c.JWTSharedSecret)
+	} else if c.JWTPublicKey != "/etc/influxdb/jwt.pem" {
+		t.Fatalf("unexpected jwt public key: %s", c.JWTPublicKey)
+	} else if c.JWTUsernameClaim != "sub" {
+		t.Fatalf("unexpected jwt username claim: %s", c.JWTUsernameClaim)
+	} else if c.LDAPEnabled != true {
+		t.Fatalf("unexpected ldap enabled: %v", c.LDAPEnabled)
+	} else if c.LDAPURL != "ldap://localhost:389" {
+		t.Fatalf("unexpected ldap url: %s", c.LDAPURL)
+	} else if c.LDAPBindDN != "uid=%s,dc=example,dc=com" {
+		t.Fatalf("unexpected ldap bind dn: %s", c.LDAPBindDN)
+	} else if c.ProvisionUsers != true {
+		t.Fatalf("unexpected provision users: %v", c.ProvisionUsers)
 	}
 }
 
@@ -1,8 +1,11 @@
 package httpd
 
 import (
+	"bufio"
 	"bytes"
 	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"expvar"
@@ -10,6 +13,7 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
+	"math"
 	"net/http"
 	"net/http/pprof"
 	"os"
@@ -18,23 +22,36 @@ import (
 	"time"
 
 	"github.com/bmizerany/pat"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
 	"github.com/influxdb/influxdb"
 	"github.com/influxdb/influxdb/client"
 	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/importer/records"
 	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
 	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/prometheus"
+	"github.com/influxdb/influxdb/prometheus/remote"
 	"github.com/influxdb/influxdb/services/continuous_querier"
+	"github.com/influxdb/influxdb/tsdb"
 	"github.com/influxdb/influxdb/uuid"
 )
 
 const (
+	// StatusTooManyRequests is the status of requests rejected by rate limits.
+	StatusTooManyRequests = 429
+
 	// DefaultChunkSize specifies the amount of data mappers will read
 	// up to, before sending results back to the engine. This is the
 	// default size in the number of values returned in a raw query.
 	//
 	// Could be many more bytes depending on fields returned.
 	DefaultChunkSize = 10000
+
+	// DefaultWriteBatchSize is the number of points of a line protocol write
+	// parsed before they are written to the database.
+	DefaultWriteBatchSize = 5000
 )
 
 // TODO: Standard response headers (see: HeaderHandler)
@@ -61,12 +78,22 @@ type Handler struct {
 		WaitForLeader(timeout time.Duration) error
 		Database(name string) (*meta.DatabaseInfo, error)
 		Authenticate(username, password string) (ui *meta.UserInfo, err error)
+		User(name string) (*meta.UserInfo, error)
 		Users() ([]meta.UserInfo, error)
+		CreateUser(name, password string, admin bool) (*meta.UserInfo, error)
 	}
 
+	// Authenticators are tried in order before the credentials of a request
+	// are checked against the users in the meta store.
+	Authenticators []Authenticator
+
+	// ProvisionUsers creates a non-admin user in the meta store for each user
+	// identified by the Authenticators who doesn't exist.
+	ProvisionUsers bool
+
 	QueryExecutor interface {
 		Authorize(u *meta.UserInfo, q *influxql.Query, db string) error
-		ExecuteQuery(q *influxql.Query, db string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error)
+		ExecuteQuery(q *influxql.Query, db string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error)
 	}
 
 	PointsWriter interface {
@@ -75,6 +102,19 @@ type Handler struct {
 
 	ContinuousQuerier continuous_querier.ContinuousQuerier
 
+	// RateLimiter limits the rates of writes and queries of each user and
+	// database. Requests over the limits are rejected with a 429.
+	RateLimiter *tsdb.RateLimiter
+
+	// Monitor renders the metrics served at /metrics.
+	Monitor interface {
+		WritePrometheus(w io.Writer) error
+	}
+
+	// WriteBatchSize is the number of points of a line protocol write parsed
+	// before they are written to the database.
+	WriteBatchSize int
+
 	Logger         *log.Logger
 	loggingEnabled bool // Log every HTTP access.
 	WriteTrace     bool // Detailed logging of write path
@@ -109,6 +149,18 @@ func NewHandler(requireAuthentication, loggingEnabled, writeTrace bool, statMap
 			"write", // Data-ingest route.
 			"POST", "/write", true, true, h.serveWrite,
 		},
+		route{
+			"prometheus-write", // Prometheus remote write
+			"POST", "/api/v1/prom/write", false, true, h.servePromWrite,
+		},
+		route{
+			"prometheus-read", // Prometheus remote read
+			"POST", "/api/v1/prom/read", false, true, h.servePromRead,
+		},
+		route{ // Prometheus metrics
+			"metrics",
+			"GET", "/metrics", true, true, h.serveMetrics,
+		},
 		route{ // Ping
 			"ping",
 			"GET", "/ping", true, true, h.servePing,
@@ -263,6 +315,14 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *meta.
 		}
 	}
 
+	// Check rate limits.
+	if err := h.RateLimiter.Query(user, db); err != nil {
+		h.statMap.Add(statQueryRateLimited, 1)
+		setRetryAfter(w, err)
+		httpError(w, err.Error(), pretty, StatusTooManyRequests)
+		return
+	}
+
 	// Parse chunk size. Use default if not provided or unparsable.
 	chunked := (q.Get("chunked") == "true")
 	chunkSize := DefaultChunkSize
@@ -284,7 +344,7 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *meta.
 
 	// Execute query.
 	w.Header().Add("content-type", "application/json")
-	results, err := h.QueryExecutor.ExecuteQuery(query, db, chunkSize, closing)
+	results, err := h.QueryExecutor.ExecuteQuery(query, db, user, chunkSize, closing)
 
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -346,6 +406,11 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *meta.
 			// Append remaining rows as new rows.
 			r.Series = r.Series[rowsMerged:]
 			cr.Series = append(cr.Series, r.Series...)
+
+			// Keep the error of a statement which failed after returning rows.
+			if r.Err != nil {
+				cr.Err = r.Err
+			}
 		} else {
 			resp.Results = append(resp.Results, r)
 		}
@@ -373,7 +438,15 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user *meta.
 	}
 	defer body.Close()
 
-	b, err := ioutil.ReadAll(body)
+	// Line protocol and records bodies are streamed, but clients may send JSON
+	// without setting the content type. If the body looks like JSON, handle it as JSON.
+	br := bufio.NewReader(body)
+	if r.URL.Query().Get("format") != "" || (r.Header.Get("Content-Type") != "application/json" && !isJSON(br)) {
+		h.serveWriteLine(w, r, br, user)
+		return
+	}
+
+	b, err := ioutil.ReadAll(br)
 	if err != nil {
 		if h.WriteTrace {
 			h.Logger.Print("write handler unable to read bytes from request body")
@@ -385,12 +458,22 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user *meta.
 	if h.WriteTrace {
 		h.Logger.Printf("write body received by handler: %s", string(b))
 	}
+	h.serveWriteJSON(w, r, b, user)
+}
 
-	if r.Header.Get("Content-Type") == "application/json" {
-		h.serveWriteJSON(w, r, b, user)
-		return
+// isJSON returns true if the first byte after the leading whitespace of a
+// body is an opening bracket.
+func isJSON(br *bufio.Reader) bool {
+	for i := 1; ; i++ {
+		b, err := br.Peek(i)
+		if len(b) < i {
+			return false
+		} else if c := b[i-1]; c == '{' {
+			return true
+		} else if c > 32 || err != nil {
+			return false
+		}
 	}
-	h.serveWriteLine(w, r, b, user)
 }
 
 // serveWriteJSON receives incoming series data in JSON and writes it to the database.
@@ -414,7 +497,8 @@ func (h *Handler) serveWriteJSON(w http.ResponseWriter, r *http.Request, body []
 		return
 	}
 
-	if di, err := h.MetaStore.Database(bp.Database); err != nil {
+	di, err := h.MetaStore.Database(bp.Database)
+	if err != nil {
 		resultError(w, influxql.Result{Err: fmt.Errorf("metastore database error: %s", err)}, http.StatusInternalServerError)
 		return
 	} else if di == nil {
@@ -427,17 +511,25 @@ func (h *Handler) serveWriteJSON(w http.ResponseWriter, r *http.Request, body []
 		return
 	}
 
-	if h.requireAuthentication && !user.Authorize(influxql.WritePrivilege, bp.Database) {
-		resultError(w, influxql.Result{Err: fmt.Errorf("%q user is not authorized to write to database %q", user.Name, bp.Database)}, http.StatusUnauthorized)
-		return
-	}
-
 	points, err := NormalizeBatchPoints(bp)
 	if err != nil {
 		resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
 		return
 	}
 
+	if h.requireAuthentication {
+		if err := authorizeWrite(user, di, bp.RetentionPolicy, points); err != nil {
+			resultError(w, influxql.Result{Err: err}, http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if err := h.RateLimiter.Write(user, bp.Database, len(points), len(body)); err != nil {
+		h.statMap.Add(statWriteRateLimited, 1)
+		rateLimitError(w, err)
+		return
+	}
+
 	// Convert the json batch struct to a points writer struct
 	if err := h.PointsWriter.WritePoints(&cluster.WritePointsRequest{
 		Database:         bp.Database,
@@ -445,6 +537,12 @@ func (h *Handler) serveWriteJSON(w http.ResponseWriter, r *http.Request, body []
 		ConsistencyLevel: cluster.ConsistencyLevelOne,
 		Points:           points,
 	}); err != nil {
+		if err, ok := err.(tsdb.PartialWriteError); ok {
+			h.statMap.Add(statPointsWrittenOK, int64(len(points)-err.Dropped))
+			h.statMap.Add(statPointsWrittenFail, int64(err.Dropped))
+			resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
+			return
+		}
 		h.statMap.Add(statPointsWrittenFail, int64(len(points)))
 		if influxdb.IsClientError(err) {
 			resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
@@ -458,40 +556,250 @@ func (h *Handler) serveWriteJSON(w http.ResponseWriter, r *http.Request, body []
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// serveWriteLine receives incoming series data in line protocol format and writes it to the database.
-func (h *Handler) serveWriteLine(w http.ResponseWriter, r *http.Request, body []byte, user *meta.UserInfo) {
-	// Some clients may not set the content-type header appropriately and send JSON with a non-json
-	// content-type.  If the body looks JSON, try to handle it as as JSON instead
-	if len(body) > 0 {
-		var i int
-		for {
-			// JSON requests must start w/ an opening bracket
-			if body[i] == '{' {
-				h.serveWriteJSON(w, r, body, user)
-				return
-			}
+// serveWriteLine receives incoming series data in line protocol format, or as CSV or JSON lines records
+// with the format parameter, and writes it to the database. The body is parsed incrementally and the
+// points are written in batches as they are read. Lines which fail to parse are reported but don't
+// prevent the other lines from being written.
+func (h *Handler) serveWriteLine(w http.ResponseWriter, r *http.Request, body io.Reader, user *meta.UserInfo) {
+	// Parameters are only read from the URL, as parsing a form would read the body.
+	q := r.URL.Query()
 
-			// check that the byte is in the standard ascii code range
-			if body[i] > 32 || i >= len(body)-1 {
-				break
-			}
-			i++
-		}
+	database := q.Get("db")
+	if database == "" {
+		resultError(w, influxql.Result{Err: fmt.Errorf("database is required")}, http.StatusBadRequest)
+		return
 	}
 
-	precision := r.FormValue("precision")
+	di, err := h.MetaStore.Database(database)
+	if err != nil {
+		resultError(w, influxql.Result{Err: fmt.Errorf("metastore database error: %s", err)}, http.StatusInternalServerError)
+		return
+	} else if di == nil {
+		resultError(w, influxql.Result{Err: fmt.Errorf("database not found: %q", database)}, http.StatusNotFound)
+		return
+	}
+
+	if h.requireAuthentication && user == nil {
+		resultError(w, influxql.Result{Err: fmt.Errorf("user is required to write to database %q", database)}, http.StatusUnauthorized)
+		return
+	}
+
+	precision := q.Get("precision")
 	if precision == "" {
 		precision = "n"
 	}
 
-	points, parseError := models.ParsePointsWithPrecision(body, time.Now().UTC(), precision)
-	// Not points parsed correctly so return the error now
-	if parseError != nil && len(points) == 0 {
-		if parseError.Error() == "EOF" {
-			w.WriteHeader(http.StatusOK)
+	// Determine required consistency level.
+	consistency := cluster.ConsistencyLevelOne
+	switch q.Get("consistency") {
+	case "all":
+		consistency = cluster.ConsistencyLevelAll
+	case "any":
+		consistency = cluster.ConsistencyLevelAny
+	case "one":
+		consistency = cluster.ConsistencyLevelOne
+	case "quorum":
+		consistency = cluster.ConsistencyLevelQuorum
+	}
+
+	cr := &countingReader{r: body}
+	var pr interface {
+		ReadPoint() (models.Point, error)
+	}
+	switch format := q.Get("format"); format {
+	case "", "line":
+		pr = models.NewPointReader(cr, time.Now().UTC(), precision)
+	default:
+		// Records are mapped to points with the mapping parameters.
+		m, err := records.ParseMapping(q.Get("measurement"), q.Get("tags"), q.Get("fields"),
+			q.Get("time-column"), q.Get("time-layout"))
+		if err != nil {
+			resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
+			return
+		}
+		if pr, err = records.NewReader(format, cr, m, time.Now().UTC()); err != nil {
+			resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	batchSize := h.WriteBatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultWriteBatchSize
+	}
+
+	var (
+		written int         // points written so far
+		size    int         // bytes read when the last batch was written
+		errs    writeErrors // lines which failed to parse and points dropped
+	)
+	writeBatch := func(points []models.Point) (int, error) {
+		defer func() { size = cr.n }()
+		if h.WriteTrace {
+			h.Logger.Printf("write batch received by handler: %d points, %d bytes", len(points), cr.n-size)
+		}
+
+		if h.requireAuthentication {
+			if err := authorizeWrite(user, di, q.Get("rp"), points); err != nil {
+				return http.StatusUnauthorized, err
+			}
+		}
+
+		if err := h.RateLimiter.Write(user, database, len(points), cr.n-size); err != nil {
+			h.statMap.Add(statWriteRateLimited, 1)
+			return StatusTooManyRequests, err
+		}
+
+		if err := h.PointsWriter.WritePoints(&cluster.WritePointsRequest{
+			Database:         database,
+			RetentionPolicy:  q.Get("rp"),
+			ConsistencyLevel: consistency,
+			Points:           points,
+		}); influxdb.IsClientError(err) {
+			h.statMap.Add(statPointsWrittenFail, int64(len(points)))
+			return http.StatusBadRequest, err
+		} else if werr, ok := err.(tsdb.PartialWriteError); ok {
+			// Points over the cardinality limits were dropped, the rest were written.
+			h.statMap.Add(statPointsWrittenOK, int64(len(points)-werr.Dropped))
+			h.statMap.Add(statPointsWrittenFail, int64(werr.Dropped))
+			written += len(points) - werr.Dropped
+			errs.add(werr)
+		} else if err != nil {
+			h.statMap.Add(statPointsWrittenFail, int64(len(points)))
+			return http.StatusInternalServerError, err
+		} else {
+			h.statMap.Add(statPointsWrittenOK, int64(len(points)))
+			written += len(points)
+		}
+		return 0, nil
+	}
+
+	points := make([]models.Point, 0, batchSize)
+	for {
+		pt, err := pr.ReadPoint()
+		if err == io.EOF {
+			break
+		} else if err, ok := err.(*models.LineError); ok {
+			errs.add(err)
+			continue
+		} else if err != nil {
+			h.statMap.Add(statWriteRequestBytesReceived, int64(cr.n))
+			lineWriteError(w, written, err, http.StatusBadRequest)
+			return
+		}
+
+		if points = append(points, pt); len(points) < batchSize {
+			continue
+		}
+		if code, err := writeBatch(points); err != nil {
+			h.statMap.Add(statWriteRequestBytesReceived, int64(cr.n))
+			lineWriteError(w, written, err, code)
+			return
+		}
+		points = make([]models.Point, 0, batchSize)
+	}
+	h.statMap.Add(statWriteRequestBytesReceived, int64(cr.n))
+
+	if len(points) > 0 {
+		if code, err := writeBatch(points); err != nil {
+			lineWriteError(w, written, err, code)
 			return
 		}
-		resultError(w, influxql.Result{Err: parseError}, http.StatusBadRequest)
+	}
+
+	if errs.n > 0 {
+		// The client sent invalid line protocol or points were dropped. We return a 400
+		// response code as well as the errors, with the number of points written if any.
+		lineWriteError(w, written, &errs, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lineWriteError writes the error which stopped or ended a line protocol write,
+// making explicit that the points written before it were kept.
+func lineWriteError(w http.ResponseWriter, written int, err error, code int) {
+	if code == StatusTooManyRequests {
+		setRetryAfter(w, err)
+	}
+	if written > 0 {
+		err = fmt.Errorf("partial write: %d points written:\n%v", written, err)
+	}
+	resultError(w, influxql.Result{Err: err}, code)
+}
+
+// maxWriteErrors is the number of errors of a write reported to the client.
+const maxWriteErrors = 100
+
+// writeErrors collects the errors which don't stop a line protocol write.
+// Only the first errors are kept as a body may have millions of bad lines.
+type writeErrors struct {
+	errs []string
+	n    int
+}
+
+// add records an error.
+func (e *writeErrors) add(err error) {
+	if e.n++; len(e.errs) < maxWriteErrors {
+		e.errs = append(e.errs, err.Error())
+	}
+}
+
+// Error returns the text of the errors kept.
+func (e *writeErrors) Error() string {
+	s := strings.Join(e.errs, "\n")
+	if e.n > len(e.errs) {
+		s += fmt.Sprintf("\nand %d more errors", e.n-len(e.errs))
+	}
+	return s
+}
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+// Read implements the io.Reader interface.
+func (cr *countingReader) Read(b []byte) (n int, err error) {
+	n, err = cr.r.Read(b)
+	cr.n += n
+	return n, err
+}
+
+// servePromWrite receives a snappy compressed Prometheus write request and
+// writes its samples to the database.
+func (h *Handler) servePromWrite(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
+	h.statMap.Add(statWriteRequest, 1)
+	h.statMap.Add(statPromWriteRequest, 1)
+
+	compressed, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
+		return
+	}
+	h.statMap.Add(statWriteRequestBytesReceived, int64(len(compressed)))
+
+	b, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
+		return
+	}
+
+	var req remote.WriteRequest
+	if err := proto.Unmarshal(b, &req); err != nil {
+		resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
+		return
+	}
+
+	// Samples which can't be stored are dropped and the rest are written.
+	points, err := prometheus.WriteRequestToPoints(&req)
+	if err == prometheus.ErrNaNDropped {
+		if h.WriteTrace {
+			h.Logger.Printf("prom write handler: %s", err)
+		}
+	} else if err != nil {
+		resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
 		return
 	}
 
@@ -501,7 +809,8 @@ func (h *Handler) serveWriteLine(w http.ResponseWriter, r *http.Request, body []
 		return
 	}
 
-	if di, err := h.MetaStore.Database(database); err != nil {
+	di, err := h.MetaStore.Database(database)
+	if err != nil {
 		resultError(w, influxql.Result{Err: fmt.Errorf("metastore database error: %s", err)}, http.StatusInternalServerError)
 		return
 	} else if di == nil {
@@ -514,51 +823,162 @@ func (h *Handler) serveWriteLine(w http.ResponseWriter, r *http.Request, body []
 		return
 	}
 
-	if h.requireAuthentication && !user.Authorize(influxql.WritePrivilege, database) {
-		resultError(w, influxql.Result{Err: fmt.Errorf("%q user is not authorized to write to database %q", user.Name, database)}, http.StatusUnauthorized)
-		return
+	if h.requireAuthentication {
+		if err := authorizeWrite(user, di, r.FormValue("rp"), points); err != nil {
+			resultError(w, influxql.Result{Err: err}, http.StatusUnauthorized)
+			return
+		}
 	}
 
-	// Determine required consistency level.
-	consistency := cluster.ConsistencyLevelOne
-	switch r.Form.Get("consistency") {
-	case "all":
-		consistency = cluster.ConsistencyLevelAll
-	case "any":
-		consistency = cluster.ConsistencyLevelAny
-	case "one":
-		consistency = cluster.ConsistencyLevelOne
-	case "quorum":
-		consistency = cluster.ConsistencyLevelQuorum
+	if err := h.RateLimiter.Write(user, database, len(points), len(compressed)); err != nil {
+		h.statMap.Add(statWriteRateLimited, 1)
+		rateLimitError(w, err)
+		return
 	}
 
-	// Write points.
 	if err := h.PointsWriter.WritePoints(&cluster.WritePointsRequest{
 		Database:         database,
 		RetentionPolicy:  r.FormValue("rp"),
-		ConsistencyLevel: consistency,
+		ConsistencyLevel: cluster.ConsistencyLevelOne,
 		Points:           points,
 	}); influxdb.IsClientError(err) {
 		h.statMap.Add(statPointsWrittenFail, int64(len(points)))
 		resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
 		return
+	} else if werr, ok := err.(tsdb.PartialWriteError); ok {
+		h.statMap.Add(statPointsWrittenOK, int64(len(points)-werr.Dropped))
+		h.statMap.Add(statPointsWrittenFail, int64(werr.Dropped))
+		resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
+		return
 	} else if err != nil {
 		h.statMap.Add(statPointsWrittenFail, int64(len(points)))
 		resultError(w, influxql.Result{Err: err}, http.StatusInternalServerError)
 		return
-	} else if parseError != nil {
-		// We wrote some of the points
-		h.statMap.Add(statPointsWrittenOK, int64(len(points)))
-		// The other points failed to parse which means the client sent invalid line protocol.  We return a 400
-		// response code as well as the lines that failed to parse.
-		resultError(w, influxql.Result{Err: fmt.Errorf("partial write:\n%v", parseError)}, http.StatusBadRequest)
-		return
 	}
 
 	h.statMap.Add(statPointsWrittenOK, int64(len(points)))
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// servePromRead executes a snappy compressed Prometheus read request and
+// returns the matching time series.
+func (h *Handler) servePromRead(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
+	h.statMap.Add(statQueryRequest, 1)
+	h.statMap.Add(statPromReadRequest, 1)
+
+	compressed, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
+		return
+	}
+
+	b, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
+		return
+	}
+
+	var req remote.ReadRequest
+	if err := proto.Unmarshal(b, &req); err != nil {
+		resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
+		return
+	}
+
+	db := r.FormValue("db")
+	query, err := prometheus.ReadRequestToInfluxQLQuery(&req, db, r.FormValue("rp"))
+	if err != nil {
+		resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
+		return
+	}
+
+	// Check authorization.
+	if h.requireAuthentication {
+		if err := h.QueryExecutor.Authorize(user, query, db); err != nil {
+			resultError(w, influxql.Result{Err: fmt.Errorf("error authorizing query: %s", err)}, http.StatusUnauthorized)
+			return
+		}
+	}
+
+	// Check rate limits.
+	if err := h.RateLimiter.Query(user, db); err != nil {
+		h.statMap.Add(statQueryRateLimited, 1)
+		rateLimitError(w, err)
+		return
+	}
+
+	// Make sure if the client disconnects we signal the query to abort
+	closing := make(chan struct{})
+	if notifier, ok := w.(http.CloseNotifier); ok {
+		notify := notifier.CloseNotify()
+		go func() {
+			<-notify
+			close(closing)
+		}()
+	}
+
+	results, err := h.QueryExecutor.ExecuteQuery(query, db, user, DefaultChunkSize, closing)
+	if err != nil {
+		resultError(w, influxql.Result{Err: err}, http.StatusInternalServerError)
+		return
+	}
+
+	// Rows of the same series may be returned in several chunks.
+	var timeseries []*remote.TimeSeries
+	var last *models.Row
+	for result := range results {
+		if result == nil {
+			continue
+		} else if result.Err != nil {
+			resultError(w, influxql.Result{Err: result.Err}, http.StatusInternalServerError)
+			return
+		}
+
+		for _, row := range result.Series {
+			ts, err := prometheus.RowToTimeSeries(row)
+			if err != nil {
+				resultError(w, influxql.Result{Err: err}, http.StatusInternalServerError)
+				return
+			}
+
+			if last != nil && last.SameSeries(row) {
+				prev := timeseries[len(timeseries)-1]
+				prev.Samples = append(prev.Samples, ts.Samples...)
+			} else {
+				timeseries = append(timeseries, ts)
+			}
+			last = row
+		}
+	}
+
+	resp := &remote.ReadResponse{Results: []*remote.QueryResult{{Timeseries: timeseries}}}
+	data, err := proto.Marshal(resp)
+	if err != nil {
+		resultError(w, influxql.Result{Err: err}, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Header().Set("Content-Encoding", "snappy")
+	n, _ := w.Write(snappy.Encode(nil, data))
+	h.statMap.Add(statQueryRequestBytesTransmitted, int64(n))
+}
+
+// serveMetrics returns the statistics and diagnostics in the Prometheus text format.
+func (h *Handler) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	if h.Monitor == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.Monitor.WritePrometheus(&buf); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(buf.Bytes())
+}
+
 // serveOptions returns an empty response to comply with OPTIONS pre-flight requests
 func (h *Handler) serveOptions(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
@@ -679,6 +1099,20 @@ func resultError(w http.ResponseWriter, result influxql.Result, code int) {
 	_ = json.NewEncoder(w).Encode(&result)
 }
 
+// rateLimitError writes a rate limit error telling the client when to retry.
+func rateLimitError(w http.ResponseWriter, err error) {
+	setRetryAfter(w, err)
+	resultError(w, influxql.Result{Err: err}, StatusTooManyRequests)
+}
+
+// setRetryAfter sets the Retry-After header of a response to the delay of a
+// rate limit error, in whole seconds.
+func setRetryAfter(w http.ResponseWriter, err error) {
+	if err, ok := err.(*tsdb.RateLimitError); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+	}
+}
+
 // Filters and filter helpers
 
 // parseCredentials returns the username and password encoded in
@@ -721,19 +1155,7 @@ func authenticate(inner func(http.ResponseWriter, *http.Request, *meta.UserInfo)
 
 		// TODO corylanou: never allow this in the future without users
 		if requireAuthentication && len(uis) > 0 {
-			username, password, err := parseCredentials(r)
-			if err != nil {
-				h.statMap.Add(statAuthFail, 1)
-				httpError(w, err.Error(), false, http.StatusUnauthorized)
-				return
-			}
-			if username == "" {
-				h.statMap.Add(statAuthFail, 1)
-				httpError(w, "username required", false, http.StatusUnauthorized)
-				return
-			}
-
-			user, err = h.MetaStore.Authenticate(username, password)
+			user, err = h.authenticateRequest(r)
 			if err != nil {
 				h.statMap.Add(statAuthFail, 1)
 				httpError(w, err.Error(), false, http.StatusUnauthorized)
@@ -744,6 +1166,56 @@ func authenticate(inner func(http.ResponseWriter, *http.Request, *meta.UserInfo)
 	})
 }
 
+// authenticateRequest returns the user making the request. The user is taken
+// from the first authenticator with credentials in the request, otherwise
+// the request's username and password are checked against the meta store.
+func (h *Handler) authenticateRequest(r *http.Request) (*meta.UserInfo, error) {
+	for _, a := range h.Authenticators {
+		username, err := a.Authenticate(r)
+		if err == ErrNoCredentials {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		return h.authenticatedUser(username)
+	}
+
+	username, password, err := parseCredentials(r)
+	if err != nil {
+		return nil, err
+	}
+	if username == "" {
+		return nil, errors.New("username required")
+	}
+	return h.MetaStore.Authenticate(username, password)
+}
+
+// authenticatedUser returns the user identified by an authenticator,
+// creating it if users are provisioned.
+func (h *Handler) authenticatedUser(username string) (*meta.UserInfo, error) {
+	ui, err := h.MetaStore.User(username)
+	if err != nil {
+		return nil, err
+	} else if ui != nil {
+		return ui, nil
+	} else if !h.ProvisionUsers {
+		return nil, meta.ErrUserNotFound
+	}
+
+	// The user can only log in through the authenticator, so it is
+	// created with a random password.
+	password := make([]byte, 32)
+	if _, err := rand.Read(password); err != nil {
+		return nil, err
+	}
+	ui, err = h.MetaStore.CreateUser(username, hex.EncodeToString(password), false)
+	if err == meta.ErrUserExists {
+		// The user was created by a concurrent request.
+		return h.MetaStore.User(username)
+	}
+	return ui, err
+}
+
 type gzipResponseWriter struct {
 	io.Writer
 	http.ResponseWriter
@@ -911,6 +1383,28 @@ func (r *Response) Error() error {
 	return nil
 }
 
+// authorizeWrite returns an error if the user isn't authorized to write each
+// of the points, by a write privilege on the database or on the measurements.
+func authorizeWrite(user *meta.UserInfo, di *meta.DatabaseInfo, rp string, points []models.Point) error {
+	if user.Authorize(influxql.WritePrivilege, di.Name) {
+		return nil
+	}
+
+	if rp == "" {
+		rp = di.DefaultRetentionPolicy
+	}
+	for _, p := range points {
+		if !user.AuthorizeMeasurement(influxql.WritePrivilege, di.Name, rp, p.Name()) {
+			return fmt.Errorf("%q user is not authorized to write to measurement %q of database %q", user.Name, p.Name(), di.Name)
+		}
+	}
+
+	if len(points) == 0 {
+		return fmt.Errorf("%q user is not authorized to write to database %q", user.Name, di.Name)
+	}
+	return nil
+}
+
 // NormalizeBatchPoints returns a slice of Points, created by populating individual
 // points within the batch, which do not have times or tags, with the top-level
 // values.
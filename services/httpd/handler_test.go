@@ -6,18 +6,24 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
 	"github.com/influxdb/influxdb"
 	"github.com/influxdb/influxdb/client"
+	"github.com/influxdb/influxdb/cluster"
 	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
 	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/prometheus/remote"
 	"github.com/influxdb/influxdb/services/httpd"
 	"github.com/influxdb/influxdb/tsdb"
 )
@@ -136,7 +142,7 @@ func TestBatchWrite_UnmarshalRFC(t *testing.T) {
 // Ensure the handler returns results from a query (including nil results).
 func TestHandler_Query(t *testing.T) {
 	h := NewHandler(false)
-	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
 		if q.String() != `SELECT * FROM bar` {
 			t.Fatalf("unexpected query: %s", q.String())
 		} else if db != `foo` {
@@ -158,10 +164,101 @@ func TestHandler_Query(t *testing.T) {
 	}
 }
 
+// Ensure the handler authenticates queries with its authenticators.
+func TestHandler_Query_Authenticator(t *testing.T) {
+	h := NewHandler(true)
+	h.Authenticators = []httpd.Authenticator{&httpd.JWTAuthenticator{Secret: []byte("secret")}}
+	h.MetaStore.UsersFn = func() ([]meta.UserInfo, error) {
+		return []meta.UserInfo{{Name: "admin", Admin: true}}, nil
+	}
+	h.MetaStore.UserFn = func(name string) (*meta.UserInfo, error) {
+		if name == "admin" {
+			return &meta.UserInfo{Name: "admin", Admin: true}, nil
+		}
+		return nil, nil
+	}
+	h.QueryExecutor.AuthorizeFn = func(u *meta.UserInfo, q *influxql.Query, db string) error {
+		if u == nil || u.Name != "admin" {
+			t.Fatalf("unexpected user: %#v", u)
+		}
+		return nil
+	}
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+		return NewResultChan(&influxql.Result{StatementID: 1}), nil
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+	r := MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar", nil)
+	r.Header.Set("Authorization", "Bearer "+MustSignJWT("HS256", []byte("secret"), map[string]interface{}{"username": "admin", "exp": exp}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+
+	// Users who don't exist are rejected unless they are provisioned.
+	r.Header.Set("Authorization", "Bearer "+MustSignJWT("HS256", []byte("secret"), map[string]interface{}{"username": "susy", "exp": exp}))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"error":"user not found"}` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+
+	// Invalid credentials aren't passed on to the meta store.
+	r.Header.Set("Authorization", "Bearer "+MustSignJWT("HS256", []byte("wrong"), map[string]interface{}{"username": "admin", "exp": exp}))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"error":"invalid token signature"}` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure the handler creates non-admin users identified by its authenticators.
+func TestHandler_Query_ProvisionUsers(t *testing.T) {
+	h := NewHandler(true)
+	h.ProvisionUsers = true
+	h.Authenticators = []httpd.Authenticator{&httpd.JWTAuthenticator{Secret: []byte("secret")}}
+	h.MetaStore.UsersFn = func() ([]meta.UserInfo, error) {
+		return []meta.UserInfo{{Name: "admin", Admin: true}}, nil
+	}
+	h.MetaStore.UserFn = func(name string) (*meta.UserInfo, error) { return nil, nil }
+	h.MetaStore.CreateUserFn = func(name, password string, admin bool) (*meta.UserInfo, error) {
+		if name != "susy" {
+			t.Fatalf("unexpected name: %s", name)
+		} else if password == "" {
+			t.Fatal("expected password")
+		} else if admin {
+			t.Fatal("unexpected admin user")
+		}
+		return &meta.UserInfo{Name: name}, nil
+	}
+	h.QueryExecutor.AuthorizeFn = func(u *meta.UserInfo, q *influxql.Query, db string) error {
+		if u == nil || u.Name != "susy" {
+			t.Fatalf("unexpected user: %#v", u)
+		}
+		return nil
+	}
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+		return NewResultChan(&influxql.Result{StatementID: 1}), nil
+	}
+
+	r := MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar", nil)
+	r.Header.Set("Authorization", "Bearer "+MustSignJWT("HS256", []byte("secret"), map[string]interface{}{"username": "susy", "exp": time.Now().Add(time.Hour).Unix()}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+}
+
 // Ensure the handler returns results from a query (including nil results).
 func TestHandler_QueryRegex(t *testing.T) {
 	h := NewHandler(false)
-	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
 		if q.String() != `SELECT * FROM test WHERE url =~ /http\:\/\/www.akamai\.com/` {
 			t.Fatalf("unexpected query: %s", q.String())
 		} else if db != `test` {
@@ -179,7 +276,7 @@ func TestHandler_QueryRegex(t *testing.T) {
 // Ensure the handler merges results from the same statement.
 func TestHandler_Query_MergeResults(t *testing.T) {
 	h := NewHandler(false)
-	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
 		return NewResultChan(
 			&influxql.Result{StatementID: 1, Series: models.Rows([]*models.Row{{Name: "series0"}})},
 			&influxql.Result{StatementID: 1, Series: models.Rows([]*models.Row{{Name: "series1"}})},
@@ -198,7 +295,7 @@ func TestHandler_Query_MergeResults(t *testing.T) {
 // Ensure the handler merges results from the same statement.
 func TestHandler_Query_MergeEmptyResults(t *testing.T) {
 	h := NewHandler(false)
-	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
 		return NewResultChan(
 			&influxql.Result{StatementID: 1, Series: models.Rows{}},
 			&influxql.Result{StatementID: 1, Series: models.Rows([]*models.Row{{Name: "series1"}})},
@@ -217,7 +314,7 @@ func TestHandler_Query_MergeEmptyResults(t *testing.T) {
 // Ensure the handler can parse chunked and chunk size query parameters.
 func TestHandler_Query_Chunked(t *testing.T) {
 	h := NewHandler(false)
-	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
 		if chunkSize != 2 {
 			t.Fatalf("unexpected chunk size: %d", chunkSize)
 		}
@@ -277,7 +374,7 @@ func TestHandler_Query_ErrInvalidQuery(t *testing.T) {
 // Ensure the handler returns a status 500 if an error is returned from the query executor.
 func TestHandler_Query_ErrExecuteQuery(t *testing.T) {
 	h := NewHandler(false)
-	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
 		return nil, errors.New("marker")
 	}
 
@@ -291,7 +388,7 @@ func TestHandler_Query_ErrExecuteQuery(t *testing.T) {
 // Ensure the handler returns a status 200 if an error is returned in the result.
 func TestHandler_Query_ErrResult(t *testing.T) {
 	h := NewHandler(false)
-	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
 		return NewResultChan(&influxql.Result{Err: errors.New("measurement not found")}), nil
 	}
 
@@ -304,6 +401,288 @@ func TestHandler_Query_ErrResult(t *testing.T) {
 	}
 }
 
+// Ensure the handler authorizes each point written by measurement privileges.
+func TestHandler_Write_MeasurementPrivileges(t *testing.T) {
+	h := NewHandler(true)
+	h.MetaStore.UsersFn = func() ([]meta.UserInfo, error) {
+		return []meta.UserInfo{{Name: "susy"}}, nil
+	}
+	h.MetaStore.AuthenticateFn = func(username, password string) (*meta.UserInfo, error) {
+		return &meta.UserInfo{
+			Name: "susy",
+			MeasurementPrivileges: []meta.MeasurementPrivilege{
+				{Database: "foo", RetentionPolicy: "default", Measurement: "cpu", Privilege: influxql.WritePrivilege},
+			},
+		}, nil
+	}
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return &meta.DatabaseInfo{Name: name, DefaultRetentionPolicy: "default"}, nil
+	}
+
+	var written int
+	h.PointsWriter.WritePointsFn = func(p *cluster.WritePointsRequest) error {
+		written += len(p.Points)
+		return nil
+	}
+
+	for i, tt := range []struct {
+		url  string
+		body string
+		code int
+	}{
+		{url: "/write?db=foo&u=susy&p=pass", body: "cpu value=1\ncpu value=2", code: http.StatusNoContent},
+		{url: "/write?db=foo&rp=default&u=susy&p=pass", body: "cpu value=1", code: http.StatusNoContent},
+		{url: "/write?db=foo&rp=other&u=susy&p=pass", body: "cpu value=1", code: http.StatusUnauthorized},
+		{url: "/write?db=foo&u=susy&p=pass", body: "cpu value=1\nmem value=2", code: http.StatusUnauthorized},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, MustNewRequest("POST", tt.url, strings.NewReader(tt.body)))
+		if w.Code != tt.code {
+			t.Errorf("%d. unexpected status: %d: %s", i, w.Code, w.Body.String())
+		}
+	}
+
+	if written != 3 {
+		t.Fatalf("unexpected points written: %d", written)
+	}
+}
+
+// Ensure the handler writes line protocol in batches and reports the lines
+// which failed to parse.
+func TestHandler_Write_LineProtocol_Batches(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return &meta.DatabaseInfo{Name: name}, nil
+	}
+	h.WriteBatchSize = 2
+
+	var batches []int
+	h.PointsWriter.WritePointsFn = func(p *cluster.WritePointsRequest) error {
+		batches = append(batches, len(p.Points))
+		return nil
+	}
+
+	body := "cpu value=1\ncpu value=2\ncpu value=\ncpu value=3\ncpu value=4\ncpu value=5"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo", strings.NewReader(body)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if !strings.Contains(w.Body.String(), `partial write: 5 points written:\nline 3: unable to parse 'cpu value='`) {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	} else if !reflect.DeepEqual(batches, []int{2, 2, 1}) {
+		t.Fatalf("unexpected batches: %v", batches)
+	}
+
+	// Form encoded bodies are streamed too.
+	batches = nil
+	req := MustNewRequest("POST", "/write?db=foo", strings.NewReader("cpu value=1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if !reflect.DeepEqual(batches, []int{1}) {
+		t.Fatalf("unexpected batches: %v", batches)
+	}
+
+	// A body without any valid line isn't a partial write.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo", strings.NewReader("cpu value=")))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if !strings.HasPrefix(w.Body.String(), `{"error":"line 1: unable to parse 'cpu value='`) {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure the handler writes CSV records with a column mapping.
+func TestHandler_Write_CSV(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return &meta.DatabaseInfo{Name: name}, nil
+	}
+
+	var written []models.Point
+	h.PointsWriter.WritePointsFn = func(p *cluster.WritePointsRequest) error {
+		written = append(written, p.Points...)
+		return nil
+	}
+
+	body := "time,host,value\n1420070400,serverA,1\n1420070401,serverB,2\n"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo&format=csv&measurement=cpu&tags=host&time-column=time&time-layout=unix", strings.NewReader(body)))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if len(written) != 2 {
+		t.Fatalf("unexpected points written: %d", len(written))
+	} else if s := written[1].String(); s != "cpu,host=serverB value=2 1420070401000000000" {
+		t.Fatalf("unexpected point: %s", s)
+	}
+
+	// The measurement of the records is required.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo&format=csv", strings.NewReader(body)))
+	if w.Code != http.StatusBadRequest || w.Body.String() != "{\"error\":\"measurement is required\"}\n" {
+		t.Fatalf("unexpected response: %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// Ensure the handler rejects writes over the rate limits with a 429.
+func TestHandler_Write_RateLimited(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return &meta.DatabaseInfo{Name: name}, nil
+	}
+	h.PointsWriter.WritePointsFn = func(p *cluster.WritePointsRequest) error { return nil }
+	h.RateLimiter = tsdb.NewRateLimiter(tsdb.RateLimitConfig{DatabasePointsPerSecond: 2})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo", strings.NewReader("cpu value=1\ncpu value=2")))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo", strings.NewReader("cpu value=3")))
+	if w.Code != httpd.StatusTooManyRequests {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if w.Header().Get("Retry-After") != "1" {
+		t.Fatalf("unexpected Retry-After: %q", w.Header().Get("Retry-After"))
+	}
+
+	// Other databases have their own limits.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=bar", strings.NewReader("cpu value=3")))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// Ensure the handler writes the samples of a Prometheus write request.
+func TestHandler_PromWrite(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return &meta.DatabaseInfo{Name: name}, nil
+	}
+
+	var written []models.Point
+	h.PointsWriter.WritePointsFn = func(p *cluster.WritePointsRequest) error {
+		if p.Database != "foo" || p.RetentionPolicy != "bar" {
+			t.Fatalf("unexpected database/retention policy: %s/%s", p.Database, p.RetentionPolicy)
+		}
+		written = p.Points
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewPromRequest("/api/v1/prom/write?db=foo&rp=bar", &remote.WriteRequest{
+		Timeseries: []*remote.TimeSeries{
+			{
+				Labels: []*remote.LabelPair{
+					{Name: "__name__", Value: "cpu"},
+					{Name: "host", Value: "serverA"},
+				},
+				Samples: []*remote.Sample{
+					{Value: 1.5, TimestampMs: 1000},
+					{Value: math.NaN(), TimestampMs: 2000},
+				},
+			},
+		},
+	}))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if len(written) != 1 {
+		t.Fatalf("unexpected points written: %v", written)
+	} else if s := written[0].String(); s != "cpu,host=serverA value=1.5 1000000000" {
+		t.Fatalf("unexpected point: %s", s)
+	}
+}
+
+// Ensure the handler returns the series matching a Prometheus read request.
+func TestHandler_PromRead(t *testing.T) {
+	h := NewHandler(false)
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+		if q.String() != `SELECT value FROM foo.bar.cpu WHERE time >= '1970-01-01T00:00:01Z' AND time <= '1970-01-01T00:00:02Z' AND host =~ /^(?:server.*)$/ GROUP BY *` {
+			t.Fatalf("unexpected query: %s", q.String())
+		} else if db != `foo` {
+			t.Fatalf("unexpected db: %s", db)
+		}
+		return NewResultChan(
+			&influxql.Result{Series: models.Rows([]*models.Row{
+				{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Columns: []string{"time", "value"}, Values: [][]interface{}{{time.Unix(1, 0), 1.5}}},
+			})},
+			&influxql.Result{Series: models.Rows([]*models.Row{
+				{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Columns: []string{"time", "value"}, Values: [][]interface{}{{time.Unix(2, 0), int64(2)}}},
+				{Name: "cpu", Tags: map[string]string{"host": "serverB"}, Columns: []string{"time", "value"}, Values: [][]interface{}{{time.Unix(1, 0), 3.5}}},
+			})},
+		), nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewPromRequest("/api/v1/prom/read?db=foo&rp=bar", &remote.ReadRequest{
+		Queries: []*remote.Query{{
+			StartTimestampMs: 1000,
+			EndTimestampMs:   2000,
+			Matchers: []*remote.LabelMatcher{
+				{Type: remote.MatchType_EQUAL, Name: "__name__", Value: "cpu"},
+				{Type: remote.MatchType_REGEX_MATCH, Name: "host", Value: "server.*"},
+			},
+		}},
+	}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+
+	b, err := snappy.Decode(nil, w.Body.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp remote.ReadResponse
+	if err := proto.Unmarshal(b, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if exp := []*remote.TimeSeries{
+		{
+			Labels:  []*remote.LabelPair{{Name: "__name__", Value: "cpu"}, {Name: "host", Value: "serverA"}},
+			Samples: []*remote.Sample{{Value: 1.5, TimestampMs: 1000}, {Value: 2, TimestampMs: 2000}},
+		},
+		{
+			Labels:  []*remote.LabelPair{{Name: "__name__", Value: "cpu"}, {Name: "host", Value: "serverB"}},
+			Samples: []*remote.Sample{{Value: 3.5, TimestampMs: 1000}},
+		},
+	}; len(resp.Results) != 1 || !reflect.DeepEqual(resp.Results[0].Timeseries, exp) {
+		t.Fatalf("unexpected response: %s", resp.String())
+	}
+}
+
+// Ensure the handler serves the metrics written by the monitor.
+func TestHandler_Metrics(t *testing.T) {
+	h := NewHandler(false)
+
+	// Metrics aren't served without a monitor.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+
+	h.Handler.Monitor = &HandlerMonitor{
+		WritePrometheusFn: func(w io.Writer) error {
+			_, err := io.WriteString(w, "# TYPE influxdb_httpd_req_total counter\ninfluxdb_httpd_req_total 1\n")
+			return err
+		},
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if ct := w.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4" {
+		t.Fatalf("unexpected content type: %s", ct)
+	} else if w.Body.String() != "# TYPE influxdb_httpd_req_total counter\ninfluxdb_httpd_req_total 1\n" {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
 // Ensure the handler handles ping requests correctly.
 func TestHandler_Ping(t *testing.T) {
 	h := NewHandler(false)
@@ -470,6 +849,7 @@ type Handler struct {
 	*httpd.Handler
 	MetaStore     HandlerMetaStore
 	QueryExecutor HandlerQueryExecutor
+	PointsWriter  HandlerPointsWriter
 	TSDBStore     HandlerTSDBStore
 }
 
@@ -481,6 +861,7 @@ func NewHandler(requireAuthentication bool) *Handler {
 	}
 	h.Handler.MetaStore = &h.MetaStore
 	h.Handler.QueryExecutor = &h.QueryExecutor
+	h.Handler.PointsWriter = &h.PointsWriter
 	h.Handler.Version = "0.0.0"
 	return h
 }
@@ -490,7 +871,9 @@ type HandlerMetaStore struct {
 	WaitForLeaderFn func(d time.Duration) error
 	DatabaseFn      func(name string) (*meta.DatabaseInfo, error)
 	AuthenticateFn  func(username, password string) (ui *meta.UserInfo, err error)
+	UserFn          func(name string) (*meta.UserInfo, error)
 	UsersFn         func() ([]meta.UserInfo, error)
+	CreateUserFn    func(name, password string, admin bool) (*meta.UserInfo, error)
 }
 
 func (s *HandlerMetaStore) WaitForLeader(d time.Duration) error {
@@ -509,22 +892,48 @@ func (s *HandlerMetaStore) Authenticate(username, password string) (ui *meta.Use
 	return s.AuthenticateFn(username, password)
 }
 
+func (s *HandlerMetaStore) User(name string) (*meta.UserInfo, error) {
+	return s.UserFn(name)
+}
+
 func (s *HandlerMetaStore) Users() ([]meta.UserInfo, error) {
 	return s.UsersFn()
 }
 
+func (s *HandlerMetaStore) CreateUser(name, password string, admin bool) (*meta.UserInfo, error) {
+	return s.CreateUserFn(name, password, admin)
+}
+
 // HandlerQueryExecutor is a mock implementation of Handler.QueryExecutor.
 type HandlerQueryExecutor struct {
 	AuthorizeFn    func(u *meta.UserInfo, q *influxql.Query, db string) error
-	ExecuteQueryFn func(q *influxql.Query, db string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error)
+	ExecuteQueryFn func(q *influxql.Query, db string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error)
 }
 
 func (e *HandlerQueryExecutor) Authorize(u *meta.UserInfo, q *influxql.Query, db string) error {
 	return e.AuthorizeFn(u, q, db)
 }
 
-func (e *HandlerQueryExecutor) ExecuteQuery(q *influxql.Query, db string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
-	return e.ExecuteQueryFn(q, db, chunkSize, closing)
+func (e *HandlerQueryExecutor) ExecuteQuery(q *influxql.Query, db string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+	return e.ExecuteQueryFn(q, db, user, chunkSize, closing)
+}
+
+// HandlerPointsWriter is a mock implementation of Handler.PointsWriter.
+type HandlerPointsWriter struct {
+	WritePointsFn func(p *cluster.WritePointsRequest) error
+}
+
+func (w *HandlerPointsWriter) WritePoints(p *cluster.WritePointsRequest) error {
+	return w.WritePointsFn(p)
+}
+
+// HandlerMonitor is a mock implementation of Handler.Monitor.
+type HandlerMonitor struct {
+	WritePrometheusFn func(w io.Writer) error
+}
+
+func (m *HandlerMonitor) WritePrometheus(w io.Writer) error {
+	return m.WritePrometheusFn(w)
 }
 
 // HandlerTSDBStore is a mock implementation of Handler.TSDBStore
@@ -552,6 +961,19 @@ func MustNewJSONRequest(method, urlStr string, body io.Reader) *http.Request {
 	return r
 }
 
+// MustNewPromRequest returns a new HTTP request with a snappy compressed
+// protobuf body. Panic on error.
+func MustNewPromRequest(urlStr string, msg proto.Message) *http.Request {
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	r := MustNewRequest("POST", urlStr, bytes.NewReader(snappy.Encode(nil, b)))
+	r.Header.Set("Content-Type", "application/x-protobuf")
+	r.Header.Set("Content-Encoding", "snappy")
+	return r
+}
+
 // matchRegex returns true if a s matches pattern.
 func matchRegex(pattern, s string) bool {
 	return regexp.MustCompile(pattern).MatchString(s)
@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"expvar"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
@@ -25,6 +26,10 @@ const (
 	statPointsWrittenOK              = "pointsWrittenOK"   // Number of points written OK
 	statPointsWrittenFail            = "pointsWrittenFail" // Number of points that failed to be written
 	statAuthFail                     = "authFail"          // Number of authentication failures
+	statPromWriteRequest             = "promWriteReq"      // Number of Prometheus write requests served
+	statPromReadRequest              = "promReadReq"       // Number of Prometheus read requests served
+	statWriteRateLimited             = "writeRateLimited"  // Number of write requests rejected by rate limits
+	statQueryRateLimited             = "queryRateLimited"  // Number of query requests rejected by rate limits
 )
 
 // Service manages the listener and handler for an HTTP endpoint.
@@ -35,6 +40,8 @@ type Service struct {
 	cert  string
 	err   chan error
 
+	config Config
+
 	Handler *Handler
 
 	Logger  *log.Logger
@@ -54,6 +61,8 @@ func NewService(c Config) *Service {
 		https: c.HTTPSEnabled,
 		cert:  c.HTTPSCertificate,
 		err:   make(chan error),
+
+		config: c,
 		Handler: NewHandler(
 			c.AuthEnabled,
 			c.LogEnabled,
@@ -63,6 +72,8 @@ func NewService(c Config) *Service {
 		Logger: log.New(os.Stderr, "[httpd] ", log.LstdFlags),
 	}
 	s.Handler.Logger = s.Logger
+	s.Handler.ProvisionUsers = c.ProvisionUsers
+	s.Handler.WriteBatchSize = c.WriteBatchSize
 	return s
 }
 
@@ -71,6 +82,13 @@ func (s *Service) Open() error {
 	s.Logger.Println("Starting HTTP service")
 	s.Logger.Println("Authentication enabled:", s.Handler.requireAuthentication)
 
+	// Add the authenticators in the configuration.
+	authenticators, err := newAuthenticators(s.config)
+	if err != nil {
+		return err
+	}
+	s.Handler.Authenticators = append(authenticators, s.Handler.Authenticators...)
+
 	// Open listener.
 	if s.https {
 		cert, err := tls.LoadX509KeyPair(s.cert, s.cert)
@@ -126,6 +144,31 @@ func (s *Service) Addr() net.Addr {
 	return nil
 }
 
+// newAuthenticators returns the authenticators enabled in the configuration.
+func newAuthenticators(c Config) ([]Authenticator, error) {
+	var a []Authenticator
+	if c.JWTSharedSecret != "" || c.JWTPublicKey != "" {
+		jwt := &JWTAuthenticator{
+			Secret:        []byte(c.JWTSharedSecret),
+			UsernameClaim: c.JWTUsernameClaim,
+		}
+		if c.JWTPublicKey != "" {
+			b, err := ioutil.ReadFile(c.JWTPublicKey)
+			if err != nil {
+				return nil, err
+			}
+			if jwt.PublicKey, err = ParseRSAPublicKey(b); err != nil {
+				return nil, fmt.Errorf("jwt public key: %s", err)
+			}
+		}
+		a = append(a, jwt)
+	}
+	if c.LDAPEnabled {
+		a = append(a, &LDAPAuthenticator{URL: c.LDAPURL, BindDN: c.LDAPBindDN})
+	}
+	return a, nil
+}
+
 // serve serves the handler from the listener.
 func (s *Service) serve() {
 	// The listener was closed so exit
@@ -42,6 +42,14 @@ type Config struct {
 	BatchPending     int           `toml:"batch-pending"`
 	BatchTimeout     toml.Duration `toml:"batch-timeout"`
 	LogPointErrors   bool          `toml:"log-point-errors"`
+
+	// RateLimited subjects the points received to the per-database limits
+	// of the [rate-limits] section.
+	RateLimited bool `toml:"rate-limited"`
+
+	// QueryEnabled serves the /api/query and /api/suggest endpoints. They
+	// aren't authenticated, so anyone able to connect can read the database.
+	QueryEnabled bool `toml:"query-enabled"`
 }
 
 // NewConfig returns a new config for the service.
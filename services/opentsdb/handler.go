@@ -8,13 +8,28 @@ import (
 	"expvar"
 	"io"
 	"log"
+	"math"
 	"net"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/influxdb/influxdb"
 	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/meta"
 	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/tsdb"
+)
+
+const (
+	// StatusTooManyRequests is the HTTP status returned for requests over a rate limit.
+	StatusTooManyRequests = 429
+
+	// DefaultSuggestLimit is the default number of values returned by /api/suggest.
+	DefaultSuggestLimit = 25
 )
 
 // Handler is an http.Handler for the service.
@@ -27,6 +42,20 @@ type Handler struct {
 		WritePoints(p *cluster.WritePointsRequest) error
 	}
 
+	QueryExecutor interface {
+		ExecuteQuery(query *influxql.Query, database string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error)
+	}
+
+	TSDBStore interface {
+		DatabaseIndex(name string) *tsdb.DatabaseIndex
+	}
+
+	// RateLimiter rejects the writes and queries over the limits of the database.
+	RateLimiter *tsdb.RateLimiter
+
+	// QueryEnabled serves the /api/query and /api/suggest endpoints.
+	QueryEnabled bool
+
 	Logger *log.Logger
 
 	statMap *expvar.Map
@@ -38,6 +67,19 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNoContent)
 	case "/api/put":
 		h.servePut(w, r)
+	case "/api/query", "/api/suggest":
+		// Reads aren't authenticated, so they must be enabled explicitly.
+		if !h.QueryEnabled {
+			httpError(w, "queries are disabled", http.StatusForbidden)
+			return
+		}
+		if r.URL.Path == "/api/query" {
+			h.serveQuery(w, r)
+		} else {
+			h.serveSuggest(w, r)
+		}
+	case "/api/aggregators":
+		h.serveAggregators(w, r)
 	default:
 		http.NotFound(w, r)
 	}
@@ -53,10 +95,13 @@ func (h *Handler) servePut(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Count the bytes received for rate limiting.
+	body := &countingReader{r: r.Body}
+
 	// Wrap reader if it's gzip encoded.
 	var br *bufio.Reader
 	if r.Header.Get("Content-Encoding") == "gzip" {
-		zr, err := gzip.NewReader(r.Body)
+		zr, err := gzip.NewReader(body)
 		if err != nil {
 			http.Error(w, "could not read gzip, "+err.Error(), http.StatusBadRequest)
 			return
@@ -64,7 +109,7 @@ func (h *Handler) servePut(w http.ResponseWriter, r *http.Request) {
 
 		br = bufio.NewReader(zr)
 	} else {
-		br = bufio.NewReader(r.Body)
+		br = bufio.NewReader(body)
 	}
 
 	// Lookahead at the first byte.
@@ -122,6 +167,15 @@ func (h *Handler) servePut(w http.ResponseWriter, r *http.Request) {
 		points = append(points, pt)
 	}
 
+	if err := h.RateLimiter.Write(nil, h.Database, len(points), body.n); err != nil {
+		h.statMap.Add(statPointsRateLimited, int64(len(points)))
+		if err, ok := err.(*tsdb.RateLimitError); ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+		}
+		http.Error(w, err.Error(), StatusTooManyRequests)
+		return
+	}
+
 	// Write points.
 	if err := h.PointsWriter.WritePoints(&cluster.WritePointsRequest{
 		Database:         h.Database,
@@ -141,6 +195,245 @@ func (h *Handler) servePut(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// serveQuery implements OpenTSDB's HTTP /api/query endpoint. Each metric
+// query is translated into an InfluxQL statement selecting its series.
+func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	h.statMap.Add(statHTTPQueryRequests, 1)
+
+	// Read the request from the URL of GET requests or the body of POST requests.
+	var req *queryRequest
+	switch r.Method {
+	case "GET":
+		var err error
+		if req, err = parseQueryString(r.URL.Query()); err != nil {
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case "POST":
+		req = &queryRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			httpError(w, "json object decode error: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		httpError(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if req.Start == nil {
+		httpError(w, "missing start time", http.StatusBadRequest)
+		return
+	} else if len(req.Queries) == 0 {
+		httpError(w, "missing sub queries", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now().UTC()
+	start, err := parseTime(req.Start, now)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	end := now
+	if req.End != nil {
+		if end, err = parseTime(req.End, now); err != nil {
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if end.Before(start) {
+		httpError(w, "start time must be before end time", http.StatusBadRequest)
+		return
+	}
+
+	query := &influxql.Query{}
+	for _, q := range req.Queries {
+		stmt, err := q.SelectStatement(h.Database, h.RetentionPolicy, start, end)
+		if err != nil {
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		query.Statements = append(query.Statements, stmt)
+	}
+
+	if err := h.RateLimiter.Query(nil, h.Database); err != nil {
+		if err, ok := err.(*tsdb.RateLimitError); ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+		}
+		httpError(w, err.Error(), StatusTooManyRequests)
+		return
+	}
+
+	// Execute the statements and merge the rows of each statement.
+	closing := make(chan struct{})
+	defer close(closing)
+	ch, err := h.QueryExecutor.ExecuteQuery(query, h.Database, nil, 0, closing)
+	if err != nil {
+		h.statMap.Add(statHTTPQueryFail, 1)
+		httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rows := make([]rowSet, len(query.Statements))
+	for result := range ch {
+		if result.Err != nil {
+			if err == nil {
+				err = result.Err
+			}
+			continue
+		}
+		for _, row := range result.Series {
+			rows[result.StatementID].add(row)
+		}
+	}
+	if err != nil {
+		h.statMap.Add(statHTTPQueryFail, 1)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results := []*queryResult{}
+	for i, q := range req.Queries {
+		results = append(results, q.Results(rows[i].rows, req.MsResolution)...)
+	}
+	writeJSON(w, results)
+}
+
+// rowSet merges the rows of a statement by series. The rows are kept in the
+// order their series were first returned in.
+type rowSet struct {
+	rows  models.Rows
+	index map[string]*models.Row // rows by series key
+}
+
+// add appends a row to the set, or its values to the row of the same series.
+func (s *rowSet) add(row *models.Row) {
+	key := row.Name + string(models.Tags(row.Tags).HashKey())
+	if r, ok := s.index[key]; ok {
+		r.Values = append(r.Values, row.Values...)
+		return
+	}
+
+	if s.index == nil {
+		s.index = make(map[string]*models.Row)
+	}
+	s.index[key] = row
+	s.rows = append(s.rows, row)
+}
+
+// serveSuggest implements OpenTSDB's HTTP /api/suggest endpoint, suggesting
+// the measurements, tag keys or tag values of the database.
+func (h *Handler) serveSuggest(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	h.statMap.Add(statHTTPSuggestRequests, 1)
+
+	var req struct {
+		Type string `json:"type"`
+		Q    string `json:"q"`
+		Max  int    `json:"max"`
+	}
+	switch r.Method {
+	case "GET":
+		q := r.URL.Query()
+		req.Type, req.Q = q.Get("type"), q.Get("q")
+		if s := q.Get("max"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				httpError(w, "invalid max: "+s, http.StatusBadRequest)
+				return
+			}
+			req.Max = n
+		}
+	case "POST":
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httpError(w, "json object decode error: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		httpError(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if req.Max <= 0 {
+		req.Max = DefaultSuggestLimit
+	}
+
+	var measurements []*tsdb.Measurement
+	if index := h.TSDBStore.DatabaseIndex(h.Database); index != nil {
+		measurements = index.MeasurementsByName(index.MeasurementNames())
+	}
+
+	// Collect the distinct values of the requested type.
+	set := make(map[string]struct{})
+	switch req.Type {
+	case "metrics":
+		for _, m := range measurements {
+			set[m.Name] = struct{}{}
+		}
+	case "tagk", "tagv":
+		for _, m := range measurements {
+			for _, k := range m.TagKeys() {
+				if req.Type == "tagk" {
+					set[k] = struct{}{}
+					continue
+				}
+				for _, v := range m.TagValues(k) {
+					set[v] = struct{}{}
+				}
+			}
+		}
+	case "":
+		httpError(w, "missing type", http.StatusBadRequest)
+		return
+	default:
+		httpError(w, "invalid type: "+req.Type, http.StatusBadRequest)
+		return
+	}
+
+	values := []string{}
+	for v := range set {
+		if strings.HasPrefix(v, req.Q) {
+			values = append(values, v)
+		}
+	}
+	sort.Strings(values)
+	if len(values) > req.Max {
+		values = values[:req.Max]
+	}
+	writeJSON(w, values)
+}
+
+// serveAggregators implements OpenTSDB's HTTP /api/aggregators endpoint.
+func (h *Handler) serveAggregators(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(aggregators))
+	for name := range aggregators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	writeJSON(w, names)
+}
+
+// writeJSON writes v to the response as JSON.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// httpError writes an error to the response in the format of OpenTSDB.
+func httpError(w http.ResponseWriter, msg string, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	var resp struct {
+		Error struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	resp.Error.Code, resp.Error.Message = code, msg
+	json.NewEncoder(w).Encode(resp)
+}
+
 // chanListener represents a listener that receives connections through a channel.
 type chanListener struct {
 	addr net.Addr
@@ -182,6 +475,19 @@ type readerConn struct {
 // Read implements the io.Reader interface.
 func (conn *readerConn) Read(b []byte) (n int, err error) { return conn.r.Read(b) }
 
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+// Read implements the io.Reader interface.
+func (cr *countingReader) Read(b []byte) (n int, err error) {
+	n, err = cr.r.Read(b)
+	cr.n += n
+	return n, err
+}
+
 // point represents an incoming JSON data point.
 type point struct {
 	Metric string            `json:"metric"`
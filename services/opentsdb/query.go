@@ -0,0 +1,683 @@
+package opentsdb
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/models"
+)
+
+// Aggregators are the aggregation functions supported by /api/query, with
+// their InfluxQL equivalents used for downsampling.
+var aggregators = map[string]string{
+	"sum":    "sum",
+	"zimsum": "sum",
+	"avg":    "mean",
+	"min":    "min",
+	"mimmin": "min",
+	"max":    "max",
+	"mimmax": "max",
+	"count":  "count",
+	"dev":    "stddev",
+	"first":  "first",
+	"last":   "last",
+	"median": "median",
+	"p50":    "percentile",
+	"p75":    "percentile",
+	"p90":    "percentile",
+	"p95":    "percentile",
+	"p99":    "percentile",
+	"p999":   "percentile",
+	"none":   "",
+}
+
+// queryRequest represents an OpenTSDB /api/query request.
+type queryRequest struct {
+	Start        interface{} `json:"start"`
+	End          interface{} `json:"end"`
+	Queries      []*subQuery `json:"queries"`
+	MsResolution bool        `json:"msResolution"`
+}
+
+// subQuery represents a metric query of a /api/query request.
+type subQuery struct {
+	Aggregator  string            `json:"aggregator"`
+	Metric      string            `json:"metric"`
+	Rate        bool              `json:"rate"`
+	RateOptions rateOptions       `json:"rateOptions"`
+	Downsample  string            `json:"downsample"`
+	Tags        map[string]string `json:"tags"`
+	Filters     []*tagFilter      `json:"filters"`
+}
+
+// rateOptions represents the options of the rate of a sub query.
+type rateOptions struct {
+	Counter    bool    `json:"counter"`
+	CounterMax float64 `json:"counterMax"`
+	ResetValue float64 `json:"resetValue"`
+	DropResets bool    `json:"dropResets"`
+}
+
+// tagFilter represents a filter on the values of a tag.
+type tagFilter struct {
+	Type    string `json:"type"`
+	Tagk    string `json:"tagk"`
+	Filter  string `json:"filter"`
+	GroupBy bool   `json:"groupBy"`
+}
+
+// queryResult represents a series of a /api/query response.
+type queryResult struct {
+	Metric        string             `json:"metric"`
+	Tags          map[string]string  `json:"tags"`
+	AggregateTags []string           `json:"aggregateTags"`
+	DPs           map[string]float64 `json:"dps"`
+}
+
+// parseQueryString parses a /api/query request from the parameters of a GET
+// request, where each "m" parameter is a sub query in the format:
+//   aggregator:[rate[{counter[,counterMax[,resetValue]]}]:][downsample:]metric[{filters}][{filters}]
+func parseQueryString(params map[string][]string) (*queryRequest, error) {
+	req := &queryRequest{}
+	if v := params["start"]; len(v) > 0 {
+		req.Start = v[0]
+	}
+	if v := params["end"]; len(v) > 0 {
+		req.End = v[0]
+	}
+	if v := params["ms"]; len(v) > 0 {
+		req.MsResolution = v[0] == "" || v[0] == "true"
+	}
+
+	for _, m := range params["m"] {
+		parts := splitOutside(m, ':', '{', '}')
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid metric query: %q", m)
+		}
+
+		q := &subQuery{Aggregator: parts[0]}
+		for _, part := range parts[1 : len(parts)-1] {
+			if strings.HasPrefix(part, "rate") {
+				if err := q.parseRate(part); err != nil {
+					return nil, err
+				}
+			} else {
+				q.Downsample = part
+			}
+		}
+
+		// The metric is followed by the group by filters and other filters.
+		metric := parts[len(parts)-1]
+		for i, groupBy := 0, true; metric != ""; i++ {
+			start := strings.IndexByte(metric, '{')
+			if i == 0 {
+				if start < 0 {
+					q.Metric = metric
+					break
+				}
+				q.Metric, metric = metric[:start], metric[start:]
+				continue
+			}
+
+			end := strings.IndexByte(metric, '}')
+			if start != 0 || end < 0 || i > 2 {
+				return nil, fmt.Errorf("invalid metric query: %q", m)
+			}
+			for _, s := range splitOutside(metric[1:end], ',', '(', ')') {
+				if s == "" {
+					continue
+				}
+				kv := strings.SplitN(s, "=", 2)
+				if len(kv) != 2 {
+					return nil, fmt.Errorf("invalid filter %q in %q", s, m)
+				}
+				q.Filters = append(q.Filters, parseTagFilter(kv[0], kv[1], groupBy))
+			}
+			metric, groupBy = metric[end+1:], false
+		}
+		req.Queries = append(req.Queries, q)
+	}
+	return req, nil
+}
+
+// parseRate parses the rate of a sub query in the format:
+//   rate[{counter[,counterMax[,resetValue]]}]
+func (q *subQuery) parseRate(s string) error {
+	q.Rate = true
+	if s == "rate" {
+		return nil
+	} else if !strings.HasPrefix(s, "rate{") || !strings.HasSuffix(s, "}") {
+		return fmt.Errorf("invalid rate: %q", s)
+	}
+
+	opts := strings.Split(s[len("rate{"):len(s)-1], ",")
+	if opts[0] != "counter" && opts[0] != "dropcounter" {
+		return fmt.Errorf("invalid rate: %q", s)
+	}
+	q.RateOptions.Counter = true
+	q.RateOptions.DropResets = opts[0] == "dropcounter"
+
+	for i, p := range []*float64{&q.RateOptions.CounterMax, &q.RateOptions.ResetValue} {
+		if len(opts) <= i+1 || opts[i+1] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(opts[i+1], 64)
+		if err != nil {
+			return fmt.Errorf("invalid rate: %q", s)
+		}
+		*p = v
+	}
+	return nil
+}
+
+// splitOutside splits s by sep, except between the open and close runes.
+func splitOutside(s string, sep, open, close rune) []string {
+	var a []string
+	var depth, start int
+	for i, c := range s {
+		switch c {
+		case open:
+			depth++
+		case close:
+			depth--
+		case sep:
+			if depth == 0 {
+				a = append(a, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(a, s[start:])
+}
+
+// parseTagFilter parses a filter on the values of a tag, either in the
+// format type(filter) or as values separated by pipes, which may contain
+// wildcards.
+func parseTagFilter(tagk, s string, groupBy bool) *tagFilter {
+	f := &tagFilter{Tagk: tagk, GroupBy: groupBy}
+	if i := strings.IndexByte(s, '('); i > 0 && strings.HasSuffix(s, ")") {
+		f.Type, f.Filter = s[:i], s[i+1:len(s)-1]
+	} else if strings.Contains(s, "*") {
+		f.Type, f.Filter = "wildcard", s
+	} else {
+		f.Type, f.Filter = "literal_or", s
+	}
+	return f
+}
+
+// Condition returns the InfluxQL condition of the filter, or nil if the
+// filter matches any value.
+func (f *tagFilter) Condition() (influxql.Expr, error) {
+	if f.Tagk == "" {
+		return nil, errors.New("missing tagk of filter")
+	}
+	ref := &influxql.VarRef{Val: f.Tagk}
+	values := strings.Split(f.Filter, "|")
+
+	var pattern string
+	op := influxql.EQREGEX
+	switch f.Type {
+	case "literal_or", "not_literal_or":
+		var cond influxql.Expr
+		for _, v := range values {
+			expr := &influxql.BinaryExpr{Op: influxql.EQ, LHS: ref, RHS: &influxql.StringLiteral{Val: v}}
+			if f.Type == "not_literal_or" {
+				expr.Op = influxql.NEQ
+			}
+			if cond == nil {
+				cond = expr
+			} else if f.Type == "not_literal_or" {
+				cond = &influxql.BinaryExpr{Op: influxql.AND, LHS: cond, RHS: expr}
+			} else {
+				cond = &influxql.BinaryExpr{Op: influxql.OR, LHS: cond, RHS: expr}
+			}
+		}
+		return &influxql.ParenExpr{Expr: cond}, nil
+	case "iliteral_or", "not_iliteral_or":
+		for i, v := range values {
+			values[i] = regexp.QuoteMeta(v)
+		}
+		pattern = `(?i)^(` + strings.Join(values, "|") + `)$`
+		if f.Type == "not_iliteral_or" {
+			op = influxql.NEQREGEX
+		}
+	case "wildcard", "iwildcard":
+		if f.Filter == "*" {
+			return nil, nil
+		}
+		for i, v := range values {
+			nodes := strings.Split(v, "*")
+			for j := range nodes {
+				nodes[j] = regexp.QuoteMeta(nodes[j])
+			}
+			values[i] = strings.Join(nodes, ".*")
+		}
+		pattern = `^(` + strings.Join(values, "|") + `)$`
+		if f.Type == "iwildcard" {
+			pattern = `(?i)` + pattern
+		}
+	case "regexp":
+		pattern = f.Filter
+	default:
+		return nil, fmt.Errorf("unknown filter type: %q", f.Type)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q of tag %q: %s", f.Filter, f.Tagk, err)
+	}
+	return &influxql.BinaryExpr{Op: op, LHS: ref, RHS: &influxql.RegexLiteral{Val: re}}, nil
+}
+
+// filters returns the filters of the sub query, including its tags.
+func (q *subQuery) filters() []*tagFilter {
+	filters := append([]*tagFilter{}, q.Filters...)
+	keys := make([]string, 0, len(q.Tags))
+	for k := range q.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		filters = append(filters, parseTagFilter(k, q.Tags[k], true))
+	}
+	return filters
+}
+
+// SelectStatement returns the statement selecting the series of the sub
+// query between start and end, one row per series. The values are
+// downsampled by the statement, while rates and aggregation across series
+// are computed from its results.
+func (q *subQuery) SelectStatement(database, retentionPolicy string, start, end time.Time) (*influxql.SelectStatement, error) {
+	if q.Metric == "" {
+		return nil, errors.New("missing metric")
+	} else if _, ok := aggregators[q.Aggregator]; !ok {
+		return nil, fmt.Errorf("unknown aggregator: %q", q.Aggregator)
+	}
+
+	stmt := &influxql.SelectStatement{
+		Fields: influxql.Fields{{Expr: &influxql.VarRef{Val: "value"}}},
+		Sources: influxql.Sources{&influxql.Measurement{
+			Database:        database,
+			RetentionPolicy: retentionPolicy,
+			Name:            q.Metric,
+		}},
+		Dimensions: influxql.Dimensions{{Expr: &influxql.Wildcard{}}},
+	}
+
+	// Apply the downsampling in the format interval-function[-fill].
+	if q.Downsample != "" {
+		parts := strings.Split(q.Downsample, "-")
+		if len(parts) != 2 && len(parts) != 3 {
+			return nil, fmt.Errorf("invalid downsample: %q", q.Downsample)
+		}
+		interval, err := parseDuration(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid downsample: %q", q.Downsample)
+		}
+		call, err := aggregateCall(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		stmt.Fields[0].Expr = call
+		stmt.Dimensions = append(influxql.Dimensions{{Expr: &influxql.Call{
+			Name: "time",
+			Args: []influxql.Expr{&influxql.DurationLiteral{Val: interval}},
+		}}}, stmt.Dimensions...)
+		stmt.Fill = influxql.NoFill
+
+		if len(parts) == 3 {
+			switch parts[2] {
+			case "none", "nan", "null":
+			case "zero":
+				stmt.Fill, stmt.FillValue = influxql.NumberFill, float64(0)
+			default:
+				return nil, fmt.Errorf("invalid fill policy of downsample: %q", q.Downsample)
+			}
+		}
+	}
+
+	cond := influxql.Expr(&influxql.BinaryExpr{
+		Op:  influxql.AND,
+		LHS: &influxql.BinaryExpr{Op: influxql.GTE, LHS: &influxql.VarRef{Val: "time"}, RHS: &influxql.TimeLiteral{Val: start}},
+		RHS: &influxql.BinaryExpr{Op: influxql.LTE, LHS: &influxql.VarRef{Val: "time"}, RHS: &influxql.TimeLiteral{Val: end}},
+	})
+	for _, f := range q.filters() {
+		expr, err := f.Condition()
+		if err != nil {
+			return nil, err
+		} else if expr != nil {
+			cond = &influxql.BinaryExpr{Op: influxql.AND, LHS: cond, RHS: expr}
+		}
+	}
+	stmt.Condition = cond
+
+	return stmt, nil
+}
+
+// aggregateCall returns the InfluxQL call of an aggregator.
+func aggregateCall(name string) (*influxql.Call, error) {
+	fn := aggregators[name]
+	if fn == "" {
+		return nil, fmt.Errorf("unknown downsampling function: %q", name)
+	}
+
+	call := &influxql.Call{Name: fn, Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}
+	if fn == "percentile" {
+		call.Args = append(call.Args, &influxql.NumberLiteral{Val: percentileOf(name)})
+	}
+	return call, nil
+}
+
+// percentileOf returns the percentile of a pNN aggregator, where p999 is the
+// 99.9th percentile.
+func percentileOf(name string) float64 {
+	v, _ := strconv.ParseFloat(name[1:], 64)
+	for v > 100 {
+		v /= 10
+	}
+	return v
+}
+
+// series is a series of the results of a sub query.
+type series struct {
+	tags   map[string]string
+	times  []time.Time
+	values []float64
+}
+
+// Results aggregates the rows returned by the statement of the sub query.
+func (q *subQuery) Results(rows models.Rows, msResolution bool) []*queryResult {
+	var groupBy []string
+	for _, f := range q.filters() {
+		if f.GroupBy {
+			groupBy = append(groupBy, f.Tagk)
+		}
+	}
+
+	// Group the series by the values of the group by tags, skipping the
+	// series without them.
+	groups := make(map[string][]*series)
+	var keys []string
+	for i, row := range rows {
+		s := &series{tags: make(map[string]string)}
+		for k, v := range row.Tags {
+			if v != "" {
+				s.tags[k] = v
+			}
+		}
+
+		key := make([]string, 0, len(groupBy))
+		for _, k := range groupBy {
+			key = append(key, k+"="+s.tags[k])
+			if s.tags[k] == "" {
+				s = nil
+				break
+			}
+		}
+		if s == nil {
+			continue
+		}
+
+		for _, values := range row.Values {
+			if len(values) < 2 {
+				continue
+			}
+			t, ok := values[0].(time.Time)
+			if !ok {
+				continue
+			}
+			switch v := values[1].(type) {
+			case float64:
+				s.times, s.values = append(s.times, t), append(s.values, v)
+			case int64:
+				s.times, s.values = append(s.times, t), append(s.values, float64(v))
+			}
+		}
+		if q.Rate {
+			s.rate(q.RateOptions)
+		}
+
+		// Each series is a group of its own without aggregation.
+		k := strings.Join(key, ",")
+		if q.Aggregator == "none" {
+			k = fmt.Sprintf("%s %d", k, i)
+		}
+		if groups[k] == nil {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], s)
+	}
+	sort.Strings(keys)
+
+	results := make([]*queryResult, 0, len(keys))
+	for _, k := range keys {
+		results = append(results, q.aggregate(groups[k], msResolution))
+	}
+	return results
+}
+
+// aggregate aggregates the values of a group of series with the same
+// timestamps.
+func (q *subQuery) aggregate(group []*series, msResolution bool) *queryResult {
+	result := &queryResult{
+		Metric:        q.Metric,
+		Tags:          make(map[string]string),
+		AggregateTags: []string{},
+		DPs:           make(map[string]float64),
+	}
+
+	// The tags with the same value in every series are the tags of the
+	// result, the others are aggregated.
+	aggregated := make(map[string]bool)
+	for i, s := range group {
+		for k, v := range s.tags {
+			if i == 0 {
+				result.Tags[k] = v
+			} else if result.Tags[k] != v {
+				aggregated[k] = true
+			}
+		}
+		for k := range result.Tags {
+			if _, ok := s.tags[k]; !ok {
+				aggregated[k] = true
+			}
+		}
+	}
+	for k := range aggregated {
+		delete(result.Tags, k)
+		result.AggregateTags = append(result.AggregateTags, k)
+	}
+	sort.Strings(result.AggregateTags)
+
+	// Collect the values of the series for each timestamp.
+	values := make(map[int64][]float64)
+	for _, s := range group {
+		for i, t := range s.times {
+			values[t.UnixNano()] = append(values[t.UnixNano()], s.values[i])
+		}
+	}
+	for ts, a := range values {
+		key := strconv.FormatInt(ts/int64(time.Second), 10)
+		if msResolution {
+			key = strconv.FormatInt(ts/int64(time.Millisecond), 10)
+		}
+		if v := aggregate(q.Aggregator, a); !math.IsNaN(v) && !math.IsInf(v, 0) {
+			result.DPs[key] = v
+		}
+	}
+	return result
+}
+
+// rate replaces the values of the series by their rate of change per
+// second.
+func (s *series) rate(opts rateOptions) {
+	var times []time.Time
+	var values []float64
+	for i := 1; i < len(s.values); i++ {
+		dt := s.times[i].Sub(s.times[i-1]).Seconds()
+		if dt <= 0 {
+			continue
+		}
+
+		delta := s.values[i] - s.values[i-1]
+		if opts.Counter && delta < 0 {
+			if opts.DropResets {
+				continue
+			}
+			max := opts.CounterMax
+			if max == 0 {
+				max = math.MaxInt64
+			}
+			delta = max - s.values[i-1] + s.values[i]
+		}
+
+		v := delta / dt
+		if opts.Counter && opts.ResetValue > 0 && v > opts.ResetValue {
+			v = 0
+		}
+		times, values = append(times, s.times[i]), append(values, v)
+	}
+	s.times, s.values = times, values
+}
+
+// aggregate returns the aggregate of values by an aggregator.
+func aggregate(name string, values []float64) float64 {
+	switch aggregators[name] {
+	case "sum":
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		return sum
+	case "mean":
+		return aggregate("sum", values) / float64(len(values))
+	case "min":
+		min := values[0]
+		for _, v := range values[1:] {
+			min = math.Min(min, v)
+		}
+		return min
+	case "max":
+		max := values[0]
+		for _, v := range values[1:] {
+			max = math.Max(max, v)
+		}
+		return max
+	case "count":
+		return float64(len(values))
+	case "stddev":
+		if len(values) < 2 {
+			return 0
+		}
+		mean := aggregate("avg", values)
+		var variance float64
+		for _, v := range values {
+			variance += (v - mean) * (v - mean)
+		}
+		return math.Sqrt(variance / float64(len(values)-1))
+	case "first":
+		return values[0]
+	case "last":
+		return values[len(values)-1]
+	case "median", "percentile":
+		p := 50.0
+		if name != "median" {
+			p = percentileOf(name)
+		}
+		a := make([]float64, len(values))
+		copy(a, values)
+		sort.Float64s(a)
+		i := int(math.Floor(float64(len(a))*p/100.0+0.5)) - 1
+		if i < 0 {
+			i = 0
+		} else if i >= len(a) {
+			i = len(a) - 1
+		}
+		return a[i]
+	}
+
+	// Values are not aggregated by "none", which has a single series.
+	return values[0]
+}
+
+// relativeTimeRegexp matches the durations of relative times and downsampling.
+var relativeTimeRegexp = regexp.MustCompile(`^(\d+)(ms|s|m|h|d|w|n|y)$`)
+
+// parseDuration parses a duration in the OpenTSDB format, such as 1h or 30d.
+func parseDuration(s string) (time.Duration, error) {
+	m := relativeTimeRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("invalid duration: %q", s)
+	}
+
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid duration: %q", s)
+	}
+
+	unit := map[string]time.Duration{
+		"ms": time.Millisecond,
+		"s":  time.Second,
+		"m":  time.Minute,
+		"h":  time.Hour,
+		"d":  24 * time.Hour,
+		"w":  7 * 24 * time.Hour,
+		"n":  30 * 24 * time.Hour,
+		"y":  365 * 24 * time.Hour,
+	}[m[2]]
+	return time.Duration(n) * unit, nil
+}
+
+// absoluteTimeLayouts are the layouts of the absolute times of queries.
+var absoluteTimeLayouts = []string{
+	"2006/01/02-15:04:05",
+	"2006/01/02 15:04:05",
+	"2006/01/02-15:04",
+	"2006/01/02 15:04",
+	"2006/01/02",
+}
+
+// parseTime parses the start or end time of a query, either a timestamp in
+// seconds or milliseconds, a relative time such as 1h-ago or an absolute
+// time in UTC such as 2015/01/01-00:00:00.
+func parseTime(v interface{}, now time.Time) (time.Time, error) {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatInt(int64(v), 10)
+	default:
+		return time.Time{}, fmt.Errorf("invalid time: %v", v)
+	}
+
+	if strings.HasSuffix(s, "-ago") {
+		d, err := parseDuration(strings.TrimSuffix(s, "-ago"))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time: %q", s)
+		}
+		return now.Add(-d), nil
+	}
+
+	if ts, err := strconv.ParseInt(s, 10, 64); err == nil {
+		// Timestamps over 10 digits are in milliseconds.
+		if ts < 10000000000 {
+			return time.Unix(ts, 0).UTC(), nil
+		}
+		return time.Unix(ts/1000, (ts%1000)*int64(time.Millisecond)).UTC(), nil
+	}
+
+	for _, layout := range absoluteTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %q", s)
+}
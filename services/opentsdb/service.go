@@ -18,6 +18,7 @@ import (
 
 	"github.com/influxdb/influxdb"
 	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
 	"github.com/influxdb/influxdb/models"
 	"github.com/influxdb/influxdb/tsdb"
@@ -43,6 +44,10 @@ const (
 	statConnectionsActive        = "connsActive"
 	statConnectionsHandled       = "connsHandled"
 	statDroppedPointsInvalid     = "droppedPointsInvalid"
+	statPointsRateLimited        = "pointsRateLimited"
+	statHTTPQueryRequests        = "httpQueryReq"
+	statHTTPQueryFail            = "httpQueryFail"
+	statHTTPSuggestRequests      = "httpSuggestReq"
 )
 
 // Service manages the listener and handler for an HTTP endpoint.
@@ -70,6 +75,19 @@ type Service struct {
 		CreateDatabaseIfNotExists(name string) (*meta.DatabaseInfo, error)
 	}
 
+	// QueryExecutor and TSDBStore serve the /api/query and /api/suggest
+	// endpoints, if QueryEnabled is set.
+	QueryEnabled  bool
+	QueryExecutor interface {
+		ExecuteQuery(query *influxql.Query, database string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error)
+	}
+	TSDBStore interface {
+		DatabaseIndex(name string) *tsdb.DatabaseIndex
+	}
+
+	// RateLimiter drops the points over the limits of the database.
+	RateLimiter *tsdb.RateLimiter
+
 	// Points received over the telnet protocol are batched.
 	batchSize    int
 	batchPending int
@@ -102,6 +120,7 @@ func NewService(c Config) (*Service, error) {
 		batchTimeout:     time.Duration(c.BatchTimeout),
 		Logger:           log.New(os.Stderr, "[opentsdb] ", log.LstdFlags),
 		LogPointErrors:   c.LogPointErrors,
+		QueryEnabled:     c.QueryEnabled,
 	}
 	return s, nil
 }
@@ -118,6 +137,7 @@ func (s *Service) Open() error {
 	key := strings.Join([]string{"opentsdb", s.BindAddress}, ":")
 	tags := map[string]string{"bind": s.BindAddress}
 	s.statMap = influxdb.NewStatistics(key, "opentsdb", tags)
+	influxdb.DeclareGauges("opentsdb", statConnectionsActive, statTelnetConnectionsActive)
 
 	if err := s.MetaStore.WaitForLeader(leaderWaitTimeout); err != nil {
 		s.Logger.Printf("Failed to detect a cluster leader: %s", err.Error())
@@ -356,6 +376,11 @@ func (s *Service) handleTelnetConn(conn net.Conn) {
 			}
 			continue
 		}
+
+		if err := s.RateLimiter.Write(nil, s.Database, 1, len(line)); err != nil {
+			s.statMap.Add(statPointsRateLimited, 1)
+			continue
+		}
 		s.batcher.In() <- pt
 	}
 }
@@ -367,6 +392,10 @@ func (s *Service) serveHTTP() {
 		RetentionPolicy:  s.RetentionPolicy,
 		ConsistencyLevel: s.ConsistencyLevel,
 		PointsWriter:     s.PointsWriter,
+		QueryExecutor:    s.QueryExecutor,
+		TSDBStore:        s.TSDBStore,
+		RateLimiter:      s.RateLimiter,
+		QueryEnabled:     s.QueryEnabled,
 		Logger:           s.Logger,
 		statMap:          s.statMap,
 	}}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"reflect"
 	"strings"
 	"sync/atomic"
@@ -13,9 +14,11 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
 	"github.com/influxdb/influxdb/models"
 	"github.com/influxdb/influxdb/services/opentsdb"
+	"github.com/influxdb/influxdb/tsdb"
 )
 
 // Ensure a point can be written via the telnet protocol.
@@ -123,9 +126,205 @@ func TestService_HTTP(t *testing.T) {
 	}
 }
 
+// Ensure series are queried via the HTTP /api/query endpoint and aggregated
+// by their group by tags.
+func TestService_HTTP_Query(t *testing.T) {
+	t.Parallel()
+
+	s := NewService("db0")
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	t0 := time.Unix(1420070400, 0).UTC()
+	s.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+		if db != "db0" {
+			t.Fatalf("unexpected database: %s", db)
+		} else if exp := `SELECT mean(value) FROM db0.."sys.cpu.user" WHERE time >= '2015-01-01T00:00:00Z' AND time <= '2015-01-01T01:00:00Z' AND host =~ /^(web.*)$/ AND (dc = 'lga' OR dc = 'nyc') GROUP BY time(1m), * fill(0)`; q.String() != exp {
+			t.Fatalf("unexpected query: %s", q.String())
+		}
+
+		ch := make(chan *influxql.Result, 1)
+		ch <- &influxql.Result{Series: models.Rows{
+			{Name: "sys.cpu.user", Tags: map[string]string{"dc": "lga", "host": "web01"}, Values: [][]interface{}{{t0, 1.0}, {t0.Add(time.Minute), 2.0}}},
+			{Name: "sys.cpu.user", Tags: map[string]string{"dc": "lga", "host": "web02"}, Values: [][]interface{}{{t0, 3.0}}},
+			{Name: "sys.cpu.user", Tags: map[string]string{"dc": "nyc", "host": "web03"}, Values: [][]interface{}{{t0, 5.0}}},
+		}}
+		close(ch)
+		return ch, nil
+	}
+
+	resp, err := http.Post("http://"+s.Addr().String()+"/api/query", "application/json", strings.NewReader(`{
+		"start": 1420070400,
+		"end": "2015/01/01-01:00:00",
+		"queries": [{
+			"aggregator": "sum",
+			"metric": "sys.cpu.user",
+			"downsample": "1m-avg-zero",
+			"tags": {"dc": "lga|nyc"},
+			"filters": [{"type": "wildcard", "tagk": "host", "filter": "web*", "groupBy": false}]
+		}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code: %d: %s", resp.StatusCode, body)
+	} else if exp := `[{"metric":"sys.cpu.user","tags":{"dc":"lga"},"aggregateTags":["host"],"dps":{"1420070400":4,"1420070460":2}},{"metric":"sys.cpu.user","tags":{"dc":"nyc","host":"web03"},"aggregateTags":[],"dps":{"1420070400":5}}]` + "\n"; string(body) != exp {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+// Ensure metric queries of GET requests are parsed and rates are computed.
+func TestService_HTTP_QueryString(t *testing.T) {
+	t.Parallel()
+
+	s := NewService("db0")
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	t0 := time.Unix(1420070400, 0).UTC()
+	s.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+		if exp := `SELECT value FROM db0.."sys.net.bytes" WHERE time >= '2015-01-01T00:00:00Z' AND time <= '2015-01-01T00:10:00Z' AND host =~ /(?i)^(web01|web02)$/ GROUP BY *`; q.String() != exp {
+			t.Fatalf("unexpected query: %s", q.String())
+		}
+
+		ch := make(chan *influxql.Result, 1)
+		ch <- &influxql.Result{Series: models.Rows{
+			{Name: "sys.net.bytes", Tags: map[string]string{"host": "web01"}, Values: [][]interface{}{{t0, 100.0}, {t0.Add(10 * time.Second), 200.0}, {t0.Add(20 * time.Second), 50.0}}},
+		}}
+		close(ch)
+		return ch, nil
+	}
+
+	params := url.Values{
+		"start": {"1420070400000"},
+		"end":   {"1420071000"},
+		"m":     {"none:rate{counter,250}:sys.net.bytes{host=iliteral_or(web01|web02)}"},
+	}
+	resp, err := http.Get("http://" + s.Addr().String() + "/api/query?" + params.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code: %d: %s", resp.StatusCode, body)
+	} else if exp := `[{"metric":"sys.net.bytes","tags":{"host":"web01"},"aggregateTags":[],"dps":{"1420070410":10,"1420070420":10}}]` + "\n"; string(body) != exp {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+// Ensure invalid queries are rejected with an OpenTSDB error.
+func TestService_HTTP_QueryError(t *testing.T) {
+	t.Parallel()
+
+	s := NewService("db0")
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	resp, err := http.Get("http://" + s.Addr().String() + "/api/query?start=1h-ago&m=foo:sys.cpu.user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unexpected status code: %d", resp.StatusCode)
+	} else if exp := `{"error":{"code":400,"message":"unknown aggregator: \"foo\""}}` + "\n"; string(body) != exp {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+// Ensure the HTTP /api/query and /api/suggest endpoints are forbidden unless enabled.
+func TestService_HTTP_QueryDisabled(t *testing.T) {
+	t.Parallel()
+
+	s := NewService("db0")
+	s.QueryEnabled = false
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for _, path := range []string{"/api/query?start=1h-ago&m=sum:sys.cpu.user", "/api/suggest?type=metrics"} {
+		resp, err := http.Get("http://" + s.Addr().String() + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("%s: unexpected status code: %d", path, resp.StatusCode)
+		}
+	}
+}
+
+// Ensure measurements and tags are suggested via the HTTP /api/suggest endpoint.
+func TestService_HTTP_Suggest(t *testing.T) {
+	t.Parallel()
+
+	s := NewService("db0")
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	index := tsdb.NewDatabaseIndex()
+	for _, pt := range []models.Point{
+		models.MustNewPoint("sys.cpu.user", map[string]string{"host": "web01"}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+		models.MustNewPoint("sys.cpu.user", map[string]string{"host": "web02"}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+		models.MustNewPoint("sys.mem.free", map[string]string{"dc": "lga"}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+		models.MustNewPoint("app.requests", map[string]string{"host": "app01"}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+	} {
+		index.CreateSeriesIndexIfNotExists(pt.Name(), tsdb.NewSeries(string(pt.Key()), pt.Tags()))
+	}
+	s.TSDBStore.DatabaseIndexFn = func(name string) *tsdb.DatabaseIndex {
+		if name != "db0" {
+			t.Fatalf("unexpected database: %s", name)
+		}
+		return index
+	}
+
+	for _, tt := range []struct {
+		query string
+		body  string
+	}{
+		{query: "type=metrics&q=sys", body: `["sys.cpu.user","sys.mem.free"]`},
+		{query: "type=metrics&max=1", body: `["app.requests"]`},
+		{query: "type=tagk", body: `["dc","host"]`},
+		{query: "type=tagv&q=web", body: `["web01","web02"]`},
+	} {
+		resp, err := http.Get("http://" + s.Addr().String() + "/api/suggest?" + tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: unexpected status code: %d", tt.query, resp.StatusCode)
+		} else if string(body) != tt.body+"\n" {
+			t.Fatalf("%s: unexpected body: %s", tt.query, body)
+		}
+	}
+}
+
 type Service struct {
 	*opentsdb.Service
-	PointsWriter PointsWriter
+	PointsWriter  PointsWriter
+	QueryExecutor QueryExecutor
+	TSDBStore     TSDBStore
 }
 
 // NewService returns a new instance of Service.
@@ -134,9 +333,12 @@ func NewService(database string) *Service {
 		BindAddress:      "127.0.0.1:0",
 		Database:         database,
 		ConsistencyLevel: "one",
+		QueryEnabled:     true,
 	})
 	s := &Service{Service: srv}
 	s.Service.PointsWriter = &s.PointsWriter
+	s.Service.QueryExecutor = &s.QueryExecutor
+	s.Service.TSDBStore = &s.TSDBStore
 	s.Service.MetaStore = &DatabaseCreator{}
 
 	if !testing.Verbose() {
@@ -155,6 +357,24 @@ func (w *PointsWriter) WritePoints(p *cluster.WritePointsRequest) error {
 	return w.WritePointsFn(p)
 }
 
+// QueryExecutor represents a mock impl of QueryExecutor.
+type QueryExecutor struct {
+	ExecuteQueryFn func(q *influxql.Query, db string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error)
+}
+
+func (e *QueryExecutor) ExecuteQuery(q *influxql.Query, db string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+	return e.ExecuteQueryFn(q, db, user, chunkSize, closing)
+}
+
+// TSDBStore represents a mock impl of TSDBStore.
+type TSDBStore struct {
+	DatabaseIndexFn func(name string) *tsdb.DatabaseIndex
+}
+
+func (s *TSDBStore) DatabaseIndex(name string) *tsdb.DatabaseIndex {
+	return s.DatabaseIndexFn(name)
+}
+
 type DatabaseCreator struct {
 }
 
@@ -0,0 +1,221 @@
+package statsd
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdb/influxdb/models"
+)
+
+// series identifies the series of a metric.
+type series struct {
+	name string
+	tags map[string]string
+}
+
+// counter holds the sum of a counter over an interval.
+type counter struct {
+	series
+	value float64
+}
+
+// timer holds the values of a timer or histogram over an interval.
+type timer struct {
+	series
+	values []float64
+	count  float64 // number of values, accounting for the sample rate
+}
+
+// gauge holds the value of a gauge.
+type gauge struct {
+	series
+	value   float64
+	updated bool // updated since last flushed
+	idle    int  // flush intervals without updates
+}
+
+// set holds the unique values of a set over an interval.
+type set struct {
+	series
+	values map[string]struct{}
+}
+
+// Aggregator aggregates StatsD metrics over flush intervals. Counters are
+// summed, gauges keep their last value, sets count their unique values and
+// the statistics of timers and histograms are computed, including the
+// configured percentiles. Gauges that aren't updated for a number of flush
+// intervals are dropped. It is safe for concurrent use.
+type Aggregator struct {
+	mu       sync.Mutex
+	counters map[string]*counter
+	gauges   map[string]*gauge
+	timers   map[string]*timer
+	sets     map[string]*set
+
+	percentiles []float64
+	gaugeExpiry int
+}
+
+// NewAggregator returns an aggregator computing the given percentiles of
+// timers and histograms, and dropping the gauges not updated for gaugeExpiry
+// flush intervals.
+func NewAggregator(percentiles []float64, gaugeExpiry int) *Aggregator {
+	return &Aggregator{
+		counters:    make(map[string]*counter),
+		gauges:      make(map[string]*gauge),
+		timers:      make(map[string]*timer),
+		sets:        make(map[string]*set),
+		percentiles: percentiles,
+		gaugeExpiry: gaugeExpiry,
+	}
+}
+
+// Add adds a metric to the current interval.
+func (a *Aggregator) Add(m Metric) {
+	key := string(models.MakeKey([]byte(m.Name), m.Tags))
+	s := series{name: m.Name, tags: m.Tags}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch m.Type {
+	case Counter:
+		c := a.counters[key]
+		if c == nil {
+			c = &counter{series: s}
+			a.counters[key] = c
+		}
+		c.value += m.Value / m.SampleRate
+
+	case Gauge:
+		g := a.gauges[key]
+		if g == nil {
+			g = &gauge{series: s}
+			a.gauges[key] = g
+		}
+		if m.Delta {
+			g.value += m.Value
+		} else {
+			g.value = m.Value
+		}
+		g.updated = true
+
+	case Timer, Histogram:
+		t := a.timers[key]
+		if t == nil {
+			t = &timer{series: s}
+			a.timers[key] = t
+		}
+		t.values = append(t.values, m.Value)
+		t.count += 1 / m.SampleRate
+
+	case Set:
+		st := a.sets[key]
+		if st == nil {
+			st = &set{series: s, values: make(map[string]struct{})}
+			a.sets[key] = st
+		}
+		st.values[m.SetValue] = struct{}{}
+	}
+}
+
+// Flush returns the points of the metrics received since the last flush,
+// timestamped with now, and starts a new interval. Gauges are kept to apply
+// the deltas of later intervals until they expire.
+func (a *Aggregator) Flush(now time.Time) []models.Point {
+	a.mu.Lock()
+	counters, timers, sets := a.counters, a.timers, a.sets
+	a.counters = make(map[string]*counter)
+	a.timers = make(map[string]*timer)
+	a.sets = make(map[string]*set)
+
+	var points []models.Point
+	for key, g := range a.gauges {
+		if g.updated {
+			points = appendPoint(points, g.series, map[string]interface{}{"value": g.value}, now)
+			g.updated, g.idle = false, 0
+			continue
+		}
+
+		g.idle++
+		if g.idle >= a.gaugeExpiry {
+			delete(a.gauges, key)
+		}
+	}
+	a.mu.Unlock()
+
+	for _, c := range counters {
+		points = appendPoint(points, c.series, map[string]interface{}{"value": c.value}, now)
+	}
+	for _, st := range sets {
+		points = appendPoint(points, st.series, map[string]interface{}{"value": float64(len(st.values))}, now)
+	}
+	for _, t := range timers {
+		points = appendPoint(points, t.series, t.fields(a.percentiles), now)
+	}
+	return points
+}
+
+// appendPoint appends the point of a series to points, skipping the series
+// which aren't valid points.
+func appendPoint(points []models.Point, s series, fields map[string]interface{}, now time.Time) []models.Point {
+	pt, err := models.NewPoint(s.name, s.tags, fields, now)
+	if err != nil {
+		return points
+	}
+	return append(points, pt)
+}
+
+// fields returns the statistics of the values of the timer, and the
+// statistics of the values under each percentile, suffixed by the
+// percentile such as upper_90 or mean_99_9.
+func (t *timer) fields(percentiles []float64) map[string]interface{} {
+	values := t.values
+	sort.Float64s(values)
+
+	var sum float64
+	cumulative := make([]float64, len(values))
+	for i, v := range values {
+		sum += v
+		cumulative[i] = sum
+	}
+	n := float64(len(values))
+	mean := sum / n
+
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+
+	median := values[len(values)/2]
+	if len(values)%2 == 0 {
+		median = (values[len(values)/2-1] + median) / 2
+	}
+
+	fields := map[string]interface{}{
+		"count":  t.count,
+		"lower":  values[0],
+		"upper":  values[len(values)-1],
+		"mean":   mean,
+		"median": median,
+		"stddev": math.Sqrt(variance / n),
+		"sum":    sum,
+	}
+
+	for _, p := range percentiles {
+		i := int(math.Floor(p/100*n + 0.5))
+		if i == 0 {
+			continue
+		}
+		suffix := "_" + strings.Replace(strconv.FormatFloat(p, 'f', -1, 64), ".", "_", -1)
+		fields["count"+suffix] = float64(i)
+		fields["upper"+suffix] = values[i-1]
+		fields["sum"+suffix] = cumulative[i-1]
+		fields["mean"+suffix] = cumulative[i-1] / float64(i)
+	}
+	return fields
+}
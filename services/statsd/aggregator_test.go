@@ -0,0 +1,134 @@
+package statsd_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/services/statsd"
+)
+
+// Ensure counters, gauges and sets are aggregated over an interval.
+func TestAggregator_Flush(t *testing.T) {
+	a := statsd.NewAggregator(nil, 2)
+	for _, line := range []string{
+		"requests:1|c",
+		"requests:2|c|@0.5",
+		"requests:1|c|#host:server01",
+		"load:10|g",
+		"load:+5|g",
+		"users:alice|s",
+		"users:bob|s",
+		"users:alice|s",
+	} {
+		a.Add(mustParseMetric(line))
+	}
+
+	now := time.Unix(10, 0).UTC()
+	points := pointsByKey(a.Flush(now))
+	if len(points) != 4 {
+		t.Fatalf("unexpected point count: %d", len(points))
+	}
+	for key, value := range map[string]float64{
+		"requests":               5,
+		"requests,host=server01": 1,
+		"load":                   15,
+		"users":                  2,
+	} {
+		pt := points[key]
+		if pt == nil {
+			t.Errorf("%s: missing point", key)
+		} else if v := pt.Fields()["value"]; v != value {
+			t.Errorf("%s: unexpected value: %v", key, v)
+		} else if !pt.Time().Equal(now) {
+			t.Errorf("%s: unexpected time: %s", key, pt.Time())
+		}
+	}
+
+	// Only the gauges are kept, and only written again once updated.
+	if points := a.Flush(now); len(points) != 0 {
+		t.Fatalf("unexpected points: %v", points)
+	}
+	a.Add(mustParseMetric("load:-3|g"))
+	points = pointsByKey(a.Flush(now))
+	if len(points) != 1 || points["load"] == nil || points["load"].Fields()["value"] != float64(12) {
+		t.Fatalf("unexpected points: %v", points)
+	}
+}
+
+// Ensure gauges not updated for the expiry intervals are dropped.
+func TestAggregator_Flush_GaugeExpiry(t *testing.T) {
+	a := statsd.NewAggregator(nil, 2)
+	a.Add(mustParseMetric("load:10|g"))
+
+	now := time.Unix(10, 0).UTC()
+	a.Flush(now)
+
+	// The gauge is kept while it's idle for less than 2 intervals.
+	a.Flush(now)
+	a.Add(mustParseMetric("load:+5|g"))
+	points := pointsByKey(a.Flush(now))
+	if len(points) != 1 || points["load"] == nil || points["load"].Fields()["value"] != float64(15) {
+		t.Fatalf("unexpected points: %v", points)
+	}
+
+	// The gauge is dropped after 2 idle intervals.
+	a.Flush(now)
+	a.Flush(now)
+	a.Add(mustParseMetric("load:+5|g"))
+	points = pointsByKey(a.Flush(now))
+	if len(points) != 1 || points["load"] == nil || points["load"].Fields()["value"] != float64(5) {
+		t.Fatalf("unexpected points: %v", points)
+	}
+}
+
+// Ensure the statistics and percentiles of timers are computed.
+func TestAggregator_Flush_Timer(t *testing.T) {
+	a := statsd.NewAggregator([]float64{90, 50}, 2)
+	for i := 1; i <= 10; i++ {
+		a.Add(statsd.Metric{Name: "latency", Type: statsd.Timer, Value: float64(i), SampleRate: 0.5})
+	}
+
+	points := a.Flush(time.Unix(10, 0))
+	if len(points) != 1 {
+		t.Fatalf("unexpected point count: %d", len(points))
+	}
+	if fields := points[0].Fields(); !reflect.DeepEqual(fields, models.Fields{
+		"count":    float64(20),
+		"lower":    float64(1),
+		"upper":    float64(10),
+		"mean":     5.5,
+		"median":   5.5,
+		"stddev":   2.8722813232690143,
+		"sum":      float64(55),
+		"count_90": float64(9),
+		"upper_90": float64(9),
+		"sum_90":   float64(45),
+		"mean_90":  float64(5),
+		"count_50": float64(5),
+		"upper_50": float64(5),
+		"sum_50":   float64(15),
+		"mean_50":  float64(3),
+	}) {
+		t.Fatalf("unexpected fields: %v", fields)
+	}
+}
+
+// mustParseMetric parses a metric. Panic on error.
+func mustParseMetric(line string) statsd.Metric {
+	m, err := statsd.ParseMetric(line)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// pointsByKey returns points keyed by their series key.
+func pointsByKey(points []models.Point) map[string]models.Point {
+	m := make(map[string]models.Point)
+	for _, pt := range points {
+		m[string(pt.Key())] = pt
+	}
+	return m
+}
@@ -0,0 +1,119 @@
+package statsd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdb/influxdb/toml"
+)
+
+const (
+	// DefaultBindAddress is the default address of the UDP listener.
+	DefaultBindAddress = ":8125"
+
+	// DefaultDatabase is the default database for StatsD metrics.
+	DefaultDatabase = "statsd"
+
+	// DefaultBatchSize is the default StatsD batch size.
+	DefaultBatchSize = 5000
+
+	// DefaultBatchPending is the default number of pending StatsD batches.
+	DefaultBatchPending = 10
+
+	// DefaultBatchTimeout is the default StatsD batch timeout.
+	DefaultBatchTimeout = time.Second
+
+	// DefaultFlushInterval is the default interval the metrics are aggregated
+	// over, the same as the default of StatsD.
+	DefaultFlushInterval = 10 * time.Second
+
+	// DefaultUDPPayloadSize is the default size of the UDP packets read.
+	DefaultUDPPayloadSize = 65536
+
+	// DefaultGaugeExpiry is the default number of flush intervals a gauge is
+	// kept without being updated.
+	DefaultGaugeExpiry = 6
+)
+
+// DefaultPercentiles are the default percentiles computed for timers.
+var DefaultPercentiles = []float64{90}
+
+// Config holds various configuration settings for the StatsD listener.
+type Config struct {
+	Enabled        bool   `toml:"enabled"`
+	BindAddress    string `toml:"bind-address"`
+	TCPBindAddress string `toml:"tcp-bind-address"`
+
+	Database        string        `toml:"database"`
+	RetentionPolicy string        `toml:"retention-policy"`
+	BatchSize       int           `toml:"batch-size"`
+	BatchPending    int           `toml:"batch-pending"`
+	ReadBuffer      int           `toml:"read-buffer"`
+	BatchTimeout    toml.Duration `toml:"batch-timeout"`
+	UDPPayloadSize  int           `toml:"udp-payload-size"`
+
+	// FlushInterval is the interval the metrics are aggregated over before
+	// they are written.
+	FlushInterval toml.Duration `toml:"flush-interval"`
+
+	// Percentiles are the percentiles computed for timers and histograms.
+	Percentiles []float64 `toml:"percentiles"`
+
+	// GaugeExpiry is the number of flush intervals after which a gauge that
+	// wasn't updated is dropped, along with its value.
+	GaugeExpiry int `toml:"gauge-expiry"`
+
+	// RateLimited subjects the metrics received to the per-database limits
+	// of the [rate-limits] section.
+	RateLimited bool `toml:"rate-limited"`
+}
+
+// WithDefaults takes the given config and returns a new config with any required
+// default values set.
+func (c *Config) WithDefaults() *Config {
+	d := *c
+	if d.BindAddress == "" {
+		d.BindAddress = DefaultBindAddress
+	}
+	if d.Database == "" {
+		d.Database = DefaultDatabase
+	}
+	if d.BatchSize == 0 {
+		d.BatchSize = DefaultBatchSize
+	}
+	if d.BatchPending == 0 {
+		d.BatchPending = DefaultBatchPending
+	}
+	if d.BatchTimeout == 0 {
+		d.BatchTimeout = toml.Duration(DefaultBatchTimeout)
+	}
+	if d.UDPPayloadSize == 0 {
+		d.UDPPayloadSize = DefaultUDPPayloadSize
+	}
+	if d.FlushInterval == 0 {
+		d.FlushInterval = toml.Duration(DefaultFlushInterval)
+	}
+	if d.Percentiles == nil {
+		d.Percentiles = DefaultPercentiles
+	}
+	if d.GaugeExpiry == 0 {
+		d.GaugeExpiry = DefaultGaugeExpiry
+	}
+	return &d
+}
+
+// Validate returns an error if the config is invalid.
+func (c *Config) Validate() error {
+	if c.FlushInterval < 0 {
+		return fmt.Errorf("invalid flush interval: %s", time.Duration(c.FlushInterval))
+	}
+	if c.GaugeExpiry < 0 {
+		return fmt.Errorf("invalid gauge expiry: %d", c.GaugeExpiry)
+	}
+	for _, p := range c.Percentiles {
+		if p <= 0 || p > 100 {
+			return fmt.Errorf("percentile must be between 0 and 100, found %v", p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package statsd_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdb/influxdb/services/statsd"
+)
+
+func TestConfig_Parse(t *testing.T) {
+	// Parse configuration.
+	var c statsd.Config
+	if _, err := toml.Decode(`
+enabled = true
+bind-address = ":8125"
+tcp-bind-address = ":8126"
+database = "awesomedb"
+retention-policy = "awesomerp"
+flush-interval = "1m"
+percentiles = [90.0, 99.9]
+gauge-expiry = 3
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Validate configuration.
+	if c.Enabled != true {
+		t.Fatalf("unexpected enabled: %v", c.Enabled)
+	} else if c.BindAddress != ":8125" {
+		t.Fatalf("unexpected bind address: %s", c.BindAddress)
+	} else if c.TCPBindAddress != ":8126" {
+		t.Fatalf("unexpected tcp bind address: %s", c.TCPBindAddress)
+	} else if c.Database != "awesomedb" {
+		t.Fatalf("unexpected database: %s", c.Database)
+	} else if c.RetentionPolicy != "awesomerp" {
+		t.Fatalf("unexpected retention policy: %s", c.RetentionPolicy)
+	} else if time.Duration(c.FlushInterval) != time.Minute {
+		t.Fatalf("unexpected flush interval: %v", c.FlushInterval)
+	} else if len(c.Percentiles) != 2 || c.Percentiles[0] != 90 || c.Percentiles[1] != 99.9 {
+		t.Fatalf("unexpected percentiles: %v", c.Percentiles)
+	} else if c.GaugeExpiry != 3 {
+		t.Fatalf("unexpected gauge expiry: %d", c.GaugeExpiry)
+	}
+}
+
+// Ensure percentiles outside of (0, 100] are rejected.
+func TestConfig_Validate(t *testing.T) {
+	for _, p := range []float64{0, -1, 100.1} {
+		c := statsd.Config{Percentiles: []float64{p}}
+		if err := c.Validate(); err == nil {
+			t.Errorf("%v: expected error", p)
+		}
+	}
+
+	c := statsd.Config{Percentiles: []float64{50, 100}}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,92 @@
+package statsd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Types of the StatsD metrics.
+const (
+	Counter   = "c"
+	Gauge     = "g"
+	Timer     = "ms"
+	Histogram = "h"
+	Set       = "s"
+)
+
+// Metric is a StatsD metric, in the format:
+//   name:value|type[|@sample_rate][|#tag:value,...]
+// where the tags are DogStatsD-style tags.
+type Metric struct {
+	Name       string
+	Type       string
+	Value      float64
+	SetValue   string  // value of sets
+	Delta      bool    // gauge values with a sign are added to the gauge
+	SampleRate float64 // sample rate of counters and timers, 1 if unsampled
+	Tags       map[string]string
+}
+
+// ParseMetric parses a StatsD metric.
+func ParseMetric(line string) (Metric, error) {
+	i := strings.Index(line, ":")
+	if i <= 0 {
+		return Metric{}, fmt.Errorf("invalid metric: %q", line)
+	}
+
+	parts := strings.Split(line[i+1:], "|")
+	if len(parts) < 2 {
+		return Metric{}, fmt.Errorf("invalid metric: %q", line)
+	}
+
+	m := Metric{
+		Name:       line[:i],
+		Type:       parts[1],
+		SampleRate: 1,
+	}
+
+	switch m.Type {
+	case Counter, Gauge, Timer, Histogram:
+		v, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return Metric{}, fmt.Errorf("invalid value of metric %q: %s", line, err)
+		}
+		m.Value = v
+		m.Delta = m.Type == Gauge && (parts[0][0] == '+' || parts[0][0] == '-')
+	case Set:
+		m.SetValue = parts[0]
+	default:
+		return Metric{}, fmt.Errorf("unknown type of metric %q", line)
+	}
+
+	for _, part := range parts[2:] {
+		switch {
+		case strings.HasPrefix(part, "@"):
+			v, err := strconv.ParseFloat(part[1:], 64)
+			if err != nil || v <= 0 || v > 1 {
+				return Metric{}, fmt.Errorf("invalid sample rate of metric %q", line)
+			}
+			m.SampleRate = v
+		case strings.HasPrefix(part, "#"):
+			m.Tags = make(map[string]string)
+			for _, tag := range strings.Split(part[1:], ",") {
+				if tag == "" {
+					continue
+				}
+				// Tags without a value are set to true.
+				kv := strings.SplitN(tag, ":", 2)
+				if len(kv) == 1 {
+					kv = append(kv, "true")
+				}
+				if kv[0] == "" || kv[1] == "" {
+					return Metric{}, fmt.Errorf("invalid tag %q of metric %q", tag, line)
+				}
+				m.Tags[kv[0]] = kv[1]
+			}
+		default:
+			return Metric{}, fmt.Errorf("invalid metric: %q", line)
+		}
+	}
+	return m, nil
+}
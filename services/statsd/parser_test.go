@@ -0,0 +1,56 @@
+package statsd_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/influxdb/influxdb/services/statsd"
+)
+
+// Ensure StatsD metrics are parsed.
+func TestParseMetric(t *testing.T) {
+	for _, tt := range []struct {
+		line string
+		m    statsd.Metric
+		err  string
+	}{
+		{line: "gorets:1|c", m: statsd.Metric{Name: "gorets", Type: statsd.Counter, Value: 1, SampleRate: 1}},
+		{line: "gorets:1|c|@0.1", m: statsd.Metric{Name: "gorets", Type: statsd.Counter, Value: 1, SampleRate: 0.1}},
+		{line: "glork:320|ms", m: statsd.Metric{Name: "glork", Type: statsd.Timer, Value: 320, SampleRate: 1}},
+		{line: "glork:3.5|h", m: statsd.Metric{Name: "glork", Type: statsd.Histogram, Value: 3.5, SampleRate: 1}},
+		{line: "gaugor:333|g", m: statsd.Metric{Name: "gaugor", Type: statsd.Gauge, Value: 333, SampleRate: 1}},
+		{line: "gaugor:-10|g", m: statsd.Metric{Name: "gaugor", Type: statsd.Gauge, Value: -10, Delta: true, SampleRate: 1}},
+		{line: "gaugor:+4|g", m: statsd.Metric{Name: "gaugor", Type: statsd.Gauge, Value: 4, Delta: true, SampleRate: 1}},
+		{line: "uniques:765|s", m: statsd.Metric{Name: "uniques", Type: statsd.Set, SetValue: "765", SampleRate: 1}},
+		{
+			line: "page.views:1|c|@0.5|#host:server01,canary",
+			m: statsd.Metric{Name: "page.views", Type: statsd.Counter, Value: 1, SampleRate: 0.5,
+				Tags: map[string]string{"host": "server01", "canary": "true"}},
+		},
+
+		{line: "gorets", err: `invalid metric: "gorets"`},
+		{line: ":1|c", err: `invalid metric: ":1|c"`},
+		{line: "gorets:1", err: `invalid metric: "gorets:1"`},
+		{line: "gorets:1|x", err: `unknown type of metric "gorets:1|x"`},
+		{line: "gorets:abc|c", err: `invalid value of metric "gorets:abc|c": strconv.ParseFloat: parsing "abc": invalid syntax`},
+		{line: "gorets:1|c|@0", err: `invalid sample rate of metric "gorets:1|c|@0"`},
+		{line: "gorets:1|c|@2", err: `invalid sample rate of metric "gorets:1|c|@2"`},
+		{line: "gorets:1|c|#:a", err: `invalid tag ":a" of metric "gorets:1|c|#:a"`},
+		{line: "gorets:1|c|foo", err: `invalid metric: "gorets:1|c|foo"`},
+	} {
+		m, err := statsd.ParseMetric(tt.line)
+		if errstr(err) != tt.err {
+			t.Errorf("%s: unexpected error: exp=%s, got=%v", tt.line, tt.err, err)
+		} else if err == nil && !reflect.DeepEqual(m, tt.m) {
+			t.Errorf("%s: unexpected metric:\n\nexp=%#v\n\ngot=%#v", tt.line, tt.m, m)
+		}
+	}
+}
+
+// errstr returns the string representation of an error.
+func errstr(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
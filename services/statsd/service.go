@@ -0,0 +1,398 @@
+package statsd
+
+import (
+	"bufio"
+	"errors"
+	"expvar"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdb/influxdb"
+	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/tsdb"
+)
+
+const (
+	// Arbitrary, the same as the UDP service.
+	parserChanLen = 1000
+)
+
+// statistics gathered by the StatsD package.
+const (
+	statMetricsReceived       = "metricsRx"
+	statBytesReceived         = "bytesRx"
+	statMetricsParseFail      = "metricsParseFail"
+	statMetricsRateLimited    = "metricsRateLimited"
+	statReadFail              = "readFail"
+	statTCPConnectionsActive  = "tcpConnsActive"
+	statTCPConnectionsHandled = "tcpConnsHandled"
+	statPointsFlushed         = "pointsFlushed"
+	statBatchesTrasmitted     = "batchesTx"
+	statPointsTransmitted     = "pointsTx"
+	statBatchesTransmitFail   = "batchesTxFail"
+)
+
+// Service represents a StatsD service that listens for metrics over UDP
+// and TCP, aggregates them over flush intervals and writes the aggregates.
+type Service struct {
+	conn  *net.UDPConn
+	addr  *net.UDPAddr
+	tcpln net.Listener
+	wg    sync.WaitGroup
+	done  chan struct{}
+
+	// The writer runs until the batcher emitted its last batch, after the
+	// other goroutines exited.
+	writerWg   sync.WaitGroup
+	writerDone chan struct{}
+
+	parserChan chan []byte
+	batcher    *tsdb.PointBatcher
+	aggregator *Aggregator
+	config     Config
+
+	PointsWriter interface {
+		WritePoints(p *cluster.WritePointsRequest) error
+	}
+
+	MetaStore interface {
+		CreateDatabaseIfNotExists(name string) (*meta.DatabaseInfo, error)
+	}
+
+	// RateLimiter drops the metrics over the limits of the database.
+	RateLimiter *tsdb.RateLimiter
+
+	Logger  *log.Logger
+	statMap *expvar.Map
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) *Service {
+	d := *c.WithDefaults()
+	return &Service{
+		config:     d,
+		done:       make(chan struct{}),
+		parserChan: make(chan []byte, parserChanLen),
+		batcher:    tsdb.NewPointBatcher(d.BatchSize, d.BatchPending, time.Duration(d.BatchTimeout)),
+		aggregator: NewAggregator(d.Percentiles, d.GaugeExpiry),
+		Logger:     log.New(os.Stderr, "[statsd] ", log.LstdFlags),
+	}
+}
+
+// Open starts the service
+func (s *Service) Open() (err error) {
+	// Configure expvar monitoring. It's OK to do this even if the service fails to open and
+	// should be done before any data could arrive for the service.
+	key := strings.Join([]string{"statsd", s.config.BindAddress}, ":")
+	tags := map[string]string{"bind": s.config.BindAddress}
+	s.statMap = influxdb.NewStatistics(key, "statsd", tags)
+	influxdb.DeclareGauges("statsd", statTCPConnectionsActive)
+
+	if err := s.config.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := s.MetaStore.CreateDatabaseIfNotExists(s.config.Database); err != nil {
+		return errors.New("Failed to ensure target database exists")
+	}
+
+	s.addr, err = net.ResolveUDPAddr("udp", s.config.BindAddress)
+	if err != nil {
+		s.Logger.Printf("Failed to resolve UDP address %s: %s", s.config.BindAddress, err)
+		return err
+	}
+
+	s.conn, err = net.ListenUDP("udp", s.addr)
+	if err != nil {
+		s.Logger.Printf("Failed to set up UDP listener at address %s: %s", s.addr, err)
+		return err
+	}
+
+	// Don't leave the UDP listener open if the service fails to open.
+	defer func() {
+		if err != nil {
+			s.conn.Close()
+			s.conn = nil
+		}
+	}()
+
+	if s.config.ReadBuffer != 0 {
+		err = s.conn.SetReadBuffer(s.config.ReadBuffer)
+		if err != nil {
+			s.Logger.Printf("Failed to set UDP read buffer to %d: %s",
+				s.config.ReadBuffer, err)
+			return err
+		}
+	}
+
+	s.Logger.Printf("Started listening on UDP: %s", s.conn.LocalAddr())
+
+	if s.config.TCPBindAddress != "" {
+		s.tcpln, err = net.Listen("tcp", s.config.TCPBindAddress)
+		if err != nil {
+			s.Logger.Printf("Failed to set up TCP listener at address %s: %s", s.config.TCPBindAddress, err)
+			return err
+		}
+
+		s.Logger.Printf("Started listening on TCP: %s", s.tcpln.Addr())
+		s.wg.Add(1)
+		go s.serveTCP()
+	}
+
+	s.batcher.Start()
+
+	s.wg.Add(3)
+	go s.serve()
+	go s.parser()
+	go s.flusher()
+
+	s.writerDone = make(chan struct{})
+	s.writerWg.Add(1)
+	go s.writer()
+
+	return nil
+}
+
+func (s *Service) writer() {
+	defer s.writerWg.Done()
+
+	for {
+		select {
+		case batch := <-s.batcher.Out():
+			s.writeBatch(batch)
+
+		case <-s.writerDone:
+			// Write the batches emitted while the batcher stopped.
+			for {
+				select {
+				case batch := <-s.batcher.Out():
+					s.writeBatch(batch)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// writeBatch writes a batch of points to the database.
+func (s *Service) writeBatch(batch []models.Point) {
+	if err := s.PointsWriter.WritePoints(&cluster.WritePointsRequest{
+		Database:         s.config.Database,
+		RetentionPolicy:  s.config.RetentionPolicy,
+		ConsistencyLevel: cluster.ConsistencyLevelOne,
+		Points:           batch,
+	}); err == nil {
+		s.statMap.Add(statBatchesTrasmitted, 1)
+		s.statMap.Add(statPointsTransmitted, int64(len(batch)))
+	} else {
+		s.Logger.Printf("failed to write point batch to database %q: %s", s.config.Database, err)
+		s.statMap.Add(statBatchesTransmitFail, 1)
+	}
+}
+
+func (s *Service) serve() {
+	defer s.wg.Done()
+
+	for {
+		buf := make([]byte, s.config.UDPPayloadSize)
+		n, _, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-s.done:
+				// We closed the connection, time to go.
+				return
+			default:
+			}
+			s.statMap.Add(statReadFail, 1)
+			s.Logger.Printf("Failed to read UDP message: %s", err)
+			continue
+		}
+		s.statMap.Add(statBytesReceived, int64(n))
+
+		select {
+		case s.parserChan <- buf[:n]:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *Service) parser() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case buf := <-s.parserChan:
+			lines := strings.Split(strings.TrimSpace(string(buf)), "\n")
+			if err := s.RateLimiter.Write(nil, s.config.Database, len(lines), len(buf)); err != nil {
+				s.statMap.Add(statMetricsRateLimited, int64(len(lines)))
+				continue
+			}
+
+			for _, line := range lines {
+				s.handleLine(line)
+			}
+		}
+	}
+}
+
+// serveTCP accepts TCP connections, each sending a metric per line.
+func (s *Service) serveTCP() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.tcpln.Accept()
+		if opErr, ok := err.(*net.OpError); ok && !opErr.Temporary() {
+			s.Logger.Println("StatsD TCP listener closed")
+			return
+		} else if err != nil {
+			s.Logger.Printf("Failed to accept TCP connection: %s", err)
+			continue
+		}
+
+		s.wg.Add(1)
+		go s.handleTCPConnection(conn)
+	}
+}
+
+// handleTCPConnection aggregates the metrics of a TCP connection until
+// it's closed.
+func (s *Service) handleTCPConnection(conn net.Conn) {
+	defer s.wg.Done()
+	defer conn.Close()
+	defer s.statMap.Add(statTCPConnectionsActive, -1)
+	s.statMap.Add(statTCPConnectionsActive, 1)
+	s.statMap.Add(statTCPConnectionsHandled, 1)
+
+	// Close the connection when the service is closed.
+	done, closed := s.done, make(chan struct{})
+	defer close(closed)
+	go func() {
+		select {
+		case <-done:
+			conn.Close()
+		case <-closed:
+		}
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := scanner.Text()
+		s.statMap.Add(statBytesReceived, int64(len(line)+1))
+
+		if err := s.RateLimiter.Write(nil, s.config.Database, 1, len(line)); err != nil {
+			s.statMap.Add(statMetricsRateLimited, 1)
+			continue
+		}
+		s.handleLine(line)
+	}
+}
+
+// handleLine parses a metric and adds it to the current interval.
+func (s *Service) handleLine(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+
+	m, err := ParseMetric(line)
+	if err != nil {
+		s.statMap.Add(statMetricsParseFail, 1)
+		s.Logger.Printf("Failed to parse metric: %s", err)
+		return
+	}
+	s.aggregator.Add(m)
+	s.statMap.Add(statMetricsReceived, 1)
+}
+
+// flusher writes the aggregated metrics at the end of each flush interval.
+func (s *Service) flusher() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(s.config.FlushInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			s.flush(now)
+
+		case <-s.done:
+			// Flush the current interval so its metrics aren't lost.
+			s.flush(time.Now())
+			return
+		}
+	}
+}
+
+// flush sends the metrics aggregated until now to the batcher. The batcher and
+// the writer run until the flusher exited, so sending doesn't block forever.
+func (s *Service) flush(now time.Time) {
+	points := s.aggregator.Flush(now.UTC())
+	for _, pt := range points {
+		s.batcher.In() <- pt
+	}
+	s.statMap.Add(statPointsFlushed, int64(len(points)))
+}
+
+// Close closes the underlying listeners.
+func (s *Service) Close() error {
+	if s.conn == nil {
+		return errors.New("Service already closed")
+	}
+
+	close(s.done)
+	s.conn.Close()
+	if s.tcpln != nil {
+		s.tcpln.Close()
+	}
+	s.wg.Wait()
+
+	// Write the points flushed on close before stopping the writer. Stop only
+	// emits the current batch so the batcher is flushed until it has taken
+	// all the points queued.
+	for len(s.batcher.In()) > 0 {
+		s.batcher.Flush()
+	}
+	s.batcher.Stop()
+	close(s.writerDone)
+	s.writerWg.Wait()
+
+	// Release all remaining resources.
+	s.done = nil
+	s.conn = nil
+	s.tcpln = nil
+
+	s.Logger.Print("Service closed")
+
+	return nil
+}
+
+// SetLogger sets the internal logger to the logger passed in.
+func (s *Service) SetLogger(l *log.Logger) {
+	s.Logger = l
+}
+
+// Addr returns the address of the UDP listener.
+func (s *Service) Addr() net.Addr {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.LocalAddr()
+}
+
+// TCPAddr returns the address of the TCP listener, or nil if it's disabled.
+func (s *Service) TCPAddr() net.Addr {
+	if s.tcpln == nil {
+		return nil
+	}
+	return s.tcpln.Addr()
+}
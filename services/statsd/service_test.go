@@ -0,0 +1,214 @@
+package statsd_test
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/services/statsd"
+	"github.com/influxdb/influxdb/toml"
+)
+
+// Ensure metrics received over UDP and TCP are aggregated and written.
+func TestService_Write(t *testing.T) {
+	t.Parallel()
+
+	config := statsd.Config{}
+	config.Database = "statsdb"
+	config.BindAddress = "127.0.0.1:0"
+	config.TCPBindAddress = "127.0.0.1:0"
+	config.BatchTimeout = toml.Duration(10 * time.Millisecond)
+	config.FlushInterval = toml.Duration(50 * time.Millisecond)
+
+	service := statsd.NewService(config)
+
+	// Allow test to wait until both metrics are written.
+	var mu sync.Mutex
+	values := make(map[string]float64)
+	done := make(chan struct{})
+	service.PointsWriter = &PointsWriter{
+		WritePointsFn: func(req *cluster.WritePointsRequest) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if req.Database != "statsdb" {
+				t.Errorf("unexpected database: %s", req.Database)
+			}
+			// Sum the values in case the metrics span flush intervals.
+			for _, pt := range req.Points {
+				v, _ := pt.Fields()["value"].(float64)
+				values[string(pt.Key())] += v
+			}
+			if len(values) == 2 && done != nil {
+				close(done)
+				done = nil
+			}
+			return nil
+		},
+	}
+	dbCreator := DatabaseCreator{}
+	service.MetaStore = &dbCreator
+
+	if err := service.Open(); err != nil {
+		t.Fatalf("failed to open StatsD service: %s", err)
+	}
+	defer service.Close()
+
+	if !dbCreator.Created {
+		t.Fatalf("failed to create target database")
+	}
+
+	udp, err := net.Dial("udp", service.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udp.Close()
+	if _, err := udp.Write([]byte("requests:1|c\nrequests:3|c|@0.5\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	tcp, err := net.Dial("tcp", service.TCPAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcp.Close()
+	if _, err := tcp.Write([]byte("load:42|g|#host:server01\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	ch := done
+	mu.Unlock()
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for points")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if values["requests"] != 7 {
+		t.Fatalf("unexpected requests: %v", values["requests"])
+	} else if values["load,host=server01"] != 42 {
+		t.Fatalf("unexpected load: %v", values["load,host=server01"])
+	}
+}
+
+// Ensure the metrics of the current interval are written when the service closes.
+func TestService_Close_Flush(t *testing.T) {
+	t.Parallel()
+
+	config := statsd.Config{}
+	config.Database = "statsdb"
+	config.BindAddress = "127.0.0.1:0"
+	config.BatchSize = 10
+	config.BatchPending = 200
+	config.BatchTimeout = toml.Duration(time.Hour)
+	config.FlushInterval = toml.Duration(time.Hour)
+
+	service := statsd.NewService(config)
+
+	var mu sync.Mutex
+	var n int
+	service.PointsWriter = &PointsWriter{
+		WritePointsFn: func(req *cluster.WritePointsRequest) error {
+			// Write slowly so the flushed points queue up in the batcher.
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			defer mu.Unlock()
+			n += len(req.Points)
+			return nil
+		},
+	}
+	service.MetaStore = &DatabaseCreator{}
+
+	if err := service.Open(); err != nil {
+		t.Fatalf("failed to open StatsD service: %s", err)
+	}
+
+	udp, err := net.Dial("udp", service.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udp.Close()
+
+	// Send many more metrics than a batch holds.
+	var buf bytes.Buffer
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&buf, "requests%d:1|c\n", i)
+	}
+	if _, err := udp.Write(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Give the service time to parse the metrics.
+	time.Sleep(100 * time.Millisecond)
+
+	if err := service.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if n != 2000 {
+		t.Fatalf("unexpected points written: %d", n)
+	}
+}
+
+// Ensure the UDP listener is closed when the TCP listener fails to open.
+func TestService_Open_TCPError(t *testing.T) {
+	t.Parallel()
+
+	// Find a free UDP port.
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := conn.LocalAddr().String()
+	conn.Close()
+
+	config := statsd.Config{}
+	config.Database = "statsdb"
+	config.BindAddress = addr
+	config.TCPBindAddress = "127.0.0.1:-1"
+
+	service := statsd.NewService(config)
+	service.MetaStore = &DatabaseCreator{}
+	if err := service.Open(); err == nil {
+		t.Fatal("expected error")
+	} else if service.Addr() != nil {
+		t.Fatalf("unexpected address: %s", service.Addr())
+	}
+
+	// The UDP port can be listened on again.
+	udpAddr, _ := net.ResolveUDPAddr("udp", addr)
+	conn, err = net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+}
+
+// PointsWriter represents a mock impl of PointsWriter.
+type PointsWriter struct {
+	WritePointsFn func(*cluster.WritePointsRequest) error
+}
+
+func (w *PointsWriter) WritePoints(p *cluster.WritePointsRequest) error {
+	return w.WritePointsFn(p)
+}
+
+type DatabaseCreator struct {
+	Created bool
+}
+
+func (d *DatabaseCreator) CreateDatabaseIfNotExists(name string) (*meta.DatabaseInfo, error) {
+	d.Created = true
+	return nil, nil
+}
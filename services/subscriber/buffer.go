@@ -0,0 +1,308 @@
+package subscriber
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/services/hh"
+)
+
+// ErrBufferFull is returned when a write doesn't fit in the buffer of a
+// destination.
+var ErrBufferFull = errors.New("subscription buffer is full")
+
+// buffer is a bounded FIFO of the write requests awaiting delivery to a
+// destination. The request at the head can be read again until the buffer
+// is advanced.
+type buffer interface {
+	Append(p *cluster.WritePointsRequest) error
+
+	// Current returns the request at the head of the buffer, or io.EOF if
+	// the buffer is empty.
+	Current() (*cluster.WritePointsRequest, error)
+	Advance() error
+	Close() error
+}
+
+// memoryBuffer is a buffer which holds the requests in memory.
+type memoryBuffer struct {
+	mu       sync.Mutex
+	requests []*cluster.WritePointsRequest
+	sizes    []int64
+	size     int64
+	maxSize  int64
+}
+
+func newMemoryBuffer(maxSize int64) *memoryBuffer {
+	return &memoryBuffer{maxSize: maxSize}
+}
+
+func (b *memoryBuffer) Append(p *cluster.WritePointsRequest) error {
+	var sz int64
+	for _, pt := range p.Points {
+		sz += int64(len(pt.String()))
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.size+sz > b.maxSize {
+		return ErrBufferFull
+	}
+	b.requests = append(b.requests, p)
+	b.sizes = append(b.sizes, sz)
+	b.size += sz
+	return nil
+}
+
+func (b *memoryBuffer) Current() (*cluster.WritePointsRequest, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.requests) == 0 {
+		return nil, io.EOF
+	}
+	return b.requests[0], nil
+}
+
+func (b *memoryBuffer) Advance() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.requests) == 0 {
+		return nil
+	}
+	b.size -= b.sizes[0]
+	b.requests[0] = nil
+	b.requests, b.sizes = b.requests[1:], b.sizes[1:]
+	return nil
+}
+
+func (b *memoryBuffer) Close() error { return nil }
+
+// diskBuffer is a buffer which holds the requests in a hinted handoff queue,
+// so they survive restarts. The requests of a subscription all have the same
+// database and retention policy, so only the points are stored.
+type diskBuffer struct {
+	db, rp string
+	queue  *hh.Queue
+}
+
+func newDiskBuffer(dir, db, rp string, maxSize int64) (*diskBuffer, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("mkdir all: %s", err)
+	}
+	queue, err := hh.NewQueue(dir, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := queue.Open(); err != nil {
+		return nil, err
+	}
+	return &diskBuffer{db: db, rp: rp, queue: queue}, nil
+}
+
+func (b *diskBuffer) Append(p *cluster.WritePointsRequest) error {
+	var buf []byte
+	for _, pt := range p.Points {
+		buf = append(buf, pt.String()...)
+		buf = append(buf, '\n')
+	}
+	if err := b.queue.Append(buf); err == hh.ErrQueueFull {
+		return ErrBufferFull
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (b *diskBuffer) Current() (*cluster.WritePointsRequest, error) {
+	buf, err := b.queue.Current()
+	if err != nil {
+		return nil, err
+	}
+	points, err := models.ParsePoints(buf)
+	if err != nil {
+		return nil, err
+	}
+	return &cluster.WritePointsRequest{
+		Database:        b.db,
+		RetentionPolicy: b.rp,
+		Points:          points,
+	}, nil
+}
+
+func (b *diskBuffer) Advance() error { return b.queue.Advance() }
+func (b *diskBuffer) Close() error   { return b.queue.Close() }
+
+// bufferedWriter buffers the writes to a destination and writes them in the
+// background. Failed writes are retried with an exponential backoff, and
+// writes are dropped while the buffer is full.
+type bufferedWriter struct {
+	writer PointsWriter
+	buf    buffer
+
+	RetryInterval    time.Duration
+	RetryMaxInterval time.Duration
+
+	failing int32 // set while the writes are backing off after a failure
+
+	wg     sync.WaitGroup
+	notify chan struct{}
+	done   chan struct{}
+
+	statMap *expvar.Map
+	Logger  *log.Logger
+}
+
+// newBufferedWriter returns a bufferedWriter writing the requests of buf to w.
+// The requests already in the buffer are written once it's opened.
+func newBufferedWriter(w PointsWriter, buf buffer, statMap *expvar.Map) *bufferedWriter {
+	return &bufferedWriter{
+		writer:           w,
+		buf:              buf,
+		RetryInterval:    DefaultRetryInterval,
+		RetryMaxInterval: DefaultRetryMaxInterval,
+		notify:           make(chan struct{}, 1),
+		done:             make(chan struct{}),
+		statMap:          statMap,
+		Logger:           log.New(os.Stderr, "[subscriber] ", log.LstdFlags),
+	}
+}
+
+// Open starts writing the buffered requests.
+func (b *bufferedWriter) Open() {
+	b.notify <- struct{}{}
+	b.wg.Add(1)
+	go b.run()
+}
+
+// Close stops writing, closes the buffer and the connection to the
+// destination, if any. Requests remaining in a disk buffer are written once
+// it's opened again.
+func (b *bufferedWriter) Close() error {
+	close(b.done)
+	b.wg.Wait()
+
+	if c, ok := b.writer.(io.Closer); ok {
+		c.Close()
+	}
+	return b.buf.Close()
+}
+
+// WritePoints adds the request to the buffer.
+func (b *bufferedWriter) WritePoints(p *cluster.WritePointsRequest) error {
+	if err := b.buf.Append(p); err != nil {
+		b.statMap.Add(statPointsDropped, int64(len(p.Points)))
+		return err
+	}
+	b.statMap.Add(statPointsBuffered, int64(len(p.Points)))
+
+	// Wake up the writer unless it's already been.
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Failing returns true while the writes to the destination are backing off
+// after a failure.
+func (b *bufferedWriter) Failing() bool {
+	return atomic.LoadInt32(&b.failing) == 1
+}
+
+// run writes the buffered requests when notified of new ones, or once the
+// retry interval elapsed after a failure.
+func (b *bufferedWriter) run() {
+	defer b.wg.Done()
+
+	interval := b.RetryInterval
+	var retry <-chan time.Time
+	for {
+		// New requests don't cut the backoff short.
+		notify := b.notify
+		if retry != nil {
+			notify = nil
+		}
+
+		select {
+		case <-b.done:
+			return
+		case <-notify:
+		case <-retry:
+		}
+
+		if err := b.flush(); err != nil {
+			b.Logger.Printf("write to subscription destination failed, retrying in %s: %s", interval, err)
+			atomic.StoreInt32(&b.failing, 1)
+			retry = time.After(interval)
+			interval *= 2
+			if interval > b.RetryMaxInterval {
+				interval = b.RetryMaxInterval
+			}
+			continue
+		}
+
+		// Success! Ensure backoff is cancelled.
+		atomic.StoreInt32(&b.failing, 0)
+		retry = nil
+		interval = b.RetryInterval
+	}
+}
+
+// flush writes the buffered requests until the buffer is empty, or returns
+// the error of the first failed write.
+func (b *bufferedWriter) flush() error {
+	for {
+		select {
+		case <-b.done:
+			return nil
+		default:
+		}
+
+		p, err := b.buf.Current()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			// The request can't be read back, skip it.
+			b.Logger.Printf("failed to read buffered write: %s", err)
+			b.statMap.Add(statWriteFailures, 1)
+			if err := b.buf.Advance(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := b.writer.WritePoints(p); err != nil {
+			b.statMap.Add(statWriteFailures, 1)
+			if _, ok := err.(rejectedError); !ok {
+				return err
+			}
+
+			// Writing the points again won't succeed, drop them.
+			b.Logger.Printf("subscription destination rejected %d points: %s", len(p.Points), err)
+			b.statMap.Add(statPointsDropped, int64(len(p.Points)))
+		} else {
+			b.statMap.Add(statPointsWritten, int64(len(p.Points)))
+		}
+
+		if err := b.buf.Advance(); err != nil {
+			return err
+		}
+	}
+}
+
+// rejectedError is returned by destinations which rejected the points of a
+// write, so that writing them again won't succeed.
+type rejectedError struct {
+	err error
+}
+
+func (e rejectedError) Error() string { return e.err.Error() }
@@ -1,12 +1,98 @@
 package subscriber
 
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdb/influxdb/toml"
+)
+
+const (
+	// BufferTypeMemory buffers the writes to a destination in memory.
+	BufferTypeMemory = "memory"
+
+	// BufferTypeDisk buffers the writes to a destination on disk, so they
+	// survive restarts.
+	BufferTypeDisk = "disk"
+)
+
+const (
+	// DefaultBufferType is the default type of the destination buffers.
+	DefaultBufferType = BufferTypeMemory
+
+	// DefaultBufferSize is the default maximum size of the buffer of each
+	// destination in bytes.
+	DefaultBufferSize = 10 * 1024 * 1024
+
+	// DefaultWriteTimeout is the default time within which a write to a
+	// destination must complete.
+	DefaultWriteTimeout = 5 * time.Second
+
+	// DefaultRetryInterval is the default amount of time the subscriber waits
+	// before retrying a failed write to a destination. With each failure, this
+	// retry interval increases exponentially until it reaches the maximum.
+	DefaultRetryInterval = time.Second
+
+	// DefaultRetryMaxInterval is the maximum the retry interval will ever be.
+	DefaultRetryMaxInterval = time.Minute
+
+	// DefaultUDPPayloadSize is the default maximum size of the datagrams sent
+	// to UDP destinations, which avoids fragmentation on most networks.
+	DefaultUDPPayloadSize = 1024
+)
+
 // Config represents a configuration of the subscriber service.
 type Config struct {
 	// Whether to enable to Subscriber service
 	Enabled bool `toml:"enabled"`
+
+	// The writes to each destination are buffered, either in memory or on
+	// disk under BufferDir, up to BufferSize bytes. Writes are dropped while
+	// the buffer of a destination is full.
+	BufferType string `toml:"buffer-type"`
+	BufferDir  string `toml:"buffer-dir"`
+	BufferSize int64  `toml:"buffer-size"`
+
+	WriteTimeout     toml.Duration `toml:"write-timeout"`
+	RetryInterval    toml.Duration `toml:"retry-interval"`
+	RetryMaxInterval toml.Duration `toml:"retry-max-interval"`
+
+	// Skip the verification of the certificates of https destinations.
+	InsecureSkipVerify bool `toml:"insecure-skip-verify"`
+
+	UDPPayloadSize int `toml:"udp-payload-size"`
 }
 
 // NewConfig returns a new instance of a subscriber config.
 func NewConfig() Config {
-	return Config{Enabled: true}
+	return Config{
+		Enabled:          true,
+		BufferType:       DefaultBufferType,
+		BufferSize:       DefaultBufferSize,
+		WriteTimeout:     toml.Duration(DefaultWriteTimeout),
+		RetryInterval:    toml.Duration(DefaultRetryInterval),
+		RetryMaxInterval: toml.Duration(DefaultRetryMaxInterval),
+		UDPPayloadSize:   DefaultUDPPayloadSize,
+	}
+}
+
+// Validate returns an error if the config is invalid.
+func (c *Config) Validate() error {
+	switch c.BufferType {
+	case BufferTypeMemory:
+	case BufferTypeDisk:
+		if c.BufferDir == "" {
+			return errors.New("buffer-dir must be specified for disk buffers")
+		}
+	default:
+		return fmt.Errorf("unknown buffer type %q", c.BufferType)
+	}
+	if c.BufferSize <= 0 {
+		return errors.New("buffer-size must be positive")
+	}
+	if c.RetryInterval <= 0 || c.RetryMaxInterval < c.RetryInterval {
+		return errors.New("retry-interval must be positive and at most retry-max-interval")
+	}
+	return nil
 }
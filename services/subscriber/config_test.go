@@ -2,6 +2,7 @@ package subscriber_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/influxdb/influxdb/services/subscriber"
@@ -12,6 +13,14 @@ func TestConfig_Parse(t *testing.T) {
 	var c subscriber.Config
 	if _, err := toml.Decode(`
 enabled = false
+buffer-type = "disk"
+buffer-dir = "/var/lib/influxdb/subscriber"
+buffer-size = 1024
+write-timeout = "2s"
+retry-interval = "100ms"
+retry-max-interval = "10s"
+insecure-skip-verify = true
+udp-payload-size = 512
 `, &c); err != nil {
 		t.Fatal(err)
 	}
@@ -19,5 +28,42 @@ enabled = false
 	// Validate configuration.
 	if c.Enabled != false {
 		t.Fatalf("unexpected enabled state: %v", c.Enabled)
+	} else if c.BufferType != subscriber.BufferTypeDisk {
+		t.Fatalf("unexpected buffer type: %s", c.BufferType)
+	} else if c.BufferDir != "/var/lib/influxdb/subscriber" {
+		t.Fatalf("unexpected buffer dir: %s", c.BufferDir)
+	} else if c.BufferSize != 1024 {
+		t.Fatalf("unexpected buffer size: %d", c.BufferSize)
+	} else if time.Duration(c.WriteTimeout) != 2*time.Second {
+		t.Fatalf("unexpected write timeout: %v", c.WriteTimeout)
+	} else if time.Duration(c.RetryInterval) != 100*time.Millisecond {
+		t.Fatalf("unexpected retry interval: %v", c.RetryInterval)
+	} else if time.Duration(c.RetryMaxInterval) != 10*time.Second {
+		t.Fatalf("unexpected retry max interval: %v", c.RetryMaxInterval)
+	} else if c.InsecureSkipVerify != true {
+		t.Fatalf("unexpected insecure skip verify: %v", c.InsecureSkipVerify)
+	} else if c.UDPPayloadSize != 512 {
+		t.Fatalf("unexpected udp payload size: %d", c.UDPPayloadSize)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	c := subscriber.NewConfig()
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, fn := range []func(c *subscriber.Config){
+		func(c *subscriber.Config) { c.BufferType = "tape" },
+		func(c *subscriber.Config) { c.BufferType = subscriber.BufferTypeDisk },
+		func(c *subscriber.Config) { c.BufferSize = 0 },
+		func(c *subscriber.Config) { c.RetryInterval = 0 },
+		func(c *subscriber.Config) { c.RetryMaxInterval = c.RetryInterval / 2 },
+	} {
+		c := subscriber.NewConfig()
+		fn(&c)
+		if err := c.Validate(); err == nil {
+			t.Errorf("%d. expected error", i)
+		}
 	}
 }
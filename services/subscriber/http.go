@@ -0,0 +1,89 @@
+package subscriber
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/influxdb/influxdb/cluster"
+)
+
+// HTTP supports writing points to the /write endpoint of an HTTP or HTTPS
+// destination, such as another InfluxDB, using the line protocol. The
+// credentials of the destination URL are sent with basic authentication.
+type HTTP struct {
+	url      url.URL
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewHTTP returns a new HTTP writer for the destination u. Writes must
+// complete within timeout.
+func NewHTTP(u url.URL, timeout time.Duration, insecureSkipVerify bool) *HTTP {
+	h := &HTTP{
+		url: u,
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: insecureSkipVerify},
+			},
+		},
+	}
+	if u.User != nil {
+		h.username = u.User.Username()
+		h.password, _ = u.User.Password()
+		h.url.User = nil
+	}
+	h.url.Path = path.Join("/", u.Path, "write")
+	return h
+}
+
+// WritePoints posts the points to the destination.
+func (h *HTTP) WritePoints(p *cluster.WritePointsRequest) error {
+	var buf bytes.Buffer
+	for _, pt := range p.Points {
+		buf.WriteString(pt.String())
+		buf.WriteByte('\n')
+	}
+
+	u := h.url
+	params := u.Query()
+	params.Set("db", p.Database)
+	params.Set("rp", p.RetentionPolicy)
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "")
+	if h.username != "" {
+		req.SetBasicAuth(h.username, h.password)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	switch {
+	case resp.StatusCode/100 == 2:
+		return nil
+	case resp.StatusCode == http.StatusBadRequest:
+		// The destination won't ever accept these points, unlike when it's
+		// unavailable, throttling writes or rejecting the credentials.
+		return rejectedError{fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))}
+	default:
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+}
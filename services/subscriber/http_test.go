@@ -0,0 +1,69 @@
+package subscriber_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/services/subscriber"
+)
+
+// Ensure points are posted to the write endpoint of the destination.
+func TestHTTP_WritePoints(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Method != "POST" {
+			t.Errorf("unexpected method: %s", r.Method)
+		} else if r.URL.Path != "/influx/write" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		} else if q := r.URL.Query(); q.Get("db") != "db0" || q.Get("rp") != "rp0" || q.Get("precision") != "n" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		} else if u, p, _ := r.BasicAuth(); u != "alice" || p != "secret" {
+			t.Errorf("unexpected credentials: %s %s", u, p)
+		} else if string(body) != "cpu value=1 1000000000\ncpu value=2 2000000000\n" {
+			t.Errorf("unexpected body: %q", body)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	u.User = url.UserPassword("alice", "secret")
+	u.Path = "/influx"
+	u.RawQuery = "precision=n"
+
+	w := subscriber.NewHTTP(*u, time.Second, false)
+	if err := w.WritePoints(&cluster.WritePointsRequest{
+		Database:        "db0",
+		RetentionPolicy: "rp0",
+		Points: []models.Point{
+			models.MustNewPoint("cpu", nil, models.Fields{"value": 1.0}, time.Unix(1, 0)),
+			models.MustNewPoint("cpu", nil, models.Fields{"value": 2.0}, time.Unix(2, 0)),
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure write errors of the destination are returned.
+func TestHTTP_WritePoints_Error(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "database not found", http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	w := subscriber.NewHTTP(*u, time.Second, false)
+	if err := w.WritePoints(&cluster.WritePointsRequest{Database: "db0"}); err == nil || err.Error() != "404 Not Found: database not found" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"log"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/influxdb/influxdb"
 	"github.com/influxdb/influxdb/cluster"
@@ -16,8 +18,10 @@ import (
 
 // Statistics for the Subscriber service.
 const (
-	statPointsWritten = "pointsWritten"
-	statWriteFailures = "writeFailures"
+	statPointsWritten  = "pointsWritten"
+	statWriteFailures  = "writeFailures"
+	statPointsBuffered = "pointsBuffered"
+	statPointsDropped  = "pointsDropped"
 )
 
 // PointsWriter is an interface for writing points to a subscription destination.
@@ -37,7 +41,8 @@ type subEntry struct {
 // to defined third party destinations.
 // Subscriptions are defined per database and retention policy.
 type Service struct {
-	subs      map[subEntry]PointsWriter
+	subMu     sync.RWMutex
+	subs      map[subEntry]*balancewriter
 	MetaStore interface {
 		Databases() ([]meta.DatabaseInfo, error)
 		WaitForDataChanged() error
@@ -49,18 +54,21 @@ type Service struct {
 	wg              sync.WaitGroup
 	closed          bool
 	mu              sync.Mutex
+	config          Config
 }
 
 // NewService returns a subscriber service with given settings
 func NewService(c Config) *Service {
-	return &Service{
-		subs:            make(map[subEntry]PointsWriter),
-		NewPointsWriter: newPointsWriter,
-		Logger:          log.New(os.Stderr, "[subscriber] ", log.LstdFlags),
-		statMap:         influxdb.NewStatistics("subscriber", "subscriber", nil),
-		points:          make(chan *cluster.WritePointsRequest),
-		closed:          true,
+	s := &Service{
+		subs:    make(map[subEntry]*balancewriter),
+		Logger:  log.New(os.Stderr, "[subscriber] ", log.LstdFlags),
+		statMap: influxdb.NewStatistics("subscriber", "subscriber", nil),
+		points:  make(chan *cluster.WritePointsRequest),
+		closed:  true,
+		config:  c,
 	}
+	s.NewPointsWriter = s.newPointsWriter
+	return s
 }
 
 // Open starts the subscription service.
@@ -93,6 +101,16 @@ func (s *Service) Close() error {
 	close(s.points)
 	s.closed = true
 	s.wg.Wait()
+
+	// Stop writing the buffered points.
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	for se, sub := range s.subs {
+		if err := sub.Close(); err != nil {
+			s.Logger.Printf("failed to close subscription for %s %s: %s", se.db, se.rp, err)
+		}
+		delete(s.subs, se)
+	}
 	s.Logger.Println("closed service")
 	return nil
 }
@@ -128,6 +146,9 @@ func (s *Service) Update() error {
 	if err != nil {
 		return err
 	}
+
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
 	allEntries := make(map[subEntry]bool, 0)
 	// Add in new subscriptions
 	for _, dbi := range dbis {
@@ -151,9 +172,17 @@ func (s *Service) Update() error {
 		}
 	}
 
-	// Remove deleted subs
-	for se := range s.subs {
+	// Remove deleted subs, and the points buffered for them.
+	for se, sub := range s.subs {
 		if !allEntries[se] {
+			if err := sub.Close(); err != nil {
+				s.Logger.Printf("failed to close subscription for %s %s: %s", se.db, se.rp, err)
+			}
+			if s.config.BufferType == BufferTypeDisk {
+				if err := os.RemoveAll(s.bufferDir(se)); err != nil {
+					s.Logger.Printf("failed to remove buffer of subscription for %s %s: %s", se.db, se.rp, err)
+				}
+			}
 			delete(s.subs, se)
 			s.Logger.Println("deleted old subscription for", se.db, se.rp)
 		}
@@ -162,7 +191,7 @@ func (s *Service) Update() error {
 	return nil
 }
 
-func (s *Service) createSubscription(se subEntry, mode string, destinations []string) (PointsWriter, error) {
+func (s *Service) createSubscription(se subEntry, mode string, destinations []string) (*balancewriter, error) {
 	var bm BalanceMode
 	switch mode {
 	case "ALL":
@@ -172,34 +201,63 @@ func (s *Service) createSubscription(se subEntry, mode string, destinations []st
 	default:
 		return nil, fmt.Errorf("unknown balance mode %q", mode)
 	}
-	writers := make([]PointsWriter, len(destinations))
-	statMaps := make([]*expvar.Map, len(writers))
-	for i, dest := range destinations {
+	b := &balancewriter{bm: bm}
+	for _, dest := range destinations {
 		u, err := url.Parse(dest)
 		if err != nil {
+			b.Close()
 			return nil, err
 		}
 		w, err := s.NewPointsWriter(*u)
 		if err != nil {
+			b.Close()
 			return nil, err
 		}
-		writers[i] = w
+
+		// The credentials of the destination are left out of its buffer
+		// directory and statistics.
+		name := redactURL(*u)
+
+		// Buffer the writes to each destination.
+		var buf buffer
+		if s.config.BufferType == BufferTypeDisk {
+			dir := filepath.Join(s.bufferDir(se), url.QueryEscape(name))
+			if buf, err = newDiskBuffer(dir, se.db, se.rp, s.config.BufferSize); err != nil {
+				b.Close()
+				return nil, err
+			}
+		} else {
+			buf = newMemoryBuffer(s.config.BufferSize)
+		}
+
 		tags := map[string]string{
 			"database":         se.db,
 			"retention_policy": se.rp,
 			"name":             se.name,
 			"mode":             mode,
-			"destination":      dest,
+			"destination":      name,
 		}
-		key := strings.Join([]string{"subscriber", se.db, se.rp, se.name, dest}, ":")
-		statMaps[i] = influxdb.NewStatistics(key, "subscriber", tags)
+		key := strings.Join([]string{"subscriber", se.db, se.rp, se.name, name}, ":")
+		bw := newBufferedWriter(w, buf, influxdb.NewStatistics(key, "subscriber", tags))
+		bw.RetryInterval = time.Duration(s.config.RetryInterval)
+		bw.RetryMaxInterval = time.Duration(s.config.RetryMaxInterval)
+		bw.Logger = s.Logger
+		bw.Open()
+		b.writers = append(b.writers, bw)
 	}
 	s.Logger.Println("created new subscription for", se.db, se.rp)
-	return &balancewriter{
-		bm:       bm,
-		writers:  writers,
-		statMaps: statMaps,
-	}, nil
+	return b, nil
+}
+
+// redactURL returns the URL of a destination without its user info.
+func redactURL(u url.URL) string {
+	u.User = nil
+	return u.String()
+}
+
+// bufferDir returns the directory of the disk buffers of a subscription.
+func (s *Service) bufferDir(se subEntry) string {
+	return filepath.Join(s.config.BufferDir, se.db, se.rp, se.name)
 }
 
 // Points returns a channel into which write point requests can be sent.
@@ -211,6 +269,7 @@ func (s *Service) Points() chan<- *cluster.WritePointsRequest {
 func (s *Service) writePoints() {
 	defer s.wg.Done()
 	for p := range s.points {
+		s.subMu.RLock()
 		for se, sub := range s.subs {
 			if p.Database == se.db && p.RetentionPolicy == se.rp {
 				err := sub.WritePoints(p)
@@ -220,6 +279,7 @@ func (s *Service) writePoints() {
 				}
 			}
 		}
+		s.subMu.RUnlock()
 		s.statMap.Add(statPointsWritten, int64(len(p.Points)))
 	}
 }
@@ -234,42 +294,75 @@ const (
 	ANY
 )
 
-// balances writes across PointsWriters according to BalanceMode
+// balances writes across the buffered writers of the destinations according
+// to BalanceMode. In ANY mode, the destinations backing off after a failed
+// write are skipped, unless the buffers of all the others are full, and a
+// write fails over to the next destination when the buffer of a destination
+// is full.
 type balancewriter struct {
-	bm       BalanceMode
-	writers  []PointsWriter
-	statMaps []*expvar.Map
-	i        int
+	bm      BalanceMode
+	writers []*bufferedWriter
+	i       int
 }
 
 func (b *balancewriter) WritePoints(p *cluster.WritePointsRequest) error {
 	var lastErr error
+	var failing []*bufferedWriter
 	for range b.writers {
 		// round robin through destinations.
 		i := b.i
 		w := b.writers[i]
 		b.i = (b.i + 1) % len(b.writers)
 
-		// write points to destination.
+		if b.bm == ANY && w.Failing() {
+			failing = append(failing, w)
+			continue
+		}
+
+		// buffer points for the destination.
 		err := w.WritePoints(p)
 		if err != nil {
 			lastErr = err
-			b.statMaps[i].Add(statWriteFailures, 1)
-		} else {
-			b.statMaps[i].Add(statPointsWritten, int64(len(p.Points)))
-			if b.bm == ANY {
-				break
-			}
+		} else if b.bm == ANY {
+			return nil
+		}
+	}
+
+	// Only buffer points for failing destinations when no other can.
+	for _, w := range failing {
+		err := w.WritePoints(p)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+// Close closes the writers of the destinations.
+func (b *balancewriter) Close() error {
+	var lastErr error
+	for _, w := range b.writers {
+		if err := w.Close(); err != nil {
+			lastErr = err
 		}
 	}
 	return lastErr
 }
 
 // Creates a PointsWriter from the given URL
-func newPointsWriter(u url.URL) (PointsWriter, error) {
+func (s *Service) newPointsWriter(u url.URL) (PointsWriter, error) {
 	switch u.Scheme {
 	case "udp":
-		return NewUDP(u.Host), nil
+		w := NewUDP(u.Host)
+		if s.config.UDPPayloadSize > 0 {
+			w.PayloadSize = s.config.UDPPayloadSize
+		}
+		return w, nil
+	case "tcp":
+		return NewTCP(u.Host, time.Duration(s.config.WriteTimeout)), nil
+	case "http", "https":
+		return NewHTTP(u, time.Duration(s.config.WriteTimeout), s.config.InsecureSkipVerify), nil
 	default:
 		return nil, fmt.Errorf("unknown destination scheme %s", u.Scheme)
 	}
@@ -1,13 +1,22 @@
 package subscriber_test
 
 import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/influxdb/influxdb/cluster"
 	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/models"
 	"github.com/influxdb/influxdb/services/subscriber"
+	"github.com/influxdb/influxdb/toml"
 )
 
 type MetaStore struct {
@@ -446,3 +455,248 @@ func TestService_WaitForDataChanged(t *testing.T) {
 
 	close(dataChanged)
 }
+
+// Ensure failed writes to a destination are retried until they succeed.
+func TestService_Retry(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var requests int
+	written := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if requests++; requests <= 2 {
+			http.Error(w, "timeout", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		written <- string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	c := subscriber.NewConfig()
+	c.RetryInterval = toml.Duration(10 * time.Millisecond)
+	s := subscriber.NewService(c)
+	s.MetaStore = NewSubscriptionMetaStore(ts.URL)
+	s.Open()
+	defer s.Close()
+
+	s.Points() <- &cluster.WritePointsRequest{
+		Database:        "db0",
+		RetentionPolicy: "rp0",
+		Points:          []models.Point{models.MustNewPoint("cpu", nil, models.Fields{"value": 1.0}, time.Unix(1, 0))},
+	}
+
+	select {
+	case body := <-written:
+		if body != "cpu value=1 1000000000\n" {
+			t.Fatalf("unexpected body: %q", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected points to be written")
+	}
+}
+
+// Ensure writes in mode ANY skip the destinations whose writes are failing.
+func TestService_ModeANY_Failing(t *testing.T) {
+	t.Parallel()
+
+	failed := make(chan struct{}, 1)
+	ts0 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case failed <- struct{}{}:
+		default:
+		}
+		http.Error(w, "timeout", http.StatusServiceUnavailable)
+	}))
+	defer ts0.Close()
+
+	written := make(chan string, 3)
+	ts1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		written <- string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts1.Close()
+
+	ms := NewSubscriptionMetaStore("")
+	ms.DatabasesFn = func() ([]meta.DatabaseInfo, error) {
+		return []meta.DatabaseInfo{
+			{
+				Name: "db0",
+				RetentionPolicies: []meta.RetentionPolicyInfo{
+					{
+						Name: "rp0",
+						Subscriptions: []meta.SubscriptionInfo{
+							{Name: "s0", Mode: "ANY", Destinations: []string{ts0.URL, ts1.URL}},
+						},
+					},
+				},
+			},
+		}, nil
+	}
+
+	c := subscriber.NewConfig()
+	c.RetryInterval = toml.Duration(time.Hour)
+	s := subscriber.NewService(c)
+	s.MetaStore = ms
+	s.Open()
+	defer s.Close()
+
+	write := func(v float64) {
+		s.Points() <- &cluster.WritePointsRequest{
+			Database:        "db0",
+			RetentionPolicy: "rp0",
+			Points:          []models.Point{models.MustNewPoint("cpu", nil, models.Fields{"value": v}, time.Unix(1, 0))},
+		}
+	}
+
+	// The first write goes to the first destination, which fails.
+	write(1)
+	select {
+	case <-failed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a write to the first destination")
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// The next writes all go to the second destination.
+	write(2)
+	write(3)
+	for _, exp := range []string{"cpu value=2 1000000000\n", "cpu value=3 1000000000\n"} {
+		select {
+		case body := <-written:
+			if body != exp {
+				t.Fatalf("unexpected body: %q", body)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected points to be written")
+		}
+	}
+}
+
+// Ensure points buffered on disk are written once the service is opened
+// again.
+func TestService_DiskBuffer(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "subscriber")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var mu sync.Mutex
+	available := false
+	written := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !available {
+			http.Error(w, "timeout", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		written <- string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	c := subscriber.NewConfig()
+	c.BufferType = subscriber.BufferTypeDisk
+	c.BufferDir = dir
+	c.RetryInterval = toml.Duration(10 * time.Millisecond)
+	c.RetryMaxInterval = toml.Duration(10 * time.Millisecond)
+
+	// Write points while the destination is unavailable.
+	s := subscriber.NewService(c)
+	s.MetaStore = NewSubscriptionMetaStore(ts.URL)
+	s.Open()
+	s.Points() <- &cluster.WritePointsRequest{
+		Database:        "db0",
+		RetentionPolicy: "rp0",
+		Points:          []models.Point{models.MustNewPoint("cpu", nil, models.Fields{"value": 1.0}, time.Unix(1, 0))},
+	}
+	s.Close()
+
+	mu.Lock()
+	available = true
+	mu.Unlock()
+
+	s = subscriber.NewService(c)
+	s.MetaStore = NewSubscriptionMetaStore(ts.URL)
+	s.Open()
+	defer s.Close()
+
+	select {
+	case body := <-written:
+		if body != "cpu value=1 1000000000\n" {
+			t.Fatalf("unexpected body: %q", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected buffered points to be written")
+	}
+}
+
+// Ensure the credentials of a destination aren't written in the path of its
+// disk buffer.
+func TestService_DiskBuffer_Credentials(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "subscriber")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := subscriber.NewConfig()
+	c.BufferType = subscriber.BufferTypeDisk
+	c.BufferDir = dir
+
+	s := subscriber.NewService(c)
+	s.MetaStore = NewSubscriptionMetaStore("http://admin:secret@h0:8086")
+	s.NewPointsWriter = func(u url.URL) (subscriber.PointsWriter, error) {
+		return Subscription{}, nil
+	}
+	s.Open()
+	defer s.Close()
+
+	// Wait for the subscription to be created.
+	var paths []string
+	for i := 0; i < 100 && len(paths) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+		paths, _ = filepath.Glob(filepath.Join(dir, "db0", "rp0", "s0", "*"))
+	}
+	if len(paths) != 1 {
+		t.Fatalf("unexpected buffers: %v", paths)
+	} else if strings.Contains(paths[0], "secret") {
+		t.Fatalf("credentials in buffer path: %s", paths[0])
+	}
+}
+
+// NewSubscriptionMetaStore returns a MetaStore with a subscription of db0.rp0
+// to a single destination, whose data never changes.
+func NewSubscriptionMetaStore(dest string) MetaStore {
+	return MetaStore{
+		DatabasesFn: func() ([]meta.DatabaseInfo, error) {
+			return []meta.DatabaseInfo{
+				{
+					Name: "db0",
+					RetentionPolicies: []meta.RetentionPolicyInfo{
+						{
+							Name: "rp0",
+							Subscriptions: []meta.SubscriptionInfo{
+								{Name: "s0", Mode: "ALL", Destinations: []string{dest}},
+							},
+						},
+					},
+				},
+			}, nil
+		},
+		WaitForDataChangedFn: func() error {
+			select {}
+		},
+	}
+}
@@ -0,0 +1,68 @@
+package subscriber
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/influxdb/influxdb/cluster"
+)
+
+// TCP supports writing points over a persistent TCP connection using the
+// line protocol, one point per line.
+type TCP struct {
+	mu      sync.Mutex
+	addr    string
+	conn    net.Conn
+	timeout time.Duration
+}
+
+// NewTCP returns a new TCP writer. Writes must complete within timeout.
+func NewTCP(addr string, timeout time.Duration) *TCP {
+	return &TCP{
+		addr:    addr,
+		timeout: timeout,
+	}
+}
+
+// WritePoints writes points over the TCP connection, connecting first if
+// the connection isn't open. The connection is closed if writing fails, and
+// opened again on the next write.
+func (t *TCP) WritePoints(p *cluster.WritePointsRequest) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.conn == nil {
+		conn, err := net.DialTimeout("tcp", t.addr, t.timeout)
+		if err != nil {
+			return err
+		}
+		t.conn = conn
+	}
+
+	var buf []byte
+	for _, pt := range p.Points {
+		buf = append(buf, pt.String()...)
+		buf = append(buf, '\n')
+	}
+
+	t.conn.SetWriteDeadline(time.Now().Add(t.timeout))
+	if _, err := t.conn.Write(buf); err != nil {
+		t.conn.Close()
+		t.conn = nil
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection.
+func (t *TCP) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn == nil {
+		return nil
+	}
+	err := t.conn.Close()
+	t.conn = nil
+	return err
+}
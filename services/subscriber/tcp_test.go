@@ -0,0 +1,49 @@
+package subscriber_test
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/services/subscriber"
+)
+
+// Ensure points are written over a single persistent connection.
+func TestTCP_WritePoints(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	w := subscriber.NewTCP(ln.Addr().String(), time.Second)
+	defer w.Close()
+	for i := 1; i <= 2; i++ {
+		if err := w.WritePoints(&cluster.WritePointsRequest{
+			Points: []models.Point{models.MustNewPoint("cpu", nil, models.Fields{"value": float64(i)}, time.Unix(int64(i), 0))},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	r := bufio.NewReader(conn)
+	for _, exp := range []string{"cpu value=1 1000000000\n", "cpu value=2 2000000000\n"} {
+		if line, err := r.ReadString('\n'); err != nil {
+			t.Fatal(err)
+		} else if line != exp {
+			t.Fatalf("unexpected line: %q", line)
+		}
+	}
+}
@@ -2,41 +2,79 @@ package subscriber
 
 import (
 	"net"
+	"sync"
 
 	"github.com/influxdb/influxdb/cluster"
 )
 
 // UDP supports writing points over UDP using the line protocol.
 type UDP struct {
+	mu   sync.Mutex
 	addr string
+	conn net.Conn
+
+	// PayloadSize is the maximum size of the datagrams. Points are packed in
+	// datagrams up to this size, and points larger than it are sent alone.
+	PayloadSize int
 }
 
 // NewUDP returns a new UDP listener with default options.
 func NewUDP(addr string) *UDP {
-	return &UDP{addr: addr}
+	return &UDP{
+		addr:        addr,
+		PayloadSize: DefaultUDPPayloadSize,
+	}
 }
 
-// WritePoints writes points over UDP transport.
-func (u *UDP) WritePoints(p *cluster.WritePointsRequest) (err error) {
-	var addr *net.UDPAddr
-	var con *net.UDPConn
-	addr, err = net.ResolveUDPAddr("udp", u.addr)
-	if err != nil {
-		return
-	}
+// WritePoints writes points over UDP transport. The connection is kept open
+// for the next writes, unless writing fails.
+func (u *UDP) WritePoints(p *cluster.WritePointsRequest) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
 
-	con, err = net.DialUDP("udp", nil, addr)
-	if err != nil {
-		return
+	if u.conn == nil {
+		conn, err := net.Dial("udp", u.addr)
+		if err != nil {
+			return err
+		}
+		u.conn = conn
 	}
-	defer con.Close()
 
-	for _, p := range p.Points {
-		_, err = con.Write([]byte(p.String()))
-		if err != nil {
-			return
+	var buf []byte
+	for _, pt := range p.Points {
+		line := pt.String() + "\n"
+		if len(buf) > 0 && len(buf)+len(line) > u.PayloadSize {
+			if err := u.write(buf); err != nil {
+				return err
+			}
+			buf = buf[:0]
 		}
+		buf = append(buf, line...)
+	}
+	if len(buf) > 0 {
+		return u.write(buf)
+	}
+	return nil
+}
+
+// write sends a datagram, closing the connection if it fails.
+func (u *UDP) write(buf []byte) error {
+	if _, err := u.conn.Write(buf); err != nil {
+		u.conn.Close()
+		u.conn = nil
+		return err
+	}
+	return nil
+}
 
+// Close closes the connection.
+func (u *UDP) Close() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.conn == nil {
+		return nil
 	}
-	return
+	err := u.conn.Close()
+	u.conn = nil
+	return err
 }
@@ -0,0 +1,50 @@
+package subscriber_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/services/subscriber"
+)
+
+// Ensure points are packed in datagrams up to the payload size, and that
+// points larger than the payload size are sent alone.
+func TestUDP_WritePoints(t *testing.T) {
+	t.Parallel()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w := subscriber.NewUDP(conn.LocalAddr().String())
+	w.PayloadSize = 48
+	defer w.Close()
+	if err := w.WritePoints(&cluster.WritePointsRequest{
+		Points: []models.Point{
+			models.MustNewPoint("cpu", nil, models.Fields{"value": 1.0}, time.Unix(1, 0)),
+			models.MustNewPoint("cpu", nil, models.Fields{"value": 2.0}, time.Unix(2, 0)),
+			models.MustNewPoint("cpu", map[string]string{"host": "server01", "region": "us-west"}, models.Fields{"value": 3.0}, time.Unix(3, 0)),
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 1024)
+	for _, exp := range []string{
+		"cpu value=1 1000000000\ncpu value=2 2000000000\n",
+		"cpu,host=server01,region=us-west value=3 3000000000\n",
+	} {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		} else if string(buf[:n]) != exp {
+			t.Fatalf("unexpected datagram: %q", buf[:n])
+		}
+	}
+}
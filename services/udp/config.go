@@ -63,6 +63,10 @@ type Config struct {
 	ReadBuffer      int           `toml:"read-buffer"`
 	BatchTimeout    toml.Duration `toml:"batch-timeout"`
 	UDPPayloadSize  int           `toml:"udp-payload-size"`
+
+	// RateLimited subjects the points received to the per-database limits
+	// of the [rate-limits] section.
+	RateLimited bool `toml:"rate-limited"`
 }
 
 // WithDefaults takes the given config and returns a new config with any required
@@ -31,6 +31,7 @@ const (
 	statBatchesTrasmitted   = "batchesTx"
 	statPointsTransmitted   = "pointsTx"
 	statBatchesTransmitFail = "batchesTxFail"
+	statPointsRateLimited   = "pointsRateLimited"
 )
 
 //
@@ -56,6 +57,9 @@ type Service struct {
 		CreateDatabaseIfNotExists(name string) (*meta.DatabaseInfo, error)
 	}
 
+	// RateLimiter drops the points over the limits of the database.
+	RateLimiter *tsdb.RateLimiter
+
 	Logger  *log.Logger
 	statMap *expvar.Map
 }
@@ -187,6 +191,11 @@ func (s *Service) parser() {
 				continue
 			}
 
+			if err := s.RateLimiter.Write(nil, s.config.Database, len(points), len(buf)); err != nil {
+				s.statMap.Add(statPointsRateLimited, int64(len(points)))
+				continue
+			}
+
 			for _, point := range points {
 				s.batcher.In() <- point
 			}
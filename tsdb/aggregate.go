@@ -3,6 +3,7 @@ package tsdb
 import (
 	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 	"time"
@@ -46,6 +47,9 @@ func (e *AggregateExecutor) close() {
 func (e *AggregateExecutor) Execute(closing <-chan struct{}) <-chan *models.Row {
 	out := make(chan *models.Row, 0)
 	go e.execute(out, closing)
+	if e.stmt.HasValueSort() {
+		return sortRows(e.stmt, out, closing)
+	}
 	return out
 }
 
@@ -53,6 +57,11 @@ func (e *AggregateExecutor) execute(out chan *models.Row, closing <-chan struct{
 	// It's important to close all resources when execution completes.
 	defer e.close()
 
+	// Interrupt mappers waiting on remote nodes if execution is closed.
+	done := make(chan struct{})
+	defer close(done)
+	go interruptMappers(e.mappers, closing, done)
+
 	// Create the functions which will reduce values from mappers for
 	// a given interval. The function offsets within this slice match
 	// the offsets within the value slices that are returned by the
@@ -152,14 +161,22 @@ func (e *AggregateExecutor) execute(out chan *models.Row, closing <-chan struct{
 			out <- &models.Row{Err: err}
 		}
 
-		// Perform any mathematics.
-		values = processForMath(e.stmt.Fields, values)
+		if e.stmt.HasTransform() {
+			// Transforms are applied to the filled values before any
+			// mathematics e.g. abs(difference(mean(value))).
+			values = e.processFill(values)
+			values = e.processTransform(values)
+			values = processForMath(e.stmt.Fields, values)
+		} else {
+			// Perform any mathematics.
+			values = processForMath(e.stmt.Fields, values)
 
-		// Handle any fill options
-		values = e.processFill(values)
+			// Handle any fill options
+			values = e.processFill(values)
 
-		// process derivatives
-		values = e.processDerivative(values)
+			// process derivatives
+			values = e.processDerivative(values)
+		}
 
 		// If we have multiple tag sets we'll want to filter out the empty ones
 		if hasMultipleTagSets && resultsEmpty(values) {
@@ -174,7 +191,7 @@ func (e *AggregateExecutor) execute(out chan *models.Row, closing <-chan struct{
 		case out <- row:
 		case <-closing:
 			out <- &models.Row{Err: fmt.Errorf("execute was closed by caller")}
-			break
+			return
 		case <-time.After(30 * time.Second):
 			// This should never happen, so if it does, it is a problem
 			out <- &models.Row{Err: fmt.Errorf("execute was closed by read timeout")}
@@ -241,10 +258,7 @@ func (e *AggregateExecutor) hasMultipleTagSets() bool {
 
 // ascending returns true if statement is sorted in ascending order.
 func (e *AggregateExecutor) ascending() bool {
-	if len(e.stmt.SortFields) == 0 {
-		return true
-	}
-	return e.stmt.SortFields[0].Ascending
+	return e.stmt.TimeAscending()
 }
 
 // mappersDrained returns whether all the executors Mappers have been drained of data.
@@ -343,6 +357,10 @@ func (e *AggregateExecutor) processFill(results [][]interface{}) [][]interface{}
 		return newResults
 	}
 
+	if e.stmt.Fill == influxql.LinearFill {
+		return processLinearFill(results, isCount)
+	}
+
 	// They're either filling with previous values or a specific number
 	for i, vals := range results {
 		// start at 1 because the first value is always time
@@ -362,6 +380,62 @@ func (e *AggregateExecutor) processFill(results [][]interface{}) [][]interface{}
 	return results
 }
 
+// processLinearFill fills empty windows with values interpolated between the closest
+// non-empty windows before and after them. Windows at the start or end of the results
+// without a neighbour on both sides are left empty, as are non-numeric columns.
+func processLinearFill(results [][]interface{}, isCount bool) [][]interface{} {
+	if len(results) == 0 {
+		return results
+	}
+
+	// start at 1 because the first value is always time
+	for j := 1; j < len(results[0]); j++ {
+		prev := -1
+		for i, vals := range results {
+			if vals[j] == nil || (isCount && isZero(vals[j])) {
+				continue
+			}
+
+			// Interpolate all the empty windows since the last non-empty one.
+			if prev >= 0 && i-prev > 1 {
+				x0 := results[prev][0].(time.Time).UnixNano()
+				x1 := vals[0].(time.Time).UnixNano()
+				for k := prev + 1; k < i; k++ {
+					x := results[k][0].(time.Time).UnixNano()
+					if v := interpolate(x, x0, x1, results[prev][j], vals[j]); v != nil {
+						results[k][j] = v
+					}
+				}
+			}
+			prev = i
+		}
+	}
+	return results
+}
+
+// interpolate returns the value at x on the line between (x0, y0) and (x1, y1).
+// Integers are only returned if both y0 and y1 are integers. Returns nil if
+// either of the values is not numeric.
+func interpolate(x, x0, x1 int64, y0, y1 interface{}) interface{} {
+	if i0, ok := y0.(int64); ok {
+		if i1, ok := y1.(int64); ok {
+			// Scale by the fraction of the interval in float64, since the
+			// product of a value and a time difference overflows int64.
+			return i0 + int64(math.Floor(float64(i1-i0)*float64(x-x0)/float64(x1-x0)+0.5))
+		}
+	}
+
+	f0, ok := toFloat64(y0)
+	if !ok {
+		return nil
+	}
+	f1, ok := toFloat64(y1)
+	if !ok {
+		return nil
+	}
+	return f0 + (f1-f0)*float64(x-x0)/float64(x1-x0)
+}
+
 // Returns true if the given interface is a zero valued int64 or float64.
 func isZero(i interface{}) bool {
 	switch v := i.(type) {
@@ -390,6 +464,22 @@ func (e *AggregateExecutor) processDerivative(results [][]interface{}) [][]inter
 	return results
 }
 
+// processTransform returns the values of the transform function applied to the results.
+func (e *AggregateExecutor) processTransform(results [][]interface{}) [][]interface{} {
+	c := e.stmt.FunctionCalls()[0]
+	if c.Name != "holt_winters" {
+		return ProcessAggregateTransform(results, c)
+	}
+
+	n, _ := c.Args[1].(*influxql.NumberLiteral)
+	period, _ := c.Args[2].(*influxql.NumberLiteral)
+	interval, err := e.stmt.GroupByInterval()
+	if err != nil {
+		return results
+	}
+	return ProcessHoltWinters(results, int(n.Val), int(period.Val), interval, e.ascending())
+}
+
 func (e *AggregateExecutor) processFunctions(results [][]interface{}, columnNames []string) ([][]interface{}, error) {
 	callInPosition := e.stmt.FunctionCallsByPosition()
 	hasTimeField := e.stmt.HasTimeFieldSpecified()
@@ -425,6 +515,8 @@ func (e *AggregateExecutor) processFunctions(results [][]interface{}, columnName
 				if err != nil {
 					return results, err
 				}
+			case "histogram":
+				results = e.processHistogram(results)
 			}
 		}
 	}
@@ -513,6 +605,23 @@ func (e *AggregateExecutor) processAggregates(results [][]interface{}, columnNam
 	return values, nil
 }
 
+// processHistogram expands the buckets of each interval into a row per bucket.
+// Intervals without any values are returned as a single row of nulls.
+func (e *AggregateExecutor) processHistogram(results [][]interface{}) [][]interface{} {
+	var values [][]interface{}
+	for _, vals := range results {
+		buckets, _ := vals[1].(HistogramBuckets)
+		if len(buckets) == 0 {
+			values = append(values, []interface{}{vals[0], nil, nil})
+			continue
+		}
+		for _, b := range buckets {
+			values = append(values, []interface{}{vals[0], b.Start, b.Count})
+		}
+	}
+	return values
+}
+
 func (e *AggregateExecutor) aggregatePointToQueryResult(p PositionPoint, tMin time.Time, call *influxql.Call, columnNames []string) []interface{} {
 	tm := time.Unix(0, p.Time).UTC()
 	// If we didn't explicity ask for time, and we have a group by, then use TMIN for the time returned
@@ -546,10 +655,12 @@ type AggregateMapper struct {
 	cursors     []CursorSet
 	cursorIndex int
 
-	interval     int   // Current interval for which data is being fetched.
-	intervalN    int   // Maximum number of intervals to return.
-	intervalSize int64 // Size of each interval.
-	qminWindow   int64 // Minimum time of the query floored to start of interval.
+	interval      int   // Current interval for which data is being fetched.
+	intervalN     int   // Maximum number of intervals to return.
+	intervalSize  int64 // Size of each interval.
+	intervalStart int64 // Start time of the current interval.
+	intervalLocal bool  // Intervals are aligned to the statement's timezone.
+	qminWindow    int64 // Minimum time of the query floored to start of interval.
 
 	mapFuncs   []mapFunc // The mapping functions.
 	fieldNames []string  // the field name being read for mapping.
@@ -557,6 +668,8 @@ type AggregateMapper struct {
 	selectFields []string
 	selectTags   []string
 	whereFields  []string
+
+	limiter *PointLimiter
 }
 
 // NewAggregateMapper returns a new instance of AggregateMapper.
@@ -588,44 +701,10 @@ func (m *AggregateMapper) Open() error {
 		return err
 	}
 
-	// For GROUP BY time queries, limit the number of data points returned by the limit and offset
-	d, err := m.stmt.GroupByInterval()
-	if err != nil {
+	if err := m.initializeIntervals(); err != nil {
 		return err
-	}
-
-	m.intervalSize = d.Nanoseconds()
-	if m.qmin == 0 || m.intervalSize == 0 {
-		m.intervalN = 1
-		m.intervalSize = m.qmax - m.qmin
-	} else {
-		intervalTop := m.qmax/m.intervalSize*m.intervalSize + m.intervalSize
-		intervalBottom := m.qmin / m.intervalSize * m.intervalSize
-		m.intervalN = int((intervalTop - intervalBottom) / m.intervalSize)
-	}
-
-	if m.stmt.Limit > 0 || m.stmt.Offset > 0 {
-		// ensure that the offset isn't higher than the number of points we'd get
-		if m.stmt.Offset > m.intervalN {
-			return nil
-		}
-
-		// Take the lesser of either the pre computed number of GROUP BY buckets that
-		// will be in the result or the limit passed in by the user
-		if m.stmt.Limit < m.intervalN {
-			m.intervalN = m.stmt.Limit
-		}
-	}
-
-	// If we are exceeding our MaxGroupByPoints error out
-	if m.intervalN > MaxGroupByPoints {
-		return errors.New("too many points in the group by interval. maybe you forgot to specify a where time clause?")
-	}
-
-	// Ensure that the start time for the results is on the start of the window.
-	m.qminWindow = m.qmin
-	if m.intervalSize > 0 && m.intervalN > 1 {
-		m.qminWindow = m.qminWindow / m.intervalSize * m.intervalSize
+	} else if m.intervalN == 0 {
+		return nil
 	}
 
 	// Get a read-only transaction.
@@ -705,6 +784,56 @@ func (m *AggregateMapper) openMeasurement(mm *Measurement) error {
 	return nil
 }
 
+// initializeIntervals sets the number and size of the GROUP BY intervals for
+// the mapper's time range. No intervals are returned if the offset exceeds them.
+func (m *AggregateMapper) initializeIntervals() error {
+	// For GROUP BY time queries, limit the number of data points returned by the limit and offset
+	d, err := m.stmt.GroupByInterval()
+	if err != nil {
+		return err
+	}
+
+	m.intervalSize = d.Nanoseconds()
+	if m.qmin == 0 || m.intervalSize == 0 {
+		m.intervalN = 1
+		m.intervalSize = m.qmax - m.qmin
+	} else {
+		// Intervals may be shorter or longer than the interval size when they
+		// cross a daylight savings change so round to the nearest count.
+		_, intervalTop := m.stmt.TimeWindow(m.qmax)
+		intervalBottom, _ := m.stmt.TimeWindow(m.qmin)
+		m.intervalN = int((intervalTop - intervalBottom + m.intervalSize/2) / m.intervalSize)
+		m.intervalLocal = m.stmt.Location != nil
+	}
+
+	if m.stmt.Limit > 0 || m.stmt.Offset > 0 {
+		// ensure that the offset isn't higher than the number of points we'd get
+		if m.stmt.Offset > m.intervalN {
+			m.intervalN = 0
+			return nil
+		}
+
+		// Take the lesser of either the pre computed number of GROUP BY buckets that
+		// will be in the result or the limit passed in by the user
+		if m.stmt.Limit < m.intervalN {
+			m.intervalN = m.stmt.Limit
+		}
+	}
+
+	// If we are exceeding our MaxGroupByPoints error out
+	if m.intervalN > MaxGroupByPoints {
+		return errors.New("too many points in the group by interval. maybe you forgot to specify a where time clause?")
+	}
+
+	// Ensure that the start time for the results is on the start of the window.
+	m.qminWindow = m.qmin
+	if m.intervalSize > 0 && m.intervalN > 1 {
+		m.qminWindow, _ = m.stmt.TimeWindow(m.qminWindow)
+	}
+
+	return nil
+}
+
 // initializeMapFunctions initialize the mapping functions for the mapper.
 func (m *AggregateMapper) initializeMapFunctions() error {
 	// Set up each mapping function for this statement.
@@ -740,6 +869,9 @@ func (m *AggregateMapper) initializeMapFunctions() error {
 	return nil
 }
 
+// SetPointLimiter sets the limiter counting the points read by the mapper.
+func (m *AggregateMapper) SetPointLimiter(l *PointLimiter) { m.limiter = l }
+
 // Close closes the mapper.
 func (m *AggregateMapper) Close() {
 	if m != nil && m.tx != nil {
@@ -800,12 +932,18 @@ func (m *AggregateMapper) NextChunk() (interface{}, error) {
 		Value: make([]interface{}, len(m.mapFuncs)),
 	}
 
+	// Each mapping function reads the points of its own field, so the points
+	// of the interval are counted once by the function reading the most.
+	var pointN int
 	for i := range m.mapFuncs {
 		// Build a map input from the cursor.
 		input := &MapInput{
 			TMin:  -1,
 			Items: readMapItems(cursorSet.Cursors, m.fieldNames[i], qmin, qmin, qmax),
 		}
+		if len(input.Items) > pointN {
+			pointN = len(input.Items)
+		}
 
 		if len(m.stmt.Dimensions) > 0 && !m.stmt.HasTimeFieldSpecified() {
 			input.TMin = tmin
@@ -820,6 +958,10 @@ func (m *AggregateMapper) NextChunk() (interface{}, error) {
 	}
 	output.Values = append(output.Values, mapperValue)
 
+	if err := m.limiter.Add(pointN); err != nil {
+		return nil, err
+	}
+
 	return output, nil
 }
 
@@ -894,18 +1036,36 @@ func readMapItems(cursors []*TagsCursor, field string, seek, tmin, tmax int64) [
 // nextInterval returns the next interval for which to return data.
 // If start is less than 0 there are no more intervals.
 func (m *AggregateMapper) nextInterval() (start, end int64) {
-	t := m.qminWindow + int64(m.interval+m.stmt.Offset)*m.intervalSize
+	// Skip the intervals before the offset when starting a new tagset.
+	if m.interval == 0 {
+		m.intervalStart = m.qminWindow
+		for i := 0; i < m.stmt.Offset; i++ {
+			m.intervalStart = m.intervalEnd(m.intervalStart)
+		}
+	}
+	t := m.intervalStart
 
 	// On to next interval.
 	m.interval++
 	if t > m.qmax || m.interval > m.intervalN {
 		start, end = -1, 1
 	} else {
-		start, end = t, t+m.intervalSize
+		start, end = t, m.intervalEnd(t)
+		m.intervalStart = end
 	}
 	return
 }
 
+// intervalEnd returns the end of the interval starting at t. Intervals in a
+// timezone are aligned to its wall clock so they may vary in size.
+func (m *AggregateMapper) intervalEnd(t int64) int64 {
+	if m.intervalLocal {
+		_, end := m.stmt.TimeWindow(t)
+		return end
+	}
+	return t + m.intervalSize
+}
+
 type CursorSet struct {
 	Measurement string
 	Tags        map[string]string
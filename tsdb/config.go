@@ -70,6 +70,14 @@ const (
 	// DefaultMaxPointsPerBlock is the maximum number of points in an encoded
 	// block in a TSM file
 	DefaultMaxPointsPerBlock = 1000
+
+	// DefaultMaxSeriesPerDatabase is the maximum number of series a node can hold per database.
+	// The default of zero places no limit on the series.
+	DefaultMaxSeriesPerDatabase = 0
+
+	// DefaultMaxValuesPerTag is the maximum number of values a tag can have within a measurement.
+	// The default of zero places no limit on the tag values.
+	DefaultMaxValuesPerTag = 0
 )
 
 type Config struct {
@@ -101,6 +109,11 @@ type Config struct {
 	MaxPointsPerBlock              int           `toml:"max-points-per-block"`
 
 	DataLoggingEnabled bool `toml:"data-logging-enabled"`
+
+	// Limits on the cardinality of the index. Points creating series beyond
+	// the limits are dropped. A limit of zero disables it.
+	MaxSeriesPerDatabase int `toml:"max-series-per-database"`
+	MaxValuesPerTag      int `toml:"max-values-per-tag"`
 }
 
 func NewConfig() Config {
@@ -131,6 +144,9 @@ func NewConfig() Config {
 		CompactFullWriteColdDuration:   toml.Duration(DefaultCompactFullWriteColdDuration),
 
 		DataLoggingEnabled: true,
+
+		MaxSeriesPerDatabase: DefaultMaxSeriesPerDatabase,
+		MaxValuesPerTag:      DefaultMaxValuesPerTag,
 	}
 }
 
@@ -154,3 +170,45 @@ func (c *Config) Validate() error {
 
 	return nil
 }
+
+// QueryConfig represents the configuration of the limits on queries. A limit
+// of zero disables it.
+type QueryConfig struct {
+	MaxConcurrentQueries int           `toml:"max-concurrent-queries"`
+	QueryTimeout         toml.Duration `toml:"query-timeout"`
+	MaxSelectSeriesN     int           `toml:"max-select-series"`
+	MaxSelectPointN      int           `toml:"max-select-point"`
+	MaxSelectBucketsN    int           `toml:"max-select-buckets"`
+}
+
+// NewQueryConfig returns an instance of QueryConfig with defaults.
+func NewQueryConfig() QueryConfig {
+	return QueryConfig{}
+}
+
+// Limits returns the limits applying to each query.
+func (c QueryConfig) Limits() QueryLimits {
+	return QueryLimits{
+		Timeout:           time.Duration(c.QueryTimeout),
+		MaxSelectSeriesN:  c.MaxSelectSeriesN,
+		MaxSelectPointN:   c.MaxSelectPointN,
+		MaxSelectBucketsN: c.MaxSelectBucketsN,
+	}
+}
+
+// RateLimitConfig represents the configuration of the rates of writes and
+// queries allowed per second, for each user and for each database. A rate of
+// zero is unlimited.
+type RateLimitConfig struct {
+	UserPointsPerSecond      int64 `toml:"user-points-per-second"`
+	UserBytesPerSecond       int64 `toml:"user-bytes-per-second"`
+	UserQueriesPerSecond     int64 `toml:"user-queries-per-second"`
+	DatabasePointsPerSecond  int64 `toml:"database-points-per-second"`
+	DatabaseBytesPerSecond   int64 `toml:"database-bytes-per-second"`
+	DatabaseQueriesPerSecond int64 `toml:"database-queries-per-second"`
+}
+
+// NewRateLimitConfig returns an instance of RateLimitConfig with defaults.
+func NewRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{}
+}
@@ -16,6 +16,10 @@ import (
 var (
 	// ErrFormatNotFound is returned when no format can be determined from a path.
 	ErrFormatNotFound = errors.New("format not found")
+
+	// ErrDeleteRangeNotSupported is returned when an engine can only delete
+	// series across all time.
+	ErrDeleteRangeNotSupported = errors.New("engine does not support deleting a time range")
 )
 
 // Engine represents a swappable storage engine for the shard.
@@ -29,6 +33,7 @@ type Engine interface {
 	Begin(writable bool) (Tx, error)
 	WritePoints(points []models.Point, measurementFieldsToSave map[string]*MeasurementFields, seriesToCreate []*SeriesCreate) error
 	DeleteSeries(keys []string) error
+	DeleteSeriesRange(keys []string, min, max int64) error
 	DeleteMeasurement(name string, seriesKeys []string) error
 	SeriesCount() (n int, err error)
 
@@ -8,6 +8,7 @@ import (
 	"hash/fnv"
 	"io"
 	"log"
+	"math"
 	"os"
 	"sort"
 	"sync"
@@ -326,6 +327,15 @@ func (e *Engine) WritePoints(points []models.Point, measurementFieldsToSave map[
 	return nil
 }
 
+// DeleteSeriesRange deletes the series values between min and max.  Only
+// deletes across all time are supported.
+func (e *Engine) DeleteSeriesRange(keys []string, min, max int64) error {
+	if min != math.MinInt64 || max != math.MaxInt64 {
+		return tsdb.ErrDeleteRangeNotSupported
+	}
+	return e.DeleteSeries(keys)
+}
+
 // DeleteSeries deletes the series from the engine.
 func (e *Engine) DeleteSeries(keys []string) error {
 	e.mu.Lock()
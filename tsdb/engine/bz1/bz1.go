@@ -502,6 +502,15 @@ func (e *Engine) writeBlocks(bkt *bolt.Bucket, a [][]byte) error {
 	return nil
 }
 
+// DeleteSeriesRange deletes the series values between min and max.  Only
+// deletes across all time are supported.
+func (e *Engine) DeleteSeriesRange(keys []string, min, max int64) error {
+	if min != math.MinInt64 || max != math.MaxInt64 {
+		return tsdb.ErrDeleteRangeNotSupported
+	}
+	return e.DeleteSeries(keys)
+}
+
 // DeleteSeries deletes the series from the engine.
 func (e *Engine) DeleteSeries(keys []string) error {
 	// remove it from the WAL first
@@ -69,7 +69,8 @@ type Cache struct {
 
 	// snapshots are the cache objects that are currently being written to tsm files
 	// they're kept in memory while flushing so they can be queried along with the cache.
-	// they are read only and should never be modified
+	// they are read only and should never be modified, except by DeleteRange while
+	// no snapshot is being written.
 	snapshots     []*Cache
 	snapshotsSize uint64
 }
@@ -221,6 +222,48 @@ func (c *Cache) Delete(keys []string) {
 	}
 }
 
+// DeleteRange will remove the values for all keys containing points
+// between min and max from the cache and any snapshots.
+func (c *Cache) DeleteRange(keys []string, min, max int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.size -= c.deleteRange(keys, min, max)
+	for _, s := range c.snapshots {
+		n := s.deleteRange(keys, min, max)
+		s.size -= n
+		c.snapshotsSize -= n
+	}
+}
+
+// deleteRange removes the values between min and max for keys from the store
+// and returns the number of bytes removed.  It assumes the lock has been taken.
+func (c *Cache) deleteRange(keys []string, min, max int64) uint64 {
+	var n uint64
+	for _, k := range keys {
+		e := c.store[k]
+		if e == nil {
+			continue
+		}
+
+		values := e.values.Exclude(min, max)
+		if len(values) == len(e.values) {
+			continue
+		}
+		n += uint64(e.values.Size() - values.Size())
+
+		if len(values) == 0 {
+			delete(c.store, k)
+			continue
+		}
+
+		// Replace the entry rather than modifying it since the values
+		// may be in use by a reader.
+		c.store[k] = &entry{values: values, needSort: e.needSort}
+	}
+	return n
+}
+
 // merged returns a copy of hot and snapshot values. The copy will be merged, deduped, and
 // sorted. It assumes all necessary locks have been taken. If the caller knows that the
 // the hot source data for the key will not be changed, it is safe to call this function
@@ -369,6 +412,8 @@ func (cl *CacheLoader) Load(cache *Cache) error {
 					}
 				case *DeleteWALEntry:
 					cache.Delete(t.Keys)
+				case *DeleteRangeWALEntry:
+					cache.DeleteRange(t.Keys, t.Min, t.Max)
 				}
 			}
 
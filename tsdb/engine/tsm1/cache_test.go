@@ -149,6 +149,42 @@ func TestCache_CacheSnapshot(t *testing.T) {
 	}
 }
 
+func TestCache_DeleteRange(t *testing.T) {
+	v0 := NewValue(time.Unix(1, 0).UTC(), 1.0)
+	v1 := NewValue(time.Unix(2, 0).UTC(), 2.0)
+	v2 := NewValue(time.Unix(3, 0).UTC(), 3.0)
+	v3 := NewValue(time.Unix(4, 0).UTC(), 4.0)
+
+	c := NewCache(0)
+	if err := c.Write("foo", Values{v0, v1}); err != nil {
+		t.Fatalf("failed to write key foo to cache: %s", err.Error())
+	}
+
+	// Values in the snapshot should be deleted as well
+	c.Snapshot()
+
+	if err := c.Write("foo", Values{v2, v3}); err != nil {
+		t.Fatalf("failed to write key foo to cache: %s", err.Error())
+	}
+	if err := c.Write("bar", Values{v1}); err != nil {
+		t.Fatalf("failed to write key bar to cache: %s", err.Error())
+	}
+
+	c.DeleteRange([]string{"foo", "bar"}, v1.UnixNano(), v2.UnixNano())
+
+	if exp, got := (Values{v0, v3}), c.Values("foo"); !reflect.DeepEqual(exp, got) {
+		t.Fatalf("cache values incorrect after delete, exp %v, got %v", exp, got)
+	}
+
+	if exp, keys := []string{"foo"}, c.Keys(); !reflect.DeepEqual(keys, exp) {
+		t.Fatalf("cache keys incorrect after delete, exp %v, got %v", exp, keys)
+	}
+
+	if exp, n := uint64(v3.Size()), c.Size(); n != exp {
+		t.Fatalf("cache size incorrect after delete, exp %d, got %d", exp, n)
+	}
+}
+
 func TestCache_CacheEmptySnapshot(t *testing.T) {
 	c := NewCache(512)
 
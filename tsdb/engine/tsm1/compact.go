@@ -620,6 +620,10 @@ type block struct {
 	key              string
 	minTime, maxTime time.Time
 	b                []byte
+
+	// tombstones are the deleted time ranges of the key in the file the block
+	// was read from.
+	tombstones []TimeRange
 }
 
 type blocks []*block
@@ -663,76 +667,93 @@ func (k *tsmKeyIterator) Next() bool {
 		}
 	}
 
-	// Read the next block from each TSM iterator
-	for i, v := range k.buf {
-		if v == nil {
-			iter := k.iterators[i]
-			if iter.Next() {
-				key, minTime, maxTime, b, err := iter.Read()
-				if err != nil {
-					k.err = err
-				}
-
-				k.buf[i] = append(k.buf[i], &block{
-					minTime: minTime,
-					maxTime: maxTime,
-					key:     key,
-					b:       b,
-				})
-
-				blockKey := key
-				for iter.PeekNext() == blockKey {
-					iter.Next()
+	for {
+		// Read the next block from each TSM iterator
+		for i, v := range k.buf {
+			if v == nil {
+				iter := k.iterators[i]
+				if iter.Next() {
 					key, minTime, maxTime, b, err := iter.Read()
 					if err != nil {
 						k.err = err
 					}
 
+					// Any time ranges of the key that have been deleted from this reader
+					tombstones := k.readers[i].TombstoneRange(key)
+
 					k.buf[i] = append(k.buf[i], &block{
-						minTime: minTime,
-						maxTime: maxTime,
-						key:     key,
-						b:       b,
+						minTime:    minTime,
+						maxTime:    maxTime,
+						key:        key,
+						b:          b,
+						tombstones: tombstones,
 					})
+
+					blockKey := key
+					for iter.PeekNext() == blockKey {
+						iter.Next()
+						key, minTime, maxTime, b, err := iter.Read()
+						if err != nil {
+							k.err = err
+						}
+
+						k.buf[i] = append(k.buf[i], &block{
+							minTime:    minTime,
+							maxTime:    maxTime,
+							key:        key,
+							b:          b,
+							tombstones: tombstones,
+						})
+					}
 				}
 			}
 		}
-	}
 
-	// Each reader could have a different key that it's currently at, need to find
-	// the next smallest one to keep the sort ordering.
-	var minKey string
-	for _, b := range k.buf {
-		// block could be nil if the iterator has been exhausted for that file
-		if len(b) == 0 {
-			continue
+		// Each reader could have a different key that it's currently at, need to find
+		// the next smallest one to keep the sort ordering.
+		var minKey string
+		for _, b := range k.buf {
+			// block could be nil if the iterator has been exhausted for that file
+			if len(b) == 0 {
+				continue
+			}
+			if minKey == "" || b[0].key < minKey {
+				minKey = b[0].key
+			}
 		}
-		if minKey == "" || b[0].key < minKey {
-			minKey = b[0].key
+
+		// Now we need to find all blocks that match the min key so we can combine and dedupe
+		// the blocks if necessary
+		for i, b := range k.buf {
+			if len(b) == 0 {
+				continue
+			}
+			if b[0].key == minKey {
+				k.blocks = append(k.blocks, b...)
+				k.buf[i] = nil
+			}
 		}
-	}
 
-	// Now we need to find all blocks that match the min key so we can combine and dedupe
-	// the blocks if necessary
-	for i, b := range k.buf {
-		if len(b) == 0 {
-			continue
+		// All of the iterators have been exhausted
+		if len(k.blocks) == 0 {
+			return false
 		}
-		if b[0].key == minKey {
-			k.blocks = append(k.blocks, b...)
-			k.buf[i] = nil
+
+		// Remove any deleted values from the blocks.  If every value for the key
+		// was deleted, move on to the next key.
+		k.blocks = k.excludeTombstones(k.blocks)
+		if len(k.blocks) == 0 {
+			continue
 		}
-	}
 
-	// If we have more than one block, we many need to dedup
-	var dedup bool
+		// If we have more than one block, we many need to dedup
+		var dedup bool
 
-	// Only one block, just return early everything after is wasted work
-	if len(k.blocks) == 1 {
-		return true
-	}
+		// Only one block, just return early everything after is wasted work
+		if len(k.blocks) == 1 {
+			return true
+		}
 
-	if len(k.blocks) > 1 {
 		// Quickly scan each block to see if any overlap with the first block, if they overlap then
 		// we need to dedup as there may be duplicate points now
 		for i := 1; i < len(k.blocks); i++ {
@@ -741,10 +762,59 @@ func (k *tsmKeyIterator) Next() bool {
 				break
 			}
 		}
+		k.blocks = k.combine(dedup)
+
+		return len(k.blocks) > 0
 	}
-	k.blocks = k.combine(dedup)
+}
 
-	return len(k.blocks) > 0
+// excludeTombstones re-encodes any blocks that contain values within a
+// tombstoned time range without those values.  Blocks left empty are dropped.
+func (k *tsmKeyIterator) excludeTombstones(src blocks) blocks {
+	dst := src[:0]
+	for _, b := range src {
+		var overlaps bool
+		for _, tr := range b.tombstones {
+			if tr.Overlaps(b.minTime.UnixNano(), b.maxTime.UnixNano()) {
+				overlaps = true
+				break
+			}
+		}
+
+		if !overlaps {
+			dst = append(dst, b)
+			continue
+		}
+
+		v, err := DecodeBlock(b.b, nil)
+		if err != nil {
+			k.err = err
+			return nil
+		}
+
+		values := Values(v)
+		for _, tr := range b.tombstones {
+			values = values.Exclude(tr.Min, tr.Max)
+		}
+
+		if len(values) == 0 {
+			continue
+		}
+
+		cb, err := values.Encode(nil)
+		if err != nil {
+			k.err = err
+			return nil
+		}
+
+		dst = append(dst, &block{
+			minTime: values[0].Time(),
+			maxTime: values[len(values)-1].Time(),
+			key:     b.key,
+			b:       cb,
+		})
+	}
+	return dst
 }
 
 // combine returns a new set of blocks using the current blocks in the buffers.  If dedup
@@ -488,6 +488,66 @@ func TestTSMKeyIterator_MultipleKeysDeleted(t *testing.T) {
 	}
 }
 
+// Tests that values deleted by time range are excluded when iterating blocks.
+func TestTSMKeyIterator_DeleteRange(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	v1 := tsm1.NewValue(time.Unix(1, 0), 1.0)
+	v2 := tsm1.NewValue(time.Unix(2, 0), 2.0)
+	v3 := tsm1.NewValue(time.Unix(3, 0), 3.0)
+
+	points1 := map[string][]tsm1.Value{
+		"cpu,host=A#!~#value": []tsm1.Value{v1, v2, v3},
+		"cpu,host=B#!~#value": []tsm1.Value{v2},
+	}
+
+	r1 := MustTSMReader(dir, 1, points1)
+	r1.DeleteRange([]string{"cpu,host=A#!~#value"}, v2.UnixNano(), v2.UnixNano())
+
+	points2 := map[string][]tsm1.Value{
+		"cpu,host=B#!~#value": []tsm1.Value{v1},
+	}
+
+	r2 := MustTSMReader(dir, 2, points2)
+	r2.DeleteRange([]string{"cpu,host=B#!~#value"}, v1.UnixNano(), v1.UnixNano())
+	r1.DeleteRange([]string{"cpu,host=B#!~#value"}, v1.UnixNano(), v2.UnixNano())
+
+	iter, err := tsm1.NewTSMKeyIterator(1000, false, r1, r2)
+	if err != nil {
+		t.Fatalf("unexpected error creating WALKeyIterator: %v", err)
+	}
+
+	var readValues bool
+	for iter.Next() {
+		key, _, _, block, err := iter.Read()
+		if err != nil {
+			t.Fatalf("unexpected error read: %v", err)
+		}
+
+		values, err := tsm1.DecodeBlock(block, nil)
+		if err != nil {
+			t.Fatalf("unexpected error decode: %v", err)
+		}
+
+		if got, exp := key, "cpu,host=A#!~#value"; got != exp {
+			t.Fatalf("key mismatch: got %v, exp %v", got, exp)
+		}
+
+		if got, exp := len(values), 2; got != exp {
+			t.Fatalf("values length mismatch: got %v, exp %v", got, exp)
+		}
+		readValues = true
+
+		assertValueEqual(t, values[0], v1)
+		assertValueEqual(t, values[1], v3)
+	}
+
+	if !readValues {
+		t.Fatalf("failed to read any values")
+	}
+}
+
 func TestCacheKeyIterator_Single(t *testing.T) {
 	v0 := tsm1.NewValue(time.Unix(1, 0).UTC(), 1.0)
 
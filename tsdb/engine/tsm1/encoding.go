@@ -216,6 +216,19 @@ func (a Values) Deduplicate() Values {
 	return other
 }
 
+// Exclude returns a new Values slice with any values with a timestamp between
+// min and max, inclusive, removed.
+func (a Values) Exclude(min, max int64) Values {
+	other := make([]Value, 0, len(a))
+	for _, val := range a {
+		if ts := val.UnixNano(); ts >= min && ts <= max {
+			continue
+		}
+		other = append(other, val)
+	}
+	return other
+}
+
 // Sort methods
 func (a Values) Len() int           { return len(a) }
 func (a Values) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
@@ -3,7 +3,9 @@ package tsm1
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
 	"sort"
@@ -13,6 +15,7 @@ import (
 
 	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/snapshot"
 	"github.com/influxdb/influxdb/tsdb"
 )
 
@@ -35,6 +38,11 @@ type DevEngine struct {
 	done chan struct{}
 	wg   sync.WaitGroup
 
+	// compactionsMu is held for reading while a snapshot or compaction is writing
+	// new TSM files and for writing while values are deleted by time range.  This
+	// prevents deleted values from being written back into a new file.
+	compactionsMu sync.RWMutex
+
 	path   string
 	logger *log.Logger
 
@@ -304,6 +312,63 @@ func (e *DevEngine) DeleteSeries(seriesKeys []string) error {
 	return err
 }
 
+// DeleteSeriesRange deletes the values of the series between min and max, inclusive.
+func (e *DevEngine) DeleteSeriesRange(seriesKeys []string, min, max int64) error {
+	if min == math.MinInt64 && max == math.MaxInt64 {
+		return e.DeleteSeries(seriesKeys)
+	}
+
+	e.compactionsMu.Lock()
+	defer e.compactionsMu.Unlock()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	keyMap := map[string]struct{}{}
+	for _, k := range seriesKeys {
+		keyMap[k] = struct{}{}
+	}
+
+	var deleteKeys []string
+	for _, k := range e.FileStore.Keys() {
+		seriesKey, _ := seriesAndFieldFromCompositeKey(k)
+		if _, ok := keyMap[seriesKey]; ok {
+			deleteKeys = append(deleteKeys, k)
+		}
+	}
+	if err := e.FileStore.DeleteRange(deleteKeys, min, max); err != nil {
+		return err
+	}
+
+	// find the keys in the cache, including any snapshots not yet written,
+	// and remove the values in the range
+	cacheKeys := map[string]struct{}{}
+	e.Cache.Lock()
+	stores := []map[string]*entry{e.Cache.Store()}
+	for _, s := range e.Cache.snapshots {
+		stores = append(stores, s.Store())
+	}
+	for _, s := range stores {
+		for k, _ := range s {
+			seriesKey, _ := seriesAndFieldFromCompositeKey(k)
+			if _, ok := keyMap[seriesKey]; ok {
+				cacheKeys[k] = struct{}{}
+			}
+		}
+	}
+	e.Cache.Unlock()
+
+	walKeys := make([]string, 0, len(cacheKeys))
+	for k := range cacheKeys {
+		walKeys = append(walKeys, k)
+	}
+	e.Cache.DeleteRange(walKeys, min, max)
+
+	// delete from the WAL
+	_, err := e.WAL.DeleteRange(walKeys, min, max)
+	return err
+}
+
 // DeleteMeasurement deletes a measurement and all related series.
 func (e *DevEngine) DeleteMeasurement(name string, seriesKeys []string) error {
 	return e.DeleteSeries(seriesKeys)
@@ -319,10 +384,71 @@ func (e *DevEngine) Begin(writable bool) (tsdb.Tx, error) {
 	return &devTx{engine: e}, nil
 }
 
-func (e *DevEngine) WriteTo(w io.Writer) (n int64, err error) { panic("not implemented") }
+// WriteTo writes a point-in-time snapshot of the engine's TSM files to w
+// using the snapshot archive format.
+func (e *DevEngine) WriteTo(w io.Writer) (n int64, err error) {
+	sw, err := e.SnapshotWriter()
+	if err != nil {
+		return 0, err
+	}
+	defer sw.Close()
+
+	return sw.WriteTo(w)
+}
+
+// SnapshotWriter flushes the cache and returns a snapshot.Writer for hard links
+// to the engine's current TSM and tombstone files. The manifest names each file
+// relative to the engine's path and, since TSM files are immutable and named by
+// generation, diffing two manifests only yields files written in between. The
+// links are removed once all the writer's files have been closed.
+//
+// Diffs don't record the files removed by compactions, so restoring a backup
+// with its incremental backups may bring back data deleted in between.
+func (e *DevEngine) SnapshotWriter() (*snapshot.Writer, error) {
+	if err := e.WriteSnapshot(); err != nil {
+		return nil, err
+	}
+
+	path, err := func() (string, error) {
+		e.mu.RLock()
+		defer e.mu.RUnlock()
+		return e.FileStore.CreateSnapshot()
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	fis, err := ioutil.ReadDir(path)
+	if err != nil {
+		os.RemoveAll(path)
+		return nil, err
+	}
+
+	dir := &snapshotDir{path: path, refs: len(fis)}
+	if len(fis) == 0 {
+		if err := os.RemoveAll(path); err != nil {
+			return nil, err
+		}
+	}
+
+	sw := snapshot.NewWriter()
+	for _, fi := range fis {
+		f := snapshot.File{
+			Name:    fi.Name(),
+			Size:    fi.Size(),
+			ModTime: fi.ModTime(),
+		}
+		sw.Manifest.Files = append(sw.Manifest.Files, f)
+		sw.FileWriters[f.Name] = &snapshotFile{path: filepath.Join(path, fi.Name()), dir: dir}
+	}
+	return sw, nil
+}
 
 // WriteSnapshot will snapshot the cache and write a new TSM file with its contents, releasing the snapshot when done.
 func (e *DevEngine) WriteSnapshot() error {
+	e.compactionsMu.RLock()
+	defer e.compactionsMu.RUnlock()
+
 	// Lock and grab the cache snapshot along with all the closed WAL
 	// filenames associated with the snapshot
 	closedFiles, snapshot, compactor, err := func() ([]string, *Cache, *Compactor, error) {
@@ -432,6 +558,10 @@ func (e *DevEngine) compactTSMLevel(fast bool, level int) {
 				wg.Add(1)
 				go func(groupNum int, group CompactionGroup) {
 					defer wg.Done()
+
+					e.compactionsMu.RLock()
+					defer e.compactionsMu.RUnlock()
+
 					start := time.Now()
 					e.logger.Printf("beginning level %d compaction of group %d, %d TSM files", level, groupNum, len(group))
 					for i, f := range group {
@@ -496,6 +626,10 @@ func (e *DevEngine) compactTSMFull() {
 				wg.Add(1)
 				go func(groupNum int, group CompactionGroup) {
 					defer wg.Done()
+
+					e.compactionsMu.RLock()
+					defer e.compactionsMu.RUnlock()
+
 					start := time.Now()
 					e.logger.Printf("beginning full compaction of group %d, %d TSM files", groupNum, len(group))
 					for i, f := range group {
@@ -553,6 +687,11 @@ func (e *DevEngine) cleanup() error {
 			return fmt.Errorf("error removing temp compaction files: %v", err)
 		}
 	}
+
+	// Any snapshots left behind by an interrupted backup are no longer needed.
+	if err := os.RemoveAll(filepath.Join(e.path, SnapshotDirName)); err != nil {
+		return fmt.Errorf("error removing snapshots: %v", err)
+	}
 	return nil
 }
 
@@ -601,7 +740,52 @@ func (t *devTx) Cursor(series string, fields []string, dec *tsdb.FieldCodec, asc
 func (t *devTx) Rollback() error                          { return nil }
 func (t *devTx) Size() int64                              { panic("not implemented") }
 func (t *devTx) Commit() error                            { panic("not implemented") }
-func (t *devTx) WriteTo(w io.Writer) (n int64, err error) { panic("not implemented") }
+func (t *devTx) WriteTo(w io.Writer) (n int64, err error) { return t.engine.WriteTo(w) }
+
+// snapshotDir is a directory of hard links created for a snapshot. It is
+// removed once every file in it has been released.
+type snapshotDir struct {
+	mu   sync.Mutex
+	path string
+	refs int
+}
+
+func (d *snapshotDir) release() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.refs--
+	if d.refs > 0 {
+		return nil
+	}
+	return os.RemoveAll(d.path)
+}
+
+// snapshotFile implements snapshot.FileWriter for a file in a snapshotDir.
+type snapshotFile struct {
+	path string
+	dir  *snapshotDir
+	once sync.Once
+}
+
+// WriteTo copies the contents of the file to w.
+func (f *snapshotFile) WriteTo(w io.Writer) (int64, error) {
+	fd, err := os.Open(f.path)
+	if err != nil {
+		return 0, err
+	}
+	defer fd.Close()
+
+	return io.Copy(w, fd)
+}
+
+// Close releases the file from its snapshot directory. It is safe to call
+// more than once.
+func (f *snapshotFile) Close() error {
+	var err error
+	f.once.Do(func() { err = f.dir.release() })
+	return err
+}
 
 // devCursor is a cursor that combines both TSM and cached data.
 type devCursor struct {
@@ -662,12 +846,21 @@ func (c *devCursor) SeekTo(seek int64) (int64, interface{}) {
 		c.tsmValues, _ = c.tsmKeyCursor.SeekTo(time.Unix(0, seek+1), c.ascending)
 	}
 
-	c.tsmPos = sort.Search(len(c.tsmValues), func(i int) bool {
-		return c.tsmValues[i].Time().UnixNano() >= seek
-	})
+	for {
+		c.tsmPos = sort.Search(len(c.tsmValues), func(i int) bool {
+			return c.tsmValues[i].Time().UnixNano() >= seek
+		})
+
+		if !c.ascending {
+			c.tsmPos--
+		}
 
-	if !c.ascending {
-		c.tsmPos--
+		// The block may not contain any values past the seek position if some
+		// of its values have been deleted so move to the next block.
+		if len(c.tsmValues) == 0 || (c.tsmPos >= 0 && c.tsmPos < len(c.tsmValues)) {
+			break
+		}
+		c.tsmValues, _ = c.tsmKeyCursor.Next(c.ascending)
 	}
 
 	if c.tsmPos >= 0 && c.tsmPos < len(c.tsmValues) {
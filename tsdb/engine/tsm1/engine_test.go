@@ -3,6 +3,7 @@ package tsm1
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/snapshot"
 	"github.com/influxdb/influxdb/tsdb"
 )
 
@@ -361,6 +363,181 @@ func TestDevEngine_DeleteWALLoadMetadata(t *testing.T) {
 	}
 }
 
+// Ensure values deleted by time range from the cache and TSM files are not
+// returned by queries, including after the engine is reopened.
+func TestDevEngine_DeleteSeriesRange(t *testing.T) {
+	// Generate temporary file.
+	f, _ := ioutil.TempFile("", "tsm")
+	f.Close()
+	os.Remove(f.Name())
+	walPath := filepath.Join(f.Name(), "wal")
+	os.MkdirAll(walPath, 0777)
+	defer os.RemoveAll(f.Name())
+
+	// Create a few points.
+	p1 := parsePoint("cpu,host=A value=1.1 1000000000")
+	p2 := parsePoint("cpu,host=A value=1.2 2000000000")
+	p3 := parsePoint("cpu,host=A value=1.3 3000000000")
+	p4 := parsePoint("cpu,host=A value=1.4 4000000000")
+	p5 := parsePoint("cpu,host=B value=1.5 2000000000")
+
+	// Write the first points to a TSM file and the rest to the cache.
+	e := NewDevEngine(f.Name(), walPath, tsdb.NewEngineOptions()).(*DevEngine)
+	if err := e.Open(); err != nil {
+		t.Fatalf("failed to open tsm1 engine: %s", err.Error())
+	}
+	if err := e.WritePoints([]models.Point{p1, p2, p5}, nil, nil); err != nil {
+		t.Fatalf("failed to write points: %s", err.Error())
+	}
+	if err := e.WriteSnapshot(); err != nil {
+		t.Fatalf("failed to snapshot: %s", err.Error())
+	}
+	if err := e.WritePoints([]models.Point{p3, p4}, nil, nil); err != nil {
+		t.Fatalf("failed to write points: %s", err.Error())
+	}
+
+	if err := e.DeleteSeriesRange([]string{"cpu,host=A"}, 2000000000, 3000000000); err != nil {
+		t.Fatalf("failed to delete series: %s", err.Error())
+	}
+
+	assertKeys := func(series string, exp []int64) {
+		tx := devTx{engine: e}
+		c := tx.Cursor(series, []string{"value"}, nil, true)
+
+		var got []int64
+		for k, _ := c.SeekTo(0); k != tsdb.EOF; k, _ = c.Next() {
+			got = append(got, k)
+		}
+
+		if !reflect.DeepEqual(got, exp) {
+			t.Fatalf("unexpected keys for %s: got %v, exp %v", series, got, exp)
+		}
+	}
+
+	assertKeys("cpu,host=A", []int64{1000000000, 4000000000})
+	assertKeys("cpu,host=B", []int64{2000000000})
+
+	// ensure the delete is reloaded from the tombstones and the WAL
+	if err := e.Close(); err != nil {
+		t.Fatalf("error closing: %s", err.Error())
+	}
+
+	e = NewDevEngine(f.Name(), walPath, tsdb.NewEngineOptions()).(*DevEngine)
+	if err := e.Open(); err != nil {
+		t.Fatalf("failed to open tsm1 engine: %s", err.Error())
+	}
+	defer e.Close()
+
+	assertKeys("cpu,host=A", []int64{1000000000, 4000000000})
+	assertKeys("cpu,host=B", []int64{2000000000})
+}
+
+func TestDevEngine_WriteTo(t *testing.T) {
+	// Generate temporary file.
+	f, _ := ioutil.TempFile("", "tsm")
+	f.Close()
+	os.Remove(f.Name())
+	walPath := filepath.Join(f.Name(), "wal")
+	os.MkdirAll(walPath, 0777)
+	defer os.RemoveAll(f.Name())
+
+	// Create a few points.
+	p1 := parsePoint("cpu,host=A value=1.1 1000000000")
+	p2 := parsePoint("cpu,host=A value=1.2 2000000000")
+	p3 := parsePoint("cpu,host=A value=1.3 3000000000")
+	p4 := parsePoint("cpu,host=A value=1.4 4000000000")
+
+	// Write the first points to a TSM file and the rest to the cache.
+	e := NewDevEngine(f.Name(), walPath, tsdb.NewEngineOptions()).(*DevEngine)
+	if err := e.Open(); err != nil {
+		t.Fatalf("failed to open tsm1 engine: %s", err.Error())
+	}
+	defer e.Close()
+
+	if err := e.WritePoints([]models.Point{p1, p2}, nil, nil); err != nil {
+		t.Fatalf("failed to write points: %s", err.Error())
+	}
+	if err := e.WriteSnapshot(); err != nil {
+		t.Fatalf("failed to snapshot: %s", err.Error())
+	}
+	if err := e.WritePoints([]models.Point{p3}, nil, nil); err != nil {
+		t.Fatalf("failed to write points: %s", err.Error())
+	}
+
+	var buf bytes.Buffer
+	if _, err := e.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write engine: %s", err.Error())
+	}
+
+	// The cached point should have been flushed and included in the archive.
+	sr := snapshot.NewReader(&buf)
+	m, err := sr.Manifest()
+	if err != nil {
+		t.Fatalf("failed to read manifest: %s", err.Error())
+	} else if len(m.Files) != 2 {
+		t.Fatalf("unexpected file count: got %v, exp %v", len(m.Files), 2)
+	}
+
+	var got []int64
+	for {
+		sf, err := sr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("failed to read file: %s", err.Error())
+		}
+
+		b, err := ioutil.ReadAll(sr)
+		if err != nil {
+			t.Fatalf("failed to read file %s: %s", sf.Name, err.Error())
+		}
+
+		r, err := NewTSMReaderWithOptions(TSMReaderOptions{Reader: bytes.NewReader(b)})
+		if err != nil {
+			t.Fatalf("failed to open tsm file %s: %s", sf.Name, err.Error())
+		}
+
+		values, err := r.ReadAll(SeriesFieldKey("cpu,host=A", "value"))
+		if err != nil {
+			t.Fatalf("failed to read values: %s", err.Error())
+		}
+		for _, v := range values {
+			got = append(got, v.UnixNano())
+		}
+	}
+
+	if exp := []int64{1000000000, 2000000000, 3000000000}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected keys: got %v, exp %v", got, exp)
+	}
+
+	// A later snapshot diffed against the first should only contain new files.
+	if err := e.WritePoints([]models.Point{p4}, nil, nil); err != nil {
+		t.Fatalf("failed to write points: %s", err.Error())
+	}
+
+	sw, err := e.SnapshotWriter()
+	if err != nil {
+		t.Fatalf("failed to create snapshot writer: %s", err.Error())
+	}
+	sw.Manifest = sw.Manifest.Diff(m)
+	if len(sw.Manifest.Files) != 1 {
+		t.Fatalf("unexpected file count: got %v, exp %v", len(sw.Manifest.Files), 1)
+	}
+
+	if _, err := sw.WriteTo(ioutil.Discard); err != nil {
+		t.Fatalf("failed to write snapshot: %s", err.Error())
+	}
+	sw.Close()
+
+	// The hard links should be removed once the snapshot is closed.
+	fis, err := ioutil.ReadDir(filepath.Join(f.Name(), SnapshotDirName))
+	if err != nil {
+		t.Fatalf("failed to read snapshot dir: %s", err.Error())
+	} else if len(fis) != 0 {
+		t.Fatalf("unexpected snapshot dirs: got %v, exp %v", len(fis), 0)
+	}
+}
+
 func parsePoints(buf string) []models.Point {
 	points, err := models.ParsePointsString(buf)
 	if err != nil {
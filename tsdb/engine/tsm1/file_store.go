@@ -2,6 +2,7 @@ package tsm1
 
 import (
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
@@ -12,6 +13,10 @@ import (
 	"time"
 )
 
+// SnapshotDirName is the name of the directory within a store that holds
+// the hard links created for backups.
+const SnapshotDirName = "snapshots"
+
 type TSMFile interface {
 	// Path returns the underlying file path for the TSMFile.  If the file
 	// has not be written or loaded from disk, the zero value is returne.
@@ -51,6 +56,12 @@ type TSMFile interface {
 	// Delete removes the keys from the set of keys available in this file.
 	Delete(keys []string) error
 
+	// DeleteRange removes the values for keys between min and max.
+	DeleteRange(keys []string, min, max int64) error
+
+	// TombstoneRange returns the time ranges of the key that have been deleted.
+	TombstoneRange(key string) []TimeRange
+
 	// HasTombstones returns true if file contains values that have been deleted.
 	HasTombstones() bool
 
@@ -207,6 +218,26 @@ func (f *FileStore) Delete(keys []string) error {
 	return nil
 }
 
+// DeleteRange removes the values for keys between min and max, inclusive.
+func (f *FileStore) DeleteRange(keys []string, min, max int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lastModified = time.Now()
+
+	for _, file := range f.files {
+		minTime, maxTime := file.TimeRange()
+		if minTime.UnixNano() > max || maxTime.UnixNano() < min {
+			continue
+		}
+
+		if err := file.DeleteRange(keys, min, max); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (f *FileStore) Open() error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -327,6 +358,48 @@ func (f *FileStore) Stats() []FileStat {
 	return stats
 }
 
+// CreateSnapshot creates hard links to all the active TSM files and their
+// tombstones in a new directory under the snapshots directory of the store.
+// The returned path should be removed by the caller once it is no longer needed.
+func (f *FileStore) CreateSnapshot() (string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.dir == "" {
+		return "", fmt.Errorf("snapshot not supported on in-memory file store")
+	}
+
+	root := filepath.Join(f.dir, SnapshotDirName)
+	if err := os.MkdirAll(root, 0777); err != nil {
+		return "", err
+	}
+
+	dir, err := ioutil.TempDir(root, "")
+	if err != nil {
+		return "", err
+	}
+
+	for _, tsmf := range f.files {
+		if err := os.Link(tsmf.Path(), filepath.Join(dir, filepath.Base(tsmf.Path()))); err != nil {
+			os.RemoveAll(dir)
+			return "", fmt.Errorf("error creating tsm hard link: %v", err)
+		}
+
+		// Tombstones are rewritten in place so they must be linked along
+		// with the file they apply to.
+		tombstone := (&Tombstoner{Path: tsmf.Path()}).tombstonePath()
+		if _, err := os.Stat(tombstone); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.Link(tombstone, filepath.Join(dir, filepath.Base(tombstone))); err != nil {
+			os.RemoveAll(dir)
+			return "", fmt.Errorf("error creating tombstone hard link: %v", err)
+		}
+	}
+
+	return dir, nil
+}
+
 func (f *FileStore) Replace(oldFiles, newFiles []string) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -588,7 +661,14 @@ func (c *KeyCursor) SeekTo(t time.Time, ascending bool) ([]Value, error) {
 		}
 	}
 
-	return c.readAt()
+	values, err := c.readAt()
+	if err != nil || len(values) > 0 || len(c.current) == 0 {
+		return values, err
+	}
+
+	// All the values in the matching blocks have been deleted so move on to
+	// the next blocks.
+	return c.Next(ascending)
 }
 
 func (c *KeyCursor) readAt() ([]Value, error) {
@@ -601,10 +681,14 @@ func (c *KeyCursor) readAt() ([]Value, error) {
 	first := c.current[0]
 	values, err := first.r.ReadAt(first.entry, c.buf[:0])
 	first.read = true
+	if err != nil {
+		return nil, err
+	}
+	values = c.excludeTombstones(first, values)
 
 	// Only one block with this key and time range so return it
 	if len(c.current) == 1 {
-		return values, err
+		return values, nil
 	}
 
 	// Otherwise, search the remaining blocks that overlap and append their values so we can
@@ -618,7 +702,7 @@ func (c *KeyCursor) readAt() ([]Value, error) {
 			if err != nil {
 				return nil, err
 			}
-			values = append(values, v...)
+			values = append(values, c.excludeTombstones(cur, v)...)
 		} else if !c.ascending && cur.entry.OverlapsTimeRange(first.entry.MinTime, first.entry.MaxTime) && !cur.read {
 			cur.read = true
 			c.pos--
@@ -627,21 +711,50 @@ func (c *KeyCursor) readAt() ([]Value, error) {
 			if err != nil {
 				return nil, err
 			}
-			values = append(v, values...)
+			values = append(c.excludeTombstones(cur, v), values...)
 		}
 	}
 
 	return Values(values).Deduplicate(), err
 }
 
+// excludeTombstones removes any values read from the block at loc that have
+// been deleted from its file.
+func (c *KeyCursor) excludeTombstones(loc *location, values []Value) []Value {
+	for _, tr := range loc.r.TombstoneRange(c.key) {
+		if !tr.Overlaps(loc.entry.MinTime.UnixNano(), loc.entry.MaxTime.UnixNano()) {
+			continue
+		}
+		values = Values(values).Exclude(tr.Min, tr.Max)
+	}
+	return values
+}
+
+// Next returns the values in the next block(s) for the key.  Blocks with all
+// of their values deleted are skipped.
 func (c *KeyCursor) Next(ascending bool) ([]Value, error) {
+	for {
+		if !c.next(ascending) {
+			return nil, nil
+		}
+
+		values, err := c.readAt()
+		if err != nil || len(values) > 0 {
+			return values, err
+		}
+	}
+}
+
+// next moves the cursor to the next set of blocks to read.  It returns false
+// if there are no more blocks.
+func (c *KeyCursor) next(ascending bool) bool {
 	c.current = c.current[:0]
 
 	if ascending {
 		for {
 			c.pos++
 			if c.pos >= len(c.seeks) {
-				return nil, nil
+				return false
 			}
 
 			if !c.seeks[c.pos].read {
@@ -666,13 +779,13 @@ func (c *KeyCursor) Next(ascending bool) ([]Value, error) {
 			}
 		}
 
-		return c.readAt()
+		return true
 
 	} else {
 		for {
 			c.pos--
 			if c.pos < 0 {
-				return nil, nil
+				return false
 			}
 
 			if !c.seeks[c.pos].read {
@@ -696,7 +809,7 @@ func (c *KeyCursor) Next(ascending bool) ([]Value, error) {
 			}
 		}
 
-		return c.readAt()
+		return true
 	}
 }
 
@@ -23,6 +23,10 @@ type TSMReader struct {
 	// tombstoner ensures tombstoned keys are not available by the index.
 	tombstoner *Tombstoner
 
+	// tombstones are the time ranges of keys that have been deleted but where
+	// the key still has values remaining in the file.
+	tombstones map[string][]TimeRange
+
 	// size is the size of the file on disk.
 	size int64
 
@@ -30,6 +34,21 @@ type TSMReader struct {
 	lastModified time.Time
 }
 
+// TimeRange holds a min and max timestamp.
+type TimeRange struct {
+	Min, Max int64
+}
+
+// Contains returns true if ts is between min and max, inclusive.
+func (t TimeRange) Contains(ts int64) bool {
+	return ts >= t.Min && ts <= t.Max
+}
+
+// Overlaps returns true if the time range overlaps min and max, inclusive.
+func (t TimeRange) Overlaps(min, max int64) bool {
+	return t.Min <= max && t.Max >= min
+}
+
 // BlockIterator allows iterating over each block in a TSM file in order.  It provides
 // raw access to the block bytes without decoding them.
 type BlockIterator struct {
@@ -111,7 +130,9 @@ func NewTSMReader(r io.ReadSeeker) (*TSMReader, error) {
 }
 
 func NewTSMReaderWithOptions(opt TSMReaderOptions) (*TSMReader, error) {
-	t := &TSMReader{}
+	t := &TSMReader{
+		tombstones: make(map[string][]TimeRange),
+	}
 	if opt.Reader != nil {
 		// Seek to the end of the file to determine the size
 		size, err := opt.Reader.Seek(2, 0)
@@ -167,8 +188,19 @@ func (t *TSMReader) applyTombstones() error {
 		return fmt.Errorf("init: read tombstones: %v", err)
 	}
 
+	// Keys deleted across all time are removed from the index.  Tombstones
+	// for a time range are tracked so the values can be excluded on read.
+	var keys []string
+	for _, ts := range tombstones {
+		if ts.Min == math.MinInt64 && ts.Max == math.MaxInt64 {
+			keys = append(keys, ts.Key)
+			continue
+		}
+		t.deleteRange([]string{ts.Key}, ts.Min, ts.Max)
+	}
+
 	// Update our index
-	t.index.Delete(tombstones)
+	t.deleteKeys(keys)
 	return nil
 }
 
@@ -198,7 +230,11 @@ func (t *TSMReader) Read(key string, timestamp time.Time) ([]Value, error) {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
-	return t.accessor.read(key, timestamp)
+	values, err := t.accessor.read(key, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return t.excludeTombstones(key, values), nil
 }
 
 // ReadAll returns all values for a key in all blocks.
@@ -206,7 +242,20 @@ func (t *TSMReader) ReadAll(key string) ([]Value, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	return t.accessor.readAll(key)
+	values, err := t.accessor.readAll(key)
+	if err != nil {
+		return nil, err
+	}
+	return t.excludeTombstones(key, values), nil
+}
+
+// excludeTombstones removes any values of key that fall within a tombstoned
+// time range.  The caller must hold the reader lock.
+func (t *TSMReader) excludeTombstones(key string, values []Value) []Value {
+	for _, tr := range t.tombstones[key] {
+		values = Values(values).Exclude(tr.Min, tr.Max)
+	}
+	return values
 }
 
 func (t *TSMReader) readBytes(e *IndexEntry, b []byte) ([]byte, error) {
@@ -258,10 +307,67 @@ func (t *TSMReader) Delete(keys []string) error {
 		return err
 	}
 
-	t.index.Delete(keys)
+	t.mu.Lock()
+	t.deleteKeys(keys)
+	t.mu.Unlock()
+	return nil
+}
+
+// DeleteRange removes the values for keys between min and max, inclusive.
+func (t *TSMReader) DeleteRange(keys []string, min, max int64) error {
+	if min == math.MinInt64 && max == math.MaxInt64 {
+		return t.Delete(keys)
+	}
+
+	if err := t.tombstoner.AddRange(keys, min, max); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.deleteRange(keys, min, max)
+	t.mu.Unlock()
 	return nil
 }
 
+// TombstoneRange returns the time ranges of key that have been deleted.
+func (t *TSMReader) TombstoneRange(key string) []TimeRange {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if len(t.tombstones[key]) == 0 {
+		return nil
+	}
+	return append([]TimeRange(nil), t.tombstones[key]...)
+}
+
+// deleteKeys removes keys from the index.  The caller must hold the reader lock.
+func (t *TSMReader) deleteKeys(keys []string) {
+	t.index.Delete(keys)
+	for _, k := range keys {
+		delete(t.tombstones, k)
+	}
+}
+
+// deleteRange records the deleted time range for each key.  If the range
+// covers every block of a key, the key is removed from the index.  The caller
+// must hold the reader lock.
+func (t *TSMReader) deleteRange(keys []string, min, max int64) {
+	var deleted []string
+	for _, k := range keys {
+		entries := t.index.Entries(k)
+		if len(entries) == 0 {
+			continue
+		}
+
+		if min <= entries[0].MinTime.UnixNano() && max >= entries[len(entries)-1].MaxTime.UnixNano() {
+			deleted = append(deleted, k)
+			continue
+		}
+		t.tombstones[k] = append(t.tombstones[k], TimeRange{Min: min, Max: max})
+	}
+	t.deleteKeys(deleted)
+}
+
 // TimeRange returns the min and max time across all keys in the file.
 func (t *TSMReader) TimeRange() (time.Time, time.Time) {
 	return t.index.TimeRange()
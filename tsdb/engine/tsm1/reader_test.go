@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -346,6 +347,56 @@ func TestTSMReader_MMAP_Tombstone(t *testing.T) {
 	}
 }
 
+func TestTSMReader_MMAP_TombstoneRange(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	v1 := tsm1.NewValue(time.Unix(1, 0), 1.0)
+	v2 := tsm1.NewValue(time.Unix(2, 0), 2.0)
+	v3 := tsm1.NewValue(time.Unix(3, 0), 3.0)
+	points := map[string][]tsm1.Value{
+		"cpu": []tsm1.Value{v1, v2, v3},
+		"mem": []tsm1.Value{v1},
+	}
+
+	r := MustTSMReader(dir, 1, points)
+	if err := r.DeleteRange([]string{"cpu"}, v2.UnixNano(), v2.UnixNano()); err != nil {
+		t.Fatalf("unexpected error deleting: %v", err)
+	}
+
+	// Deleting the full range of a key's values removes the key.
+	if err := r.DeleteRange([]string{"mem"}, v1.UnixNano(), v3.UnixNano()); err != nil {
+		t.Fatalf("unexpected error deleting: %v", err)
+	}
+	r.Close()
+
+	// Re-open the file to verify the tombstone is applied on load
+	r = MustOpenTSMReader(r.Path())
+	defer r.Close()
+
+	if got, exp := len(r.Keys()), 1; got != exp {
+		t.Fatalf("key length mismatch: got %v, exp %v", got, exp)
+	}
+
+	values, err := r.ReadAll("cpu")
+	if err != nil {
+		t.Fatalf("unexpected error reading all: %v", err)
+	}
+
+	exp := []tsm1.Value{v1, v3}
+	if got, exp := len(values), len(exp); got != exp {
+		t.Fatalf("value length mismatch: got %v, exp %v", got, exp)
+	}
+
+	for i, v := range exp {
+		assertValueEqual(t, values[i], v)
+	}
+
+	if got, exp := r.TombstoneRange("cpu"), []tsm1.TimeRange{{Min: v2.UnixNano(), Max: v2.UnixNano()}}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("tombstone range mismatch: got %v, exp %v", got, exp)
+	}
+}
+
 func TestTSMReader_MMAP_Stats(t *testing.T) {
 	dir := MustTempDir()
 	defer os.RemoveAll(dir)
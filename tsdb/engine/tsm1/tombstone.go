@@ -1,13 +1,26 @@
 package tsm1
 
 import (
+	"encoding/binary"
+	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 )
 
+const (
+	// v2header is the magic number written at the start of a tombstone file
+	// that records time ranges.  Tombstone files without this header are the
+	// original newline separated list of keys.
+	v2header = 0x1502
+
+	// v2headerSize is the size of the v2 tombstone header in bytes.
+	v2headerSize = 4
+)
+
 type Tombstoner struct {
 	mu sync.Mutex
 
@@ -16,7 +29,23 @@ type Tombstoner struct {
 	Path string
 }
 
+// Tombstone represents an individual deletion.
+type Tombstone struct {
+	// Key is the tombstoned series key
+	Key string
+
+	// Min and Max are the min and max unix nanosecond time ranges of Key that are deleted.  If
+	// the full range of time is deleted, Min is math.MinInt64 and Max is math.MaxInt64.
+	Min, Max int64
+}
+
+// Add adds all keys, across all timestamps, to the tombstone.
 func (t *Tombstoner) Add(keys []string) error {
+	return t.AddRange(keys, math.MinInt64, math.MaxInt64)
+}
+
+// AddRange adds all keys to the tombstone specifying only the data between min and max to be removed.
+func (t *Tombstoner) AddRange(keys []string, min, max int64) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
@@ -32,13 +61,17 @@ func (t *Tombstoner) Add(keys []string) error {
 	}
 
 	for _, k := range keys {
-		tombstones = append(tombstones, k)
+		tombstones = append(tombstones, Tombstone{
+			Key: k,
+			Min: min,
+			Max: max,
+		})
 	}
 
 	return t.writeTombstone(tombstones)
 }
 
-func (t *Tombstoner) ReadAll() ([]string, error) {
+func (t *Tombstoner) ReadAll() ([]Tombstone, error) {
 	return t.readTombstone()
 }
 
@@ -61,17 +94,38 @@ func (t *Tombstoner) HasTombstones() bool {
 	return stat.Size() > 0
 }
 
-func (t *Tombstoner) writeTombstone(tombstones []string) error {
+func (t *Tombstoner) writeTombstone(tombstones []Tombstone) error {
 	tmp, err := ioutil.TempFile(filepath.Dir(t.Path), "tombstone")
 	if err != nil {
 		return err
 	}
 	defer tmp.Close()
 
-	if _, err := tmp.Write([]byte(strings.Join(tombstones, "\n"))); err != nil {
+	var b [8]byte
+	binary.BigEndian.PutUint32(b[:4], v2header)
+	if _, err := tmp.Write(b[:4]); err != nil {
 		return err
 	}
 
+	for _, ts := range tombstones {
+		binary.BigEndian.PutUint32(b[:4], uint32(len(ts.Key)))
+		if _, err := tmp.Write(b[:4]); err != nil {
+			return err
+		}
+		if _, err := tmp.Write([]byte(ts.Key)); err != nil {
+			return err
+		}
+		binary.BigEndian.PutUint64(b[:], uint64(ts.Min))
+		if _, err := tmp.Write(b[:]); err != nil {
+			return err
+		}
+
+		binary.BigEndian.PutUint64(b[:], uint64(ts.Max))
+		if _, err := tmp.Write(b[:]); err != nil {
+			return err
+		}
+	}
+
 	// fsync the file to flush the write
 	if err := tmp.Sync(); err != nil {
 		return err
@@ -90,7 +144,7 @@ func (t *Tombstoner) writeTombstone(tombstones []string) error {
 	return dir.Sync()
 }
 
-func (t *Tombstoner) readTombstone() ([]string, error) {
+func (t *Tombstoner) readTombstone() ([]Tombstone, error) {
 	var b []byte
 	tf, err := os.Open(t.tombstonePath())
 	defer tf.Close()
@@ -101,12 +155,62 @@ func (t *Tombstoner) readTombstone() ([]string, error) {
 		}
 	}
 
+	if len(b) >= v2headerSize && binary.BigEndian.Uint32(b[:v2headerSize]) == v2header {
+		return t.readTombstoneV2(b[v2headerSize:])
+	}
+	return t.readTombstoneV1(b)
+}
+
+// readTombstoneV1 reads the first version of tombstone files that were not
+// capable of storing a min and max time for a key.  All tombstones are
+// assumed to cover the full range of time for the key.
+func (t *Tombstoner) readTombstoneV1(b []byte) ([]Tombstone, error) {
 	lines := strings.TrimSpace(string(b))
 	if lines == "" {
 		return nil, nil
 	}
 
-	return strings.Split(string(b), "\n"), nil
+	var tombstones []Tombstone
+	for _, line := range strings.Split(string(b), "\n") {
+		tombstones = append(tombstones, Tombstone{
+			Key: line,
+			Min: math.MinInt64,
+			Max: math.MaxInt64,
+		})
+	}
+	return tombstones, nil
+}
+
+// readTombstoneV2 reads the second version of tombstone files that are capable
+// of storing keys and the range of time for the key that points were deleted.
+func (t *Tombstoner) readTombstoneV2(b []byte) ([]Tombstone, error) {
+	var tombstones []Tombstone
+	for len(b) > 0 {
+		if len(b) < 4 {
+			return nil, fmt.Errorf("tombstone %s: short key length", t.tombstonePath())
+		}
+		keyLen := int(binary.BigEndian.Uint32(b[:4]))
+		b = b[4:]
+
+		if len(b) < keyLen+16 {
+			return nil, fmt.Errorf("tombstone %s: short entry", t.tombstonePath())
+		}
+		key := string(b[:keyLen])
+		b = b[keyLen:]
+
+		min := int64(binary.BigEndian.Uint64(b[:8]))
+		b = b[8:]
+
+		max := int64(binary.BigEndian.Uint64(b[:8]))
+		b = b[8:]
+
+		tombstones = append(tombstones, Tombstone{
+			Key: key,
+			Min: min,
+			Max: max,
+		})
+	}
+	return tombstones, nil
 }
 
 func (t *Tombstoner) tombstonePath() string {
@@ -1,6 +1,8 @@
 package tsm1_test
 
 import (
+	"io/ioutil"
+	"math"
 	"os"
 	"testing"
 
@@ -34,7 +36,7 @@ func TestTombstoner_Add(t *testing.T) {
 		t.Fatalf("length mismatch: got %v, exp %v", got, exp)
 	}
 
-	if got, exp := entries[0], "foo"; got != exp {
+	if got, exp := entries[0].Key, "foo"; got != exp {
 		t.Fatalf("value mismatch: got %v, exp %v", got, exp)
 	}
 
@@ -49,7 +51,7 @@ func TestTombstoner_Add(t *testing.T) {
 		t.Fatalf("length mismatch: got %v, exp %v", got, exp)
 	}
 
-	if got, exp := entries[0], "foo"; got != exp {
+	if got, exp := entries[0].Key, "foo"; got != exp {
 		t.Fatalf("value mismatch: got %v, exp %v", got, exp)
 	}
 }
@@ -74,7 +76,7 @@ func TestTombstoner_Delete(t *testing.T) {
 		t.Fatalf("length mismatch: got %v, exp %v", got, exp)
 	}
 
-	if got, exp := entries[0], "foo"; got != exp {
+	if got, exp := entries[0].Key, "foo"; got != exp {
 		t.Fatalf("value mismatch: got %v, exp %v", got, exp)
 	}
 
@@ -93,3 +95,59 @@ func TestTombstoner_Delete(t *testing.T) {
 	}
 
 }
+
+func TestTombstoner_AddRange(t *testing.T) {
+	dir := MustTempDir()
+	defer func() { os.RemoveAll(dir) }()
+
+	f := MustTempFile(dir)
+	ts := &tsm1.Tombstoner{Path: f.Name()}
+
+	if err := ts.AddRange([]string{"foo"}, 1, 2); err != nil {
+		fatal(t, "AddRange", err)
+	}
+
+	// Use a new Tombstoner to verify values are persisted
+	ts = &tsm1.Tombstoner{Path: f.Name()}
+	entries, err := ts.ReadAll()
+	if err != nil {
+		fatal(t, "ReadAll", err)
+	}
+
+	if got, exp := len(entries), 1; got != exp {
+		t.Fatalf("length mismatch: got %v, exp %v", got, exp)
+	}
+
+	if got, exp := entries[0], (tsm1.Tombstone{Key: "foo", Min: 1, Max: 2}); got != exp {
+		t.Fatalf("value mismatch: got %v, exp %v", got, exp)
+	}
+}
+
+func TestTombstoner_ReadV1(t *testing.T) {
+	dir := MustTempDir()
+	defer func() { os.RemoveAll(dir) }()
+
+	f := MustTempFile(dir)
+	if err := ioutil.WriteFile(f.Name(), []byte("foo\nbar"), 0666); err != nil {
+		fatal(t, "write v1 file", err)
+	}
+	f.Close()
+
+	if err := os.Rename(f.Name(), f.Name()+".tombstone"); err != nil {
+		fatal(t, "rename tombstone failed", err)
+	}
+
+	ts := &tsm1.Tombstoner{Path: f.Name()}
+	entries, err := ts.ReadAll()
+	if err != nil {
+		fatal(t, "ReadAll", err)
+	}
+
+	if got, exp := len(entries), 2; got != exp {
+		t.Fatalf("length mismatch: got %v, exp %v", got, exp)
+	}
+
+	if got, exp := entries[1], (tsm1.Tombstone{Key: "bar", Min: math.MinInt64, Max: math.MaxInt64}); got != exp {
+		t.Fatalf("value mismatch: got %v, exp %v", got, exp)
+	}
+}
@@ -43,11 +43,13 @@ type SegmentInfo struct {
 type WalEntryType byte
 
 const (
-	WriteWALEntryType  WalEntryType = 0x01
-	DeleteWALEntryType WalEntryType = 0x02
+	WriteWALEntryType       WalEntryType = 0x01
+	DeleteWALEntryType      WalEntryType = 0x02
+	DeleteRangeWALEntryType WalEntryType = 0x03
 )
 
 var ErrWALClosed = fmt.Errorf("WAL closed")
+var ErrWALCorrupt = fmt.Errorf("corrupted WAL entry")
 
 type WAL struct {
 	mu            sync.RWMutex
@@ -283,6 +285,24 @@ func (l *WAL) Delete(keys []string) (int, error) {
 	return id, nil
 }
 
+// DeleteRange deletes the given keys' data in the given time range, returning the segment ID for the operation.
+func (l *WAL) DeleteRange(keys []string, min, max int64) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	entry := &DeleteRangeWALEntry{
+		Keys: keys,
+		Min:  min,
+		Max:  max,
+	}
+
+	id, err := l.writeToLog(entry)
+	if err != nil {
+		return -1, err
+	}
+	return id, nil
+}
+
 // Close will finish any flush that is currently in process and close file handles
 func (l *WAL) Close() error {
 	l.mu.Lock()
@@ -543,6 +563,69 @@ func (w *DeleteWALEntry) Type() WalEntryType {
 	return DeleteWALEntryType
 }
 
+// DeleteRangeWALEntry represents the deletion of multiple series within a time range.
+type DeleteRangeWALEntry struct {
+	Keys     []string
+	Min, Max int64
+}
+
+func (w *DeleteRangeWALEntry) MarshalBinary() ([]byte, error) {
+	b := make([]byte, defaultBufLen)
+	return w.Encode(b)
+}
+
+func (w *DeleteRangeWALEntry) UnmarshalBinary(b []byte) error {
+	if len(b) < 16 {
+		return ErrWALCorrupt
+	}
+
+	w.Min = int64(btou64(b[:8]))
+	w.Max = int64(btou64(b[8:16]))
+
+	i := 16
+	for i < len(b) {
+		if i+4 > len(b) {
+			return ErrWALCorrupt
+		}
+		sz := int(btou32(b[i : i+4]))
+		i += 4
+
+		if i+sz > len(b) {
+			return ErrWALCorrupt
+		}
+		w.Keys = append(w.Keys, string(b[i:i+sz]))
+		i += sz
+	}
+	return nil
+}
+
+func (w *DeleteRangeWALEntry) Encode(dst []byte) ([]byte, error) {
+	sz := 16
+	for _, k := range w.Keys {
+		sz += 4 + len(k)
+	}
+
+	if len(dst) < sz {
+		dst = make([]byte, sz)
+	}
+
+	copy(dst[:8], u64tob(uint64(w.Min)))
+	copy(dst[8:16], u64tob(uint64(w.Max)))
+
+	i := 16
+	for _, k := range w.Keys {
+		copy(dst[i:i+4], u32tob(uint32(len(k))))
+		i += 4
+		i += copy(dst[i:], k)
+	}
+
+	return dst[:i], nil
+}
+
+func (w *DeleteRangeWALEntry) Type() WalEntryType {
+	return DeleteRangeWALEntryType
+}
+
 // WALSegmentWriter writes WAL segments.
 type WALSegmentWriter struct {
 	w    io.WriteCloser
@@ -656,6 +739,8 @@ func (r *WALSegmentReader) Next() bool {
 		}
 	case DeleteWALEntryType:
 		r.entry = &DeleteWALEntry{}
+	case DeleteRangeWALEntryType:
+		r.entry = &DeleteRangeWALEntry{}
 	default:
 		r.err = fmt.Errorf("unknown wal entry type: %v", entryType)
 		return true
@@ -3,6 +3,7 @@ package tsm1_test
 import (
 	"fmt"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -240,6 +241,47 @@ func TestWALWriter_WriteDelete_Single(t *testing.T) {
 	}
 }
 
+func TestWALWriter_WriteDeleteRange_Single(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+	f := MustTempFile(dir)
+	w := tsm1.NewWALSegmentWriter(f)
+
+	entry := &tsm1.DeleteRangeWALEntry{
+		Keys: []string{"cpu", "mem"},
+		Min:  5,
+		Max:  10,
+	}
+
+	if err := w.Write(mustMarshalEntry(entry)); err != nil {
+		fatal(t, "write points", err)
+	}
+
+	if _, err := f.Seek(0, os.SEEK_SET); err != nil {
+		fatal(t, "seek", err)
+	}
+
+	r := tsm1.NewWALSegmentReader(f)
+
+	if !r.Next() {
+		t.Fatalf("expected next, got false")
+	}
+
+	we, err := r.Read()
+	if err != nil {
+		fatal(t, "read entry", err)
+	}
+
+	e, ok := we.(*tsm1.DeleteRangeWALEntry)
+	if !ok {
+		t.Fatalf("expected DeleteRangeWALEntry: got %#v", e)
+	}
+
+	if !reflect.DeepEqual(e, entry) {
+		t.Fatalf("entry mismatch: got %#v, exp %#v", e, entry)
+	}
+}
+
 func TestWALWriter_WritePointsDelete_Multiple(t *testing.T) {
 	dir := MustTempDir()
 	defer os.RemoveAll(dir)
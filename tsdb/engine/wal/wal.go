@@ -187,6 +187,7 @@ func NewLog(path string) *Log {
 	// should be done before any data could arrive for the service.
 	key := strings.Join([]string{"wal", path}, ":")
 	tags := map[string]string{"path": path}
+	influxdb.DeclareGauges("wal", statMemorySize)
 
 	return &Log{
 		path:  path,
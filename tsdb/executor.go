@@ -6,3 +6,25 @@ import "github.com/influxdb/influxdb/models"
 type Executor interface {
 	Execute(closing <-chan struct{}) <-chan *models.Row
 }
+
+// Interrupter is implemented by mappers which can be interrupted while waiting
+// for a chunk, such as mappers reading a shard on a remote node.
+type Interrupter interface {
+	Interrupt()
+}
+
+// interruptMappers interrupts the mappers if closing is signalled before done
+// is closed, so that an executor waiting on a remote node can return.
+func interruptMappers(mappers []*StatefulMapper, closing <-chan struct{}, done <-chan struct{}) {
+	select {
+	case <-closing:
+	case <-done:
+		return
+	}
+
+	for _, m := range mappers {
+		if i, ok := m.Mapper.(Interrupter); ok {
+			i.Interrupt()
+		}
+	}
+}
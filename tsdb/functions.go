@@ -14,9 +14,12 @@ import (
 	"math/rand"
 	"reflect"
 	"sort"
+	"time"
 
 	// "github.com/davecgh/go-spew/spew"
 	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/pkg/tdigest"
 )
 
 // MapInput represents a collection of values to be processed by the mapper.
@@ -114,7 +117,25 @@ func initializeMapFunc(c *influxql.Call) (mapFunc, error) {
 		}, nil
 	case "percentile":
 		return MapEcho, nil
-	case "derivative", "non_negative_derivative":
+	case "percentile_approx":
+		return func(input *MapInput) interface{} {
+			return MapPercentileApprox(input, c.Fields()[0])
+		}, nil
+	case "histogram":
+		lit, _ := c.Args[1].(*influxql.NumberLiteral)
+		width := lit.Val
+		return func(input *MapInput) interface{} {
+			return MapHistogram(input, c.Fields()[0], width)
+		}, nil
+	case "mode":
+		return func(input *MapInput) interface{} {
+			return MapMode(input, c.Fields()[0])
+		}, nil
+	case "integral":
+		return func(input *MapInput) interface{} {
+			return MapIntegral(input, c.Fields()[0])
+		}, nil
+	case "derivative", "non_negative_derivative", "cumulative_sum", "moving_average", "difference", "elapsed", "holt_winters":
 		// If the arg is another aggregate e.g. derivative(mean(value)), then
 		// use the map func for that nested aggregate
 		if fn, ok := c.Args[0].(*influxql.Call); ok {
@@ -175,7 +196,26 @@ func initializeReduceFunc(c *influxql.Call) (reduceFunc, error) {
 			percentile := lit.Val
 			return ReducePercentile(values, percentile)
 		}, nil
-	case "derivative", "non_negative_derivative":
+	case "percentile_approx":
+		lit, _ := c.Args[1].(*influxql.NumberLiteral)
+		percentile := lit.Val
+		return func(values []interface{}) interface{} {
+			return ReducePercentileApprox(values, percentile)
+		}, nil
+	case "histogram":
+		return ReduceHistogram, nil
+	case "mode":
+		return ReduceMode, nil
+	case "integral":
+		unit := time.Second
+		if len(c.Args) == 2 {
+			lit, _ := c.Args[1].(*influxql.DurationLiteral)
+			unit = lit.Val
+		}
+		return func(values []interface{}) interface{} {
+			return ReduceIntegral(values, unit)
+		}, nil
+	case "derivative", "non_negative_derivative", "cumulative_sum", "moving_average", "difference", "elapsed", "holt_winters":
 		// If the arg is another aggregate e.g. derivative(mean(value)), then
 		// use the map func for that nested aggregate
 		if fn, ok := c.Args[0].(*influxql.Call); ok {
@@ -197,6 +237,15 @@ func InitializeUnmarshaller(c *influxql.Call) (UnmarshalFunc, error) {
 		}, nil
 	}
 
+	// Transforms of a nested aggregate e.g. derivative(mean(value)) are
+	// sent in the format of the nested aggregate.
+	switch c.Name {
+	case "derivative", "non_negative_derivative", "cumulative_sum", "moving_average", "difference", "elapsed", "holt_winters":
+		if fn, ok := c.Args[0].(*influxql.Call); ok {
+			return InitializeUnmarshaller(fn)
+		}
+	}
+
 	// Retrieve marshal function by name
 	switch c.Name {
 	case "mean":
@@ -256,6 +305,45 @@ func InitializeUnmarshaller(c *influxql.Call) (UnmarshalFunc, error) {
 			err := json.Unmarshal(b, &a)
 			return a, err
 		}, nil
+	case "mode":
+		return func(b []byte) (interface{}, error) {
+			a := make([]*modeMapOutput, 0)
+			if err := json.Unmarshal(b, &a); err != nil {
+				return nil, err
+			}
+
+			// JSON decodes all numbers as floats so restore the integers.
+			for _, o := range a {
+				if f, ok := o.Value.(float64); ok && o.Type == Int64Type {
+					o.Value = int64(f)
+				}
+			}
+			return a, nil
+		}, nil
+	case "integral":
+		return func(b []byte) (interface{}, error) {
+			if string(b) == "null" {
+				return nil, nil
+			}
+			var o integralMapOutputs
+			err := json.Unmarshal(b, &o)
+			return o, err
+		}, nil
+	case "percentile_approx":
+		return func(b []byte) (interface{}, error) {
+			if string(b) == "null" {
+				return nil, nil
+			}
+			var o tdigest.TDigest
+			err := json.Unmarshal(b, &o)
+			return &o, err
+		}, nil
+	case "histogram":
+		return func(b []byte) (interface{}, error) {
+			var o HistogramBuckets
+			err := json.Unmarshal(b, &o)
+			return o, err
+		}, nil
 	default:
 		return func(b []byte) (interface{}, error) {
 			var val interface{}
@@ -1683,10 +1771,288 @@ func ReducePercentile(values []interface{}, percentile float64) interface{} {
 	return allValues[index]
 }
 
+// modeMapOutput is the number of times a value occurred and the time it first occurred.
+type modeMapOutput struct {
+	Value interface{}
+	Type  NumberType
+	Count int
+	Time  int64
+}
+
+// MapMode counts the occurrences of each value to pass to the reducer.
+func MapMode(input *MapInput, fieldName string) interface{} {
+	var out []*modeMapOutput
+	set := make(map[interface{}]*modeMapOutput)
+	for _, item := range input.Items {
+		v := item.Value
+		if m, ok := v.(map[string]interface{}); ok {
+			v = m[fieldName]
+		}
+		if v == nil {
+			continue
+		}
+
+		o := set[v]
+		if o == nil {
+			o = &modeMapOutput{Value: v, Time: item.Timestamp}
+			if _, ok := v.(int64); ok {
+				o.Type = Int64Type
+			}
+			set[v] = o
+			out = append(out, o)
+		}
+		o.Count++
+		if item.Timestamp < o.Time {
+			o.Time = item.Timestamp
+		}
+	}
+
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// ReduceMode returns the most frequent value. If several values occur the same
+// number of times, the value which occurred first is returned.
+func ReduceMode(values []interface{}) interface{} {
+	set := make(map[interface{}]*modeMapOutput)
+	for _, v := range values {
+		a, _ := v.([]*modeMapOutput)
+		for _, o := range a {
+			curr := set[o.Value]
+			if curr == nil {
+				curr = &modeMapOutput{Value: o.Value, Type: o.Type, Time: o.Time}
+				set[o.Value] = curr
+			}
+			curr.Count += o.Count
+			if o.Time < curr.Time {
+				curr.Time = o.Time
+			}
+		}
+	}
+
+	var mode *modeMapOutput
+	for _, o := range set {
+		switch {
+		case mode == nil, o.Count > mode.Count:
+			mode = o
+		case o.Count < mode.Count:
+		case o.Time < mode.Time:
+			mode = o
+		case o.Time == mode.Time && compareSortValues(o.Value, mode.Value) < 0:
+			mode = o
+		}
+	}
+
+	if mode == nil {
+		return nil
+	}
+	return mode.Value
+}
+
+// integralMapOutput is the area under the points of a series in an interval,
+// in value nanoseconds, along with the first and last points. The reducer uses
+// these to add the area between the outputs of the series from mappers
+// covering adjacent time ranges.
+type integralMapOutput struct {
+	Series    string
+	Area      float64
+	FirstTime int64
+	First     float64
+	LastTime  int64
+	Last      float64
+}
+
+type integralMapOutputs []*integralMapOutput
+
+func (a integralMapOutputs) Len() int { return len(a) }
+func (a integralMapOutputs) Less(i, j int) bool {
+	if a[i].Series != a[j].Series {
+		return a[i].Series < a[j].Series
+	}
+	return a[i].FirstTime < a[j].FirstTime
+}
+func (a integralMapOutputs) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+
+// MapIntegral computes the area under the points of each series using the
+// trapezoidal rule. The series are integrated separately so the result doesn't
+// depend on how they are spread across shards.
+func MapIntegral(input *MapInput, fieldName string) interface{} {
+	var items MapItems
+	for _, item := range input.Items {
+		v := item.Value
+		if m, ok := v.(map[string]interface{}); ok {
+			v = m[fieldName]
+		}
+		if f, ok := toFloat64(v); ok {
+			items = append(items, MapItem{Timestamp: item.Timestamp, Value: f, Tags: item.Tags})
+		}
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+	sort.Stable(items)
+
+	outputs := make(map[string]*integralMapOutput)
+	for _, item := range items {
+		v := item.Value.(float64)
+		key := string(models.Tags(item.Tags).HashKey())
+		out := outputs[key]
+		if out == nil {
+			outputs[key] = &integralMapOutput{
+				Series:    key,
+				FirstTime: item.Timestamp,
+				First:     v,
+				LastTime:  item.Timestamp,
+				Last:      v,
+			}
+			continue
+		}
+		out.Area += trapezoidArea(out.LastTime, out.Last, item.Timestamp, v)
+		out.LastTime, out.Last = item.Timestamp, v
+	}
+
+	a := make(integralMapOutputs, 0, len(outputs))
+	for _, out := range outputs {
+		a = append(a, out)
+	}
+	sort.Sort(a)
+	return a
+}
+
+// ReduceIntegral joins the areas of each series from the mapper outputs, adds
+// up the series and returns the integral in the given unit of time.
+func ReduceIntegral(values []interface{}, unit time.Duration) interface{} {
+	var a integralMapOutputs
+	for _, v := range values {
+		if o, _ := v.(integralMapOutputs); o != nil {
+			a = append(a, o...)
+		}
+	}
+
+	if len(a) == 0 {
+		return nil
+	}
+	sort.Sort(a)
+
+	// The outputs of a series come from shards of different time ranges so
+	// the gap between them is bridged.
+	var area float64
+	for i, o := range a {
+		if i > 0 && a[i-1].Series == o.Series {
+			area += trapezoidArea(a[i-1].LastTime, a[i-1].Last, o.FirstTime, o.First)
+		}
+		area += o.Area
+	}
+	return area / float64(unit)
+}
+
+// trapezoidArea returns the area under the line between two points.
+func trapezoidArea(t0 int64, v0 float64, t1 int64, v1 float64) float64 {
+	return (v0 + v1) / 2 * float64(t1-t0)
+}
+
+// MapPercentileApprox summarizes the values in a t-digest to pass to the reducer.
+// Unlike MapEcho, the size of the output is bounded regardless of the number of values.
+func MapPercentileApprox(input *MapInput, fieldName string) interface{} {
+	d := tdigest.New()
+	for _, item := range input.Items {
+		v := item.Value
+		if m, ok := v.(map[string]interface{}); ok {
+			v = m[fieldName]
+		}
+		if f, ok := toFloat64(v); ok {
+			d.Add(f)
+		}
+	}
+
+	if len(d.Centroids) == 0 {
+		return nil
+	}
+	d.Compress()
+	return d
+}
+
+// ReducePercentileApprox merges the t-digests and returns the estimated percentile.
+func ReducePercentileApprox(values []interface{}, percentile float64) interface{} {
+	d := tdigest.New()
+	for _, v := range values {
+		if o, _ := v.(*tdigest.TDigest); o != nil {
+			d.Merge(o)
+		}
+	}
+
+	if len(d.Centroids) == 0 {
+		return nil
+	}
+	return d.Quantile(percentile / 100)
+}
+
+// HistogramBucket is the number of values within a bucket of a histogram.
+type HistogramBucket struct {
+	Start float64
+	Count int64
+}
+
+// HistogramBuckets is a list of histogram buckets sorted by their start.
+type HistogramBuckets []HistogramBucket
+
+func (a HistogramBuckets) Len() int           { return len(a) }
+func (a HistogramBuckets) Less(i, j int) bool { return a[i].Start < a[j].Start }
+func (a HistogramBuckets) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// MapHistogram counts the values in each bucket of the given width. Only
+// buckets containing values are returned.
+func MapHistogram(input *MapInput, fieldName string, width float64) interface{} {
+	counts := make(map[float64]int64)
+	for _, item := range input.Items {
+		v := item.Value
+		if m, ok := v.(map[string]interface{}); ok {
+			v = m[fieldName]
+		}
+		if f, ok := toFloat64(v); ok {
+			counts[math.Floor(f/width)*width]++
+		}
+	}
+
+	if len(counts) == 0 {
+		return nil
+	}
+	return newHistogramBuckets(counts)
+}
+
+// ReduceHistogram adds the counts of each bucket.
+func ReduceHistogram(values []interface{}) interface{} {
+	counts := make(map[float64]int64)
+	for _, v := range values {
+		a, _ := v.(HistogramBuckets)
+		for _, b := range a {
+			counts[b.Start] += b.Count
+		}
+	}
+
+	if len(counts) == 0 {
+		return nil
+	}
+	return newHistogramBuckets(counts)
+}
+
+// newHistogramBuckets returns the sorted buckets for a map of bucket counts.
+func newHistogramBuckets(counts map[float64]int64) HistogramBuckets {
+	a := make(HistogramBuckets, 0, len(counts))
+	for start, n := range counts {
+		a = append(a, HistogramBucket{Start: start, Count: n})
+	}
+	sort.Sort(a)
+	return a
+}
+
 // IsNumeric returns whether a given aggregate can only be run on numeric fields.
 func IsNumeric(c *influxql.Call) bool {
 	switch c.Name {
-	case "count", "first", "last", "distinct":
+	case "count", "first", "last", "distinct", "mode", "elapsed":
 		return false
 	default:
 		return true
@@ -1,6 +1,7 @@
 package tsdb
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 	"time"
@@ -992,3 +993,321 @@ func TestInitializeUnmarshallerTopBottom(t *testing.T) {
 		}
 	}
 }
+
+func TestMapReduceMode(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs [][]MapItem
+		exp    interface{}
+	}{
+		{
+			name:   "empty",
+			inputs: [][]MapItem{{}},
+			exp:    nil,
+		},
+		{
+			name: "most frequent",
+			inputs: [][]MapItem{{
+				{Timestamp: 1, Value: 2.0},
+				{Timestamp: 2, Value: 1.0},
+				{Timestamp: 3, Value: 1.0},
+			}},
+			exp: 1.0,
+		},
+		{
+			name: "ties use the first value",
+			inputs: [][]MapItem{{
+				{Timestamp: 3, Value: "b"},
+				{Timestamp: 1, Value: "a"},
+				{Timestamp: 2, Value: "b"},
+				{Timestamp: 4, Value: "a"},
+			}},
+			exp: "a",
+		},
+		{
+			name: "counts across mappers",
+			inputs: [][]MapItem{
+				{
+					{Timestamp: 1, Value: int64(1)},
+					{Timestamp: 2, Value: int64(2)},
+				},
+				{
+					{Timestamp: 3, Value: int64(2)},
+					{Timestamp: 4, Value: int64(1)},
+					{Timestamp: 5, Value: int64(2)},
+				},
+			},
+			exp: int64(2),
+		},
+		{
+			name: "multiple fields",
+			inputs: [][]MapItem{{
+				{Timestamp: 1, Value: map[string]interface{}{"value": true, "other": false}},
+				{Timestamp: 2, Value: map[string]interface{}{"value": true, "other": false}},
+				{Timestamp: 3, Value: map[string]interface{}{"other": false}},
+			}},
+			exp: true,
+		},
+	}
+
+	for _, test := range tests {
+		var outputs []interface{}
+		for _, items := range test.inputs {
+			outputs = append(outputs, MapMode(&MapInput{Items: items}, "value"))
+		}
+		if got := ReduceMode(outputs); !reflect.DeepEqual(got, test.exp) {
+			t.Errorf("%s: unexpected mode: exp %v (%T) got %v (%T)", test.name, test.exp, test.exp, got, got)
+		}
+	}
+}
+
+func TestMapReduceIntegral(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs [][]MapItem
+		unit   time.Duration
+		exp    interface{}
+	}{
+		{
+			name:   "empty",
+			inputs: [][]MapItem{{}},
+			unit:   time.Second,
+			exp:    nil,
+		},
+		{
+			name:   "single point",
+			inputs: [][]MapItem{{{Timestamp: 0, Value: 5.0}}},
+			unit:   time.Second,
+			exp:    0.0,
+		},
+		{
+			name: "unsorted points",
+			inputs: [][]MapItem{{
+				{Timestamp: int64(2 * time.Second), Value: int64(20)},
+				{Timestamp: 0, Value: int64(10)},
+				{Timestamp: int64(1 * time.Second), Value: int64(10)},
+			}},
+			unit: time.Second,
+			exp:  25.0,
+		},
+		{
+			name: "areas between mappers",
+			inputs: [][]MapItem{
+				{
+					{Timestamp: int64(2 * time.Minute), Value: 20.0},
+					{Timestamp: int64(3 * time.Minute), Value: 20.0},
+				},
+				{
+					{Timestamp: 0, Value: 10.0},
+					{Timestamp: int64(1 * time.Minute), Value: 10.0},
+				},
+			},
+			unit: time.Minute,
+			exp:  45.0,
+		},
+		{
+			name: "one series per mapper",
+			inputs: [][]MapItem{
+				{
+					{Timestamp: 0, Value: 10.0, Tags: map[string]string{"host": "a"}},
+					{Timestamp: int64(1 * time.Minute), Value: 10.0, Tags: map[string]string{"host": "a"}},
+					{Timestamp: int64(2 * time.Minute), Value: 20.0, Tags: map[string]string{"host": "a"}},
+					{Timestamp: int64(3 * time.Minute), Value: 20.0, Tags: map[string]string{"host": "a"}},
+				},
+				{
+					{Timestamp: 0, Value: 1.0, Tags: map[string]string{"host": "b"}},
+					{Timestamp: int64(2 * time.Minute), Value: 3.0, Tags: map[string]string{"host": "b"}},
+					{Timestamp: int64(3 * time.Minute), Value: 3.0, Tags: map[string]string{"host": "b"}},
+				},
+			},
+			unit: time.Minute,
+			exp:  45.0 + 7.0,
+		},
+		{
+			name: "all series in one mapper",
+			inputs: [][]MapItem{
+				{
+					{Timestamp: 0, Value: 10.0, Tags: map[string]string{"host": "a"}},
+					{Timestamp: int64(1 * time.Minute), Value: 10.0, Tags: map[string]string{"host": "a"}},
+					{Timestamp: int64(2 * time.Minute), Value: 20.0, Tags: map[string]string{"host": "a"}},
+					{Timestamp: int64(3 * time.Minute), Value: 20.0, Tags: map[string]string{"host": "a"}},
+					{Timestamp: 0, Value: 1.0, Tags: map[string]string{"host": "b"}},
+					{Timestamp: int64(2 * time.Minute), Value: 3.0, Tags: map[string]string{"host": "b"}},
+					{Timestamp: int64(3 * time.Minute), Value: 3.0, Tags: map[string]string{"host": "b"}},
+				},
+			},
+			unit: time.Minute,
+			exp:  45.0 + 7.0,
+		},
+		{
+			name: "series across mappers",
+			inputs: [][]MapItem{
+				{
+					{Timestamp: 0, Value: 10.0, Tags: map[string]string{"host": "a"}},
+					{Timestamp: int64(1 * time.Minute), Value: 10.0, Tags: map[string]string{"host": "a"}},
+					{Timestamp: 0, Value: 1.0, Tags: map[string]string{"host": "b"}},
+				},
+				{
+					{Timestamp: int64(2 * time.Minute), Value: 20.0, Tags: map[string]string{"host": "a"}},
+					{Timestamp: int64(3 * time.Minute), Value: 20.0, Tags: map[string]string{"host": "a"}},
+					{Timestamp: int64(2 * time.Minute), Value: 3.0, Tags: map[string]string{"host": "b"}},
+					{Timestamp: int64(3 * time.Minute), Value: 3.0, Tags: map[string]string{"host": "b"}},
+				},
+			},
+			unit: time.Minute,
+			exp:  45.0 + 7.0,
+		},
+	}
+
+	for _, test := range tests {
+		var outputs []interface{}
+		for _, items := range test.inputs {
+			outputs = append(outputs, MapIntegral(&MapInput{Items: items}, "value"))
+		}
+		if got := ReduceIntegral(outputs, test.unit); !reflect.DeepEqual(got, test.exp) {
+			t.Errorf("%s: unexpected integral: exp %v got %v", test.name, test.exp, got)
+		}
+	}
+}
+
+func TestInitializeUnmarshallerModeIntegral(t *testing.T) {
+	tests := []struct {
+		Name   string
+		call   *influxql.Call
+		inputs [][]MapItem
+		output interface{}
+	}{
+		{
+			Name: "mode - integers",
+			call: &influxql.Call{Name: "mode", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}},
+			inputs: [][]MapItem{
+				{{Timestamp: 1, Value: int64(3)}},
+				{{Timestamp: 2, Value: int64(3)}, {Timestamp: 3, Value: int64(4)}},
+			},
+			output: int64(3),
+		},
+		{
+			Name: "integral - hours",
+			call: &influxql.Call{Name: "integral", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}, &influxql.DurationLiteral{Val: time.Hour}}},
+			inputs: [][]MapItem{
+				{{Timestamp: 0, Value: 1.0}},
+				{{Timestamp: int64(time.Hour), Value: 3.0}},
+			},
+			output: 2.0,
+		},
+		{
+			Name: "difference - nested aggregate",
+			call: &influxql.Call{Name: "difference", Args: []influxql.Expr{
+				&influxql.Call{Name: "mode", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}},
+			}},
+			inputs: [][]MapItem{
+				{{Timestamp: 1, Value: int64(8)}},
+			},
+			output: int64(8),
+		},
+	}
+
+	for _, test := range tests {
+		mapper, err := initializeMapFunc(test.call)
+		if err != nil {
+			t.Fatalf("%s: initialize map function: %s", test.Name, err)
+		}
+		reducer, err := initializeReduceFunc(test.call)
+		if err != nil {
+			t.Fatalf("%s: initialize reduce function: %s", test.Name, err)
+		}
+		unmarshaller, err := InitializeUnmarshaller(test.call)
+		if err != nil {
+			t.Fatalf("%s: initialize unmarshaller: %s", test.Name, err)
+		}
+
+		// Send the output of each mapper as if it was from a remote server.
+		var outputs []interface{}
+		for _, items := range test.inputs {
+			b, err := json.Marshal(mapper(&MapInput{Items: items}))
+			if err != nil {
+				t.Fatalf("%s: marshal: %s", test.Name, err)
+			}
+			v, err := unmarshaller(b)
+			if err != nil {
+				t.Fatalf("%s: unmarshal: %s", test.Name, err)
+			}
+			outputs = append(outputs, v)
+		}
+
+		if got := reducer(outputs); !reflect.DeepEqual(got, test.output) {
+			t.Errorf("%s: wrong output: exp %v (%T) got %v (%T)", test.Name, test.output, test.output, got, got)
+		}
+	}
+}
+
+func TestMapReducePercentileApprox(t *testing.T) {
+	call := &influxql.Call{Name: "percentile_approx", Args: []influxql.Expr{
+		&influxql.VarRef{Val: "value"},
+		&influxql.NumberLiteral{Val: 90},
+	}}
+	mapper, _ := initializeMapFunc(call)
+	reducer, _ := initializeReduceFunc(call)
+	unmarshaller, _ := InitializeUnmarshaller(call)
+
+	// Map the values 1 to 1000 over two shards, one of them remote.
+	var local, remote []MapItem
+	for i := 1; i <= 1000; i++ {
+		item := MapItem{Timestamp: int64(i), Value: int64(i)}
+		if i%2 == 0 {
+			local = append(local, item)
+		} else {
+			remote = append(remote, item)
+		}
+	}
+	b, err := json.Marshal(mapper(&MapInput{Items: remote}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	remoteOutput, err := unmarshaller(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := reducer([]interface{}{mapper(&MapInput{Items: local}), remoteOutput, nil}).(float64)
+	if !ok || got < 895 || got > 905 {
+		t.Fatalf("unexpected percentile: %v", got)
+	}
+
+	if got := reducer([]interface{}{mapper(&MapInput{})}); got != nil {
+		t.Fatalf("unexpected percentile of no values: %v", got)
+	}
+}
+
+func TestMapReduceHistogram(t *testing.T) {
+	call := &influxql.Call{Name: "histogram", Args: []influxql.Expr{
+		&influxql.VarRef{Val: "value"},
+		&influxql.NumberLiteral{Val: 10},
+	}}
+	mapper, _ := initializeMapFunc(call)
+	reducer, _ := initializeReduceFunc(call)
+	unmarshaller, _ := InitializeUnmarshaller(call)
+
+	local := mapper(&MapInput{Items: []MapItem{
+		{Timestamp: 1, Value: 12.5},
+		{Timestamp: 2, Value: -0.5},
+		{Timestamp: 3, Value: map[string]interface{}{"value": int64(10)}},
+	}})
+	b, err := json.Marshal(mapper(&MapInput{Items: []MapItem{
+		{Timestamp: 4, Value: int64(0)},
+		{Timestamp: 5, Value: 19.9},
+		{Timestamp: 6, Value: "invalid"},
+	}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote, err := unmarshaller(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := HistogramBuckets{{Start: -10, Count: 1}, {Start: 0, Count: 1}, {Start: 10, Count: 3}}
+	if got := reducer([]interface{}{local, remote, nil}); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected histogram: %v", got)
+	}
+}
@@ -0,0 +1,100 @@
+package tsdb
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// Names of the query limits, as used in the [query] configuration section.
+const (
+	LimitMaxConcurrentQueries = "max-concurrent-queries"
+	LimitQueryTimeout         = "query-timeout"
+	LimitMaxSelectSeries      = "max-select-series"
+	LimitMaxSelectPoint       = "max-select-point"
+	LimitMaxSelectBuckets     = "max-select-buckets"
+)
+
+// QueryLimits are the limits on the resources used by a single query. A limit
+// of zero disables it.
+type QueryLimits struct {
+	Timeout           time.Duration
+	MaxSelectSeriesN  int
+	MaxSelectPointN   int
+	MaxSelectBucketsN int
+}
+
+// Override returns a copy of the limits with the overrides of a user applied.
+// Overrides are keyed by the names used by ALTER USER. Query timeouts are in
+// nanoseconds.
+func (l QueryLimits) Override(overrides map[string]int64) QueryLimits {
+	for name, v := range overrides {
+		switch name {
+		case influxql.QueryTimeoutLimit:
+			l.Timeout = time.Duration(v)
+		case influxql.MaxSelectSeriesLimit:
+			l.MaxSelectSeriesN = int(v)
+		case influxql.MaxSelectPointLimit:
+			l.MaxSelectPointN = int(v)
+		case influxql.MaxSelectBucketsLimit:
+			l.MaxSelectBucketsN = int(v)
+		}
+	}
+	return l
+}
+
+// QueryLimitError is returned when a query exceeds one of its limits.
+type QueryLimitError struct {
+	Limit string // name of the limit
+	Max   int
+}
+
+// Error returns the text of the error.
+func (e *QueryLimitError) Error() string {
+	return fmt.Sprintf("%s limit exceeded (%d)", e.Limit, e.Max)
+}
+
+// PointLimiter counts the points read by the mappers of a query and fails
+// the query once it reads more points than allowed. It is safe for concurrent
+// use by the mappers.
+type PointLimiter struct {
+	max int64
+	n   int64
+}
+
+// NewPointLimiter returns a limiter allowing max points to be read.
+func NewPointLimiter(max int) *PointLimiter {
+	return &PointLimiter{max: int64(max)}
+}
+
+// Add records that n points were read. It returns a *QueryLimitError if the
+// points read exceed the limit.
+func (l *PointLimiter) Add(n int) error {
+	if l == nil || n == 0 {
+		return nil
+	}
+	if atomic.AddInt64(&l.n, int64(n)) > l.max {
+		return &QueryLimitError{Limit: LimitMaxSelectPoint, Max: int(l.max)}
+	}
+	return nil
+}
+
+// N returns the number of points read.
+func (l *PointLimiter) N() int {
+	return int(atomic.LoadInt64(&l.n))
+}
+
+// Remaining returns the number of points which can still be read.
+func (l *PointLimiter) Remaining() int {
+	if n := l.max - atomic.LoadInt64(&l.n); n > 0 {
+		return int(n)
+	}
+	return 0
+}
+
+// PointLimitedMapper is implemented by mappers which count the points they read.
+type PointLimitedMapper interface {
+	SetPointLimiter(l *PointLimiter)
+}
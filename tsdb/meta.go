@@ -1,6 +1,7 @@
 package tsdb
 
 import (
+	"expvar"
 	"fmt"
 	"regexp"
 	"sort"
@@ -21,6 +22,12 @@ const (
 	maxStringLength = 64 * 1024
 )
 
+// Statistics of the cardinality of a database's index.
+const (
+	statDatabaseSeries       = "numSeries"       // Number of series in the database
+	statDatabaseMeasurements = "numMeasurements" // Number of measurements in the database
+)
+
 // DatabaseIndex is the in memory index of a collection of measurements, time series, and their tags.
 // Exported functions are goroutine safe while un-exported functions assume the caller will use the appropriate locks
 type DatabaseIndex struct {
@@ -29,12 +36,15 @@ type DatabaseIndex struct {
 	measurements map[string]*Measurement // measurement name to object and index
 	series       map[string]*Series      // map series key to the Series object
 	lastID       uint64                  // last used series ID. They're in memory only for this shard
+
+	statMap *expvar.Map
 }
 
 func NewDatabaseIndex() *DatabaseIndex {
 	return &DatabaseIndex{
 		measurements: make(map[string]*Measurement),
 		series:       make(map[string]*Series),
+		statMap:      new(expvar.Map).Init(),
 	}
 }
 
@@ -73,6 +83,19 @@ func (d *DatabaseIndex) MeasurementsByName(names []string) []*Measurement {
 	return a
 }
 
+// MeasurementNames returns a sorted list of the measurement names.
+func (d *DatabaseIndex) MeasurementNames() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	names := make([]string, 0, len(d.measurements))
+	for name := range d.measurements {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // MeasurementSeriesCounts returns the number of measurements and series currently indexed by the database.
 // Useful for reporting and monitoring.
 func (d *DatabaseIndex) MeasurementSeriesCounts() (nMeasurements int, nSeries int) {
@@ -99,6 +122,7 @@ func (s *DatabaseIndex) CreateSeriesIndexIfNotExists(measurementName string, ser
 
 	series.measurement = m
 	s.series[series.Key] = series
+	s.statMap.Add(statDatabaseSeries, 1)
 
 	m.AddSeries(series)
 
@@ -112,6 +136,7 @@ func (s *DatabaseIndex) CreateMeasurementIndexIfNotExists(name string) *Measurem
 	if m == nil {
 		m = NewMeasurement(name, s)
 		s.measurements[name] = m
+		s.statMap.Add(statDatabaseMeasurements, 1)
 	}
 	return m
 }
@@ -277,9 +302,11 @@ func (db *DatabaseIndex) DropMeasurement(name string) {
 	}
 
 	delete(db.measurements, name)
+	db.statMap.Add(statDatabaseMeasurements, -1)
 	for _, s := range m.seriesByID {
 		delete(db.series, s.Key)
 	}
+	db.statMap.Add(statDatabaseSeries, -int64(len(m.seriesByID)))
 }
 
 // DropSeries removes the series keys and their tags from the index
@@ -293,6 +320,7 @@ func (db *DatabaseIndex) DropSeries(keys []string) {
 		}
 		series.measurement.DropSeries(series.id)
 		delete(db.series, k)
+		db.statMap.Add(statDatabaseSeries, -1)
 	}
 }
 
@@ -553,6 +581,15 @@ func (m *Measurement) AddSeries(s *Series) bool {
 	return true
 }
 
+// tagValueN returns the number of values of the tag key and whether value is one of them.
+func (m *Measurement) tagValueN(key, value string) (int, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	values := m.seriesByTagKeyValue[key]
+	_, ok := values[value]
+	return len(values), ok
+}
+
 // DropSeries will remove a series from the measurementIndex.
 func (m *Measurement) DropSeries(seriesID uint64) {
 	m.mu.Lock()
@@ -4,8 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/influxdb/influxdb/influxql"
@@ -50,11 +52,26 @@ type QueryExecutor struct {
 		WritePointsInto(p *IntoWriteRequest) error
 	}
 
+	// Lists and kills queries running on the other nodes in the cluster.
+	RemoteQueryManager interface {
+		Queries() ([]QueryInfo, error)
+		KillQuery(nodeID, queryID uint64) error
+	}
+
 	Logger          *log.Logger
 	QueryLogEnabled bool
 
+	// Limits on the queries run by the executor. A limit of zero disables it.
+	// The limits of each query may be overridden for its user with ALTER USER.
+	MaxConcurrentQueries int
+	QueryLimits          QueryLimits
+
 	// the local data store
 	Store *Store
+
+	mu          sync.Mutex
+	queries     map[uint64]*queryTask // running queries by ID
+	nextQueryID uint64
 }
 
 // partial copy of cluster.WriteRequest
@@ -67,8 +84,9 @@ type IntoWriteRequest struct {
 // NewQueryExecutor returns an initialized QueryExecutor
 func NewQueryExecutor(store *Store) *QueryExecutor {
 	return &QueryExecutor{
-		Store:  store,
-		Logger: log.New(os.Stderr, "[query] ", log.LstdFlags),
+		Store:   store,
+		Logger:  log.New(os.Stderr, "[query] ", log.LstdFlags),
+		queries: make(map[uint64]*queryTask),
 	}
 }
 
@@ -127,6 +145,11 @@ func (q *QueryExecutor) Authorize(u *meta.UserInfo, query *influxql.Query, datab
 				db = database
 			}
 			if !u.Authorize(p.Privilege, db) {
+				// Privileges on retention policies and measurements can
+				// authorize the measurements a SELECT reads and writes.
+				if s, ok := stmt.(*influxql.SelectStatement); ok && q.authorizeSelect(u, s, p.Privilege, database) {
+					continue
+				}
 				msg := fmt.Sprintf("statement '%s', requires %s on %s", stmt, p.Privilege.String(), db)
 				return NewErrAuthorize(q, query, u.Name, database, msg)
 			}
@@ -135,10 +158,79 @@ func (q *QueryExecutor) Authorize(u *meta.UserInfo, query *influxql.Query, datab
 	return nil
 }
 
-// ExecuteQuery executes an InfluxQL query against the server.
+// authorizeSelect returns true if the user has read privilege on each
+// measurement the statement reads, or write privilege on the measurement it
+// writes into.
+func (q *QueryExecutor) authorizeSelect(u *meta.UserInfo, stmt *influxql.SelectStatement, privilege influxql.Privilege, database string) bool {
+	if privilege == influxql.WritePrivilege {
+		return stmt.Target != nil && q.authorizeMeasurement(u, privilege, database, stmt.Target.Measurement)
+	}
+
+	for _, src := range stmt.Sources {
+		switch src := src.(type) {
+		case *influxql.Measurement:
+			if !q.authorizeMeasurement(u, privilege, database, src) {
+				return false
+			}
+		case *influxql.SubQuery:
+			if !q.authorizeSelect(u, src.Statement, privilege, database) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return len(stmt.Sources) > 0
+}
+
+// authorizeMeasurement returns true if the user has a privilege on the
+// measurement. Measurements without a name, such as regexes, require a
+// privilege on all measurements of the retention policy.
+func (q *QueryExecutor) authorizeMeasurement(u *meta.UserInfo, privilege influxql.Privilege, database string, m *influxql.Measurement) bool {
+	db, rp := m.Database, m.RetentionPolicy
+	if db == "" {
+		db = database
+	}
+
+	if rp == "" {
+		di, err := q.MetaStore.Database(db)
+		if err != nil || di == nil {
+			return false
+		}
+		rp = di.DefaultRetentionPolicy
+	}
+
+	return u.AuthorizeMeasurement(privilege, db, rp, m.Name)
+}
+
+// ExecuteQuery executes an InfluxQL query against the server on behalf of user.
 // It sends results down the passed in chan and closes it when done. It will close the chan
 // on the first statement that throws an error.
-func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+//
+// The query is registered while it runs so that it's listed by SHOW QUERIES and
+// can be killed with KILL QUERY. Queries exceeding their limits are killed and
+// return the violated limit as the error of their result. The limits overridden
+// for user apply. user is nil when authentication is disabled.
+func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, user *meta.UserInfo, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+	limits := q.QueryLimits
+	var userName string
+	if user != nil {
+		limits = limits.Override(user.QueryLimits)
+		userName = user.Name
+	}
+
+	task, err := q.attachQuery(query, database, userName, limits.Timeout, closing)
+	if err != nil {
+		results := make(chan *influxql.Result, len(query.Statements))
+		results <- &influxql.Result{Err: err}
+		for i := 1; i < len(query.Statements); i++ {
+			results <- &influxql.Result{Err: ErrNotExecuted}
+		}
+		close(results)
+		return results, nil
+	}
+	closing = task.closing
+
 	// Execute each statement. Keep the iterator external so we can
 	// track how many of the statements were executed
 	results := make(chan *influxql.Result)
@@ -170,8 +262,8 @@ func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chu
 			var res *influxql.Result
 			switch stmt := stmt.(type) {
 			case *influxql.SelectStatement:
-				if err := q.executeStatement(i, stmt, database, results, chunkSize, closing); err != nil {
-					results <- &influxql.Result{Err: err}
+				if err := q.executeStatement(i, stmt, database, results, chunkSize, limits, closing); err != nil {
+					results <- &influxql.Result{Err: task.error(err)}
 					break
 				}
 			case *influxql.DropSeriesStatement:
@@ -183,13 +275,13 @@ func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chu
 				// TODO: handle this in a cluster
 				res = q.executeDropMeasurementStatement(stmt, database)
 			case *influxql.ShowMeasurementsStatement:
-				if err := q.executeStatement(i, stmt, database, results, chunkSize, closing); err != nil {
-					results <- &influxql.Result{Err: err}
+				if err := q.executeStatement(i, stmt, database, results, chunkSize, limits, closing); err != nil {
+					results <- &influxql.Result{Err: task.error(err)}
 					break
 				}
 			case *influxql.ShowTagKeysStatement:
-				if err := q.executeStatement(i, stmt, database, results, chunkSize, closing); err != nil {
-					results <- &influxql.Result{Err: err}
+				if err := q.executeStatement(i, stmt, database, results, chunkSize, limits, closing); err != nil {
+					results <- &influxql.Result{Err: task.error(err)}
 					break
 				}
 			case *influxql.ShowTagValuesStatement:
@@ -197,13 +289,18 @@ func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chu
 			case *influxql.ShowFieldKeysStatement:
 				res = q.executeShowFieldKeysStatement(stmt, database)
 			case *influxql.DeleteStatement:
-				res = &influxql.Result{Err: ErrInvalidQuery}
+				// TODO: handle this in a cluster
+				res = q.executeDeleteStatement(stmt, database)
 			case *influxql.DropDatabaseStatement:
 				// TODO: handle this in a cluster
 				res = q.executeDropDatabaseStatement(stmt)
 			case *influxql.ShowStatsStatement, *influxql.ShowDiagnosticsStatement:
 				// Send monitor-related queries to the monitor service.
 				res = q.MonitorStatementExecutor.ExecuteStatement(stmt)
+			case *influxql.ShowQueriesStatement:
+				res = q.executeShowQueriesStatement(stmt)
+			case *influxql.KillQueryStatement:
+				res = q.executeKillQueryStatement(stmt)
 			default:
 				// Delegate all other meta statements to a separate executor. They don't hit tsdb storage.
 				res = q.MetaStatementExecutor.ExecuteStatement(stmt)
@@ -219,6 +316,11 @@ func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chu
 					break
 				}
 			}
+
+			// Don't execute the remaining statements of a killed query.
+			if task.killed() {
+				break
+			}
 		}
 
 		// if there was an error send results that the remaining statements weren't executed
@@ -226,14 +328,246 @@ func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chu
 			results <- &influxql.Result{Err: ErrNotExecuted}
 		}
 
+		q.detachQuery(task)
 		close(results)
 	}()
 
 	return results, nil
 }
 
+// Query statuses reported by SHOW QUERIES.
+const (
+	QueryStatusRunning = "running"
+	QueryStatusKilled  = "killed"
+)
+
+// QueryInfo represents a query running on a node.
+type QueryInfo struct {
+	ID       uint64
+	NodeID   uint64
+	Query    string
+	Database string
+	User     string
+	Start    time.Time
+	Status   string
+}
+
+// queryTask is a query registered with the executor while it runs.
+type queryTask struct {
+	info QueryInfo
+
+	mu      sync.Mutex
+	closing chan struct{} // closed when the query is killed or closed by the caller
+	closed  bool
+	err     error         // reason the query was killed
+	done    chan struct{} // closed when the query is detached
+}
+
+// close closes the task's closing channel if it's not already closed.
+func (t *queryTask) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.closed {
+		close(t.closing)
+		t.closed = true
+	}
+}
+
+// kill marks the query as killed for the given reason and closes it.
+func (t *queryTask) kill(err error) {
+	t.mu.Lock()
+	t.info.Status = QueryStatusKilled
+	t.err = err
+	t.mu.Unlock()
+
+	t.close()
+}
+
+// killed returns true if the query was killed.
+func (t *queryTask) killed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err != nil
+}
+
+// error returns the reason the query was killed in place of err, since
+// killing a query can make its executors fail with unrelated errors.
+func (t *queryTask) error(err error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return t.err
+	}
+	return err
+}
+
+// attachQuery registers a query and returns its task. The task's closing
+// channel is closed when the query is killed, when it runs for longer than
+// timeout or when closing is closed. A timeout of zero disables it.
+func (q *QueryExecutor) attachQuery(query *influxql.Query, database, user string, timeout time.Duration, closing <-chan struct{}) (*queryTask, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Queries managing other queries are allowed past the limit so that
+	// queries can still be killed once it's reached.
+	if q.MaxConcurrentQueries > 0 && len(q.queries) >= q.MaxConcurrentQueries && !isQueryManagement(query) {
+		return nil, ErrMaxConcurrentQueriesReached
+	}
+
+	if q.queries == nil {
+		q.queries = make(map[uint64]*queryTask)
+	}
+	q.nextQueryID++
+
+	t := &queryTask{
+		info: QueryInfo{
+			ID:       q.nextQueryID,
+			Query:    query.String(),
+			Database: database,
+			User:     user,
+			Start:    time.Now(),
+			Status:   QueryStatusRunning,
+		},
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	q.queries[t.info.ID] = t
+
+	go func() {
+		var expired <-chan time.Time
+		if timeout > 0 {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			expired = timer.C
+		}
+
+		select {
+		case <-closing:
+			t.close()
+		case <-expired:
+			t.kill(ErrQueryTimeoutReached)
+		case <-t.done:
+		}
+	}()
+
+	return t, nil
+}
+
+// isQueryManagement returns true if query only lists or kills queries.
+func isQueryManagement(query *influxql.Query) bool {
+	for _, stmt := range query.Statements {
+		switch stmt.(type) {
+		case *influxql.ShowQueriesStatement, *influxql.KillQueryStatement:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// detachQuery removes a finished query from the registry.
+func (q *QueryExecutor) detachQuery(t *queryTask) {
+	q.mu.Lock()
+	delete(q.queries, t.info.ID)
+	q.mu.Unlock()
+
+	close(t.done)
+}
+
+// Queries returns the queries running on the local node, sorted by ID.
+func (q *QueryExecutor) Queries() []QueryInfo {
+	var nodeID uint64
+	if q.MetaStore != nil {
+		nodeID = q.MetaStore.NodeID()
+	}
+
+	q.mu.Lock()
+	a := make([]QueryInfo, 0, len(q.queries))
+	for _, t := range q.queries {
+		t.mu.Lock()
+		info := t.info
+		t.mu.Unlock()
+
+		info.NodeID = nodeID
+		a = append(a, info)
+	}
+	q.mu.Unlock()
+
+	sort.Sort(queryInfos(a))
+	return a
+}
+
+// KillQuery kills a query running on the local node. Killing a query closes
+// its executors, which interrupts the mappers of remote shards.
+func (q *QueryExecutor) KillQuery(id uint64) error {
+	q.mu.Lock()
+	t := q.queries[id]
+	q.mu.Unlock()
+
+	if t == nil {
+		return ErrQueryNotFound
+	}
+
+	t.kill(ErrQueryKilled)
+	return nil
+}
+
+// executeShowQueriesStatement lists the queries running on all nodes.
+func (q *QueryExecutor) executeShowQueriesStatement(stmt *influxql.ShowQueriesStatement) *influxql.Result {
+	queries := q.Queries()
+	if q.RemoteQueryManager != nil {
+		// Queries of unreachable nodes are left out so that queries can still
+		// be listed and killed when a node is down.
+		a, err := q.RemoteQueryManager.Queries()
+		if err != nil {
+			q.Logger.Printf("unable to list queries of remote nodes: %s", err)
+		}
+		queries = append(queries, a...)
+	}
+
+	now := time.Now()
+	row := &models.Row{Columns: []string{"qid", "node_id", "query", "database", "user", "started", "duration", "status"}}
+	for _, qi := range queries {
+		d := now.Sub(qi.Start)
+		d -= d % time.Millisecond
+		row.Values = append(row.Values, []interface{}{qi.ID, qi.NodeID, qi.Query, qi.Database, qi.User, qi.Start.UTC(), d.String(), qi.Status})
+	}
+	return &influxql.Result{Series: []*models.Row{row}}
+}
+
+// executeKillQueryStatement kills a query on the local node, or on the node
+// given by the statement.
+func (q *QueryExecutor) executeKillQueryStatement(stmt *influxql.KillQueryStatement) *influxql.Result {
+	if stmt.NodeID == 0 || stmt.NodeID == q.MetaStore.NodeID() {
+		return &influxql.Result{Err: q.KillQuery(stmt.QueryID)}
+	}
+
+	if q.RemoteQueryManager == nil {
+		return &influxql.Result{Err: fmt.Errorf("node not found: %d", stmt.NodeID)}
+	}
+	return &influxql.Result{Err: q.RemoteQueryManager.KillQuery(stmt.NodeID, stmt.QueryID)}
+}
+
+// queryInfos sorts queries by node and ID.
+type queryInfos []QueryInfo
+
+func (a queryInfos) Len() int      { return len(a) }
+func (a queryInfos) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a queryInfos) Less(i, j int) bool {
+	if a[i].NodeID != a[j].NodeID {
+		return a[i].NodeID < a[j].NodeID
+	}
+	return a[i].ID < a[j].ID
+}
+
 // Plan creates an execution plan for the given SelectStatement and returns an Executor.
 func (q *QueryExecutor) PlanSelect(stmt *influxql.SelectStatement, chunkSize int) (Executor, error) {
+	return q.planSelect(stmt, chunkSize, q.QueryLimits)
+}
+
+// planSelect creates an execution plan for a SelectStatement which reads no
+// more series, points and GROUP BY buckets than allowed by limits.
+func (q *QueryExecutor) planSelect(stmt *influxql.SelectStatement, chunkSize int, limits QueryLimits) (Executor, error) {
 	var shardIDs []uint64
 	shards := map[uint64]meta.ShardInfo{} // Shards requiring mappers.
 
@@ -246,10 +580,35 @@ func (q *QueryExecutor) PlanSelect(stmt *influxql.SelectStatement, chunkSize int
 	if tmax.IsZero() {
 		tmax = now
 	}
+
+	// Queries without a lower time bound are aggregated into a single bucket.
+	if limits.MaxSelectBucketsN > 0 && !tmin.IsZero() {
+		if n, err := selectBucketsN(stmt, tmin, tmax); err != nil {
+			return nil, err
+		} else if n > limits.MaxSelectBucketsN {
+			return nil, &QueryLimitError{Limit: LimitMaxSelectBuckets, Max: limits.MaxSelectBucketsN}
+		}
+	}
+
 	if tmin.IsZero() {
 		tmin = time.Unix(0, 0)
 	}
 
+	// A subquery is planned separately and its results are mapped locally.
+	if len(stmt.Sources) == 1 {
+		if sq, ok := stmt.Sources[0].(*influxql.SubQuery); ok {
+			return q.planSubQuery(stmt, sq, now, chunkSize, limits)
+		}
+	}
+
+	if limits.MaxSelectSeriesN > 0 {
+		if n, err := q.selectSeriesN(stmt); err != nil {
+			return nil, err
+		} else if n > limits.MaxSelectSeriesN {
+			return nil, &QueryLimitError{Limit: LimitMaxSelectSeries, Max: limits.MaxSelectSeriesN}
+		}
+	}
+
 	for _, src := range stmt.Sources {
 		mm, ok := src.(*influxql.Measurement)
 		if !ok {
@@ -276,11 +635,12 @@ func (q *QueryExecutor) PlanSelect(stmt *influxql.SelectStatement, chunkSize int
 	sort.Sort(uint64Slice(shardIDs))
 
 	// Build the Mappers, one per shard.
+	mstmt := mapperStatement(stmt)
 	mappers := []Mapper{}
 	for _, shardID := range shardIDs {
 		sh := shards[shardID]
 
-		m, err := q.ShardMapper.CreateMapper(sh, stmt, chunkSize)
+		m, err := q.ShardMapper.CreateMapper(sh, mstmt, chunkSize)
 		if err != nil {
 			return nil, err
 		}
@@ -291,18 +651,153 @@ func (q *QueryExecutor) PlanSelect(stmt *influxql.SelectStatement, chunkSize int
 		mappers = append(mappers, m)
 	}
 
+	// The mappers share the budget of points of the query.
+	if limits.MaxSelectPointN > 0 {
+		l := NewPointLimiter(limits.MaxSelectPointN)
+		for _, m := range mappers {
+			if m, ok := m.(PointLimitedMapper); ok {
+				m.SetPointLimiter(l)
+			}
+		}
+	}
+
 	// Certain operations on the SELECT statement can be performed by the AggregateExecutor without
 	// assistance from the Mappers. This allows the AggregateExecutor to prepare aggregation functions
 	// and mathematical functions.
 	stmt.RewriteDistinct()
+	mstmt.RewriteDistinct()
 
-	if (stmt.IsRawQuery && !stmt.HasDistinct()) || stmt.IsSimpleDerivative() {
+	if (stmt.IsRawQuery && !stmt.HasDistinct()) || stmt.IsSimpleTransform() {
 		return NewRawExecutor(stmt, mappers, chunkSize), nil
 	} else {
 		return NewAggregateExecutor(stmt, mappers), nil
 	}
 }
 
+// planSubQuery creates an execution plan for a SELECT statement reading from a
+// subquery. The subquery is planned as its own statement, so it may use mappers
+// on any node, and its rows are fed to the statement through a SubQueryMapper.
+func (q *QueryExecutor) planSubQuery(stmt *influxql.SelectStatement, sq *influxql.SubQuery, now time.Time, chunkSize int, limits QueryLimits) (Executor, error) {
+	inner := sq.Statement
+	inner.Condition = influxql.Reduce(inner.Condition, &influxql.NowValuer{Now: now})
+
+	// A subquery without a time range uses the time range of the statement.
+	if !influxql.HasTimeExpr(inner.Condition) {
+		tmin, tmax := influxql.TimeRange(stmt.Condition)
+		if !tmin.IsZero() {
+			inner.Condition = andExpr(inner.Condition, &influxql.BinaryExpr{
+				Op:  influxql.GTE,
+				LHS: &influxql.VarRef{Val: "time"},
+				RHS: &influxql.TimeLiteral{Val: tmin},
+			})
+		}
+		if !tmax.IsZero() {
+			inner.Condition = andExpr(inner.Condition, &influxql.BinaryExpr{
+				Op:  influxql.LTE,
+				LHS: &influxql.VarRef{Val: "time"},
+				RHS: &influxql.TimeLiteral{Val: tmax},
+			})
+		}
+	}
+
+	e, err := q.planSelect(inner, chunkSize, limits)
+	if err != nil {
+		return nil, err
+	}
+	mstmt := mapperStatement(stmt)
+	mappers := []Mapper{NewSubQueryMapper(mstmt, e, chunkSize)}
+
+	stmt.RewriteDistinct()
+	mstmt.RewriteDistinct()
+
+	if (stmt.IsRawQuery && !stmt.HasDistinct()) || stmt.IsSimpleTransform() {
+		return NewRawExecutor(stmt, mappers, chunkSize), nil
+	}
+	return NewAggregateExecutor(stmt, mappers), nil
+}
+
+// selectBucketsN returns the number of GROUP BY time buckets of a statement
+// between tmin and tmax.
+func selectBucketsN(stmt *influxql.SelectStatement, tmin, tmax time.Time) (int, error) {
+	d, err := stmt.GroupByInterval()
+	if err != nil {
+		return 0, err
+	} else if d == 0 {
+		return 1, nil
+	}
+
+	bottom, _ := stmt.TimeWindow(tmin.UnixNano())
+	_, top := stmt.TimeWindow(tmax.UnixNano())
+	return int((top - bottom + int64(d)/2) / int64(d)), nil
+}
+
+// selectSeriesN returns the number of series a statement reads from the
+// index of the local node. The series held only by other nodes of a cluster
+// aren't counted, so the max-select-series limit applies to each node's share
+// of the series.
+func (q *QueryExecutor) selectSeriesN(stmt *influxql.SelectStatement) (int, error) {
+	var n int
+	for _, src := range stmt.Sources {
+		mm, ok := src.(*influxql.Measurement)
+		if !ok {
+			continue
+		}
+
+		db := q.Store.DatabaseIndex(mm.Database)
+		if db == nil {
+			continue
+		}
+
+		var mms Measurements
+		if mm.Regex != nil {
+			mms = db.measurementsByRegex(mm.Regex.Val)
+		} else if m := db.Measurement(mm.Name); m != nil {
+			mms = Measurements{m}
+		}
+
+		for _, m := range mms {
+			tagSets, err := m.DimensionTagSets(stmt)
+			if err != nil {
+				return 0, err
+			}
+			for _, t := range stmt.LimitTagSets(tagSets) {
+				n += len(t.SeriesKeys)
+			}
+		}
+	}
+	return n, nil
+}
+
+// mapperStatement returns the statement to be run by the mappers. Sorting by
+// field or tag values requires all values so the LIMIT and OFFSET are removed
+// and applied by the executor once the values have been sorted. The mappers
+// only read in time order, so the value sort is removed as well. Otherwise
+// remote nodes would reject the statement for sorting by value without a LIMIT.
+func mapperStatement(stmt *influxql.SelectStatement) *influxql.SelectStatement {
+	if !stmt.HasValueSort() {
+		return stmt
+	}
+
+	other := stmt.Clone()
+	other.Limit, other.Offset = 0, 0
+	fields := other.SortFields
+	other.SortFields = nil
+	for _, f := range fields {
+		if f.IsTime() {
+			other.SortFields = append(other.SortFields, f)
+		}
+	}
+	return other
+}
+
+// andExpr returns the conjunction of lhs and rhs. If lhs is nil, rhs is returned.
+func andExpr(lhs, rhs influxql.Expr) influxql.Expr {
+	if lhs == nil {
+		return rhs
+	}
+	return &influxql.BinaryExpr{Op: influxql.AND, LHS: &influxql.ParenExpr{Expr: lhs}, RHS: rhs}
+}
+
 // expandSources expands regex sources and removes duplicates.
 // NOTE: sources must be normalized (db and rp set) before calling this function.
 func (q *QueryExecutor) expandSources(sources influxql.Sources) (influxql.Sources, error) {
@@ -488,6 +983,116 @@ func (q *QueryExecutor) executeDropSeriesStatement(stmt *influxql.DropSeriesStat
 	return &influxql.Result{}
 }
 
+// executeDeleteStatement removes the values of all series from the local store that match
+// the delete query.  If no time range is given, the series are removed entirely.
+func (q *QueryExecutor) executeDeleteStatement(stmt *influxql.DeleteStatement, database string) *influxql.Result {
+	// A fully qualified source takes precedence over the query's database.
+	if m, ok := stmt.Source.(*influxql.Measurement); ok && m.Database != "" {
+		database = m.Database
+	}
+
+	// Find the database.
+	db := q.Store.DatabaseIndex(database)
+	if db == nil {
+		return &influxql.Result{}
+	}
+
+	// Expand regex expressions in the FROM clause.
+	sources, err := q.expandSources(influxql.Sources{stmt.Source})
+	if err != nil {
+		return &influxql.Result{Err: err}
+	} else if len(sources) == 0 {
+		return &influxql.Result{}
+	}
+
+	measurements, err := measurementsFromSourcesOrDB(db, sources...)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+
+	// Replace instances of "now()" with the current time and determine the time range to delete.
+	condition := influxql.Reduce(stmt.Condition, &influxql.NowValuer{Now: time.Now().UTC()})
+	if hasOrTimeExpr(condition) {
+		return &influxql.Result{Err: errors.New("DELETE doesn't support OR with time ranges in WHERE clause")}
+	}
+	min, max := int64(math.MinInt64), int64(math.MaxInt64)
+	tmin, tmax := influxql.TimeRange(condition)
+	if !tmin.IsZero() {
+		min = tmin.UnixNano()
+	}
+	if !tmax.IsZero() {
+		max = tmax.UnixNano()
+	}
+
+	if min > max {
+		return &influxql.Result{}
+	}
+
+	var seriesKeys []string
+	for _, m := range measurements {
+		var ids SeriesIDs
+		var filters FilterExprs
+		if condition != nil {
+			// Get series IDs that match the WHERE clause.
+			ids, filters, err = m.walkWhereForSeriesIds(condition)
+			if err != nil {
+				return &influxql.Result{Err: err}
+			}
+
+			// Delete boolean literal true filter expressions.
+			// These are returned for `WHERE tagKey = 'tagVal'` and time expressions and are okay.
+			filters.DeleteBoolLiteralTrues()
+
+			// Check for unsupported field filters.
+			// Any remaining filters means there were fields (e.g., `WHERE value = 1.2`).
+			if filters.Len() > 0 {
+				return &influxql.Result{Err: errors.New("DELETE doesn't support fields in WHERE clause")}
+			}
+		} else {
+			// No WHERE clause so get all series IDs for this measurement.
+			ids = m.seriesIDs
+		}
+
+		for _, id := range ids {
+			seriesKeys = append(seriesKeys, m.seriesByID[id].Key)
+		}
+	}
+
+	// Without a time range, the series are removed the same as DROP SERIES.
+	if min == math.MinInt64 && max == math.MaxInt64 {
+		if err := q.Store.deleteSeries(database, seriesKeys); err != nil {
+			return &influxql.Result{Err: err}
+		}
+		db.DropSeries(seriesKeys)
+		return &influxql.Result{}
+	}
+
+	// delete the raw series data in the time range
+	if err := q.Store.deleteSeriesRange(database, seriesKeys, min, max); err != nil {
+		return &influxql.Result{Err: err}
+	}
+
+	return &influxql.Result{}
+}
+
+// hasOrTimeExpr returns true if a time expression is under an OR, so the
+// condition can't be reduced to a single time range.
+func hasOrTimeExpr(expr influxql.Expr) bool {
+	switch n := expr.(type) {
+	case *influxql.BinaryExpr:
+		if n.Op == influxql.OR {
+			return influxql.HasTimeExpr(n.LHS) || influxql.HasTimeExpr(n.RHS)
+		} else if n.Op == influxql.AND {
+			return hasOrTimeExpr(n.LHS) || hasOrTimeExpr(n.RHS)
+		}
+		return false
+	case *influxql.ParenExpr:
+		return hasOrTimeExpr(n.Expr)
+	default:
+		return false
+	}
+}
+
 func (q *QueryExecutor) executeShowSeriesStatement(stmt *influxql.ShowSeriesStatement, database string) *influxql.Result {
 	// Check for time in WHERE clause (not supported).
 	if influxql.HasTimeExpr(stmt.Condition) {
@@ -611,10 +1216,10 @@ func (q *QueryExecutor) filterShowSeriesResult(limit, offset int, rows models.Ro
 	return filteredSeries
 }
 
-func (q *QueryExecutor) planStatement(stmt influxql.Statement, database string, chunkSize int) (Executor, error) {
+func (q *QueryExecutor) planStatement(stmt influxql.Statement, database string, chunkSize int, limits QueryLimits) (Executor, error) {
 	switch stmt := stmt.(type) {
 	case *influxql.SelectStatement:
-		return q.PlanSelect(stmt, chunkSize)
+		return q.planSelect(stmt, chunkSize, limits)
 	case *influxql.ShowMeasurementsStatement:
 		return q.PlanShowMeasurements(stmt, database, chunkSize)
 	case *influxql.ShowTagKeysStatement:
@@ -696,9 +1301,9 @@ func (q *QueryExecutor) PlanShowTagKeys(stmt *influxql.ShowTagKeysStatement, dat
 	return executor, nil
 }
 
-func (q *QueryExecutor) executeStatement(statementID int, stmt influxql.Statement, database string, results chan *influxql.Result, chunkSize int, closing chan struct{}) error {
+func (q *QueryExecutor) executeStatement(statementID int, stmt influxql.Statement, database string, results chan *influxql.Result, chunkSize int, limits QueryLimits, closing chan struct{}) error {
 	// Plan statement execution.
-	e, err := q.planStatement(stmt, database, chunkSize)
+	e, err := q.planStatement(stmt, database, chunkSize, limits)
 	if err != nil {
 		return err
 	}
@@ -730,6 +1335,14 @@ func (q *QueryExecutor) executeStatement(statementID int, stmt influxql.Statemen
 			results <- &influxql.Result{StatementID: statementID, Series: []*models.Row{row}}
 		}
 	}
+	// The executor stops early when the query is closed, so its results are
+	// incomplete.
+	select {
+	case <-closing:
+		return ErrQueryKilled
+	default:
+	}
+
 	if writeerr != nil {
 		return writeerr
 	} else if isinto {
@@ -1043,6 +1656,20 @@ var (
 	// ErrNotExecuted is returned when a statement is not executed in a query.
 	// This can occur when a previous statement in the same query has errored.
 	ErrNotExecuted = errors.New("not executed")
+
+	// ErrQueryNotFound is returned when killing a query which isn't running.
+	ErrQueryNotFound = errors.New("query not found")
+
+	// ErrQueryKilled is returned by a query which was killed.
+	ErrQueryKilled = errors.New("query killed")
+
+	// ErrQueryTimeoutReached is returned by a query which ran for longer
+	// than its timeout.
+	ErrQueryTimeoutReached = errors.New("query timeout reached")
+
+	// ErrMaxConcurrentQueriesReached is returned when a query is executed
+	// while the maximum number of queries are already running.
+	ErrMaxConcurrentQueriesReached = errors.New("max concurrent queries reached")
 )
 
 func ErrDatabaseNotFound(name string) error { return fmt.Errorf("database not found: %s", name) }
@@ -2,16 +2,22 @@ package tsdb_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"log"
+	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/influxdb/influxdb/cluster"
 	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
 	"github.com/influxdb/influxdb/models"
+	"github.com/influxdb/influxdb/tcp"
 	"github.com/influxdb/influxdb/tsdb"
 )
 
@@ -161,6 +167,326 @@ func TestAggregateMathQuery(t *testing.T) {
 	store.Close()
 }
 
+// Ensure empty windows are interpolated by fill(linear).
+func TestAggregateLinearFillQuery(t *testing.T) {
+	store, executor := testStoreAndExecutor("")
+	defer os.RemoveAll(store.Path())
+
+	// Write two points with three empty minutes between them.
+	if err := store.WriteToShard(shardID, []models.Point{
+		models.MustNewPoint(
+			"cpu",
+			map[string]string{"host": "server"},
+			map[string]interface{}{"value": 1.0, "n": int64(10)},
+			time.Unix(3600, 0),
+		),
+		models.MustNewPoint(
+			"cpu",
+			map[string]string{"host": "server"},
+			map[string]interface{}{"value": 7.0, "n": int64(40)},
+			time.Unix(3780, 0),
+		),
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	got := executeAndGetJSON("SELECT mean(value), sum(n) FROM cpu WHERE time >= '1970-01-01T00:59:00Z' AND time < '1970-01-01T01:05:00Z' GROUP BY time(1m) fill(linear)", executor)
+	exepected := `[{"series":[{"name":"cpu","columns":["time","mean","sum"],"values":[["1970-01-01T00:59:00Z",null,null],["1970-01-01T01:00:00Z",1,10],["1970-01-01T01:01:00Z",3,20],["1970-01-01T01:02:00Z",5,30],["1970-01-01T01:03:00Z",7,40],["1970-01-01T01:04:00Z",null,null]]}]}]`
+	if exepected != got {
+		t.Fatalf("\nexp: %s\ngot: %s", exepected, got)
+	}
+
+	// Interpolate integers between nanosecond timestamps of today, whose
+	// product with the difference of the values overflows int64.
+	if err := store.WriteToShard(shardID, []models.Point{
+		models.MustNewPoint(
+			"mem",
+			map[string]string{"host": "server"},
+			map[string]interface{}{"n": int64(1000000000)},
+			time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC),
+		),
+		models.MustNewPoint(
+			"mem",
+			map[string]string{"host": "server"},
+			map[string]interface{}{"n": int64(4000000000)},
+			time.Date(2016, 3, 1, 0, 3, 0, 0, time.UTC),
+		),
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	got = executeAndGetJSON("SELECT sum(n) FROM mem WHERE time >= '2016-03-01T00:00:00Z' AND time < '2016-03-01T00:04:00Z' GROUP BY time(1m) fill(linear)", executor)
+	exepected = `[{"series":[{"name":"mem","columns":["time","sum"],"values":[["2016-03-01T00:00:00Z",1000000000],["2016-03-01T00:01:00Z",2000000000],["2016-03-01T00:02:00Z",3000000000],["2016-03-01T00:03:00Z",4000000000]]}]}]`
+	if exepected != got {
+		t.Fatalf("\nexp: %s\ngot: %s", exepected, got)
+	}
+
+	store.Close()
+}
+
+// Ensure GROUP BY time intervals can be shifted by an offset and aligned to a timezone.
+func TestAggregateTimeZoneQuery(t *testing.T) {
+	store, executor := testStoreAndExecutor("")
+	defer os.RemoveAll(store.Path())
+
+	// Write points on either side of the start of daylight savings time in Berlin.
+	var points []models.Point
+	for i, ts := range []string{"2016-03-26T12:00:00Z", "2016-03-27T12:00:00Z", "2016-03-27T21:30:00Z", "2016-03-27T22:30:00Z"} {
+		tm, _ := time.Parse(time.RFC3339, ts)
+		points = append(points, models.MustNewPoint(
+			"cpu",
+			map[string]string{"host": "server"},
+			map[string]interface{}{"value": float64(i + 1)},
+			tm,
+		))
+	}
+	if err := store.WriteToShard(shardID, points); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	got := executeAndGetJSON("SELECT sum(value) FROM cpu WHERE time >= '2016-03-26T06:00:00Z' AND time < '2016-03-28T06:00:00Z' GROUP BY time(1d, 6h)", executor)
+	exepected := `[{"series":[{"name":"cpu","columns":["time","sum"],"values":[["2016-03-26T06:00:00Z",1],["2016-03-27T06:00:00Z",9]]}]}]`
+	if exepected != got {
+		t.Fatalf("\nexp: %s\ngot: %s", exepected, got)
+	}
+
+	got = executeAndGetJSON("SELECT sum(value) FROM cpu WHERE time >= '2016-03-25T23:00:00Z' AND time < '2016-03-28T22:00:00Z' GROUP BY time(1d) tz('Europe/Berlin')", executor)
+	exepected = `[{"series":[{"name":"cpu","columns":["time","sum"],"values":[["2016-03-25T23:00:00Z",1],["2016-03-26T23:00:00Z",5],["2016-03-27T22:00:00Z",4]]}]}]`
+	if exepected != got {
+		t.Fatalf("\nexp: %s\ngot: %s", exepected, got)
+	}
+
+	got = executeAndGetJSON("SELECT sum(value) FROM cpu WHERE time >= '2016-03-25T23:00:00Z' AND time < '2016-03-28T22:00:00Z' GROUP BY time(1d) LIMIT 1 OFFSET 1 tz('Europe/Berlin')", executor)
+	exepected = `[{"series":[{"name":"cpu","columns":["time","sum"],"values":[["2016-03-26T23:00:00Z",5]]}]}]`
+	if exepected != got {
+		t.Fatalf("\nexp: %s\ngot: %s", exepected, got)
+	}
+
+	store.Close()
+}
+
+// Ensure values can be sorted by field and tag values with a limit.
+func TestOrderByValueQuery(t *testing.T) {
+	store, executor := testStoreAndExecutor("")
+	defer os.RemoveAll(store.Path())
+	testOrderByValueQuery(t, store, executor)
+}
+
+// Ensure values of remote shards can be sorted by field and tag values.
+func TestOrderByValueQuery_Remote(t *testing.T) {
+	store, executor := testStoreAndExecutor("")
+	defer os.RemoveAll(store.Path())
+
+	mapper, closeFn := testRemoteShardMapper(store)
+	defer closeFn()
+	executor.ShardMapper = mapper
+
+	testOrderByValueQuery(t, store, executor)
+}
+
+func testOrderByValueQuery(t *testing.T, store *tsdb.Store, executor *tsdb.QueryExecutor) {
+
+	// Write points for three hosts.
+	var points []models.Point
+	for i, v := range []float64{4, 9, 1, 7, 3, 8} {
+		points = append(points, models.MustNewPoint(
+			"cpu",
+			map[string]string{"host": fmt.Sprintf("server%d", i%3)},
+			map[string]interface{}{"value": v},
+			time.Unix(int64(3600+i), 0),
+		))
+	}
+	if err := store.WriteToShard(shardID, points); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	got := executeAndGetJSON("SELECT value FROM cpu ORDER BY value DESC LIMIT 3", executor)
+	exepected := `[{"series":[{"name":"cpu","columns":["time","value"],"values":[["1970-01-01T01:00:01Z",9],["1970-01-01T01:00:05Z",8],["1970-01-01T01:00:03Z",7]]}]}]`
+	if exepected != got {
+		t.Fatalf("\nexp: %s\ngot: %s", exepected, got)
+	}
+
+	got = executeAndGetJSON("SELECT value FROM cpu ORDER BY value LIMIT 2 OFFSET 1", executor)
+	exepected = `[{"series":[{"name":"cpu","columns":["time","value"],"values":[["1970-01-01T01:00:04Z",3],["1970-01-01T01:00:00Z",4]]}]}]`
+	if exepected != got {
+		t.Fatalf("\nexp: %s\ngot: %s", exepected, got)
+	}
+
+	got = executeAndGetJSON("SELECT mean(value) FROM cpu GROUP BY host ORDER BY mean DESC LIMIT 2", executor)
+	exepected = `[{"series":[{"name":"cpu","tags":{"host":"server1"},"columns":["time","mean"],"values":[["1970-01-01T00:00:00Z",6]]}]},{"series":[{"name":"cpu","tags":{"host":"server0"},"columns":["time","mean"],"values":[["1970-01-01T00:00:00Z",5.5]]}]}]`
+	if exepected != got {
+		t.Fatalf("\nexp: %s\ngot: %s", exepected, got)
+	}
+
+	got = executeAndGetJSON("SELECT max(value) FROM cpu GROUP BY host ORDER BY host DESC LIMIT 10", executor)
+	exepected = `[{"series":[{"name":"cpu","tags":{"host":"server2"},"columns":["time","max"],"values":[["1970-01-01T00:00:00Z",8]]}]},{"series":[{"name":"cpu","tags":{"host":"server1"},"columns":["time","max"],"values":[["1970-01-01T00:00:00Z",9]]}]},{"series":[{"name":"cpu","tags":{"host":"server0"},"columns":["time","max"],"values":[["1970-01-01T00:00:00Z",7]]}]}]`
+	if exepected != got {
+		t.Fatalf("\nexp: %s\ngot: %s", exepected, got)
+	}
+
+	store.Close()
+}
+
+// Ensure math functions can be applied to raw fields and aggregates.
+func TestMathFuncQuery(t *testing.T) {
+	store, executor := testStoreAndExecutor("")
+	defer os.RemoveAll(store.Path())
+
+	// Write two points.
+	if err := store.WriteToShard(shardID, []models.Point{
+		models.MustNewPoint(
+			"cpu",
+			map[string]string{"host": "server"},
+			map[string]interface{}{"value": -10.0, "load": int64(4)},
+			time.Unix(1, 0),
+		),
+		models.MustNewPoint(
+			"cpu",
+			map[string]string{"host": "server"},
+			map[string]interface{}{"value": 1000.0, "load": int64(9)},
+			time.Unix(2, 0),
+		),
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	got := executeAndGetJSON("SELECT abs(value) FROM cpu", executor)
+	exepected := `[{"series":[{"name":"cpu","columns":["time","abs"],"values":[["1970-01-01T00:00:01Z",10],["1970-01-01T00:00:02Z",1000]]}]}]`
+	if exepected != got {
+		t.Fatalf("\nexp: %s\ngot: %s", exepected, got)
+	}
+
+	got = executeAndGetJSON("SELECT sqrt(load), pow(load, 2) AS squared, log10(value) FROM cpu", executor)
+	exepected = `[{"series":[{"name":"cpu","columns":["time","sqrt","squared","log10"],"values":[["1970-01-01T00:00:01Z",2,16,null],["1970-01-01T00:00:02Z",3,81,3]]}]}]`
+	if exepected != got {
+		t.Fatalf("\nexp: %s\ngot: %s", exepected, got)
+	}
+
+	got = executeAndGetJSON("SELECT log10(max(value)), clamp(sum(load), 0, 10) FROM cpu", executor)
+	exepected = `[{"series":[{"name":"cpu","columns":["time","log10","clamp"],"values":[["1970-01-01T00:00:00Z",3,10]]}]}]`
+	if exepected != got {
+		t.Fatalf("\nexp: %s\ngot: %s", exepected, got)
+	}
+
+	got = executeAndGetJSON("SELECT round(mean(value) / 3) FROM cpu", executor)
+	exepected = `[{"series":[{"name":"cpu","columns":["time","round"],"values":[["1970-01-01T00:00:00Z",165]]}]}]`
+	if exepected != got {
+		t.Fatalf("\nexp: %s\ngot: %s", exepected, got)
+	}
+
+	store.Close()
+}
+
+// Ensure aggregates and transforms of the values of a series can be queried.
+func TestTransformQuery(t *testing.T) {
+	store, executor := testStoreAndExecutor("")
+	defer os.RemoveAll(store.Path())
+
+	// Write a point every minute, skipping the fourth minute.
+	var points []models.Point
+	for i, v := range []int64{1, 3, 3, 0, 6, 10} {
+		if i == 3 {
+			continue
+		}
+		points = append(points, models.MustNewPoint(
+			"cpu",
+			map[string]string{"host": "server"},
+			map[string]interface{}{"value": v},
+			time.Unix(int64(3600+i*60), 0),
+		))
+	}
+
+	// Write a linear series to forecast.
+	for i := 0; i < 6; i++ {
+		points = append(points, models.MustNewPoint(
+			"mem",
+			map[string]string{"host": "server"},
+			map[string]interface{}{"value": float64(2*i + 1)},
+			time.Unix(int64(3600+i*60), 0),
+		))
+	}
+	if err := store.WriteToShard(shardID, points); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	for _, tt := range []struct {
+		q   string
+		exp string
+	}{
+		{
+			q:   "SELECT mode(value), integral(value, 1m) FROM cpu",
+			exp: `[{"series":[{"name":"cpu","columns":["time","mode","integral"],"values":[["1970-01-01T00:00:00Z",3,22]]}]}]`,
+		},
+		{
+			q:   "SELECT difference(value) FROM cpu",
+			exp: `[{"series":[{"name":"cpu","columns":["time","difference"],"values":[["1970-01-01T01:01:00Z",2],["1970-01-01T01:02:00Z",0],["1970-01-01T01:04:00Z",3],["1970-01-01T01:05:00Z",4]]}]}]`,
+		},
+		{
+			q:   "SELECT elapsed(value, 1m) FROM cpu",
+			exp: `[{"series":[{"name":"cpu","columns":["time","elapsed"],"values":[["1970-01-01T01:01:00Z",1],["1970-01-01T01:02:00Z",1],["1970-01-01T01:04:00Z",2],["1970-01-01T01:05:00Z",1]]}]}]`,
+		},
+		{
+			q:   "SELECT moving_average(value, 3) FROM cpu",
+			exp: `[{"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["1970-01-01T01:02:00Z",2.3333333333333335],["1970-01-01T01:04:00Z",4],["1970-01-01T01:05:00Z",6.333333333333333]]}]}]`,
+		},
+		{
+			q:   "SELECT cumulative_sum(sum(value)) FROM cpu WHERE time >= '1970-01-01T01:00:00Z' AND time < '1970-01-01T01:06:00Z' GROUP BY time(2m)",
+			exp: `[{"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["1970-01-01T01:00:00Z",4],["1970-01-01T01:02:00Z",7],["1970-01-01T01:04:00Z",23]]}]}]`,
+		},
+		{
+			q:   "SELECT cumulative_sum(sum(value)) FROM cpu WHERE time >= '1970-01-01T01:00:00Z' AND time < '1970-01-01T01:06:00Z' GROUP BY time(1m) fill(0)",
+			exp: `[{"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["1970-01-01T01:00:00Z",1],["1970-01-01T01:01:00Z",4],["1970-01-01T01:02:00Z",7],["1970-01-01T01:03:00Z",7],["1970-01-01T01:04:00Z",13],["1970-01-01T01:05:00Z",23]]}]}]`,
+		},
+		{
+			q:   "SELECT abs(difference(max(value))) FROM cpu WHERE time >= '1970-01-01T01:00:00Z' AND time < '1970-01-01T01:06:00Z' GROUP BY time(3m)",
+			exp: `[{"series":[{"name":"cpu","columns":["time","abs"],"values":[["1970-01-01T01:03:00Z",7]]}]}]`,
+		},
+		{
+			q:   "SELECT holt_winters(max(value), 2, 0) FROM mem WHERE time >= '1970-01-01T01:00:00Z' AND time < '1970-01-01T01:06:00Z' GROUP BY time(1m)",
+			exp: `[{"series":[{"name":"mem","columns":["time","holt_winters"],"values":[["1970-01-01T01:06:00Z",13],["1970-01-01T01:07:00Z",15]]}]}]`,
+		},
+	} {
+		if got := executeAndGetJSON(tt.q, executor); tt.exp != got {
+			t.Errorf("%s:\nexp: %s\ngot: %s", tt.q, tt.exp, got)
+		}
+	}
+
+	store.Close()
+}
+
+// Ensure values can be summarized by a histogram and approximate percentiles.
+func TestHistogramQuery(t *testing.T) {
+	store, executor := testStoreAndExecutor("")
+	defer os.RemoveAll(store.Path())
+
+	var points []models.Point
+	for i, v := range []float64{1, 12, 5, 19, 27, 3} {
+		points = append(points, models.MustNewPoint(
+			"cpu",
+			map[string]string{"host": "server"},
+			map[string]interface{}{"value": v},
+			time.Unix(int64(3600+i*60), 0),
+		))
+	}
+	if err := store.WriteToShard(shardID, points); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	got := executeAndGetJSON("SELECT percentile_approx(value, 50) FROM cpu", executor)
+	exepected := `[{"series":[{"name":"cpu","columns":["time","percentile_approx"],"values":[["1970-01-01T00:00:00Z",8.5]]}]}]`
+	if exepected != got {
+		t.Fatalf("\nexp: %s\ngot: %s", exepected, got)
+	}
+
+	got = executeAndGetJSON("SELECT histogram(value, 10) FROM cpu WHERE time >= '1970-01-01T01:00:00Z' AND time < '1970-01-01T01:09:00Z' GROUP BY time(3m)", executor)
+	exepected = `[{"series":[{"name":"cpu","columns":["time","bucket","histogram"],"values":[["1970-01-01T01:00:00Z",0,2],["1970-01-01T01:00:00Z",10,1],["1970-01-01T01:03:00Z",0,1],["1970-01-01T01:03:00Z",10,1],["1970-01-01T01:03:00Z",20,1],["1970-01-01T01:06:00Z",null,null]]}]}]`
+	if exepected != got {
+		t.Fatalf("\nexp: %s\ngot: %s", exepected, got)
+	}
+
+	store.Close()
+}
+
 // Ensure writing a point and updating it results in only a single point.
 func TestWritePointsAndExecuteQuery_Update(t *testing.T) {
 	store, executor := testStoreAndExecutor("")
@@ -260,6 +586,154 @@ func TestDropSeriesStatement(t *testing.T) {
 	}
 }
 
+func TestDeleteStatement(t *testing.T) {
+	store, executor := testStoreAndExecutorWithEngine("", "tsm1")
+	defer os.RemoveAll(store.Path())
+
+	var points []models.Point
+	for i, host := range []string{"serverA", "serverA", "serverA", "serverB"} {
+		points = append(points, models.MustNewPoint(
+			"cpu",
+			map[string]string{"host": host},
+			map[string]interface{}{"value": float64(i)},
+			time.Unix(int64(i%3), 0),
+		))
+	}
+
+	if err := store.WriteToShard(shardID, points); err != nil {
+		t.Fatal(err)
+	}
+
+	got := executeAndGetJSON("DELETE FROM cpu WHERE value = 1", executor)
+	exepected := `[{"error":"DELETE doesn't support fields in WHERE clause"}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+
+	got = executeAndGetJSON("DELETE FROM cpu WHERE time < '1970-01-01T00:00:01Z' OR time > '1970-01-01T00:00:02Z'", executor)
+	exepected = `[{"error":"DELETE doesn't support OR with time ranges in WHERE clause"}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+
+	got = executeAndGetJSON("DELETE FROM cpu WHERE host = 'serverA' AND time >= '1970-01-01T00:00:01Z' AND time < '1970-01-01T00:00:02Z'", executor)
+	exepected = `[{}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+
+	got = executeAndGetJSON("SELECT * FROM cpu GROUP BY *", executor)
+	exepected = `[{"series":[{"name":"cpu","tags":{"host":"serverA"},"columns":["time","value"],"values":[["1970-01-01T00:00:00Z",0],["1970-01-01T00:00:02Z",2]]}]},{"series":[{"name":"cpu","tags":{"host":"serverB"},"columns":["time","value"],"values":[["1970-01-01T00:00:00Z",3]]}]}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+
+	// Without a time range, the series are dropped.
+	got = executeAndGetJSON("DELETE FROM cpu WHERE host = 'serverB'", executor)
+	exepected = `[{}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+
+	got = executeAndGetJSON("show series", executor)
+	exepected = `[{"series":[{"name":"cpu","columns":["_key","host"],"values":[["cpu,host=serverA","serverA"]]}]}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+
+	store.Close()
+	conf := store.EngineOptions.Config
+	store = tsdb.NewStore(store.Path())
+	store.EngineOptions.Config = conf
+	store.Open()
+	executor.Store = store
+	executor.ShardMapper = &testShardMapper{store: store}
+
+	got = executeAndGetJSON("SELECT * FROM cpu GROUP BY *", executor)
+	exepected = `[{"series":[{"name":"cpu","tags":{"host":"serverA"},"columns":["time","value"],"values":[["1970-01-01T00:00:00Z",0],["1970-01-01T00:00:02Z",2]]}]}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+}
+
+// Ensure a delete with a time range isn't partially applied when some shards
+// of the database can't delete time ranges.
+func TestDeleteStatement_MixedEngines(t *testing.T) {
+	store, executor := testStoreAndExecutorWithEngine("", "tsm1")
+	defer os.RemoveAll(store.Path())
+
+	store.EngineOptions.EngineVersion = "b1"
+	if err := store.CreateShard("foo", "bar", shardID+1); err != nil {
+		t.Fatal(err)
+	}
+
+	pt := models.MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0))
+	if err := store.WriteToShard(shardID, []models.Point{pt}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := executeAndGetJSON("DELETE FROM cpu WHERE time < '1970-01-01T00:00:02Z'", executor)
+	exepected := `[{"error":"engine does not support deleting a time range"}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+
+	got = executeAndGetJSON("SELECT * FROM cpu", executor)
+	exepected = `[{"series":[{"name":"cpu","columns":["time","host","value"],"values":[["1970-01-01T00:00:01Z","serverA",1]]}]}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+}
+
+func TestSubQuery(t *testing.T) {
+	store, executor := testStoreAndExecutor("")
+	defer os.RemoveAll(store.Path())
+
+	var points []models.Point
+	for i, v := range []float64{1, 3, 5, 7} {
+		points = append(points, models.MustNewPoint(
+			"cpu",
+			map[string]string{"host": "serverA"},
+			map[string]interface{}{"value": v},
+			time.Unix(int64(3600+30*i), 0),
+		))
+	}
+	for i, v := range []float64{2, 4, 10, 2} {
+		points = append(points, models.MustNewPoint(
+			"cpu",
+			map[string]string{"host": "serverB"},
+			map[string]interface{}{"value": v},
+			time.Unix(int64(3600+30*i), 0),
+		))
+	}
+
+	if err := store.WriteToShard(shardID, points); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		q   string
+		exp string
+	}{
+		{
+			q:   `SELECT max(m) FROM (SELECT mean(value) AS m FROM cpu GROUP BY time(1m), host) WHERE time >= '1970-01-01T01:00:00Z' AND time < '1970-01-01T01:02:00Z' GROUP BY time(1m)`,
+			exp: `[{"series":[{"name":"cpu","columns":["time","max"],"values":[["1970-01-01T01:00:00Z",3],["1970-01-01T01:01:00Z",6]]}]}]`,
+		},
+		{
+			q:   `SELECT sum(m) FROM (SELECT max(value) AS m FROM cpu WHERE time >= '1970-01-01T01:00:00Z' AND time < '1970-01-01T01:02:00Z' GROUP BY time(1m), host) GROUP BY host`,
+			exp: `[{"series":[{"name":"cpu","tags":{"host":"serverA"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",10]]}]},{"series":[{"name":"cpu","tags":{"host":"serverB"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",14]]}]}]`,
+		},
+		{
+			q:   `SELECT m FROM (SELECT mean(value) AS m FROM cpu WHERE time >= '1970-01-01T01:00:00Z' AND time < '1970-01-01T01:02:00Z' GROUP BY time(1m), host) WHERE host = 'serverB' AND m > 5`,
+			exp: `[{"series":[{"name":"cpu","columns":["time","m"],"values":[["1970-01-01T01:01:00Z",6]]}]}]`,
+		},
+	} {
+		if got := executeAndGetJSON(tt.q, executor); tt.exp != got {
+			t.Fatalf("%s\nexp: %s\ngot: %s", tt.q, tt.exp, got)
+		}
+	}
+}
+
 func TestDropMeasurementStatement(t *testing.T) {
 	store, executor := testStoreAndExecutor("")
 	defer os.RemoveAll(store.Path())
@@ -438,12 +912,261 @@ func TestAuthenticateIfUserCountZeroAndCreateUser(t *testing.T) {
 	}
 }
 
+// Ensure SELECT statements are authorized by retention policy and measurement privileges.
+func TestAuthorize_MeasurementPrivileges(t *testing.T) {
+	store, executor := testStoreAndExecutor("")
+	defer os.RemoveAll(store.Path())
+	defer store.Close()
+	executor.MetaStore = &testMetastore{userCount: 1}
+
+	u := &meta.UserInfo{
+		Name: "susy",
+		MeasurementPrivileges: []meta.MeasurementPrivilege{
+			{Database: "db0", RetentionPolicy: "foo", Measurement: "cpu", Privilege: influxql.ReadPrivilege},
+			{Database: "db0", RetentionPolicy: "bar", Privilege: influxql.AllPrivileges},
+			{Database: "db0", Regex: regexp.MustCompile(`^mem`), Privilege: influxql.ReadPrivilege},
+		},
+	}
+
+	for i, tt := range []struct {
+		q          string
+		authorized bool
+	}{
+		{q: `SELECT value FROM cpu`, authorized: true},
+		{q: `SELECT value FROM foo.cpu, mem_free`, authorized: true},
+		{q: `SELECT value FROM disk`, authorized: false},
+		{q: `SELECT value FROM cpu, disk`, authorized: false},
+		{q: `SELECT value FROM /cpu/`, authorized: false},
+		{q: `SELECT value FROM bar./.*/`, authorized: true},
+		{q: `SELECT value FROM db1..cpu`, authorized: false},
+		{q: `SELECT mean(value) FROM (SELECT value FROM cpu)`, authorized: true},
+		{q: `SELECT value INTO bar.cpu_copy FROM cpu`, authorized: true},
+		{q: `SELECT value INTO foo.cpu_copy FROM cpu`, authorized: false},
+		{q: `SHOW MEASUREMENTS`, authorized: false},
+	} {
+		err := executor.Authorize(u, mustParseQuery(tt.q), "db0")
+		if authorized := err == nil; authorized != tt.authorized {
+			t.Errorf("%d. %s: unexpected authorization: %v (err=%v)", i, tt.q, authorized, err)
+		}
+	}
+}
+
+// Ensure running queries are listed and can be killed, locally and on remote nodes.
+func TestShowQueriesAndKillQuery(t *testing.T) {
+	store, executor := testStoreAndExecutor("")
+	defer os.RemoveAll(store.Path())
+	defer store.Close()
+
+	var killed []uint64
+	executor.RemoteQueryManager = &testQueryManager{
+		queries: []tsdb.QueryInfo{{ID: 7, NodeID: 2, Query: "SELECT * FROM mem", Database: "db1", User: "bob", Start: time.Now(), Status: tsdb.QueryStatusRunning}},
+		killFn: func(nodeID, queryID uint64) error {
+			killed = append(killed, nodeID, queryID)
+			return nil
+		},
+	}
+
+	// Start a query whose results are not read yet, so it remains running.
+	ch, err := executor.ExecuteQuery(mustParseQuery("SELECT value FROM cpu; SELECT value FROM mem"), "foo", &meta.UserInfo{Name: "alice"}, 20, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The query and the remote query are listed with the SHOW QUERIES query itself.
+	res := executeQueryAndGetResults("SHOW QUERIES", executor)
+	if res[0].Err != nil {
+		t.Fatal(res[0].Err)
+	}
+	values := res[0].Series[0].Values
+	if len(values) != 3 {
+		t.Fatalf("unexpected queries: %v", values)
+	}
+	if v := values[0]; v[0] != uint64(1) || v[1] != uint64(1) || v[2] != "SELECT value FROM cpu;\nSELECT value FROM mem" || v[3] != "foo" || v[4] != "alice" || v[7] != tsdb.QueryStatusRunning {
+		t.Fatalf("unexpected query: %v", v)
+	}
+	if v := values[1]; v[0] != uint64(2) || v[2] != "SHOW QUERIES" {
+		t.Fatalf("unexpected query: %v", v)
+	}
+	if v := values[2]; v[0] != uint64(7) || v[1] != uint64(2) || v[4] != "bob" {
+		t.Fatalf("unexpected remote query: %v", v)
+	}
+
+	// Kill the local query and ensure it stops after its current statement.
+	if res := executeQueryAndGetResults("KILL QUERY 1", executor); res[0].Err != nil {
+		t.Fatal(res[0].Err)
+	}
+	var last *influxql.Result
+	for r := range ch {
+		last = r
+	}
+	if last.Err != tsdb.ErrNotExecuted {
+		t.Fatalf("unexpected result of killed query: %v", last)
+	}
+	if a := executor.Queries(); len(a) != 0 {
+		t.Fatalf("unexpected queries: %v", a)
+	}
+
+	// Killing a query which isn't running is an error.
+	if res := executeQueryAndGetResults("KILL QUERY 1", executor); res[0].Err != tsdb.ErrQueryNotFound {
+		t.Fatalf("unexpected error: %v", res[0].Err)
+	}
+
+	// Queries on other nodes are killed by the query manager.
+	if res := executeQueryAndGetResults("KILL QUERY 7 ON 2", executor); res[0].Err != nil {
+		t.Fatal(res[0].Err)
+	} else if len(killed) != 2 || killed[0] != 2 || killed[1] != 7 {
+		t.Fatalf("unexpected kill: %v", killed)
+	}
+}
+
+func TestQueryLimits(t *testing.T) {
+	store, executor := testStoreAndExecutor("")
+	defer os.RemoveAll(store.Path())
+	defer store.Close()
+
+	// Write 2 points to each of 3 series.
+	var points []models.Point
+	for _, host := range []string{"a", "b", "c"} {
+		for i := 1; i <= 2; i++ {
+			points = append(points, models.MustNewPoint(
+				"cpu",
+				map[string]string{"host": host},
+				map[string]interface{}{"value": float64(i)},
+				time.Unix(int64(i), 0),
+			))
+		}
+	}
+	if err := store.WriteToShard(shardID, points); err != nil {
+		t.Fatal(err)
+	}
+
+	alice := &meta.UserInfo{Name: "alice", QueryLimits: map[string]int64{influxql.MaxSelectSeriesLimit: 0}}
+
+	var tests = []struct {
+		limits tsdb.QueryLimits
+		user   *meta.UserInfo
+		query  string
+		err    string
+	}{
+		{
+			limits: tsdb.QueryLimits{MaxSelectSeriesN: 2},
+			query:  `SELECT value FROM cpu`,
+			err:    `max-select-series limit exceeded (2)`,
+		},
+		{
+			limits: tsdb.QueryLimits{MaxSelectSeriesN: 2},
+			query:  `SELECT value FROM cpu WHERE host = 'a' OR host = 'b'`,
+		},
+		{
+			limits: tsdb.QueryLimits{MaxSelectSeriesN: 2},
+			query:  `SELECT value FROM cpu GROUP BY host SLIMIT 1`,
+		},
+		{
+			// The limit is disabled for alice.
+			limits: tsdb.QueryLimits{MaxSelectSeriesN: 2},
+			user:   alice,
+			query:  `SELECT value FROM cpu`,
+		},
+		{
+			limits: tsdb.QueryLimits{MaxSelectPointN: 5},
+			query:  `SELECT value FROM cpu`,
+			err:    `max-select-point limit exceeded (5)`,
+		},
+		{
+			limits: tsdb.QueryLimits{MaxSelectPointN: 5},
+			query:  `SELECT count(value) FROM cpu`,
+			err:    `max-select-point limit exceeded (5)`,
+		},
+		{
+			limits: tsdb.QueryLimits{MaxSelectPointN: 5},
+			query:  `SELECT count(value) FROM cpu WHERE host = 'a'`,
+		},
+		{
+			limits: tsdb.QueryLimits{MaxSelectBucketsN: 10},
+			query:  `SELECT count(value) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:01:00Z' GROUP BY time(1s)`,
+			err:    `max-select-buckets limit exceeded (10)`,
+		},
+		{
+			limits: tsdb.QueryLimits{MaxSelectBucketsN: 10},
+			query:  `SELECT count(value) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:01:00Z' GROUP BY time(10s)`,
+		},
+	}
+
+	for i, tt := range tests {
+		executor.QueryLimits = tt.limits
+
+		ch, err := executor.ExecuteQuery(mustParseQuery(tt.query), "foo", tt.user, 20, make(chan struct{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var errstr string
+		for r := range ch {
+			if r.Err != nil {
+				if _, ok := r.Err.(*tsdb.QueryLimitError); !ok {
+					t.Errorf("%d. %s: unexpected error type: %T", i, tt.query, r.Err)
+				}
+				errstr = r.Err.Error()
+			}
+		}
+		if errstr != tt.err {
+			t.Errorf("%d. %s: error mismatch:\n  exp=%s\n  got=%s", i, tt.query, tt.err, errstr)
+		}
+	}
+}
+
+func TestQueryLimits_Concurrency(t *testing.T) {
+	store, executor := testStoreAndExecutor("")
+	defer os.RemoveAll(store.Path())
+	defer store.Close()
+
+	if err := store.WriteToShard(shardID, []models.Point{models.MustNewPoint(
+		"cpu",
+		map[string]string{"host": "server"},
+		map[string]interface{}{"value": 1.0},
+		time.Unix(1, 0),
+	)}); err != nil {
+		t.Fatal(err)
+	}
+
+	executor.MaxConcurrentQueries = 1
+	executor.QueryLimits.Timeout = 10 * time.Millisecond
+
+	// Start a query whose results are not read yet, so it remains running.
+	ch, err := executor.ExecuteQuery(mustParseQuery("SELECT value FROM cpu"), "foo", nil, 20, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Queries beyond the limit are rejected, except those managing queries.
+	if res := executeQueryAndGetResults("SELECT value FROM cpu", executor); res[0].Err != tsdb.ErrMaxConcurrentQueriesReached {
+		t.Fatalf("unexpected error: %v", res[0].Err)
+	} else if res := executeQueryAndGetResults("SHOW QUERIES", executor); res[0].Err != nil {
+		t.Fatal(res[0].Err)
+	}
+
+	// The running query is killed once it times out.
+	time.Sleep(50 * time.Millisecond)
+	var last *influxql.Result
+	for r := range ch {
+		last = r
+	}
+	if last.Err != tsdb.ErrQueryTimeoutReached {
+		t.Fatalf("unexpected result of timed out query: %v", last)
+	}
+}
+
 func testStoreAndExecutor(storePath string) (*tsdb.Store, *tsdb.QueryExecutor) {
+	return testStoreAndExecutorWithEngine(storePath, tsdb.DefaultEngine)
+}
+
+func testStoreAndExecutorWithEngine(storePath, engine string) (*tsdb.Store, *tsdb.QueryExecutor) {
 	if storePath == "" {
 		storePath, _ = ioutil.TempDir("", "")
 	}
 
 	store := tsdb.NewStore(storePath)
+	store.EngineOptions.EngineVersion = engine
 	store.EngineOptions.Config.WALDir = filepath.Join(storePath, "wal")
 
 	err := store.Open()
@@ -463,7 +1186,7 @@ func testStoreAndExecutor(storePath string) (*tsdb.Store, *tsdb.QueryExecutor) {
 }
 
 func executeAndGetJSON(query string, executor *tsdb.QueryExecutor) string {
-	ch, err := executor.ExecuteQuery(mustParseQuery(query), "foo", 20, make(chan struct{}))
+	ch, err := executor.ExecuteQuery(mustParseQuery(query), "foo", nil, 20, make(chan struct{}))
 	if err != nil {
 		panic(err.Error())
 	}
@@ -480,6 +1203,19 @@ func executeAndGetJSON(query string, executor *tsdb.QueryExecutor) string {
 	return string(b)
 }
 
+func executeQueryAndGetResults(query string, executor *tsdb.QueryExecutor) []*influxql.Result {
+	ch, err := executor.ExecuteQuery(mustParseQuery(query), "foo", nil, 20, make(chan struct{}))
+	if err != nil {
+		panic(err.Error())
+	}
+
+	var results []*influxql.Result
+	for r := range ch {
+		results = append(results, r)
+	}
+	return results
+}
+
 type testMetastore struct {
 	userCount int
 }
@@ -565,6 +1301,57 @@ func (t *testMetastore) NodeID() uint64 {
 	return 1
 }
 
+type testQueryManager struct {
+	queries []tsdb.QueryInfo
+	killFn  func(nodeID, queryID uint64) error
+}
+
+func (t *testQueryManager) Queries() ([]tsdb.QueryInfo, error) { return t.queries, nil }
+
+func (t *testQueryManager) KillQuery(nodeID, queryID uint64) error {
+	return t.killFn(nodeID, queryID)
+}
+
+// testRemoteShardMapper returns a shard mapper reading the shards of store
+// through the cluster service, as if they were owned by another node.
+func testRemoteShardMapper(store *tsdb.Store) (*cluster.ShardMapper, func()) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	mux := tcp.NewMux()
+	go mux.Serve(ln)
+
+	s := cluster.NewService(cluster.Config{})
+	s.Listener = mux.Listen(cluster.MuxHeader)
+	s.TSDBStore = store
+	s.Logger = log.New(ioutil.Discard, "", 0)
+	if err := s.Open(); err != nil {
+		panic(err)
+	}
+
+	m := cluster.NewShardMapper(time.Minute)
+	m.ForceRemoteMapping = true
+	m.MetaStore = &testNodeMetastore{host: ln.Addr().String()}
+	m.TSDBStore = store
+
+	return m, func() {
+		ln.Close()
+		s.Close()
+	}
+}
+
+// testNodeMetastore resolves every node to the same host.
+type testNodeMetastore struct {
+	host string
+}
+
+func (t *testNodeMetastore) NodeID() uint64 { return 1 }
+
+func (t *testNodeMetastore) Node(id uint64) (*meta.NodeInfo, error) {
+	return &meta.NodeInfo{ID: id, Host: t.host}, nil
+}
+
 type testShardMapper struct {
 	store *tsdb.Store
 }
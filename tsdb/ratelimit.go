@@ -0,0 +1,156 @@
+package tsdb
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/pkg/ratelimit"
+)
+
+// RateLimits are the rates of writes and queries allowed per second. A rate
+// of zero is unlimited.
+type RateLimits struct {
+	PointsPerSecond  int64
+	BytesPerSecond   int64
+	QueriesPerSecond int64
+}
+
+// Override returns a copy of the limits with the overrides of a user applied.
+// Overrides are keyed by the names used by ALTER USER.
+func (l RateLimits) Override(overrides map[string]int64) RateLimits {
+	for name, v := range overrides {
+		switch name {
+		case influxql.WriteLimit:
+			l.PointsPerSecond = v
+		case influxql.WriteBytesLimit:
+			l.BytesPerSecond = v
+		case influxql.QueryLimit:
+			l.QueriesPerSecond = v
+		}
+	}
+	return l
+}
+
+// RateLimitError is returned when a write or a query exceeds a rate limit.
+type RateLimitError struct {
+	Limit      string // description of the limit
+	RetryAfter time.Duration
+}
+
+// Error returns the text of the error.
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s rate limit exceeded, retry after %s", e.Limit, e.RetryAfter)
+}
+
+// RateLimiter limits the rates of writes and queries of each user and of each
+// database. A nil limiter doesn't limit anything. It is safe for concurrent use.
+type RateLimiter struct {
+	UserLimits     RateLimits
+	DatabaseLimits RateLimits
+
+	// The buckets are checked and their tokens taken under mu, so that
+	// concurrent writes can't both take the last tokens.
+	mu sync.Mutex
+
+	userPoints, userBytes, userQueries *ratelimit.Group
+	dbPoints, dbBytes, dbQueries       *ratelimit.Group
+}
+
+// NewRateLimiter returns a new instance of RateLimiter.
+func NewRateLimiter(c RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		UserLimits: RateLimits{
+			PointsPerSecond:  c.UserPointsPerSecond,
+			BytesPerSecond:   c.UserBytesPerSecond,
+			QueriesPerSecond: c.UserQueriesPerSecond,
+		},
+		DatabaseLimits: RateLimits{
+			PointsPerSecond:  c.DatabasePointsPerSecond,
+			BytesPerSecond:   c.DatabaseBytesPerSecond,
+			QueriesPerSecond: c.DatabaseQueriesPerSecond,
+		},
+		userPoints:  ratelimit.NewGroup(),
+		userBytes:   ratelimit.NewGroup(),
+		userQueries: ratelimit.NewGroup(),
+		dbPoints:    ratelimit.NewGroup(),
+		dbBytes:     ratelimit.NewGroup(),
+		dbQueries:   ratelimit.NewGroup(),
+	}
+}
+
+// Write records a write of n points totalling size bytes by a user to a
+// database. The user is nil for writes which aren't authenticated. If the
+// write exceeds a rate limit, nothing is recorded and a *RateLimitError is
+// returned.
+func (l *RateLimiter) Write(u *meta.UserInfo, database string, n, size int) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	checks := []rateCheck{
+		{"database points", l.dbPoints.Bucket(database, l.DatabaseLimits.PointsPerSecond), n},
+		{"database bytes", l.dbBytes.Bucket(database, l.DatabaseLimits.BytesPerSecond), size},
+	}
+	if u != nil {
+		limits := l.UserLimits.Override(u.RateLimits)
+		checks = append(checks,
+			rateCheck{"user points", l.userPoints.Bucket(u.Name, limits.PointsPerSecond), n},
+			rateCheck{"user bytes", l.userBytes.Bucket(u.Name, limits.BytesPerSecond), size},
+		)
+	}
+	return take(checks)
+}
+
+// Query records a query by a user on a database. The user is nil for queries
+// which aren't authenticated. If the query exceeds a rate limit, nothing is
+// recorded and a *RateLimitError is returned.
+func (l *RateLimiter) Query(u *meta.UserInfo, database string) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	checks := []rateCheck{
+		{"database queries", l.dbQueries.Bucket(database, l.DatabaseLimits.QueriesPerSecond), 1},
+	}
+	if u != nil {
+		limits := l.UserLimits.Override(u.RateLimits)
+		checks = append(checks, rateCheck{"user queries", l.userQueries.Bucket(u.Name, limits.QueriesPerSecond), 1})
+	}
+	return take(checks)
+}
+
+// rateCheck is a number of tokens to take from a bucket.
+type rateCheck struct {
+	limit  string
+	bucket *ratelimit.Bucket
+	n      int
+}
+
+// take takes the tokens of each check if all of them are available.
+// Otherwise it returns the error of the check with the longest delay.
+// The caller must hold the lock of the limiter owning the buckets.
+func take(checks []rateCheck) error {
+	var err *RateLimitError
+	for _, c := range checks {
+		if d := c.bucket.Delay(c.n); d > 0 && (err == nil || d > err.RetryAfter) {
+			err = &RateLimitError{Limit: c.limit, RetryAfter: d}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, c := range checks {
+		c.bucket.Take(c.n)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package tsdb_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/tsdb"
+)
+
+// Ensure the rate limiter applies the database and user limits.
+func TestRateLimiter_Write(t *testing.T) {
+	l := tsdb.NewRateLimiter(tsdb.RateLimitConfig{
+		UserPointsPerSecond:     10,
+		DatabasePointsPerSecond: 100,
+	})
+	susy := &meta.UserInfo{Name: "susy"}
+	bob := &meta.UserInfo{Name: "bob", RateLimits: map[string]int64{influxql.WriteLimit: 50}}
+
+	if err := l.Write(susy, "foo", 10, 0); err != nil {
+		t.Fatal(err)
+	} else if err, ok := l.Write(susy, "foo", 1, 0).(*tsdb.RateLimitError); !ok || err.Limit != "user points" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The override of bob allows more points than the user limit.
+	if err := l.Write(bob, "foo", 50, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unauthenticated writes are only subject to the database limit.
+	if err := l.Write(nil, "foo", 40, 0); err != nil {
+		t.Fatal(err)
+	} else if err, ok := l.Write(nil, "foo", 1, 0).(*tsdb.RateLimitError); !ok || err.Limit != "database points" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a nil rate limiter doesn't limit anything.
+func TestRateLimiter_Nil(t *testing.T) {
+	var l *tsdb.RateLimiter
+	if err := l.Write(nil, "foo", 1000, 1000); err != nil {
+		t.Fatal(err)
+	} else if err := l.Query(nil, "foo"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure concurrent writes can't take more points than the limit allows.
+func TestRateLimiter_Write_Concurrent(t *testing.T) {
+	l := tsdb.NewRateLimiter(tsdb.RateLimitConfig{DatabasePointsPerSecond: 100})
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var written int
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Write(nil, "foo", 50, 0); err == nil {
+				mu.Lock()
+				written += 50
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if written > 100 {
+		t.Fatalf("unexpected points written: %d", written)
+	}
+}
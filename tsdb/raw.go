@@ -57,6 +57,9 @@ func (e *RawExecutor) close() {
 func (e *RawExecutor) Execute(closing <-chan struct{}) <-chan *models.Row {
 	out := make(chan *models.Row, 0)
 	go e.execute(out, closing)
+	if e.stmt.HasValueSort() {
+		return sortRows(e.stmt, out, closing)
+	}
 	return out
 }
 
@@ -64,6 +67,11 @@ func (e *RawExecutor) execute(out chan *models.Row, closing <-chan struct{}) {
 	// It's important that all resources are released when execution completes.
 	defer e.close()
 
+	// Interrupt mappers waiting on remote nodes if execution is closed.
+	done := make(chan struct{})
+	defer close(done)
+	go interruptMappers(e.mappers, closing, done)
+
 	// Open the mappers.
 	for _, m := range e.mappers {
 		if err := m.Open(); err != nil {
@@ -86,6 +94,12 @@ func (e *RawExecutor) execute(out chan *models.Row, closing <-chan struct{}) {
 		aliasFields = e.stmt.Fields.AliasNames()
 	}
 
+	// Values sorted by field are limited after they have all been sorted.
+	limit, offset := e.stmt.Limit, e.stmt.Offset
+	if e.stmt.HasValueSort() {
+		limit, offset = 0, 0
+	}
+
 	// Used to read ahead chunks from mappers.
 	var rowWriter *limitedRowWriter
 	var currTagset string
@@ -155,10 +169,7 @@ func (e *RawExecutor) execute(out chan *models.Row, closing <-chan struct{}) {
 			rowWriter = nil
 		}
 
-		ascending := true
-		if len(e.stmt.SortFields) > 0 {
-			ascending = e.stmt.SortFields[0].Ascending
-		}
+		ascending := e.stmt.TimeAscending()
 
 		var timeBoundary int64
 
@@ -235,8 +246,8 @@ func (e *RawExecutor) execute(out chan *models.Row, closing <-chan struct{}) {
 		// The Name and Tags will be the same for all mappers.
 		if rowWriter == nil {
 			rowWriter = &limitedRowWriter{
-				limit:       e.stmt.Limit,
-				offset:      e.stmt.Offset,
+				limit:       limit,
+				offset:      offset,
 				chunkSize:   e.chunkSize,
 				name:        chunkedOutput.Name,
 				tags:        chunkedOutput.Tags,
@@ -245,16 +256,19 @@ func (e *RawExecutor) execute(out chan *models.Row, closing <-chan struct{}) {
 				fields:      e.stmt.Fields,
 				c:           out,
 			}
-		}
-		if e.stmt.HasDerivative() {
-			interval, err := derivativeInterval(e.stmt)
-			if err != nil {
-				out <- &models.Row{Err: err}
-				return
-			}
-			rowWriter.transformer = &RawQueryDerivativeProcessor{
-				IsNonNegative:      e.stmt.FunctionCalls()[0].Name == "non_negative_derivative",
-				DerivativeInterval: interval,
+
+			if e.stmt.HasDerivative() {
+				interval, err := derivativeInterval(e.stmt)
+				if err != nil {
+					out <- &models.Row{Err: err}
+					return
+				}
+				rowWriter.transformer = &RawQueryDerivativeProcessor{
+					IsNonNegative:      e.stmt.FunctionCalls()[0].Name == "non_negative_derivative",
+					DerivativeInterval: interval,
+				}
+			} else if e.stmt.HasTransform() {
+				rowWriter.transformer = NewRawQueryTransformProcessor(e.stmt.FunctionCalls()[0])
 			}
 		}
 
@@ -269,7 +283,7 @@ func (e *RawExecutor) execute(out chan *models.Row, closing <-chan struct{}) {
 		select {
 		case <-closing:
 			out <- &models.Row{Err: fmt.Errorf("execute was closed by caller")}
-			break
+			return
 		default:
 			// do nothing
 		}
@@ -643,6 +657,8 @@ func processForMath(fields influxql.Fields, results [][]interface{}) [][]interfa
 			hasMath = true
 		} else if _, ok := f.Expr.(*influxql.ParenExpr); ok {
 			hasMath = true
+		} else if call, ok := f.Expr.(*influxql.Call); ok && influxql.IsMathFunc(call) {
+			hasMath = true
 		}
 	}
 
@@ -800,6 +816,8 @@ type RawMapper struct {
 	selectTags   []string
 	whereFields  []string
 
+	limiter *PointLimiter
+
 	ChunkSize int
 }
 
@@ -911,6 +929,9 @@ func (m *RawMapper) openMeasurement(mm *Measurement) error {
 	return nil
 }
 
+// SetPointLimiter sets the limiter counting the points read by the mapper.
+func (m *RawMapper) SetPointLimiter(l *PointLimiter) { m.limiter = l }
+
 // Close closes the mapper.
 func (m *RawMapper) Close() {
 	if m != nil && m.tx != nil {
@@ -943,6 +964,9 @@ func (m *RawMapper) NextChunk() (interface{}, error) {
 			m.cursorIndex++
 			if output != nil {
 				// There is data, so return it and continue when next called.
+				if err := m.limiter.Add(len(output.Values)); err != nil {
+					return nil, err
+				}
 				return output, nil
 			} else {
 				// Just go straight to the next cursor.
@@ -966,6 +990,9 @@ func (m *RawMapper) NextChunk() (interface{}, error) {
 		})
 
 		if len(output.Values) == m.ChunkSize {
+			if err := m.limiter.Add(len(output.Values)); err != nil {
+				return nil, err
+			}
 			return output, nil
 		}
 	}
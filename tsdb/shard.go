@@ -9,7 +9,9 @@ import (
 	"io"
 	"math"
 	"os"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/influxdb/influxdb"
 	"github.com/influxdb/influxdb/influxql"
@@ -26,8 +28,13 @@ const (
 	statWritePointsFail = "writePointsFail"
 	statWritePointsOK   = "writePointsOk"
 	statWriteBytes      = "writeBytes"
+	statDiskBytes       = "diskBytes"
 )
 
+// diskBytesInterval is how long the size of a shard on disk is cached for,
+// so collecting the statistics doesn't walk the shard each time.
+const diskBytesInterval = 10 * time.Second
+
 var (
 	// ErrFieldOverflow is returned when too many fields are created on a measurement.
 	ErrFieldOverflow = errors.New("field overflow")
@@ -43,6 +50,17 @@ var (
 	ErrFieldUnmappedID = errors.New("field ID not mapped")
 )
 
+// PartialWriteError is returned when some points of a write were dropped
+// and the rest were written.
+type PartialWriteError struct {
+	Reason  string
+	Dropped int
+}
+
+func (e PartialWriteError) Error() string {
+	return fmt.Sprintf("partial write: %s dropped=%d", e.Reason, e.Dropped)
+}
+
 // Shard represents a self-contained time series database. An inverted index of
 // the measurement and tag data is kept along with the raw time series data.
 // Data can be split across many shards. The query engine in TSDB is responsible
@@ -62,6 +80,11 @@ type Shard struct {
 	// expvar-based stats.
 	statMap *expvar.Map
 
+	// The size on disk last reported in the statistics.
+	diskBytesMu sync.Mutex
+	diskBytes   int64
+	diskBytesAt time.Time
+
 	// The writer used by the logger.
 	LogOutput io.Writer
 }
@@ -73,7 +96,7 @@ func NewShard(id uint64, index *DatabaseIndex, path string, walPath string, opti
 	tags := map[string]string{"path": path, "id": fmt.Sprintf("%d", id), "engine": options.EngineVersion}
 	statMap := influxdb.NewStatistics(key, "shard", tags)
 
-	return &Shard{
+	s := &Shard{
 		index:             index,
 		path:              path,
 		walPath:           walPath,
@@ -84,6 +107,16 @@ func NewShard(id uint64, index *DatabaseIndex, path string, walPath string, opti
 		statMap:   statMap,
 		LogOutput: os.Stderr,
 	}
+
+	// The size of the shard is read when statistics are collected, at most
+	// once per diskBytesInterval. The shard isn't locked as the statistics
+	// may be read while it's writing them.
+	statMap.Set(statDiskBytes, expvar.Func(func() interface{} {
+		return s.statDiskSize()
+	}))
+	influxdb.DeclareGauges("shard", statDiskBytes)
+
+	return s
 }
 
 // Path returns the path set on the shard when it was created.
@@ -156,12 +189,39 @@ func (s *Shard) close() error {
 func (s *Shard) DiskSize() (int64, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	stats, err := os.Stat(s.path)
+	return diskSize(s.path)
+}
+
+// statDiskSize returns the size on disk of the shard, reading it again only
+// if it was last read more than diskBytesInterval ago.
+func (s *Shard) statDiskSize() int64 {
+	s.diskBytesMu.Lock()
+	defer s.diskBytesMu.Unlock()
+	if time.Since(s.diskBytesAt) >= diskBytesInterval {
+		s.diskBytes, _ = diskSize(s.path)
+		s.diskBytesAt = time.Now()
+	}
+	return s.diskBytes
+}
+
+// diskSize returns the size of the file at root, or of all files below it if
+// it's a directory.
+func diskSize(root string) (int64, error) {
 	var size int64
-	if err != nil {
+	if err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		// Files may be removed by a compaction while walking the shard.
+		if os.IsNotExist(err) && path != root {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			size += fi.Size()
+		}
+		return nil
+	}); err != nil {
 		return 0, err
 	}
-	size += stats.Size()
 	return size, nil
 }
 
@@ -199,10 +259,17 @@ type SeriesCreate struct {
 func (s *Shard) WritePoints(points []models.Point) error {
 	s.statMap.Add(statWriteReq, 1)
 
-	seriesToCreate, fieldsToCreate, seriesToAddShardTo, err := s.validateSeriesAndFields(points)
-	if err != nil {
+	// A partial write error is returned once the valid points are written.
+	points, seriesToCreate, fieldsToCreate, seriesToAddShardTo, err := s.validateSeriesAndFields(points)
+	var writeError error
+	if _, ok := err.(PartialWriteError); ok {
+		writeError = err
+	} else if err != nil {
 		return err
 	}
+	if len(points) == 0 && writeError != nil {
+		return writeError
+	}
 	s.statMap.Add(statSeriesCreate, int64(len(seriesToCreate)))
 	s.statMap.Add(statFieldsCreate, int64(len(fieldsToCreate)))
 
@@ -266,7 +333,7 @@ func (s *Shard) WritePoints(points []models.Point) error {
 	}
 	s.statMap.Add(statWritePointsOK, int64(len(points)))
 
-	return nil
+	return writeError
 }
 
 func (s *Shard) ValidateAggregateFieldsInStatement(measurementName string, stmt *influxql.SelectStatement) error {
@@ -326,6 +393,17 @@ func (s *Shard) DeleteSeries(keys []string) error {
 	return s.engine.DeleteSeries(keys)
 }
 
+// DeleteSeriesRange deletes the values of a list of series between min and max.
+func (s *Shard) DeleteSeriesRange(keys []string, min, max int64) error {
+	return s.engine.DeleteSeriesRange(keys, min, max)
+}
+
+// SupportsDeleteRange returns true if the engine of the shard can delete the
+// values of series in a time range.
+func (s *Shard) SupportsDeleteRange() bool {
+	return s.engine.Format() == TSM1Format
+}
+
 // DeleteMeasurement deletes a measurement and all underlying series.
 func (s *Shard) DeleteMeasurement(name string, seriesKeys []string) error {
 	s.mu.Lock()
@@ -382,7 +460,7 @@ func (s *Shard) createFieldsAndMeasurements(fieldsToCreate []*FieldCreate) (map[
 }
 
 // validateSeriesAndFields checks which series and fields are new and whose metadata should be saved and indexed
-func (s *Shard) validateSeriesAndFields(points []models.Point) ([]*SeriesCreate, []*FieldCreate, []string, error) {
+func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point, []*SeriesCreate, []*FieldCreate, []string, error) {
 	var seriesToCreate []*SeriesCreate
 	var fieldsToCreate []*FieldCreate
 	var seriesToAddShardTo []string
@@ -395,9 +473,26 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]*SeriesCreate,
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	for _, p := range points {
+	// Points creating series beyond the cardinality limits are dropped.
+	// The first one dropped is reported in the error.
+	limits := newCardinalityLimiter(s.index, s.options.Config)
+	var reason string
+	var dropped int
+	valid := points
+
+	for i, p := range points {
 		// see if the series should be added to the index
 		if ss := s.index.series[string(p.Key())]; ss == nil {
+			if err := limits.add(p); err != "" {
+				// Copy the points so the caller's slice isn't modified.
+				if dropped == 0 {
+					reason = err
+					valid = append(make([]models.Point, 0, len(points)), points[:i]...)
+				}
+				dropped++
+				continue
+			}
+
 			series := NewSeries(string(p.Key()), p.Tags())
 			seriesToCreate = append(seriesToCreate, &SeriesCreate{p.Name(), series})
 			seriesToAddShardTo = append(seriesToAddShardTo, series.Key)
@@ -406,6 +501,9 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]*SeriesCreate,
 			seriesToCreate = append(seriesToCreate, &SeriesCreate{p.Name(), ss})
 			seriesToAddShardTo = append(seriesToAddShardTo, ss.Key)
 		}
+		if dropped > 0 {
+			valid = append(valid, p)
+		}
 
 		// see if the field definitions need to be saved to the shard
 		mf := s.measurementFields[p.Name()]
@@ -421,7 +519,7 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]*SeriesCreate,
 			if f := mf.Fields[name]; f != nil {
 				// Field present in shard metadata, make sure there is no type conflict.
 				if f.Type != influxql.InspectDataType(value) {
-					return nil, nil, nil, fmt.Errorf("field type conflict: input field \"%s\" on measurement \"%s\" is type %T, already exists as type %s", name, p.Name(), value, f.Type)
+					return nil, nil, nil, nil, fmt.Errorf("field type conflict: input field \"%s\" on measurement \"%s\" is type %T, already exists as type %s", name, p.Name(), value, f.Type)
 				}
 
 				continue // Field is present, and it's of the same type. Nothing more to do.
@@ -431,7 +529,79 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]*SeriesCreate,
 		}
 	}
 
-	return seriesToCreate, fieldsToCreate, seriesToAddShardTo, nil
+	if dropped > 0 {
+		return valid, seriesToCreate, fieldsToCreate, seriesToAddShardTo, PartialWriteError{Reason: reason, Dropped: dropped}
+	}
+	return valid, seriesToCreate, fieldsToCreate, seriesToAddShardTo, nil
+}
+
+// cardinalityLimiter checks the series created by a write against the
+// max-series-per-database and max-values-per-tag limits. The caller must
+// hold a read lock on the index.
+type cardinalityLimiter struct {
+	index           *DatabaseIndex
+	maxSeries       int
+	maxValuesPerTag int
+
+	series map[string]struct{}            // series created by the write
+	values map[string]map[string]struct{} // tag values created by the write, by measurement and tag key
+}
+
+func newCardinalityLimiter(index *DatabaseIndex, c Config) *cardinalityLimiter {
+	return &cardinalityLimiter{
+		index:           index,
+		maxSeries:       c.MaxSeriesPerDatabase,
+		maxValuesPerTag: c.MaxValuesPerTag,
+		series:          make(map[string]struct{}),
+		values:          make(map[string]map[string]struct{}),
+	}
+}
+
+// add records the new series of the point. A description of the limit is
+// returned if the series would exceed it.
+func (l *cardinalityLimiter) add(p models.Point) string {
+	key := string(p.Key())
+	if _, ok := l.series[key]; ok {
+		return ""
+	}
+
+	if l.maxSeries > 0 && len(l.index.series)+len(l.series) >= l.maxSeries {
+		return fmt.Sprintf("max-series-per-database limit exceeded: (%d) series=%q", l.maxSeries, key)
+	}
+
+	// Find the tag values which aren't in the index or the write yet.
+	var newValues []string
+	if l.maxValuesPerTag > 0 {
+		m := l.index.measurements[p.Name()]
+		for k, v := range p.Tags() {
+			tagKey := p.Name() + "," + k
+			if _, ok := l.values[tagKey][v]; ok {
+				continue
+			}
+
+			var n int
+			if m != nil {
+				var exists bool
+				if n, exists = m.tagValueN(k, v); exists {
+					continue
+				}
+			}
+			if n+len(l.values[tagKey]) >= l.maxValuesPerTag {
+				return fmt.Sprintf("max-values-per-tag limit exceeded (%d/%d): measurement=%q tag=%q value=%q series=%q",
+					n+len(l.values[tagKey]), l.maxValuesPerTag, p.Name(), k, v, key)
+			}
+			newValues = append(newValues, tagKey, v)
+		}
+	}
+
+	l.series[key] = struct{}{}
+	for i := 0; i < len(newValues); i += 2 {
+		if l.values[newValues[i]] == nil {
+			l.values[newValues[i]] = make(map[string]struct{})
+		}
+		l.values[newValues[i]][newValues[i+1]] = struct{}{}
+	}
+	return ""
 }
 
 // SeriesCount returns the number of series buckets on the shard.
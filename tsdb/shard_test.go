@@ -140,6 +140,78 @@ func TestShardWriteAddNewField(t *testing.T) {
 
 }
 
+// Ensure points creating series over the max-series-per-database limit are dropped.
+func TestShard_WritePoints_MaxSeriesPerDatabase(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+
+	index := tsdb.NewDatabaseIndex()
+	opts := tsdb.NewEngineOptions()
+	opts.Config.WALDir = filepath.Join(tmpDir, "wal")
+	opts.Config.MaxSeriesPerDatabase = 2
+
+	sh := tsdb.NewShard(1, index, path.Join(tmpDir, "shard"), path.Join(tmpDir, "wal"), opts)
+	if err := sh.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer sh.Close()
+
+	points := []models.Point{
+		models.MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		models.MustNewPoint("cpu", map[string]string{"host": "serverB"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		models.MustNewPoint("cpu", map[string]string{"host": "serverC"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		models.MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 2.0}, time.Unix(2, 0)),
+	}
+	err := sh.WritePoints(points)
+	if err == nil || err.Error() != `partial write: max-series-per-database limit exceeded: (2) series="cpu,host=serverC" dropped=1` {
+		t.Fatalf("unexpected error: %v", err)
+	} else if index.SeriesN() != 2 {
+		t.Fatalf("unexpected series count: %d", index.SeriesN())
+	} else if len(points) != 4 || points[2].Tags()["host"] != "serverC" {
+		t.Fatalf("points modified: %v", points)
+	}
+
+	// Points for existing series are still written.
+	if err := sh.WritePoints(points[3:]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure points creating tag values over the max-values-per-tag limit are dropped.
+func TestShard_WritePoints_MaxValuesPerTag(t *testing.T) {
+	tmpDir, _ := ioutil.TempDir("", "shard_test")
+	defer os.RemoveAll(tmpDir)
+
+	index := tsdb.NewDatabaseIndex()
+	opts := tsdb.NewEngineOptions()
+	opts.Config.WALDir = filepath.Join(tmpDir, "wal")
+	opts.Config.MaxValuesPerTag = 2
+
+	sh := tsdb.NewShard(1, index, path.Join(tmpDir, "shard"), path.Join(tmpDir, "wal"), opts)
+	if err := sh.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer sh.Close()
+
+	if err := sh.WritePoints([]models.Point{
+		models.MustNewPoint("cpu", map[string]string{"host": "serverA", "region": "west"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := sh.WritePoints([]models.Point{
+		models.MustNewPoint("cpu", map[string]string{"host": "serverB", "region": "west"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		models.MustNewPoint("cpu", map[string]string{"host": "serverC", "region": "west"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		models.MustNewPoint("cpu", map[string]string{"host": "serverC", "region": "west"}, map[string]interface{}{"value": 2.0}, time.Unix(2, 0)),
+		models.MustNewPoint("mem", map[string]string{"host": "serverC"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+	})
+	if err == nil || err.Error() != `partial write: max-values-per-tag limit exceeded (2/2): measurement="cpu" tag="host" value="serverC" series="cpu,host=serverC,region=west" dropped=2` {
+		t.Fatalf("unexpected error: %v", err)
+	} else if index.SeriesN() != 3 {
+		t.Fatalf("unexpected series count: %d", index.SeriesN())
+	}
+}
+
 // Ensure the shard will automatically flush the WAL after a threshold has been reached.
 func TestShard_Autoflush(t *testing.T) {
 	path, _ := ioutil.TempDir("", "shard_test")
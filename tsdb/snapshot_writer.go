@@ -75,6 +75,11 @@ func appendShardSnapshotFiles(sw *snapshot.Writer, store *Store) error {
 }
 
 func appendShardSnapshotFile(sw *snapshot.Writer, sh *Shard, name string) error {
+	// Engines that store a shard as multiple files provide their own snapshot.
+	if e, ok := sh.engine.(snapshotWriterEngine); ok {
+		return appendEngineSnapshotFiles(sw, e, name)
+	}
+
 	// Stat the underlying data file to retrieve last modified date.
 	fi, err := os.Stat(sh.Path())
 	if err != nil {
@@ -100,6 +105,30 @@ func appendShardSnapshotFile(sw *snapshot.Writer, sh *Shard, name string) error
 	return nil
 }
 
+// snapshotWriterEngine is implemented by engines which can create a snapshot
+// of their own files, such as tsm1.
+type snapshotWriterEngine interface {
+	SnapshotWriter() (*snapshot.Writer, error)
+}
+
+// appendEngineSnapshotFiles adds the files from the engine's snapshot to sw
+// under the shard's name.
+func appendEngineSnapshotFiles(sw *snapshot.Writer, e snapshotWriterEngine, name string) error {
+	esw, err := e.SnapshotWriter()
+	if err != nil {
+		return fmt.Errorf("engine snapshot: %s", err)
+	}
+
+	for _, f := range esw.Manifest.Files {
+		fw := esw.FileWriters[f.Name]
+		f.Name = filepath.Join(name, f.Name)
+
+		sw.Manifest.Files = append(sw.Manifest.Files, f)
+		sw.FileWriters[f.Name] = fw
+	}
+	return nil
+}
+
 // boltTxCloser wraps a Bolt transaction to implement io.Closer.
 type boltTxCloser struct {
 	Tx
@@ -0,0 +1,224 @@
+package tsdb
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/models"
+)
+
+// sortRows reads all rows from in and returns them ordered by the field and tag
+// values in the statement's ORDER BY clause. The LIMIT and OFFSET are applied
+// to the sorted values across all series so at most LIMIT+OFFSET values are
+// held in memory; statements sorted by value are required to have a LIMIT.
+// Values are returned grouped by series, with the series in the order of
+// their first value.
+func sortRows(stmt *influxql.SelectStatement, in <-chan *models.Row, closing <-chan struct{}) <-chan *models.Row {
+	out := make(chan *models.Row, 0)
+	go func() {
+		defer close(out)
+
+		s := newRowSorter(stmt)
+		for row := range in {
+			if row.Err != nil {
+				select {
+				case out <- row:
+				case <-closing:
+				}
+
+				// Drain the remaining rows so the producer isn't blocked.
+				for range in {
+				}
+				return
+			}
+			s.add(row)
+		}
+
+		for _, row := range s.rows() {
+			select {
+			case out <- row:
+			case <-closing:
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// rowSorter sorts the values of rows by the statement's ORDER BY clause.
+// It implements heap.Interface with the value sorted last at the top so
+// it can be evicted once the limit has been reached.
+type rowSorter struct {
+	fields    influxql.SortFields
+	ascending bool // true if the time is sorted in chronological order.
+	limit     int  // maximum number of values to keep. Unlimited if zero.
+	offset    int
+
+	items []*sortItem
+	n     int // number of values added.
+}
+
+// sortItem is a single value of a row being sorted.
+type sortItem struct {
+	row    *models.Row // row header, without values.
+	values []interface{}
+	seq    int // order the value was added, used to keep the sort stable.
+}
+
+// value returns the value of the column or tag with the given name.
+func (item *sortItem) value(name string) interface{} {
+	for i, c := range item.row.Columns {
+		if c == name && i < len(item.values) {
+			return item.values[i]
+		}
+	}
+	if v, ok := item.row.Tags[name]; ok {
+		return v
+	}
+	return nil
+}
+
+// newRowSorter returns a new instance of rowSorter.
+func newRowSorter(stmt *influxql.SelectStatement) *rowSorter {
+	s := &rowSorter{
+		fields:    stmt.SortFields,
+		ascending: stmt.TimeAscending(),
+		offset:    stmt.Offset,
+	}
+	if stmt.Limit > 0 {
+		s.limit = stmt.Limit + stmt.Offset
+	}
+	return s
+}
+
+// add adds the values of row to the sorter, evicting the last values
+// once there are more than the limit.
+func (s *rowSorter) add(row *models.Row) {
+	header := &models.Row{Name: row.Name, Tags: row.Tags, Columns: row.Columns}
+	for _, values := range row.Values {
+		heap.Push(s, &sortItem{row: header, values: values, seq: s.n})
+		s.n++
+
+		if s.limit > 0 && len(s.items) > s.limit {
+			heap.Pop(s)
+		}
+	}
+}
+
+// rows returns the sorted values after the offset, grouped by series.
+func (s *rowSorter) rows() []*models.Row {
+	items := make([]*sortItem, len(s.items))
+	for i := len(items) - 1; i >= 0; i-- {
+		items[i] = heap.Pop(s).(*sortItem)
+	}
+
+	if s.offset >= len(items) {
+		return nil
+	}
+	items = items[s.offset:]
+
+	var rows []*models.Row
+	set := make(map[string]*models.Row)
+	for _, item := range items {
+		key := item.row.Name + "|" + string(MarshalTags(item.row.Tags))
+		row := set[key]
+		if row == nil {
+			row = &models.Row{Name: item.row.Name, Tags: item.row.Tags, Columns: item.row.Columns}
+			set[key] = row
+			rows = append(rows, row)
+		}
+		row.Values = append(row.Values, item.values)
+	}
+	return rows
+}
+
+// before returns true if a is sorted before b.
+func (s *rowSorter) before(a, b *sortItem) bool {
+	for _, f := range s.fields {
+		var av, bv interface{}
+		if f.IsTime() {
+			av, bv = a.values[0], b.values[0]
+		} else {
+			av, bv = a.value(f.Name), b.value(f.Name)
+		}
+
+		// Null values are always sorted last.
+		if av == nil || bv == nil {
+			if av == nil && bv == nil {
+				continue
+			}
+			return bv == nil
+		}
+
+		if c := compareSortValues(av, bv); c != 0 {
+			return (c < 0) == f.Ascending
+		}
+	}
+
+	// Fall back to the order of time, then the order the values were read.
+	if c := compareSortValues(a.values[0], b.values[0]); c != 0 {
+		return (c < 0) == s.ascending
+	}
+	return a.seq < b.seq
+}
+
+func (s *rowSorter) Len() int           { return len(s.items) }
+func (s *rowSorter) Less(i, j int) bool { return s.before(s.items[j], s.items[i]) }
+func (s *rowSorter) Swap(i, j int)      { s.items[i], s.items[j] = s.items[j], s.items[i] }
+
+func (s *rowSorter) Push(x interface{}) { s.items = append(s.items, x.(*sortItem)) }
+
+func (s *rowSorter) Pop() interface{} {
+	item := s.items[len(s.items)-1]
+	s.items = s.items[:len(s.items)-1]
+	return item
+}
+
+// compareSortValues returns -1, 0 or 1 if a is less than, equal to or greater than b.
+// Values of different types are considered equal unless they are both numbers.
+func compareSortValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case string:
+		if b, ok := b.(string); ok {
+			switch {
+			case a < b:
+				return -1
+			case a > b:
+				return 1
+			}
+		}
+	case bool:
+		if b, ok := b.(bool); ok && a != b {
+			if b {
+				return -1
+			}
+			return 1
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			switch {
+			case a.Before(b):
+				return -1
+			case a.After(b):
+				return 1
+			}
+		}
+	default:
+		af, ok := toFloat64(a)
+		if !ok {
+			return 0
+		}
+		bf, ok := toFloat64(b)
+		if !ok {
+			return 0
+		}
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+	}
+	return 0
+}
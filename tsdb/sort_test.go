@@ -0,0 +1,67 @@
+package tsdb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/models"
+)
+
+// Ensure an error row is returned and the remaining rows are drained so the
+// producer isn't blocked.
+func TestSortRows_Err(t *testing.T) {
+	stmt := mustParseSelectStatement(`SELECT value FROM cpu ORDER BY value LIMIT 1`)
+
+	in := make(chan *models.Row)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(in)
+		in <- &models.Row{Err: errors.New("marker")}
+		in <- &models.Row{Name: "cpu"}
+		in <- &models.Row{Name: "cpu"}
+	}()
+
+	out := sortRows(stmt, in, make(chan struct{}))
+	if row := <-out; row == nil || row.Err == nil || row.Err.Error() != "marker" {
+		t.Fatalf("unexpected row: %v", row)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("producer blocked")
+	}
+	if row, ok := <-out; ok {
+		t.Fatalf("unexpected row: %v", row)
+	}
+}
+
+// Ensure the error row isn't sent once the query is closed.
+func TestSortRows_Err_Closing(t *testing.T) {
+	stmt := mustParseSelectStatement(`SELECT value FROM cpu ORDER BY value LIMIT 1`)
+
+	in := make(chan *models.Row, 1)
+	in <- &models.Row{Err: errors.New("marker")}
+	close(in)
+
+	closing := make(chan struct{})
+	close(closing)
+	out := sortRows(stmt, in, closing)
+
+	select {
+	case <-out:
+	case <-time.After(time.Second):
+		t.Fatal("sort blocked on closed query")
+	}
+}
+
+func mustParseSelectStatement(s string) *influxql.SelectStatement {
+	stmt, err := influxql.ParseStatement(s)
+	if err != nil {
+		panic(err)
+	}
+	return stmt.(*influxql.SelectStatement)
+}
@@ -1,6 +1,7 @@
 package tsdb
 
 import (
+	"expvar"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -11,6 +12,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/influxdb/influxdb"
 	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/models"
 )
@@ -104,8 +106,7 @@ func (s *Store) CreateShard(database, retentionPolicy string, shardID uint64) er
 	// create the database index if it does not exist
 	db, ok := s.databaseIndexes[database]
 	if !ok {
-		db = NewDatabaseIndex()
-		s.databaseIndexes[database] = db
+		db = s.createDatabaseIndex(database)
 	}
 
 	shardPath := filepath.Join(s.path, database, retentionPolicy, strconv.FormatUint(shardID, 10))
@@ -254,6 +255,35 @@ func (s *Store) deleteSeries(database string, keys []string) error {
 	return nil
 }
 
+// deleteSeriesRange loops through the local shards and deletes the series values between min and max
+func (s *Store) deleteSeriesRange(database string, keys []string, min, max int64) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	db, ok := s.databaseIndexes[database]
+	if !ok {
+		return ErrDatabaseNotFound(database)
+	}
+
+	// Only delete once every shard of the database supports time ranges, so a
+	// delete is never partially applied.
+	for _, sh := range s.shards {
+		if sh.index == db && !sh.SupportsDeleteRange() {
+			return ErrDeleteRangeNotSupported
+		}
+	}
+
+	for _, sh := range s.shards {
+		if sh.index != db {
+			continue
+		}
+		if err := sh.DeleteSeriesRange(keys, min, max); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // deleteMeasurement loops through the local shards and removes the measurement field encodings from each shard
 func (s *Store) deleteMeasurement(database, name string, seriesKeys []string) error {
 	s.mu.RLock()
@@ -285,11 +315,28 @@ func (s *Store) loadIndexes() error {
 			s.Logger.Printf("Skipping database dir: %s. Not a directory", db.Name())
 			continue
 		}
-		s.databaseIndexes[db.Name()] = NewDatabaseIndex()
+		s.createDatabaseIndex(db.Name())
 	}
 	return nil
 }
 
+// createDatabaseIndex creates the index of a database. The cardinality of
+// the index is reported in the database's statistics.
+func (s *Store) createDatabaseIndex(name string) *DatabaseIndex {
+	key := strings.Join([]string{"database", s.path, name}, ":")
+	statMap := influxdb.NewStatistics(key, "database", map[string]string{"database": name})
+	influxdb.DeclareGauges("database", statDatabaseSeries, statDatabaseMeasurements)
+
+	// Reset the statistics of a dropped database with the same name.
+	statMap.Set(statDatabaseSeries, new(expvar.Int))
+	statMap.Set(statDatabaseMeasurements, new(expvar.Int))
+
+	db := NewDatabaseIndex()
+	db.statMap = statMap
+	s.databaseIndexes[name] = db
+	return db
+}
+
 func (s *Store) loadShards() error {
 	// loop through the current database indexes
 	for db := range s.databaseIndexes {
@@ -422,7 +469,7 @@ func (s *Store) CreateMapper(shardID uint64, stmt influxql.Statement, chunkSize
 
 	switch stmt := stmt.(type) {
 	case *influxql.SelectStatement:
-		if (stmt.IsRawQuery && !stmt.HasDistinct()) || stmt.IsSimpleDerivative() {
+		if (stmt.IsRawQuery && !stmt.HasDistinct()) || stmt.IsSimpleTransform() {
 			m := NewRawMapper(shard, stmt)
 			m.ChunkSize = chunkSize
 			return m, nil
@@ -470,5 +517,9 @@ func IsRetryable(err error) bool {
 	if strings.Contains(err.Error(), "field type conflict") {
 		return false
 	}
+	// Retrying a write won't write points dropped for exceeding a limit.
+	if strings.Contains(err.Error(), "partial write:") {
+		return false
+	}
 	return true
 }
@@ -0,0 +1,362 @@
+package tsdb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/models"
+)
+
+// SubQueryMapper runs the map phase of a SELECT statement over the rows returned
+// by the executor of its subquery. The rows are buffered and grouped by the
+// statement's dimensions so they can be read by the same mappers used for shards.
+type SubQueryMapper struct {
+	stmt     *influxql.SelectStatement
+	executor Executor
+	closing  chan struct{}
+
+	// The raw or aggregate mapper reading from the buffered rows.
+	mapper Mapper
+
+	ChunkSize int
+}
+
+// NewSubQueryMapper returns a new instance of SubQueryMapper.
+func NewSubQueryMapper(stmt *influxql.SelectStatement, e Executor, chunkSize int) *SubQueryMapper {
+	return &SubQueryMapper{
+		stmt:      stmt,
+		executor:  e,
+		closing:   make(chan struct{}),
+		ChunkSize: chunkSize,
+	}
+}
+
+// Open executes the subquery and initializes the mapper with its results.
+func (m *SubQueryMapper) Open() error {
+	series, err := m.readSeries()
+	if err != nil {
+		return err
+	}
+
+	// Collect the fields and tags returned by the subquery.
+	fieldSet, tagSet := newStringSet(), newStringSet()
+	for _, s := range series {
+		fieldSet.add(s.fields...)
+		for k := range s.tags {
+			tagSet.add(k)
+		}
+	}
+
+	// Split the names in the SELECT into fields and tags.
+	selectFields, selectTags := newStringSet(), newStringSet()
+	if m.stmt.HasFieldWildcard() {
+		selectFields.add(fieldSet.list()...)
+	}
+	for _, name := range m.stmt.NamesInSelect() {
+		if fieldSet.contains(name) {
+			selectFields.add(name)
+		} else if tagSet.contains(name) {
+			selectTags.add(name)
+		}
+	}
+
+	// If we only have tags in our select clause we just return
+	if len(selectFields) == 0 && len(selectTags) > 0 {
+		return fmt.Errorf("statement must have at least one field in select clause")
+	}
+
+	// Determine the tags to group by.
+	_, dimensions := m.stmt.Dimensions.Normalize()
+	if m.stmt.HasDimensionWildcard() {
+		dimensions = tagSet.list()
+	}
+
+	qmin, qmax := influxql.TimeRangeAsEpochNano(m.stmt.Condition)
+	if m.stmt.IsRawQuery || m.stmt.IsSimpleTransform() {
+		mapper := &RawMapper{
+			stmt:         m.stmt,
+			qmin:         qmin,
+			qmax:         qmax,
+			selectFields: selectFields.list(),
+			selectTags:   selectTags.list(),
+			ChunkSize:    m.ChunkSize,
+		}
+
+		ascending := m.stmt.TimeAscending()
+		for _, g := range groupSubQuerySeries(series, dimensions, ascending) {
+			tsc := NewTagSetCursor(g.name, g.tags, g.cursors, ascending)
+			tsc.SelectFields = mapper.selectFields
+			if ascending {
+				tsc.Init(qmin)
+			} else {
+				tsc.Init(qmax)
+			}
+			mapper.cursors = append(mapper.cursors, tsc)
+		}
+		sort.Sort(TagSetCursors(mapper.cursors))
+
+		m.mapper = mapper
+		return nil
+	}
+
+	mapper := &AggregateMapper{
+		stmt:         m.stmt,
+		qmin:         qmin,
+		qmax:         qmax,
+		selectFields: selectFields.list(),
+		selectTags:   selectTags.list(),
+	}
+	m.mapper = mapper
+
+	if err := mapper.initializeMapFunctions(); err != nil {
+		return err
+	}
+
+	if err := mapper.initializeIntervals(); err != nil {
+		return err
+	} else if mapper.intervalN == 0 {
+		return nil
+	}
+
+	for _, g := range groupSubQuerySeries(series, dimensions, true) {
+		mapper.cursors = append(mapper.cursors, CursorSet{
+			Measurement: g.name,
+			Tags:        g.tags,
+			Key:         g.key,
+			Cursors:     g.cursors,
+		})
+	}
+	sort.Sort(CursorSets(mapper.cursors))
+
+	return nil
+}
+
+// readSeries reads all rows from the subquery and returns them as series,
+// dropping any points which don't match the statement's condition.
+func (m *SubQueryMapper) readSeries() ([]*subQuerySeries, error) {
+	filter := conditionWithoutTime(m.stmt.Condition)
+
+	var series []*subQuerySeries
+	for row := range m.executor.Execute(m.closing) {
+		if row.Err != nil {
+			return nil, row.Err
+		}
+
+		s, err := newSubQuerySeries(row, filter)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, s)
+	}
+	return series, nil
+}
+
+// Close closes the mapper.
+func (m *SubQueryMapper) Close() {
+	if m == nil {
+		return
+	}
+	if m.mapper != nil {
+		m.mapper.Close()
+	}
+	close(m.closing)
+}
+
+// TagSets returns the list of tag sets for which this mapper has data.
+func (m *SubQueryMapper) TagSets() []string {
+	if m.mapper == nil {
+		return nil
+	}
+	return m.mapper.TagSets()
+}
+
+// Fields returns all SELECT fields.
+func (m *SubQueryMapper) Fields() []string {
+	if m.mapper == nil {
+		return nil
+	}
+	return m.mapper.Fields()
+}
+
+// NextChunk returns the next chunk of data from the underlying mapper.
+func (m *SubQueryMapper) NextChunk() (interface{}, error) {
+	if m.mapper == nil {
+		return nil, nil
+	}
+	return m.mapper.NextChunk()
+}
+
+// subQuerySeries is a single series of points returned by a subquery.
+type subQuerySeries struct {
+	name   string
+	tags   map[string]string
+	fields []string
+	points []subQueryPoint
+}
+
+// subQueryPoint is a single point returned by a subquery. Null values are
+// not included in the fields.
+type subQueryPoint struct {
+	time   int64
+	fields map[string]interface{}
+}
+
+// newSubQuerySeries converts a row returned by an executor to a series.
+// Points are sorted by time and those not matching filter are dropped.
+func newSubQuerySeries(row *models.Row, filter influxql.Expr) (*subQuerySeries, error) {
+	if len(row.Columns) == 0 || row.Columns[0] != "time" {
+		return nil, errors.New("subquery must return time in the first column")
+	}
+
+	s := &subQuerySeries{
+		name:   row.Name,
+		tags:   row.Tags,
+		fields: row.Columns[1:],
+	}
+
+	for _, values := range row.Values {
+		t, ok := values[0].(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("invalid subquery time: %v", values[0])
+		}
+
+		fields := make(map[string]interface{}, len(values)-1)
+		for i, v := range values[1:] {
+			if v != nil {
+				fields[row.Columns[i+1]] = v
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		// Evaluate the filter against both the fields and tags of the point.
+		if filter != nil {
+			m := make(map[string]interface{}, len(fields)+len(s.tags))
+			for k, v := range s.tags {
+				m[k] = v
+			}
+			for k, v := range fields {
+				m[k] = v
+			}
+			if !influxql.EvalBool(filter, m) {
+				continue
+			}
+		}
+
+		s.points = append(s.points, subQueryPoint{time: t.UnixNano(), fields: fields})
+	}
+	sort.Stable(subQueryPoints(s.points))
+
+	return s, nil
+}
+
+type subQueryPoints []subQueryPoint
+
+func (a subQueryPoints) Len() int           { return len(a) }
+func (a subQueryPoints) Less(i, j int) bool { return a[i].time < a[j].time }
+func (a subQueryPoints) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// subQueryGroup is a set of series from a subquery sharing the same
+// measurement and tags for the statement's dimensions.
+type subQueryGroup struct {
+	name    string
+	tags    map[string]string
+	key     string
+	cursors []*TagsCursor
+}
+
+// groupSubQuerySeries groups series by name and the given dimensions.
+func groupSubQuerySeries(series []*subQuerySeries, dimensions []string, ascending bool) []*subQueryGroup {
+	var groups []*subQueryGroup
+	set := make(map[string]*subQueryGroup)
+	for _, s := range series {
+		tags := make(map[string]string, len(dimensions))
+		for _, d := range dimensions {
+			tags[d] = s.tags[d]
+		}
+
+		key := s.name
+		if len(tags) > 0 {
+			key = strings.Join([]string{s.name, string(MarshalTags(tags))}, "|")
+		}
+
+		g := set[key]
+		if g == nil {
+			g = &subQueryGroup{name: s.name, tags: tags, key: key}
+			set[key] = g
+			groups = append(groups, g)
+		}
+
+		c := &subQueryCursor{points: s.points, ascending: ascending}
+		g.cursors = append(g.cursors, NewTagsCursor(c, nil, s.tags))
+	}
+	return groups
+}
+
+// subQueryCursor is a cursor over the points of a series returned by a subquery.
+type subQueryCursor struct {
+	points    []subQueryPoint
+	index     int
+	ascending bool
+}
+
+// Ascending returns true if the cursor moves forward in time.
+func (c *subQueryCursor) Ascending() bool { return c.ascending }
+
+// SeekTo moves the cursor to the first point at or after seek, or at or
+// before seek if the cursor is descending.
+func (c *subQueryCursor) SeekTo(seek int64) (key int64, value interface{}) {
+	i := sort.Search(len(c.points), func(i int) bool { return c.points[i].time >= seek })
+	if c.ascending {
+		c.index = i
+	} else {
+		if i == len(c.points) || c.points[i].time > seek {
+			i--
+		}
+		c.index = i
+	}
+	return c.Next()
+}
+
+// Next returns the next key and value from the cursor.
+func (c *subQueryCursor) Next() (key int64, value interface{}) {
+	if c.index < 0 || c.index >= len(c.points) {
+		return EOF, nil
+	}
+
+	p := c.points[c.index]
+	if c.ascending {
+		c.index++
+	} else {
+		c.index--
+	}
+	return p.time, p.fields
+}
+
+// conditionWithoutTime returns a copy of the expression with all time
+// comparisons replaced by true. Returns nil if only time is compared.
+func conditionWithoutTime(expr influxql.Expr) influxql.Expr {
+	if expr == nil || influxql.OnlyTimeExpr(expr) {
+		return nil
+	}
+
+	return influxql.RewriteFunc(influxql.CloneExpr(expr), func(n influxql.Node) influxql.Node {
+		if e, ok := n.(*influxql.BinaryExpr); ok {
+			if isTimeRef(e.LHS) || isTimeRef(e.RHS) {
+				return &influxql.BooleanLiteral{Val: true}
+			}
+		}
+		return n
+	}).(influxql.Expr)
+}
+
+// isTimeRef returns true if expr is a reference to time.
+func isTimeRef(expr influxql.Expr) bool {
+	ref, ok := expr.(*influxql.VarRef)
+	return ok && strings.ToLower(ref.Val) == "time"
+}
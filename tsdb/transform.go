@@ -0,0 +1,338 @@
+package tsdb
+
+import (
+	"math"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// transformer calculates the values of a transform function, such as
+// difference(), from the points of a series in time order.
+type transformer interface {
+	// transform returns the value of the function at the point. False is
+	// returned if there is no value for the point, e.g. the first point
+	// passed to difference().
+	transform(t int64, v interface{}) (interface{}, bool)
+}
+
+// newTransformer returns the transformer for a transform call. Holt-Winters
+// forecasts require all points so aren't supported.
+func newTransformer(c *influxql.Call) transformer {
+	switch c.Name {
+	case "cumulative_sum":
+		return &cumulativeSumTransformer{}
+	case "difference":
+		return &differenceTransformer{}
+	case "moving_average":
+		lit, _ := c.Args[1].(*influxql.NumberLiteral)
+		return &movingAverageTransformer{n: int(lit.Val)}
+	case "elapsed":
+		unit := time.Nanosecond
+		if len(c.Args) == 2 {
+			lit, _ := c.Args[1].(*influxql.DurationLiteral)
+			unit = lit.Val
+		}
+		return &elapsedTransformer{unit: unit}
+	}
+	return nil
+}
+
+// transformValue returns the value of a selector's point, or v otherwise.
+func transformValue(v interface{}) interface{} {
+	if p, ok := v.(PositionPoint); ok {
+		return p.Value
+	}
+	return v
+}
+
+// cumulativeSumTransformer returns the running total of the values. The total
+// is an integer until a float is added.
+type cumulativeSumTransformer struct {
+	isum    int64
+	fsum    float64
+	isFloat bool
+}
+
+func (c *cumulativeSumTransformer) transform(t int64, v interface{}) (interface{}, bool) {
+	switch v := transformValue(v).(type) {
+	case int64:
+		if !c.isFloat {
+			c.isum += v
+			return c.isum, true
+		}
+		c.fsum += float64(v)
+	case float64:
+		if !c.isFloat {
+			c.fsum, c.isFloat = float64(c.isum), true
+		}
+		c.fsum += v
+	default:
+		return nil, false
+	}
+	return c.fsum, true
+}
+
+// differenceTransformer returns the difference between each value and the previous one.
+type differenceTransformer struct {
+	prev interface{}
+}
+
+func (d *differenceTransformer) transform(t int64, v interface{}) (interface{}, bool) {
+	v = transformValue(v)
+	if _, ok := toFloat64(v); !ok {
+		return nil, false
+	}
+
+	prev := d.prev
+	d.prev = v
+	if prev == nil {
+		return nil, false
+	}
+
+	// Integers are only returned if both values are integers.
+	if p, ok := prev.(int64); ok {
+		if c, ok := v.(int64); ok {
+			return c - p, true
+		}
+	}
+	p, _ := toFloat64(prev)
+	c, _ := toFloat64(v)
+	return c - p, true
+}
+
+// movingAverageTransformer returns the mean of each window of n values.
+type movingAverageTransformer struct {
+	n      int
+	window []float64
+}
+
+func (m *movingAverageTransformer) transform(t int64, v interface{}) (interface{}, bool) {
+	f, ok := toFloat64(transformValue(v))
+	if !ok {
+		return nil, false
+	}
+
+	m.window = append(m.window, f)
+	if len(m.window) > m.n {
+		m.window = m.window[1:]
+	}
+	if len(m.window) < m.n {
+		return nil, false
+	}
+
+	var sum float64
+	for _, f := range m.window {
+		sum += f
+	}
+	return sum / float64(m.n), true
+}
+
+// elapsedTransformer returns the time elapsed since the previous point in the given unit.
+type elapsedTransformer struct {
+	unit    time.Duration
+	prev    int64
+	hasPrev bool
+}
+
+func (e *elapsedTransformer) transform(t int64, v interface{}) (interface{}, bool) {
+	if v == nil {
+		return nil, false
+	}
+
+	prev, hasPrev := e.prev, e.hasPrev
+	e.prev, e.hasPrev = t, true
+	if !hasPrev {
+		return nil, false
+	}
+	return (t - prev) / int64(e.unit), true
+}
+
+// RawQueryTransformProcessor applies a transform to the values of a raw query.
+// The same processor is used for all chunks of a tagset so the transform
+// continues from the values of the previous chunk.
+type RawQueryTransformProcessor struct {
+	field string
+	t     transformer
+}
+
+// NewRawQueryTransformProcessor returns a processor for the transform call.
+func NewRawQueryTransformProcessor(c *influxql.Call) *RawQueryTransformProcessor {
+	p := &RawQueryTransformProcessor{t: newTransformer(c)}
+	if ref, ok := c.Args[0].(*influxql.VarRef); ok {
+		p.field = ref.Val
+	}
+	return p
+}
+
+// Process returns the transformed values.
+func (p *RawQueryTransformProcessor) Process(input []*MapperValue) []*MapperValue {
+	values := []*MapperValue{}
+	for _, v := range input {
+		value := v.Value
+		if fields, ok := value.(map[string]interface{}); ok {
+			value = fields[p.field]
+		}
+
+		if value, ok := p.t.transform(v.Time, value); ok {
+			values = append(values, &MapperValue{Time: v.Time, Value: value})
+		}
+	}
+	return values
+}
+
+// ProcessAggregateTransform returns the values of the transform call applied to
+// an aggregate result set. Null values are dropped.
+func ProcessAggregateTransform(results [][]interface{}, c *influxql.Call) [][]interface{} {
+	t := newTransformer(c)
+	values := [][]interface{}{}
+	for _, r := range results {
+		if v, ok := t.transform(r[0].(time.Time).UnixNano(), r[1]); ok {
+			values = append(values, []interface{}{r[0], v})
+		}
+	}
+	return values
+}
+
+// holtWintersSteps is the number of values of each smoothing parameter
+// tried between 0 and 1 when fitting the Holt-Winters model.
+const holtWintersSteps = 10
+
+// ProcessHoltWinters returns n values forecast from an aggregate result set
+// using the Holt-Winters method. If period is greater than 1, the values are
+// modelled with an additive seasonal component of that many intervals and at
+// least two seasons of values are required. Null values are dropped.
+func ProcessHoltWinters(results [][]interface{}, n, period int, interval time.Duration, ascending bool) [][]interface{} {
+	var times []time.Time
+	var values []float64
+	for _, r := range results {
+		if f, ok := toFloat64(r[1]); ok {
+			times = append(times, r[0].(time.Time))
+			values = append(values, f)
+		}
+	}
+
+	// Fit the model to the values in time order.
+	if !ascending {
+		for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+			times[i], times[j] = times[j], times[i]
+			values[i], values[j] = values[j], values[i]
+		}
+	}
+
+	if period < 2 {
+		period = 0
+	}
+	if len(values) < 2 || len(values) < 2*period {
+		return [][]interface{}{}
+	}
+
+	// Use the smoothing parameters with the smallest error in the
+	// one step ahead forecasts of the values.
+	var hw *holtWinters
+	for a := 0; a <= holtWintersSteps; a++ {
+		for b := 0; b <= holtWintersSteps; b++ {
+			for g := 0; g <= holtWintersSteps; g++ {
+				if period == 0 && g > 0 {
+					break
+				}
+
+				fit := fitHoltWinters(values, period,
+					float64(a)/holtWintersSteps,
+					float64(b)/holtWintersSteps,
+					float64(g)/holtWintersSteps,
+				)
+				if hw == nil || fit.sse < hw.sse {
+					hw = fit
+				}
+			}
+		}
+	}
+
+	last := times[len(times)-1]
+	forecasts := make([][]interface{}, 0, n)
+	for h := 1; h <= n; h++ {
+		v := hw.forecast(h)
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		forecasts = append(forecasts, []interface{}{last.Add(time.Duration(h) * interval), v})
+	}
+
+	if !ascending {
+		for i, j := 0, len(forecasts)-1; i < j; i, j = i+1, j-1 {
+			forecasts[i], forecasts[j] = forecasts[j], forecasts[i]
+		}
+	}
+	return forecasts
+}
+
+// holtWinters is a Holt-Winters model fitted to a series of values.
+type holtWinters struct {
+	period   int
+	n        int // number of values fitted.
+	level    float64
+	trend    float64
+	seasonal []float64 // indexed by the position of a value in the period.
+	sse      float64   // sum of the squared errors of the fitted values.
+}
+
+// fitHoltWinters fits the model to the values with the smoothing parameters
+// for the level, trend and seasonal components.
+func fitHoltWinters(values []float64, period int, alpha, beta, gamma float64) *holtWinters {
+	hw := &holtWinters{period: period, n: len(values)}
+
+	start := 1
+	if period > 0 {
+		// Initialize the components from the first two seasons.
+		var mean0, mean1 float64
+		for i := 0; i < period; i++ {
+			mean0 += values[i]
+			mean1 += values[period+i]
+		}
+		mean0 /= float64(period)
+		mean1 /= float64(period)
+
+		// The mean of the first season is its level at the middle of the
+		// season, so the seasonal components are taken from the trend line.
+		hw.trend = (mean1 - mean0) / float64(period)
+		mid := float64(period-1) / 2
+		hw.seasonal = make([]float64, period)
+		for i := 0; i < period; i++ {
+			hw.seasonal[i] = values[i] - (mean0 + (float64(i)-mid)*hw.trend)
+		}
+		hw.level = mean0 + mid*hw.trend
+		start = period
+	} else {
+		hw.level = values[0]
+		hw.trend = values[1] - values[0]
+	}
+
+	for i := start; i < len(values); i++ {
+		var s float64
+		if period > 0 {
+			s = hw.seasonal[i%period]
+		}
+
+		y := values[i]
+		err := y - (hw.level + hw.trend + s)
+		hw.sse += err * err
+
+		level := alpha*(y-s) + (1-alpha)*(hw.level+hw.trend)
+		hw.trend = beta*(level-hw.level) + (1-beta)*hw.trend
+		if period > 0 {
+			hw.seasonal[i%period] = gamma*(y-level) + (1-gamma)*s
+		}
+		hw.level = level
+	}
+	return hw
+}
+
+// forecast returns the value forecast h intervals after the last fitted value.
+func (hw *holtWinters) forecast(h int) float64 {
+	v := hw.level + float64(h)*hw.trend
+	if hw.period > 0 {
+		v += hw.seasonal[(hw.n-1+h)%hw.period]
+	}
+	return v
+}
@@ -0,0 +1,138 @@
+package tsdb_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/tsdb"
+)
+
+func TestProcessAggregateTransform(t *testing.T) {
+	t0 := time.Unix(0, 0)
+	for i, tt := range []struct {
+		call    string
+		results [][]interface{}
+		exp     [][]interface{}
+	}{
+		{
+			call:    `cumulative_sum(mean(value))`,
+			results: [][]interface{}{},
+			exp:     [][]interface{}{},
+		},
+		{
+			call: `cumulative_sum(sum(value))`,
+			results: [][]interface{}{
+				{t0, int64(1)},
+				{t0.Add(time.Hour), nil},
+				{t0.Add(2 * time.Hour), int64(2)},
+				{t0.Add(3 * time.Hour), 0.5},
+			},
+			exp: [][]interface{}{
+				{t0, int64(1)},
+				{t0.Add(2 * time.Hour), int64(3)},
+				{t0.Add(3 * time.Hour), 3.5},
+			},
+		},
+		{
+			call: `difference(max(value))`,
+			results: [][]interface{}{
+				{t0, tsdb.PositionPoint{Value: int64(5)}},
+				{t0.Add(time.Hour), tsdb.PositionPoint{Value: int64(3)}},
+				{t0.Add(2 * time.Hour), 4.5},
+				{t0.Add(3 * time.Hour), "invalid"},
+			},
+			exp: [][]interface{}{
+				{t0.Add(time.Hour), int64(-2)},
+				{t0.Add(2 * time.Hour), 1.5},
+			},
+		},
+		{
+			call: `moving_average(mean(value), 2)`,
+			results: [][]interface{}{
+				{t0, 1.0},
+				{t0.Add(time.Hour), 3.0},
+				{t0.Add(2 * time.Hour), nil},
+				{t0.Add(3 * time.Hour), int64(8)},
+			},
+			exp: [][]interface{}{
+				{t0.Add(time.Hour), 2.0},
+				{t0.Add(3 * time.Hour), 5.5},
+			},
+		},
+		{
+			call: `elapsed(count(value), 1m)`,
+			results: [][]interface{}{
+				{t0, int64(1)},
+				{t0.Add(time.Hour), nil},
+				{t0.Add(2 * time.Hour), int64(4)},
+				{t0.Add(150 * time.Minute), int64(2)},
+			},
+			exp: [][]interface{}{
+				{t0.Add(2 * time.Hour), int64(120)},
+				{t0.Add(150 * time.Minute), int64(30)},
+			},
+		},
+	} {
+		call := MustParseExpr(tt.call).(*influxql.Call)
+		if results := tsdb.ProcessAggregateTransform(tt.results, call); !reflect.DeepEqual(results, tt.exp) {
+			t.Errorf("%d. %s: unexpected results: %s", i, tt.call, spew.Sdump(results))
+		}
+	}
+}
+
+func TestRawQueryTransform_Process(t *testing.T) {
+	p := tsdb.NewRawQueryTransformProcessor(MustParseExpr(`difference(value)`).(*influxql.Call))
+
+	// The difference continues from the last value of the previous chunk.
+	results := p.Process([]*tsdb.MapperValue{
+		{Time: 1, Value: int64(10)},
+		{Time: 2, Value: int64(15)},
+	})
+	results = append(results, p.Process([]*tsdb.MapperValue{
+		{Time: 3, Value: map[string]interface{}{"value": int64(12), "other": 1.0}},
+		{Time: 4, Value: map[string]interface{}{"other": 1.0}},
+		{Time: 5, Value: int64(13)},
+	})...)
+
+	if !reflect.DeepEqual(results, []*tsdb.MapperValue{
+		{Time: 2, Value: int64(5)},
+		{Time: 3, Value: int64(-3)},
+		{Time: 5, Value: int64(1)},
+	}) {
+		t.Fatalf("unexpected results: %s", spew.Sdump(results))
+	}
+}
+
+func TestProcessHoltWinters(t *testing.T) {
+	t0 := time.Unix(0, 0)
+
+	// A series with a linear trend and a season of four intervals.
+	season := []float64{2, -1, 0, -1}
+	var results [][]interface{}
+	for i := 0; i < 12; i++ {
+		results = append(results, []interface{}{t0.Add(time.Duration(i) * time.Hour), 10 + float64(i) + season[i%4]})
+	}
+
+	forecasts := tsdb.ProcessHoltWinters(results, 4, 4, time.Hour, true)
+	if len(forecasts) != 4 {
+		t.Fatalf("unexpected forecasts: %s", spew.Sdump(forecasts))
+	}
+	for i, f := range forecasts {
+		n := 12 + i
+		if exp := t0.Add(time.Duration(n) * time.Hour); !f[0].(time.Time).Equal(exp) {
+			t.Errorf("%d. unexpected time: exp %s got %s", i, exp, f[0])
+		}
+		if exp := 10 + float64(n) + season[n%4]; math.Abs(f[1].(float64)-exp) > 1e-6 {
+			t.Errorf("%d. unexpected value: exp %v got %v", i, exp, f[1])
+		}
+	}
+
+	// Two seasons are required to fit the model.
+	if forecasts := tsdb.ProcessHoltWinters(results[:7], 4, 4, time.Hour, true); len(forecasts) != 0 {
+		t.Fatalf("unexpected forecasts: %s", spew.Sdump(forecasts))
+	}
+}